@@ -0,0 +1,286 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"log"
+	"sync"
+	"time"
+
+	"stratux-ng/internal/config"
+	"stratux-ng/internal/decoder"
+	"stratux-ng/internal/gdl90"
+	"stratux-ng/internal/traffic"
+	"stratux-ng/internal/uat978"
+	"stratux-ng/internal/web"
+)
+
+// uplinkDedupWindow suppresses the same FIS-B uplink heard by several 978
+// inputs; ground stations don't repeat an identical frame this quickly.
+const uplinkDedupWindow = 2 * time.Second
+
+// decoderInput is one extra ingest endpoint from a band's inputs list. Exactly
+// one of the client fields is set.
+type decoderInput struct {
+	name     string
+	kind     string
+	endpoint string
+
+	ndjson *decoder.NDJSONClient
+	line   *decoder.LineClient
+	beast  *decoder.BeastClient
+	file   *decoder.JSONFilePoller
+}
+
+func (in *decoderInput) Close() {
+	if in == nil {
+		return
+	}
+	in.ndjson.Close()
+	in.line.Close()
+	in.beast.Close()
+	in.file.Close()
+}
+
+func (in *decoderInput) Snapshot(nowUTC time.Time) web.DecoderInputSnapshot {
+	out := web.DecoderInputSnapshot{Name: in.name, Kind: in.kind, Endpoint: in.endpoint}
+	switch {
+	case in.ndjson != nil:
+		st := in.ndjson.Snapshot(nowUTC)
+		out.Stream = &st
+	case in.line != nil && in.kind == "raw":
+		st := in.line.Snapshot(nowUTC)
+		out.RawStream = &st
+	case in.line != nil:
+		st := in.line.Snapshot(nowUTC)
+		out.SBSStream = &st
+	case in.beast != nil:
+		st := in.beast.Snapshot(nowUTC)
+		out.BeastStream = &st
+	case in.file != nil:
+		st := in.file.Snapshot(nowUTC)
+		out.AircraftJSONPoll = &st
+	}
+	return out
+}
+
+// startDecoderInput connects one configured input for band ("adsb1090" or
+// "uat978"). Config validation has already checked the band restrictions.
+func (r *liveRuntime) startDecoderInput(ctx context.Context, band string, cfg config.DecoderInput) (*decoderInput, error) {
+	in := &decoderInput{name: cfg.Name}
+	clientName := band + "-" + cfg.Name
+	var err error
+	switch {
+	case cfg.JSONAddr != "":
+		in.kind, in.endpoint = "json", cfg.JSONAddr
+		in.ndjson, err = decoder.NewNDJSONClient(decoder.NDJSONClientConfig{Name: clientName, Addr: cfg.JSONAddr})
+		if err == nil {
+			handler := r.adsb1090JSONHandler(cfg.Name)
+			if band == "uat978" {
+				handler = r.uat978JSONHandler(cfg.Name)
+			}
+			err = in.ndjson.Start(ctx, handler)
+		}
+	case cfg.RawAddr != "":
+		in.kind, in.endpoint = "raw", cfg.RawAddr
+		in.line, err = decoder.NewLineClient(decoder.LineClientConfig{Name: clientName, Addr: cfg.RawAddr})
+		if err == nil {
+			// An input has a single endpoint, so its downlinks are its only
+			// source of traffic.
+			err = in.line.Start(ctx, r.uat978RawHandler(cfg.Name, true))
+		}
+	case cfg.BeastAddr != "":
+		in.kind, in.endpoint = "beast", cfg.BeastAddr
+		in.beast, err = decoder.NewBeastClient(decoder.BeastClientConfig{Name: clientName, Addr: cfg.BeastAddr})
+		if err == nil {
+			err = in.beast.Start(ctx, r.adsb1090BeastHandler(cfg.Name))
+		}
+	case cfg.SBSAddr != "":
+		in.kind, in.endpoint = "sbs", cfg.SBSAddr
+		in.line, err = decoder.NewLineClient(decoder.LineClientConfig{Name: clientName, Addr: cfg.SBSAddr})
+		if err == nil {
+			err = in.line.Start(ctx, r.adsb1090SBSHandler(cfg.Name))
+		}
+	case cfg.AircraftJSON != "":
+		in.kind, in.endpoint = "aircraft_json", cfg.AircraftJSON
+		in.file, err = decoder.NewJSONFilePoller(decoder.JSONFilePollerConfig{Name: clientName, Path: cfg.AircraftJSON})
+		if err == nil {
+			err = in.file.Start(ctx, r.adsb1090AircraftJSONHandler(cfg.Name))
+		}
+	default:
+		return nil, fmt.Errorf("%s input %q has no endpoint", band, cfg.Name)
+	}
+	if err != nil {
+		in.Close()
+		return nil, fmt.Errorf("%s input %q: %w", band, cfg.Name, err)
+	}
+	log.Printf("%s input started name=%s kind=%s endpoint=%s", band, in.name, in.kind, in.endpoint)
+	return in, nil
+}
+
+func (r *liveRuntime) applyTraffic(now time.Time, input string, upd traffic.TrafficUpdate) {
+	if r.trafficStore == nil {
+		return
+	}
+	upd.Input = input
+	r.trafficStore.Apply(now, upd)
+}
+
+func (r *liveRuntime) adsb1090JSONHandler(input string) func(json.RawMessage) error {
+	return func(raw json.RawMessage) error {
+		// Keep the stream healthy: never return errors for parse issues.
+		if upd, ok := traffic.ParseDump1090RawJSON(raw); ok {
+			r.applyTraffic(time.Now().UTC(), input, upd)
+		}
+		return nil
+	}
+}
+
+func (r *liveRuntime) adsb1090BeastHandler(input string) func(decoder.BeastFrame) error {
+	modes := traffic.NewModeSDecoder()
+	var lastRef time.Time
+	return func(f decoder.BeastFrame) error {
+		// Keep the stream healthy: never return errors for parse issues.
+		now := time.Now().UTC()
+		if f.Type == decoder.BeastModeAC {
+			r.trafficStore.CountMessage(now, "ModeAC")
+			return nil
+		}
+		if f.Type != decoder.BeastModeSShort && f.Type != decoder.BeastModeSLong {
+			return nil
+		}
+		// Surface positions need a nearby reference; use our GPS fix.
+		if now.Sub(lastRef) > 10*time.Second {
+			lastRef = now
+			if gs, ok := r.GPSSnapshot(); ok && gs.Valid {
+				modes.SetReference(gs.LatDeg, gs.LonDeg)
+			}
+		}
+		upd, ok := modes.Decode(now, f.Data)
+		if !ok {
+			// All-calls, replies failing CRC and the like still count
+			// towards the receiver message rate.
+			if len(f.Data) > 0 {
+				r.trafficStore.CountMessage(now, traffic.ModeSKind(int(f.Data[0]>>3)))
+			}
+			return nil
+		}
+		upd.RSSI, upd.HasRSSI = f.RSSIDbfs(), true
+		r.applyTraffic(now, input, upd)
+		return nil
+	}
+}
+
+func (r *liveRuntime) adsb1090SBSHandler(input string) func([]byte) error {
+	return func(line []byte) error {
+		// Keep the stream healthy: never return errors for parse issues.
+		if upd, ok := traffic.ParseSBSLine(line); ok {
+			r.applyTraffic(time.Now().UTC(), input, upd)
+		}
+		return nil
+	}
+}
+
+func (r *liveRuntime) adsb1090AircraftJSONHandler(input string) func(json.RawMessage) error {
+	tracker := traffic.NewAircraftJSONTracker(traffic.Source1090)
+	return func(raw json.RawMessage) error {
+		upds, err := tracker.Updates(raw)
+		if err != nil {
+			return err
+		}
+		now := time.Now().UTC()
+		for _, upd := range upds {
+			r.applyTraffic(now, input, upd)
+		}
+		return nil
+	}
+}
+
+func (r *liveRuntime) uat978JSONHandler(input string) func(json.RawMessage) error {
+	return func(raw json.RawMessage) error {
+		// Keep the stream healthy: never return errors for parse issues.
+		if upd, ok := traffic.ParseDump978NDJSON(raw); ok {
+			r.applyTraffic(time.Now().UTC(), input, upd)
+		}
+		return nil
+	}
+}
+
+// uat978RawHandler handles a dump978 raw stream. Traffic is taken from its
+// downlinks only when decodeDownlinks is set; a band that also reads the
+// decoder's JSON output already gets every target from there.
+func (r *liveRuntime) uat978RawHandler(input string, decodeDownlinks bool) func([]byte) error {
+	return func(line []byte) error {
+		// Downlinks ("-" prefix) carry UAT traffic; uplinks ("+") carry FIS-B.
+		if d, ok := traffic.ParseDump978RawDownlink(line); ok {
+			now := time.Now().UTC()
+			if d.AddrQualifier.IsTISB() && r.uat978Agg != nil {
+				r.uat978Agg.AddTISB(now, d.TISBSiteID)
+			}
+			if !decodeDownlinks {
+				return nil
+			}
+			if upd, ok := traffic.NewTrafficUpdateFromUATDownlink(d); ok {
+				upd.RSSI, upd.HasRSSI = traffic.ParseDump978RawSignal(line)
+				r.applyTraffic(now, input, upd)
+			}
+			return nil
+		}
+		payload, ss, hasSS, ok := traffic.ParseDump978RawUplinkLineWithMeta(line)
+		if !ok {
+			return nil
+		}
+		now := time.Now().UTC()
+		if r.uat978Dedup.duplicate(now, payload) {
+			return nil
+		}
+		if r.uat978Agg != nil {
+			if decoded, ok := uat978.DecodeUplinkFrame(payload); ok {
+				signalDb := 0.0
+				if hasSS {
+					signalDb = uat978.SignalStrengthDbFromAmplitude(ss)
+				}
+				r.uat978Agg.Add(now, decoded, signalDb, hasSS)
+			}
+		}
+		frame := gdl90.UATUplinkFrame(payload)
+		select {
+		case r.uat978UplinkQ <- frame:
+		default:
+		}
+		return nil
+	}
+}
+
+// uplinkDedup remembers recently relayed uplink payloads so the same frame
+// received by several 978 inputs is only relayed once.
+type uplinkDedup struct {
+	mu   sync.Mutex
+	seen map[uint64]time.Time
+}
+
+func (d *uplinkDedup) duplicate(now time.Time, payload []byte) bool {
+	h := fnv.New64a()
+	_, _ = h.Write(payload)
+	key := h.Sum64()
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.seen == nil {
+		d.seen = make(map[uint64]time.Time)
+	}
+	if at, ok := d.seen[key]; ok && now.Sub(at) < uplinkDedupWindow {
+		return true
+	}
+	d.seen[key] = now
+	if len(d.seen) > 512 {
+		for k, at := range d.seen {
+			if now.Sub(at) >= uplinkDedupWindow {
+				delete(d.seen, k)
+			}
+		}
+	}
+	return false
+}
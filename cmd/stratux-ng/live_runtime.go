@@ -2,10 +2,11 @@ package main
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"log"
+	"net"
 	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -14,13 +15,16 @@ import (
 	"stratux-ng/internal/config"
 	"stratux-ng/internal/decoder"
 	"stratux-ng/internal/fancontrol"
-	"stratux-ng/internal/gdl90"
 	"stratux-ng/internal/gps"
+	"stratux-ng/internal/nmeaout"
+	"stratux-ng/internal/ntp"
 	"stratux-ng/internal/sdr"
+	"stratux-ng/internal/timesync"
 	"stratux-ng/internal/traffic"
 	"stratux-ng/internal/uat978"
 	"stratux-ng/internal/udp"
 	"stratux-ng/internal/web"
+	"stratux-ng/internal/wifi"
 )
 
 func isDump1090Command(cmd string) bool {
@@ -62,17 +66,31 @@ type liveRuntime struct {
 	sender             *safeBroadcaster
 	ahrsSvc            *ahrs.Service
 	gpsSvc             *gps.Service
+	gpsIntegrity       *gps.IntegrityMonitor
 	fanSvc             *fancontrol.Service
+	timeSync           *timesync.Service
+	ntpSrv             *ntp.Server
+	nmeaOut            *nmeaout.Server
 
 	adsb1090Sup    *decoder.Supervisor
 	uat978Sup      *decoder.Supervisor
 	adsb1090Stream *decoder.NDJSONClient
+	adsb1090Beast  *decoder.BeastClient
+	adsb1090SBS    *decoder.LineClient
+	adsb1090File   *decoder.JSONFilePoller
+	adsb1090Inputs []*decoderInput
 	uat978Stream   *decoder.NDJSONClient
 	uat978Raw      *decoder.LineClient
+	uat978Inputs   []*decoderInput
+	uat978Dedup    uplinkDedup
 	uat978UplinkQ  chan []byte
 	uat978Agg      *uat978.Aggregator
 
+	ognAPRS  *decoder.LineClient
+	ognFLARM *decoder.LineClient
+
 	trafficStore *traffic.Store
+	registry     *registryWatcher
 
 	cfg    config.Config
 	ticker *time.Ticker
@@ -105,6 +123,27 @@ func decoderBandEqual(a, b config.DecoderBandConfig) bool {
 	if strings.TrimSpace(a.Decoder.RawAddr) != strings.TrimSpace(b.Decoder.RawAddr) {
 		return false
 	}
+	if strings.TrimSpace(a.Decoder.BeastListen) != strings.TrimSpace(b.Decoder.BeastListen) {
+		return false
+	}
+	if strings.TrimSpace(a.Decoder.BeastAddr) != strings.TrimSpace(b.Decoder.BeastAddr) {
+		return false
+	}
+	if strings.TrimSpace(a.Decoder.SBSListen) != strings.TrimSpace(b.Decoder.SBSListen) {
+		return false
+	}
+	if strings.TrimSpace(a.Decoder.SBSAddr) != strings.TrimSpace(b.Decoder.SBSAddr) {
+		return false
+	}
+	if strings.TrimSpace(a.Decoder.AircraftJSON) != strings.TrimSpace(b.Decoder.AircraftJSON) {
+		return false
+	}
+	if !slices.Equal(a.Inputs, b.Inputs) {
+		return false
+	}
+	if strings.TrimSpace(a.TowerDB) != strings.TrimSpace(b.TowerDB) {
+		return false
+	}
 	if strings.TrimSpace(a.SDR.SerialTag) != strings.TrimSpace(b.SDR.SerialTag) {
 		return false
 	}
@@ -184,6 +223,10 @@ func newLiveRuntime(ctx context.Context, cfg config.Config, resolvedConfigPath s
 		logicalUAT978:      c.UAT978,
 	}
 
+	// Optional: offline aircraft database for traffic enrichment.
+	r.registry = newRegistryWatcher(r.trafficStore, c.Traffic.RegistryDB)
+	r.registry.start(ctx)
+
 	// Optional: external decoders (1090/dump1090-fa, 978/dump978-fa).
 	// Start supervised processes (if configured) and attach NDJSON clients.
 	if err := r.initDecoders(ctx); err != nil {
@@ -191,20 +234,106 @@ func newLiveRuntime(ctx context.Context, cfg config.Config, resolvedConfigPath s
 		return nil, err
 	}
 
+	// Optional: FLARM/OGN traffic (ogn-decode APRS, FLARM NMEA).
+	if err := r.initOGN(ctx, c.OGN); err != nil {
+		r.Close()
+		return nil, err
+	}
+
+	// Optional: raw GPS sentences for loggers and moving maps.
+	if c.NMEAOut.Enable {
+		srv := nmeaout.New(nmeaout.Config{
+			Enable:    true,
+			Listen:    c.NMEAOut.Listen,
+			UDPDest:   c.NMEAOut.UDPDest,
+			RateHz:    c.NMEAOut.RateHz,
+			Sentences: c.NMEAOut.Sentences,
+		})
+		r.nmeaOut = srv
+		if err := srv.Start(ctx); err != nil {
+			log.Printf("nmea_out init failed: %v", err)
+		} else {
+			log.Printf("nmea_out started listen=%s udp_dest=%s", c.NMEAOut.Listen, c.NMEAOut.UDPDest)
+		}
+	}
+
 	// Optional: real GPS bring-up (USB serial NMEA).
 	if c.GPS.Enable {
-		svc := gps.New(gps.Config{
+		var inputs []gps.InputConfig
+		for _, in := range c.GPS.Inputs {
+			inputs = append(inputs, gps.InputConfig{
+				Source:   in.Source,
+				GPSDAddr: in.GPSDAddr,
+				Device:   in.Device,
+				Baud:     in.Baud,
+				Listen:   in.Listen,
+			})
+		}
+		gpsCfg := gps.Config{
 			Enable:   c.GPS.Enable,
 			Source:   c.GPS.Source,
 			GPSDAddr: c.GPS.GPSDAddr,
 			Device:   c.GPS.Device,
 			Baud:     c.GPS.Baud,
-		})
+			Listen:   c.GPS.Listen,
+			UBlox: gps.UBloxConfig{
+				Configure: c.GPS.UBlox.Configure,
+				RateHz:    c.GPS.UBlox.RateHz,
+				Baud:      c.GPS.UBlox.Baud,
+			},
+			Static: gps.StaticPosition{
+				LatDeg:        c.GPS.Static.LatDeg,
+				LonDeg:        c.GPS.Static.LonDeg,
+				ElevationFeet: c.GPS.Static.ElevationFeet,
+			},
+			Inputs:      inputs,
+			SkipDevices: r.serialDevicesInUse(),
+		}
+		if r.nmeaOut != nil {
+			gpsCfg.OnSentence = r.nmeaOut.Publish
+		}
+		svc := gps.New(gpsCfg)
 		if err := svc.Start(ctx); err != nil {
 			// Keep Stratux-NG running even if GPS fails to init.
 			log.Printf("gps init failed: %v", err)
 		}
 		r.gpsSvc = svc
+		if c.GPS.Integrity.Enable {
+			r.gpsIntegrity = gps.NewIntegrityMonitor(c.GPS.Integrity.BaroToleranceFeet)
+		}
+	}
+
+	// Optional: system clock from GPS (and PPS).
+	if c.TimeSync.Enable && r.gpsSvc != nil {
+		coarse := timesync.GPSSource(r.GPSSnapshot)
+		sources := []timesync.Source{coarse}
+		if c.TimeSync.PPSDevice != "" {
+			sources = append([]timesync.Source{timesync.PPSSource(c.TimeSync.PPSDevice, coarse)}, sources...)
+		}
+		svc := timesync.New(timesync.Config{
+			Enable:        true,
+			StepThreshold: c.TimeSync.StepThreshold,
+			Sources:       sources,
+		})
+		r.timeSync = svc
+		if err := svc.Start(ctx); err != nil {
+			log.Printf("timesync init failed: %v", err)
+		}
+	}
+
+	// Optional: serve the GPS-derived time to EFBs on the AP network.
+	if c.NTP.Enable && r.timeSync != nil {
+		listen := c.NTP.Listen
+		if listen == "" {
+			listen = net.JoinHostPort(wifi.APHostIP(c.WiFi.APIP), "123")
+		}
+		srv := ntp.New(ntp.Config{Enable: true, Listen: listen, Status: r.ntpStatus})
+		r.ntpSrv = srv
+		if err := srv.Start(ctx); err != nil {
+			log.Printf("ntp init failed: %v", err)
+		} else {
+			log.Printf("ntp server started listen=%s", listen)
+		}
 	}
 
 	// Optional: fan control.
@@ -291,10 +420,19 @@ func (r *liveRuntime) initDecoders(ctx context.Context) error {
 		if endpoint == "" {
 			endpoint = strings.TrimSpace(band.Decoder.JSONListen)
 		}
-		if endpoint == "" {
-			return fmt.Errorf("adsb1090.decoder.json_addr or json_listen is required")
+		beastEndpoint := strings.TrimSpace(band.Decoder.BeastAddr)
+		if beastEndpoint == "" {
+			beastEndpoint = strings.TrimSpace(band.Decoder.BeastListen)
+		}
+		sbsEndpoint := strings.TrimSpace(band.Decoder.SBSAddr)
+		if sbsEndpoint == "" {
+			sbsEndpoint = strings.TrimSpace(band.Decoder.SBSListen)
+		}
+		aircraftJSON := strings.TrimSpace(band.Decoder.AircraftJSON)
+		if endpoint == "" && beastEndpoint == "" && sbsEndpoint == "" && aircraftJSON == "" && len(band.Inputs) == 0 {
+			return fmt.Errorf("adsb1090.decoder requires a json_*, beast_*, sbs_* endpoint, aircraft_json or inputs")
 		}
-		log.Printf("adsb1090 enabled json_endpoint=%s", endpoint)
+		log.Printf("adsb1090 enabled json_endpoint=%s beast_endpoint=%s sbs_endpoint=%s aircraft_json=%s", endpoint, beastEndpoint, sbsEndpoint, aircraftJSON)
 		if cmd := strings.TrimSpace(band.Decoder.Command); cmd != "" {
 			log.Printf("adsb1090 supervising decoder cmd=%s args=%q", cmd, band.Decoder.Args)
 			sup, err := decoder.NewSupervisor(decoder.SupervisorConfig{
@@ -324,40 +462,112 @@ func (r *liveRuntime) initDecoders(ctx context.Context) error {
 				}
 			}()
 		}
-		client, err := decoder.NewNDJSONClient(decoder.NDJSONClientConfig{
-			Name: "adsb1090",
-			Addr: endpoint,
-		})
-		if err != nil {
-			return fmt.Errorf("adsb1090 ndjson: %w", err)
+		if endpoint != "" {
+			client, err := decoder.NewNDJSONClient(decoder.NDJSONClientConfig{
+				Name: "adsb1090",
+				Addr: endpoint,
+			})
+			if err != nil {
+				return fmt.Errorf("adsb1090 ndjson: %w", err)
+			}
+			if err := client.Start(ctx, r.adsb1090JSONHandler("adsb1090")); err != nil {
+				return fmt.Errorf("adsb1090 ndjson start: %w", err)
+			}
+			r.adsb1090Stream = client
+			go func() {
+				time.Sleep(2 * time.Second)
+				snap := client.Snapshot(time.Now().UTC())
+				if snap.State != "connected" {
+					log.Printf("adsb1090 ndjson state=%s addr=%s last_error=%s", snap.State, snap.Addr, snap.LastError)
+				}
+			}()
 		}
-		if err := client.Start(ctx, func(raw json.RawMessage) error {
-			upd, ok := traffic.ParseDump1090RawJSON(raw)
-			if !ok || r.trafficStore == nil {
-				return nil
+		if beastEndpoint != "" {
+			bc, err := decoder.NewBeastClient(decoder.BeastClientConfig{
+				Name: "adsb1090-beast",
+				Addr: beastEndpoint,
+			})
+			if err != nil {
+				return fmt.Errorf("adsb1090 beast: %w", err)
 			}
-			now := time.Now().UTC()
-			r.trafficStore.Apply(now, upd)
-			return nil
-		}); err != nil {
-			return fmt.Errorf("adsb1090 ndjson start: %w", err)
-		}
-		r.adsb1090Stream = client
-		go func() {
-			time.Sleep(2 * time.Second)
-			snap := client.Snapshot(time.Now().UTC())
-			if snap.State != "connected" {
-				log.Printf("adsb1090 ndjson state=%s addr=%s last_error=%s", snap.State, snap.Addr, snap.LastError)
+			if err := bc.Start(ctx, r.adsb1090BeastHandler("adsb1090")); err != nil {
+				return fmt.Errorf("adsb1090 beast start: %w", err)
 			}
-		}()
+			r.adsb1090Beast = bc
+			go func() {
+				time.Sleep(2 * time.Second)
+				snap := bc.Snapshot(time.Now().UTC())
+				if snap.State != "connected" {
+					log.Printf("adsb1090 beast state=%s addr=%s last_error=%s", snap.State, snap.Addr, snap.LastError)
+				}
+			}()
+		}
+		if sbsEndpoint != "" {
+			lc, err := decoder.NewLineClient(decoder.LineClientConfig{
+				Name: "adsb1090-sbs",
+				Addr: sbsEndpoint,
+			})
+			if err != nil {
+				return fmt.Errorf("adsb1090 sbs: %w", err)
+			}
+			if err := lc.Start(ctx, r.adsb1090SBSHandler("adsb1090")); err != nil {
+				return fmt.Errorf("adsb1090 sbs start: %w", err)
+			}
+			r.adsb1090SBS = lc
+			go func() {
+				time.Sleep(2 * time.Second)
+				snap := lc.Snapshot(time.Now().UTC())
+				if snap.State != "connected" {
+					log.Printf("adsb1090 sbs state=%s addr=%s last_error=%s", snap.State, snap.Addr, snap.LastError)
+				}
+			}()
+		}
+		if aircraftJSON != "" {
+			poller, err := decoder.NewJSONFilePoller(decoder.JSONFilePollerConfig{
+				Name: "adsb1090-aircraft-json",
+				Path: aircraftJSON,
+			})
+			if err != nil {
+				return fmt.Errorf("adsb1090 aircraft_json: %w", err)
+			}
+			if err := poller.Start(ctx, r.adsb1090AircraftJSONHandler("adsb1090")); err != nil {
+				return fmt.Errorf("adsb1090 aircraft_json start: %w", err)
+			}
+			r.adsb1090File = poller
+			go func() {
+				time.Sleep(2 * time.Second)
+				snap := poller.Snapshot(time.Now().UTC())
+				if snap.State != "polling" {
+					log.Printf("adsb1090 aircraft_json state=%s path=%s last_error=%s", snap.State, snap.Path, snap.LastError)
+				}
+			}()
+		}
+		for _, inCfg := range band.Inputs {
+			in, err := r.startDecoderInput(ctx, "adsb1090", inCfg)
+			if err != nil {
+				return err
+			}
+			r.adsb1090Inputs = append(r.adsb1090Inputs, in)
+		}
 	}
 
 	// 978
 	if r.cfg.UAT978.Enable {
+		band := r.cfg.UAT978
 		if r.uat978Agg == nil {
-			r.uat978Agg = uat978.NewAggregator(uat978.AggregatorConfig{})
+			var db *uat978.TowerDB
+			if path := strings.TrimSpace(band.TowerDB); path != "" {
+				loaded, err := uat978.LoadTowerDB(path)
+				if err != nil {
+					// Towers still decode without names; don't block ingest.
+					log.Printf("uat978 tower db load failed path=%s: %v", path, err)
+				} else {
+					log.Printf("uat978 tower db loaded path=%s stations=%d", path, loaded.Len())
+					db = loaded
+				}
+			}
+			r.uat978Agg = uat978.NewAggregator(uat978.AggregatorConfig{TowerDB: db})
 		}
-		band := r.cfg.UAT978
 		if strings.TrimSpace(band.Decoder.Command) != "" && isDump978Command(band.Decoder.Command) {
 			// Prefer the dedicated Stratux UATRadio (FTDI serial) if present, before
 			// falling back to RTL-SDR auto-assignment.
@@ -424,14 +634,7 @@ func (r *liveRuntime) initDecoders(ctx context.Context) error {
 			if err != nil {
 				return fmt.Errorf("uat978 ndjson: %w", err)
 			}
-			if err := client.Start(ctx, func(raw json.RawMessage) error {
-				// Keep the stream healthy: never return errors for parse issues.
-				upd, ok := traffic.ParseDump978NDJSON(raw)
-				if ok && r.trafficStore != nil {
-					r.trafficStore.Apply(time.Now().UTC(), upd)
-				}
-				return nil
-			}); err != nil {
+			if err := client.Start(ctx, r.uat978JSONHandler("uat978")); err != nil {
 				return fmt.Errorf("uat978 ndjson start: %w", err)
 			}
 			r.uat978Stream = client
@@ -451,27 +654,7 @@ func (r *liveRuntime) initDecoders(ctx context.Context) error {
 			if err != nil {
 				return fmt.Errorf("uat978 raw: %w", err)
 			}
-			if err := lc.Start(ctx, func(line []byte) error {
-				payload, ss, hasSS, ok := traffic.ParseDump978RawUplinkLineWithMeta(line)
-				if !ok {
-					return nil
-				}
-				if r.uat978Agg != nil {
-					if decoded, ok := uat978.DecodeUplinkFrame(payload); ok {
-						signalDb := 0.0
-						if hasSS {
-							signalDb = uat978.SignalStrengthDbFromAmplitude(ss)
-						}
-						r.uat978Agg.Add(time.Now().UTC(), decoded, signalDb, hasSS)
-					}
-				}
-				frame := gdl90.UATUplinkFrame(payload)
-				select {
-				case r.uat978UplinkQ <- frame:
-				default:
-				}
-				return nil
-			}); err != nil {
+			if err := lc.Start(ctx, r.uat978RawHandler("uat978", endpoint == "")); err != nil {
 				return fmt.Errorf("uat978 raw start: %w", err)
 			}
 			r.uat978Raw = lc
@@ -483,22 +666,66 @@ func (r *liveRuntime) initDecoders(ctx context.Context) error {
 				}
 			}()
 		}
+		for _, inCfg := range band.Inputs {
+			in, err := r.startDecoderInput(ctx, "uat978", inCfg)
+			if err != nil {
+				return err
+			}
+			r.uat978Inputs = append(r.uat978Inputs, in)
+		}
 	}
 
 	return nil
 }
 
+// serialDevicesInUse lists the serial devices owned by the FLARM reader and
+// the dump978 UAT radio, which GPS auto-detection must leave alone. It runs
+// after initDecoders, which adds an auto-detected radio to the dump978 args.
+func (r *liveRuntime) serialDevicesInUse() []string {
+	var out []string
+	if r.cfg.OGN.Enable {
+		if dev := strings.TrimSpace(r.cfg.OGN.FLARMDevice); dev != "" {
+			out = append(out, dev)
+		}
+	}
+	if r.cfg.UAT978.Enable {
+		args := r.cfg.UAT978.Decoder.Args
+		for i, a := range args {
+			if v, ok := strings.CutPrefix(a, "--stratuxv3="); ok {
+				out = append(out, v)
+			} else if a == "--stratuxv3" && i+1 < len(args) {
+				out = append(out, args[i+1])
+			}
+		}
+	}
+	return out
+}
+
 func (r *liveRuntime) Close() {
 	if r == nil {
 		return
 	}
+	r.registry.Close()
 	if r.ahrsSvc != nil {
 		r.ahrsSvc.Close()
 		r.ahrsSvc = nil
 	}
+	if r.ntpSrv != nil {
+		r.ntpSrv.Close()
+		r.ntpSrv = nil
+	}
+	if r.timeSync != nil {
+		r.timeSync.Close()
+		r.timeSync = nil
+	}
 	if r.gpsSvc != nil {
 		r.gpsSvc.Close()
 		r.gpsSvc = nil
+		r.gpsIntegrity = nil
+	}
+	if r.nmeaOut != nil {
+		r.nmeaOut.Close()
+		r.nmeaOut = nil
 	}
 	if r.fanSvc != nil {
 		r.fanSvc.Close()
@@ -508,14 +735,42 @@ func (r *liveRuntime) Close() {
 		r.adsb1090Stream.Close()
 		r.adsb1090Stream = nil
 	}
+	if r.adsb1090Beast != nil {
+		r.adsb1090Beast.Close()
+		r.adsb1090Beast = nil
+	}
+	if r.adsb1090SBS != nil {
+		r.adsb1090SBS.Close()
+		r.adsb1090SBS = nil
+	}
+	if r.adsb1090File != nil {
+		r.adsb1090File.Close()
+		r.adsb1090File = nil
+	}
+	for _, in := range r.adsb1090Inputs {
+		in.Close()
+	}
+	r.adsb1090Inputs = nil
 	if r.uat978Stream != nil {
 		r.uat978Stream.Close()
 		r.uat978Stream = nil
 	}
+	for _, in := range r.uat978Inputs {
+		in.Close()
+	}
+	r.uat978Inputs = nil
 	if r.uat978Raw != nil {
 		r.uat978Raw.Close()
 		r.uat978Raw = nil
 	}
+	if r.ognAPRS != nil {
+		r.ognAPRS.Close()
+		r.ognAPRS = nil
+	}
+	if r.ognFLARM != nil {
+		r.ognFLARM.Close()
+		r.ognFLARM = nil
+	}
 	if r.adsb1090Sup != nil {
 		r.adsb1090Sup.Close()
 		r.adsb1090Sup = nil
@@ -537,6 +792,43 @@ func (r *liveRuntime) TrafficSnapshots(nowUTC time.Time) []traffic.TargetSnapsho
 	return r.trafficStore.SnapshotDetailed(nowUTC)
 }
 
+// SetTrafficReference updates the receiver position used to range-gate new
+// traffic targets.
+func (r *liveRuntime) SetTrafficReference(latDeg, lonDeg float64, ok bool) {
+	if r == nil || r.trafficStore == nil {
+		return
+	}
+	r.trafficStore.SetReference(latDeg, lonDeg, ok)
+}
+
+func (r *liveRuntime) TrafficRejectStats() []traffic.RejectStats {
+	if r == nil || r.trafficStore == nil {
+		return nil
+	}
+	return r.trafficStore.RejectStats()
+}
+
+func (r *liveRuntime) EmergencyEvents() []traffic.EmergencyEvent {
+	if r == nil || r.trafficStore == nil {
+		return nil
+	}
+	return r.trafficStore.EmergencyEvents()
+}
+
+func (r *liveRuntime) RegistrySnapshot() (web.RegistrySnapshot, bool) {
+	if r == nil {
+		return web.RegistrySnapshot{}, false
+	}
+	return r.registry.Snapshot()
+}
+
+func (r *liveRuntime) ReceiverStats(nowUTC time.Time) traffic.ReceiverStats {
+	if r == nil || r.trafficStore == nil {
+		return traffic.ReceiverStats{}
+	}
+	return r.trafficStore.ReceiverStats(nowUTC)
+}
+
 func (r *liveRuntime) ADSB1090DecoderSnapshot(nowUTC time.Time) (web.DecoderStatusSnapshot, bool) {
 	if r == nil {
 		return web.DecoderStatusSnapshot{}, false
@@ -549,12 +841,23 @@ func (r *liveRuntime) ADSB1090DecoderSnapshot(nowUTC time.Time) (web.DecoderStat
 	if endpoint == "" {
 		endpoint = strings.TrimSpace(cur.Decoder.JSONListen)
 	}
+	beastEP := strings.TrimSpace(cur.Decoder.BeastAddr)
+	if beastEP == "" {
+		beastEP = strings.TrimSpace(cur.Decoder.BeastListen)
+	}
+	sbsEP := strings.TrimSpace(cur.Decoder.SBSAddr)
+	if sbsEP == "" {
+		sbsEP = strings.TrimSpace(cur.Decoder.SBSListen)
+	}
 	snap := web.DecoderStatusSnapshot{
-		Enabled:      true,
-		SerialTag:    strings.TrimSpace(cur.SDR.SerialTag),
-		Command:      strings.TrimSpace(cur.Decoder.Command),
-		Args:         append([]string(nil), cur.Decoder.Args...),
-		JSONEndpoint: endpoint,
+		Enabled:       true,
+		SerialTag:     strings.TrimSpace(cur.SDR.SerialTag),
+		Command:       strings.TrimSpace(cur.Decoder.Command),
+		Args:          append([]string(nil), cur.Decoder.Args...),
+		JSONEndpoint:  endpoint,
+		BeastEndpoint: beastEP,
+		SBSEndpoint:   sbsEP,
+		AircraftJSON:  strings.TrimSpace(cur.Decoder.AircraftJSON),
 	}
 	if r.adsb1090Sup != nil {
 		snap.Supervisor = r.adsb1090Sup.Snapshot()
@@ -563,6 +866,21 @@ func (r *liveRuntime) ADSB1090DecoderSnapshot(nowUTC time.Time) (web.DecoderStat
 		st := r.adsb1090Stream.Snapshot(nowUTC)
 		snap.Stream = &st
 	}
+	if r.adsb1090Beast != nil {
+		bs := r.adsb1090Beast.Snapshot(nowUTC)
+		snap.BeastStream = &bs
+	}
+	if r.adsb1090SBS != nil {
+		ss := r.adsb1090SBS.Snapshot(nowUTC)
+		snap.SBSStream = &ss
+	}
+	if r.adsb1090File != nil {
+		fs := r.adsb1090File.Snapshot(nowUTC)
+		snap.AircraftJSONPoll = &fs
+	}
+	for _, in := range r.adsb1090Inputs {
+		snap.Inputs = append(snap.Inputs, in.Snapshot(nowUTC))
+	}
 	return snap, true
 }
 
@@ -601,6 +919,9 @@ func (r *liveRuntime) UAT978DecoderSnapshot(nowUTC time.Time) (web.DecoderStatus
 		rs := r.uat978Raw.Snapshot(nowUTC)
 		snap.RawStream = &rs
 	}
+	for _, in := range r.uat978Inputs {
+		snap.Inputs = append(snap.Inputs, in.Snapshot(nowUTC))
+	}
 	return snap, true
 }
 
@@ -633,6 +954,27 @@ func (r *liveRuntime) UAT978DecodedSnapshot(nowUTC time.Time) ([]uat978.TowerSna
 	return towers, weather, true
 }
 
+func (r *liveRuntime) UAT978TowerReport(nowUTC time.Time, ownLat, ownLon float64, ownValid bool) (uat978.TowersReport, bool) {
+	if r == nil || r.uat978Agg == nil {
+		return uat978.TowersReport{}, false
+	}
+	return r.uat978Agg.TowerReport(nowUTC, ownLat, ownLon, ownValid), true
+}
+
+func (r *liveRuntime) UAT978Winds(nowUTC time.Time) ([]uat978.WindsStation, bool) {
+	if r == nil || r.uat978Agg == nil {
+		return nil, false
+	}
+	return r.uat978Agg.WindsSnapshot(nowUTC), true
+}
+
+func (r *liveRuntime) UAT978Grids(nowUTC time.Time) ([]uat978.Grid, bool) {
+	if r == nil || r.uat978Agg == nil {
+		return nil, false
+	}
+	return r.uat978Agg.Grids(nowUTC), true
+}
+
 func (r *liveRuntime) FanSnapshot() (fancontrol.Snapshot, bool) {
 	if r == nil || r.fanSvc == nil {
 		return fancontrol.Snapshot{}, false
@@ -647,6 +989,41 @@ func (r *liveRuntime) AHRSSnapshot() (ahrs.Snapshot, bool) {
 	return r.ahrsSvc.Snapshot(), true
 }
 
+func (r *liveRuntime) TimeSyncSnapshot() (timesync.Snapshot, bool) {
+	if r == nil || r.timeSync == nil {
+		return timesync.Snapshot{}, false
+	}
+	return r.timeSync.Snapshot(), true
+}
+
+// ntpStatus describes the local clock to the NTP server. Serial GPS time is
+// only good to the receiver's output latency; PPS to about a millisecond.
+func (r *liveRuntime) ntpStatus() ntp.Status {
+	ts, ok := r.TimeSyncSnapshot()
+	if !ok || ts.LastSyncAt.IsZero() {
+		return ntp.Status{}
+	}
+	st := ntp.Status{Synced: true, RefID: strings.ToUpper(ts.Source), RefTime: ts.LastSyncAt, Error: 250 * time.Millisecond}
+	if ts.Source == "pps" {
+		st.Error = time.Millisecond
+	}
+	return st
+}
+
+func (r *liveRuntime) NTPSnapshot() (ntp.Snapshot, bool) {
+	if r == nil || r.ntpSrv == nil {
+		return ntp.Snapshot{}, false
+	}
+	return r.ntpSrv.Snapshot(), true
+}
+
+func (r *liveRuntime) NMEAOutSnapshot() (nmeaout.Snapshot, bool) {
+	if r == nil || r.nmeaOut == nil {
+		return nmeaout.Snapshot{}, false
+	}
+	return r.nmeaOut.Snapshot(), true
+}
+
 func (r *liveRuntime) GPSSnapshot() (gps.Snapshot, bool) {
 	if r == nil || r.gpsSvc == nil {
 		return gps.Snapshot{}, false
@@ -654,6 +1031,15 @@ func (r *liveRuntime) GPSSnapshot() (gps.Snapshot, bool) {
 	return r.gpsSvc.Snapshot(), true
 }
 
+// GPSIntegrity runs the integrity monitor over a GPS snapshot, using baro
+// pressure altitude when AHRS has it.
+func (r *liveRuntime) GPSIntegrity(nowUTC time.Time, snap gps.Snapshot, haveAHRS bool, ahrsSnap ahrs.Snapshot) (gps.Integrity, bool) {
+	if r == nil || r.gpsIntegrity == nil {
+		return gps.Integrity{}, false
+	}
+	return r.gpsIntegrity.Check(nowUTC, snap, ahrsSnap.PressureAltFeet, haveAHRS && ahrsSnap.PressureAltValid), true
+}
+
 func (r *liveRuntime) AHRSSetLevel() error {
 	if r == nil || r.ahrsSvc == nil {
 		return fmt.Errorf("ahrs unavailable")
@@ -726,9 +1112,18 @@ func (r *liveRuntime) Apply(next config.Config) error {
 	if c.AHRS.Enable != r.cfg.AHRS.Enable || c.AHRS.I2CBus != r.cfg.AHRS.I2CBus || c.AHRS.IMUAddr != r.cfg.AHRS.IMUAddr || c.AHRS.BaroAddr != r.cfg.AHRS.BaroAddr {
 		return fmt.Errorf("ahrs settings require restart")
 	}
-	if c.GPS.Enable != r.cfg.GPS.Enable || strings.TrimSpace(c.GPS.Device) != strings.TrimSpace(r.cfg.GPS.Device) || c.GPS.Baud != r.cfg.GPS.Baud {
+	if c.GPS.Enable != r.cfg.GPS.Enable || c.GPS.Source != r.cfg.GPS.Source || c.GPS.GPSDAddr != r.cfg.GPS.GPSDAddr || strings.TrimSpace(c.GPS.Device) != strings.TrimSpace(r.cfg.GPS.Device) || c.GPS.Baud != r.cfg.GPS.Baud || c.GPS.Listen != r.cfg.GPS.Listen || c.GPS.UBlox != r.cfg.GPS.UBlox || c.GPS.Integrity != r.cfg.GPS.Integrity || c.GPS.Static != r.cfg.GPS.Static || !slices.Equal(c.GPS.Inputs, r.cfg.GPS.Inputs) {
 		return fmt.Errorf("gps settings require restart")
 	}
+	if c.TimeSync != r.cfg.TimeSync {
+		return fmt.Errorf("timesync settings require restart")
+	}
+	if c.NTP != r.cfg.NTP {
+		return fmt.Errorf("ntp settings require restart")
+	}
+	if c.NMEAOut.Enable != r.cfg.NMEAOut.Enable || c.NMEAOut.Listen != r.cfg.NMEAOut.Listen || c.NMEAOut.UDPDest != r.cfg.NMEAOut.UDPDest || c.NMEAOut.RateHz != r.cfg.NMEAOut.RateHz || !slices.Equal(c.NMEAOut.Sentences, r.cfg.NMEAOut.Sentences) {
+		return fmt.Errorf("nmea_out settings require restart")
+	}
 	if c.Fan.Enable != r.cfg.Fan.Enable || c.Fan.PWMPin != r.cfg.Fan.PWMPin || c.Fan.PWMFrequency != r.cfg.Fan.PWMFrequency || c.Fan.TempTargetC != r.cfg.Fan.TempTargetC || c.Fan.PWMDutyMin != r.cfg.Fan.PWMDutyMin || c.Fan.UpdateInterval != r.cfg.Fan.UpdateInterval {
 		return fmt.Errorf("fan settings require restart")
 	}
@@ -742,6 +1137,9 @@ func (r *liveRuntime) Apply(next config.Config) error {
 	if !decoderBandEqual(c.UAT978, r.logicalUAT978) {
 		return fmt.Errorf("uat978 settings require restart")
 	}
+	if c.OGN != r.cfg.OGN {
+		return fmt.Errorf("ogn settings require restart")
+	}
 
 	// Pre-validate side effects before committing anything.
 	var nextBroadcaster *udp.Broadcaster
@@ -753,6 +1151,12 @@ func (r *liveRuntime) Apply(next config.Config) error {
 		nextBroadcaster = b
 	}
 
+	// Commit: switch aircraft database (loaded in the background).
+	if c.Traffic.RegistryDB != r.cfg.Traffic.RegistryDB {
+		r.registry.setPath(c.Traffic.RegistryDB)
+		go r.registry.check()
+	}
+
 	// Commit: swap broadcaster.
 	if nextBroadcaster != nil {
 		r.sender.Swap(nextBroadcaster)
@@ -23,6 +23,7 @@ import (
 	"stratux-ng/internal/gps"
 	"stratux-ng/internal/replay"
 	"stratux-ng/internal/traffic"
+	"stratux-ng/internal/uat978"
 	"stratux-ng/internal/udp"
 	"stratux-ng/internal/web"
 	"stratux-ng/internal/wifi"
@@ -172,10 +173,14 @@ func trafficReportsFromSnapshots(snaps []traffic.TargetSnapshot) []gdl90.Traffic
 	}
 	reports := make([]gdl90.Traffic, 0, len(snaps))
 	for _, snap := range snaps {
-		if !snap.PositionValid {
+		if !snap.PositionValid && !snap.Bearingless {
 			continue
 		}
-		reports = append(reports, snap.Traffic)
+		t := snap.Traffic
+		if t.AddrType == traffic.AddrTypeOGN {
+			t.AddrType = traffic.OGNWireAddrType
+		}
+		reports = append(reports, t)
 	}
 	if len(reports) == 0 {
 		return nil
@@ -183,6 +188,46 @@ func trafficReportsFromSnapshots(snaps []traffic.TargetSnapshot) []gdl90.Traffic
 	return reports
 }
 
+// markBearinglessTraffic turns nearby position-less Mode C/S targets into
+// bearingless targets. Mode C reports pressure altitude, so baro altitude is
+// preferred over GPS altitude for the relative altitude.
+func markBearinglessTraffic(cfg config.BearinglessConfig, nowUTC time.Time, snaps []traffic.TargetSnapshot, gpsSnap gps.Snapshot, gpsValid bool, haveAHRS bool, ahrsSnap ahrs.Snapshot) {
+	if !cfg.Enable || !gpsValid {
+		return
+	}
+	var ownAlt int
+	switch {
+	case haveAHRS && ahrsSnap.PressureAltValid:
+		ownAlt = int(math.Round(ahrsSnap.PressureAltFeet))
+	case gpsSnap.AltFeet != nil:
+		ownAlt = *gpsSnap.AltFeet
+	default:
+		return
+	}
+	traffic.MarkBearingless(snaps, traffic.BearinglessConfig{
+		RSSIAt1Nm:     cfg.RSSIAt1NmDbfs,
+		MaxRangeNm:    cfg.MaxRangeNm,
+		MaxRelAltFeet: cfg.MaxRelAltFeet,
+	}, nowUTC, gpsSnap.LatDeg, gpsSnap.LonDeg, ownAlt)
+}
+
+// logEmergencyEvents logs events newer than lastSeq and returns the newest
+// sequence number seen.
+func logEmergencyEvents(events []traffic.EmergencyEvent, lastSeq uint64) uint64 {
+	for _, ev := range events {
+		if ev.Seq <= lastSeq {
+			continue
+		}
+		pos := "unknown"
+		if ev.PositionValid {
+			pos = fmt.Sprintf("%.5f,%.5f", ev.LatDeg, ev.LonDeg)
+		}
+		log.Printf("traffic emergency icao=%s tail=%s squawk=%s status=%s pos=%s alt=%d source=%s", ev.ICAO, ev.Tail, ev.Squawk, ev.Status, pos, ev.AltFeet, ev.Source)
+		lastSeq = ev.Seq
+	}
+	return lastSeq
+}
+
 func buildTrafficStatusSnapshots(gpsSnap gps.Snapshot, gpsValid bool, snaps []traffic.TargetSnapshot) []web.TrafficSnapshot {
 	if len(snaps) == 0 {
 		return nil
@@ -207,12 +252,37 @@ func buildTrafficStatusSnapshots(gpsSnap gps.Snapshot, gpsValid bool, snaps []tr
 			Extrapolated:    snap.Traffic.Extrapolated,
 			PositionValid:   snap.PositionValid,
 			Source:          string(snap.Source),
+			Inputs:          snap.Inputs,
+			Link:            snap.Link.String(),
+			AddrType:        snap.Traffic.AddrType,
 			Squawk:          strings.TrimSpace(snap.Squawk),
 			EmitterCategory: snap.Traffic.EmitterCategory,
+			Messages:        snap.Messages,
+			MsgRate:         math.Round(snap.MsgRate*10) / 10,
 		}
 		if !snap.SeenAt.IsZero() {
 			ts.SeenUnixNano = snap.SeenAt.UTC().UnixNano()
 		}
+		if !snap.FirstSeen.IsZero() {
+			ts.FirstSeenUTC = snap.FirstSeen.UTC().Format(time.RFC3339)
+		}
+		if snap.Traffic.PriorityStatus != traffic.EmergencyNone {
+			ts.Emergency = traffic.EmergencyName(snap.Traffic.PriorityStatus)
+		}
+		if snap.HasRegistry {
+			ts.Registration = snap.Registry.Registration
+			ts.AircraftType = snap.Registry.TypeCode
+			ts.WakeCategory = snap.Registry.Wake
+			ts.Owner = snap.Registry.Owner
+		}
+		if snap.HasRSSI {
+			rssi, avg := math.Round(snap.RSSI*10)/10, math.Round(snap.RSSIAvg*10)/10
+			ts.RSSIDbfs, ts.RSSIAvgDbfs = &rssi, &avg
+		}
+		if snap.Bearingless {
+			rng, rel := math.Round(snap.EstRangeNm*10)/10, snap.RelAltFeet
+			ts.Bearingless, ts.EstRangeNm, ts.RelAltFeet = true, &rng, &rel
+		}
 		if gpsValid && snap.PositionValid {
 			dist := haversineNm(ownLat, ownLon, snap.Traffic.LatDeg, snap.Traffic.LonDeg)
 			ts.DistanceNm = &dist
@@ -222,6 +292,47 @@ func buildTrafficStatusSnapshots(gpsSnap gps.Snapshot, gpsValid bool, snaps []tr
 	return out
 }
 
+// buildWindsAloftSnapshot interpolates winds/temperature at ownship and, when
+// AHRS and GPS are both available, solves the wind triangle for TAS and wind
+// correction angle. Pressure altitude is preferred over GPS altitude because
+// FD levels are referenced to pressure altitude.
+func buildWindsAloftSnapshot(stations []uat978.WindsStation, gpsSnap gps.Snapshot, gpsValid bool, ahrsValid bool, ahrsSnap ahrs.Snapshot) web.WindsAloftSnapshot {
+	out := web.WindsAloftSnapshot{Stations: stations}
+	for _, st := range stations {
+		if st.LatDeg == nil {
+			out.Unlocated = append(out.Unlocated, st.Station)
+		}
+	}
+	if !gpsValid {
+		return out
+	}
+	altFeet := 0
+	switch {
+	case ahrsValid && ahrsSnap.PressureAltValid:
+		altFeet = int(math.Round(ahrsSnap.PressureAltFeet))
+	case gpsSnap.AltFeet != nil:
+		altFeet = *gpsSnap.AltFeet
+	default:
+		return out
+	}
+	est, ok := uat978.InterpolateWinds(stations, gpsSnap.LatDeg, gpsSnap.LonDeg, altFeet)
+	if !ok {
+		return out
+	}
+	out.Estimate = &est
+	if !ahrsValid || gpsSnap.GroundKt == nil || gpsSnap.TrackDeg == nil {
+		return out
+	}
+	tas, hdg, wca := uat978.WindTriangle(float64(*gpsSnap.GroundKt), *gpsSnap.TrackDeg, est)
+	tas = math.Round(tas)
+	hdg = math.Round(hdg)
+	wca = math.Round(wca)
+	out.TASKt = &tas
+	out.HeadingDeg = &hdg
+	out.WCADeg = &wca
+	return out
+}
+
 func icaoStringFromBytes(icao [3]byte) string {
 	return fmt.Sprintf("%02X%02X%02X", icao[0], icao[1], icao[2])
 }
@@ -565,6 +676,7 @@ func main() {
 		}
 
 		var lastUDPErrorLog time.Time
+		var lastEmergencySeq uint64
 		for {
 			tickC := rt.TickChan()
 			select {
@@ -597,25 +709,14 @@ func main() {
 				if fanSnap, haveFan := rt.FanSnapshot(); haveFan {
 					status.SetFan(now.UTC(), fanSnap)
 				}
-				if curCfg.GPS.Enable {
-					gpsSnap, haveGPS = rt.GPSSnapshot()
-					if haveGPS {
-						if gpsSnap.LastFixUTC != "" {
-							if tFix, perr := time.Parse(time.RFC3339Nano, gpsSnap.LastFixUTC); perr == nil {
-								age := now.UTC().Sub(tFix.UTC()).Seconds()
-								if age < 0 {
-									age = 0
-								}
-								gpsSnap.FixAgeSec = age
-								gpsSnap.FixStale = age > 3.0
-							}
-						}
-						status.SetGPS(now.UTC(), gpsSnap)
-					} else {
-						status.SetGPS(now.UTC(), gps.Snapshot{Enabled: true, Valid: false})
-					}
-				} else {
-					status.SetGPS(now.UTC(), gps.Snapshot{Enabled: false})
+				if tsSnap, ok := rt.TimeSyncSnapshot(); ok {
+					status.SetTimeSync(now.UTC(), tsSnap)
+				}
+				if ntpSnap, ok := rt.NTPSnapshot(); ok {
+					status.SetNTP(now.UTC(), ntpSnap)
+				}
+				if outSnap, ok := rt.NMEAOutSnapshot(); ok {
+					status.SetNMEAOut(now.UTC(), outSnap)
 				}
 				if curCfg.AHRS.Enable {
 					snap, haveAHRS = rt.AHRSSnapshot()
@@ -643,7 +744,32 @@ func main() {
 				} else {
 					status.SetAHRSSensors(now.UTC(), web.AHRSSensorsSnapshot{Enabled: false})
 				}
+				if curCfg.GPS.Enable {
+					gpsSnap, haveGPS = rt.GPSSnapshot()
+					if haveGPS {
+						if gpsSnap.LastFixUTC != "" {
+							if tFix, perr := time.Parse(time.RFC3339Nano, gpsSnap.LastFixUTC); perr == nil {
+								age := now.UTC().Sub(tFix.UTC()).Seconds()
+								if age < 0 {
+									age = 0
+								}
+								gpsSnap.FixAgeSec = age
+								gpsSnap.FixStale = age > 3.0
+							}
+						}
+						if integ, ok := rt.GPSIntegrity(now.UTC(), gpsSnap, haveAHRS, snap); ok {
+							gpsSnap.Integrity = &integ
+						}
+						status.SetGPS(now.UTC(), gpsSnap)
+					} else {
+						status.SetGPS(now.UTC(), gps.Snapshot{Enabled: true, Valid: false})
+					}
+				} else {
+					status.SetGPS(now.UTC(), gps.Snapshot{Enabled: false})
+				}
+				rt.SetTrafficReference(gpsSnap.LatDeg, gpsSnap.LonDeg, haveGPS && gpsSnap.Valid)
 				trafficSnaps := rt.TrafficSnapshots(now.UTC())
+				markBearinglessTraffic(curCfg.Traffic.Bearingless, now.UTC(), trafficSnaps, gpsSnap, haveGPS && gpsSnap.Valid, haveAHRS, snap)
 				liveTraffic := trafficReportsFromSnapshots(trafficSnaps)
 				if curCfg.GPS.Enable {
 					frames = buildGDL90FramesWithGPS(curCfg, now.UTC(), haveAHRS, snap, haveGPS, gpsSnap, liveTraffic)
@@ -654,6 +780,29 @@ func main() {
 					frames = append(frames, extra...)
 				}
 				status.SetTraffic(now.UTC(), buildTrafficStatusSnapshots(gpsSnap, haveGPS && gpsSnap.Valid, trafficSnaps))
+				status.SetTrafficRejects(now.UTC(), rt.TrafficRejectStats())
+				emergencies := rt.EmergencyEvents()
+				lastEmergencySeq = logEmergencyEvents(emergencies, lastEmergencySeq)
+				status.SetEmergencyEvents(now.UTC(), emergencies)
+				status.SetReceiverStats(now.UTC(), rt.ReceiverStats(now.UTC()))
+				reg, _ := rt.RegistrySnapshot()
+				status.SetRegistry(now.UTC(), reg)
+				if ogn, ok := rt.OGNSnapshot(now.UTC()); ok {
+					status.SetOGN(now.UTC(), ogn)
+				}
+				if stations, ok := rt.UAT978Winds(now.UTC()); ok {
+					status.SetWinds(now.UTC(), buildWindsAloftSnapshot(stations, gpsSnap, haveGPS && gpsSnap.Valid, haveAHRS && snap.Valid, snap))
+				}
+				if grids, ok := rt.UAT978Grids(now.UTC()); ok {
+					var hazards []uat978.Hazard
+					if haveGPS && gpsSnap.Valid {
+						hazards = uat978.Hazards(grids, gpsSnap.LatDeg, gpsSnap.LonDeg)
+					}
+					status.SetWxGrids(now.UTC(), grids, hazards)
+				}
+				if rep, ok := rt.UAT978TowerReport(now.UTC(), gpsSnap.LatDeg, gpsSnap.LonDeg, haveGPS && gpsSnap.Valid); ok {
+					status.SetTowers(now.UTC(), rep)
+				}
 				// Always record a "tick" time even if we fail mid-send.
 				status.MarkTick(now.UTC(), 0)
 				sent := 0
@@ -814,7 +963,18 @@ func buildGDL90FramesWithGPS(cfg config.Config, now time.Time, haveAHRS bool, ah
 		return frames
 	}
 
+	// Prefer the receiver's own accuracy estimate (u-blox hAcc) over the
+	// configured figure.
 	nacp := gdl90.NACpFromHorizontalAccuracyMeters(cfg.GPS.HorizontalAccuracyM)
+	if gpsSnap.HorizAccM != nil && *gpsSnap.HorizAccM > 0 {
+		nacp = gdl90.NACpFromHorizontalAccuracyMeters(*gpsSnap.HorizAccM)
+	}
+	// A suspect fix keeps being reported, but with unknown accuracy and
+	// integrity so traffic apps don't trust it for alerting.
+	nic := byte(8)
+	if gpsSnap.Integrity != nil && gpsSnap.Integrity.Degraded {
+		nic, nacp = 0, 0
+	}
 
 	geoAltFeet := 0
 	if gpsSnap.AltFeet != nil {
@@ -850,7 +1010,7 @@ func buildGDL90FramesWithGPS(cfg config.Config, now time.Time, haveAHRS bool, ah
 		LonDeg:      gpsSnap.LonDeg,
 		AltFeet:     ownshipAltFeet,
 		HaveNICNACp: true,
-		NIC:         8,
+		NIC:         nic,
 		NACp:        nacp,
 		GroundKt:    groundKt,
 		TrackDeg:    trackDeg,
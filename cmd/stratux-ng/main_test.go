@@ -1,6 +1,8 @@
 package main
 
 import (
+	"context"
+	"math"
 	"testing"
 	"time"
 
@@ -8,6 +10,8 @@ import (
 	"stratux-ng/internal/config"
 	"stratux-ng/internal/gdl90"
 	"stratux-ng/internal/gps"
+	"stratux-ng/internal/traffic"
+	"stratux-ng/internal/uat978"
 )
 
 func unframeForMsg(t *testing.T, frame []byte) []byte {
@@ -306,6 +310,46 @@ func TestBuildGDL90FramesWithGPS_OwnshipVerticalSpeedFromGPS(t *testing.T) {
 	}
 }
 
+func TestBuildGDL90FramesWithGPS_IntegrityDegradesNICNACp(t *testing.T) {
+	cfg := config.Config{
+		GDL90: config.GDL90Config{Dest: "127.0.0.1:4000", Interval: 1 * time.Second},
+		GPS:   config.GPSConfig{Enable: true, HorizontalAccuracyM: 10},
+		Ownship: config.OwnshipConfig{
+			ICAO:     "F00001",
+			Callsign: "STRATUX",
+		},
+	}
+
+	now := time.Date(2025, 12, 22, 12, 0, 0, 0, time.UTC)
+	alt := 5000
+	gpsSnap := gps.Snapshot{
+		Enabled:    true,
+		Valid:      true,
+		LatDeg:     45.5,
+		LonDeg:     -122.9,
+		AltFeet:    &alt,
+		LastFixUTC: now.UTC().Format(time.RFC3339Nano),
+	}
+
+	nicNACp := func(snap gps.Snapshot) byte {
+		for _, f := range buildGDL90FramesWithGPS(cfg, now, false, ahrs.Snapshot{}, true, snap, nil) {
+			if msg := unframeForMsg(t, f); msg[0] == 0x0A {
+				return msg[13]
+			}
+		}
+		t.Fatalf("expected ownship report")
+		return 0
+	}
+
+	if got := nicNACp(gpsSnap); got != 0x89 {
+		t.Fatalf("healthy NIC/NACp=0x%02X want 0x89", got)
+	}
+	gpsSnap.Integrity = &gps.Integrity{Degraded: true, Reasons: []string{"cn0_drop"}}
+	if got := nicNACp(gpsSnap); got != 0x00 {
+		t.Fatalf("degraded NIC/NACp=0x%02X want 0x00", got)
+	}
+}
+
 func TestBuildGDL90FramesWithGPS_OwnshipVerticalSpeedNegativeFromGPS(t *testing.T) {
 	cfg := config.Config{
 		GDL90: config.GDL90Config{Dest: "127.0.0.1:4000", Interval: 1 * time.Second},
@@ -641,6 +685,51 @@ func TestBuildGDL90FramesWithGPS_AppendsLiveTraffic(t *testing.T) {
 	}
 }
 
+func TestBuildGDL90FramesWithGPS_StaticPositionOwnshipOnGround(t *testing.T) {
+	cfg := config.Config{
+		GDL90: config.GDL90Config{Dest: "127.0.0.1:4000", Interval: 1 * time.Second},
+		GPS:   config.GPSConfig{Enable: true, Source: "static", HorizontalAccuracyM: 10},
+		Ownship: config.OwnshipConfig{
+			ICAO:     "F00001",
+			Callsign: "STRATUX",
+		},
+	}
+
+	svc := gps.New(gps.Config{Enable: true, Source: "static", Static: gps.StaticPosition{LatDeg: 45.5, LonDeg: -122.9, ElevationFeet: 210}})
+	if err := svc.Start(context.Background()); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	defer svc.Close()
+	gpsSnap := svc.Snapshot()
+	if !gpsSnap.Valid || !gpsSnap.Static {
+		t.Fatalf("snapshot=%+v", gpsSnap)
+	}
+
+	now := time.Now().UTC()
+	var ownshipMsg []byte
+	for _, f := range buildGDL90FramesWithGPS(cfg, now, false, ahrs.Snapshot{}, true, gpsSnap, nil) {
+		if msg := unframeForMsg(t, f); msg[0] == 0x0A {
+			ownshipMsg = msg
+		}
+	}
+	if ownshipMsg == nil {
+		t.Fatalf("expected ownship report")
+	}
+	if ownshipMsg[12]&0x08 != 0 {
+		t.Fatalf("static ownship reported airborne")
+	}
+
+	// Traffic gets distance and bearing from the static position.
+	icaoT := mustParseICAO(t, "ABC123")
+	got := buildTrafficStatusSnapshots(gpsSnap, true, []traffic.TargetSnapshot{{
+		Traffic:       gdl90.Traffic{ICAO: icaoT, LatDeg: 45.6, LonDeg: -122.9, AltFeet: 3000},
+		PositionValid: true,
+	}})
+	if len(got) != 1 || got[0].DistanceNm == nil || math.Abs(*got[0].DistanceNm-6) > 0.1 {
+		t.Fatalf("traffic=%+v", got)
+	}
+}
+
 func TestAttitudeSnapshotFromPayload_PrefersAHRSButKeepsHeading(t *testing.T) {
 	snap := ahrs.Snapshot{
 		Valid:            true,
@@ -715,3 +804,60 @@ func TestAttitudeSnapshotFromPayload_FallsBackWhenAHRSInvalid(t *testing.T) {
 		t.Fatalf("expected heading from payload, got %+v", out.HeadingDeg)
 	}
 }
+
+func TestBuildWindsAloftSnapshot_TASRequiresAHRSAndGPS(t *testing.T) {
+	stations := uat978.ParseWindsReport("WINDS SEA 171200Z  FT 3000 6000\nSEA 3620 3620+05", time.Unix(1_000_000, 0).UTC())
+	lat, lon := 47.45, -122.31
+	for i := range stations {
+		stations[i].LatDeg = &lat
+		stations[i].LonDeg = &lon
+	}
+	alt := 4500
+	gs := 100
+	trk := 0.0
+	gpsSnap := gps.Snapshot{Enabled: true, Valid: true, LatDeg: lat, LonDeg: lon, AltFeet: &alt, GroundKt: &gs, TrackDeg: &trk}
+
+	// GPS only: estimate but no TAS.
+	got := buildWindsAloftSnapshot(stations, gpsSnap, true, false, ahrs.Snapshot{})
+	if got.Estimate == nil || got.Estimate.DirDeg != 0 && got.Estimate.DirDeg != 360 || got.Estimate.SpeedKt != 20 {
+		t.Fatalf("unexpected estimate: %+v", got.Estimate)
+	}
+	if got.TASKt != nil {
+		t.Fatalf("expected no TAS without AHRS")
+	}
+	if got.Unlocated != nil {
+		t.Fatalf("unexpected unlocated stations %v", got.Unlocated)
+	}
+
+	// AHRS + GPS: 100 kt ground speed into a 20 kt headwind.
+	got = buildWindsAloftSnapshot(stations, gpsSnap, true, true, ahrs.Snapshot{Valid: true, PressureAltValid: true, PressureAltFeet: 4500})
+	if got.TASKt == nil || *got.TASKt != 120 || got.WCADeg == nil || *got.WCADeg != 0 {
+		t.Fatalf("unexpected TAS/WCA: tas=%v wca=%v", got.TASKt, got.WCADeg)
+	}
+
+	// A station without a known position is reported rather than dropped
+	// silently, and doesn't disturb the estimate.
+	stations = append(stations, uat978.WindsStation{Station: "ZZZ", Levels: stations[0].Levels})
+	got = buildWindsAloftSnapshot(stations, gpsSnap, true, false, ahrs.Snapshot{})
+	if len(got.Unlocated) != 1 || got.Unlocated[0] != "ZZZ" || got.Estimate == nil || got.Estimate.SpeedKt != 20 {
+		t.Fatalf("unlocated=%v estimate=%+v", got.Unlocated, got.Estimate)
+	}
+}
+
+func TestTrafficReportsFromSnapshots_OGNAndBearingless(t *testing.T) {
+	snaps := []traffic.TargetSnapshot{
+		{Traffic: gdl90.Traffic{ICAO: [3]byte{0xDD, 0x8F, 0x12}, AddrType: traffic.AddrTypeOGN, LatDeg: 45, LonDeg: -122}, PositionValid: true},
+		{Traffic: gdl90.Traffic{ICAO: [3]byte{0xAB, 0xC1, 0x23}, LatDeg: 45, LonDeg: -122}, Bearingless: true},
+		{Traffic: gdl90.Traffic{ICAO: [3]byte{0xAB, 0xC1, 0x24}}},
+	}
+	reports := trafficReportsFromSnapshots(snaps)
+	if len(reports) != 2 {
+		t.Fatalf("reports=%d want 2", len(reports))
+	}
+	if reports[0].AddrType != traffic.OGNWireAddrType {
+		t.Fatalf("ogn addr type=%d want %d", reports[0].AddrType, traffic.OGNWireAddrType)
+	}
+	if snaps[0].Traffic.AddrType != traffic.AddrTypeOGN {
+		t.Fatalf("snapshot mutated")
+	}
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"time"
+
+	"stratux-ng/internal/config"
+	"stratux-ng/internal/decoder"
+	"stratux-ng/internal/serialport"
+	"stratux-ng/internal/traffic"
+	"stratux-ng/internal/web"
+)
+
+// initOGN connects the configured FLARM/OGN sources.
+func (r *liveRuntime) initOGN(ctx context.Context, c config.OGNConfig) error {
+	if !c.Enable {
+		return nil
+	}
+	if c.APRSAddr != "" {
+		lc, err := decoder.NewLineClient(decoder.LineClientConfig{Name: "ogn-aprs", Addr: c.APRSAddr})
+		if err != nil {
+			return fmt.Errorf("ogn aprs client init: %w", err)
+		}
+		if err := lc.Start(ctx, r.ognAPRSHandler()); err != nil {
+			return fmt.Errorf("ogn aprs start: %w", err)
+		}
+		r.ognAPRS = lc
+		log.Printf("ogn aprs input started addr=%s", c.APRSAddr)
+	}
+	if c.FLARMAddr != "" || c.FLARMDevice != "" {
+		lcCfg := decoder.LineClientConfig{Name: "ogn-flarm", Addr: c.FLARMAddr}
+		if c.FLARMDevice != "" {
+			device, baud := c.FLARMDevice, c.FLARMBaud
+			lcCfg.Addr = fmt.Sprintf("%s@%d", device, baud)
+			lcCfg.ReconnectDelay = 5 * time.Second
+			lcCfg.Dial = func(context.Context) (io.ReadCloser, error) {
+				f, err := serialport.Open(device, baud)
+				if err != nil {
+					return nil, err
+				}
+				return f, nil
+			}
+		}
+		lc, err := decoder.NewLineClient(lcCfg)
+		if err != nil {
+			return fmt.Errorf("ogn flarm client init: %w", err)
+		}
+		if err := lc.Start(ctx, r.ognFLARMHandler()); err != nil {
+			return fmt.Errorf("ogn flarm start: %w", err)
+		}
+		r.ognFLARM = lc
+		log.Printf("ogn flarm input started endpoint=%s", lcCfg.Addr)
+	}
+	return nil
+}
+
+// ognOwnship returns the ownship position and altitude used to place FLARM
+// targets, plus the offset from GPS (MSL) to pressure altitude so OGN GPS
+// altitudes line up with transponder altitudes. Pressure altitude needs a
+// working baro; without one altitudes stay GPS-based.
+func (r *liveRuntime) ognOwnship() (lat, lon float64, altFeet, baroOffsetFeet int, ok bool) {
+	gs, haveGPS := r.GPSSnapshot()
+	if !haveGPS || !gs.Valid {
+		return 0, 0, 0, 0, false
+	}
+	as, haveAHRS := r.AHRSSnapshot()
+	switch {
+	case haveAHRS && as.PressureAltValid:
+		altFeet = int(math.Round(as.PressureAltFeet))
+		if gs.AltFeet != nil {
+			baroOffsetFeet = altFeet - *gs.AltFeet
+		}
+	case gs.AltFeet != nil:
+		altFeet = *gs.AltFeet
+	default:
+		return 0, 0, 0, 0, false
+	}
+	return gs.LatDeg, gs.LonDeg, altFeet, baroOffsetFeet, true
+}
+
+func (r *liveRuntime) ognAPRSHandler() func([]byte) error {
+	return func(line []byte) error {
+		// Keep the stream healthy: never return errors for parse issues.
+		upd, ok := traffic.ParseOGNAPRS(line)
+		if !ok {
+			return nil
+		}
+		if _, _, _, off, ok := r.ognOwnship(); ok && off != 0 {
+			upd.Traffic.AltFeet += off
+			upd.Meta.AltFeet += off
+		}
+		r.applyTraffic(time.Now().UTC(), "ogn-aprs", upd)
+		return nil
+	}
+}
+
+func (r *liveRuntime) ognFLARMHandler() func([]byte) error {
+	return func(line []byte) error {
+		// Keep the stream healthy: never return errors for parse issues.
+		lat, lon, alt, _, ok := r.ognOwnship()
+		if !ok {
+			// Relative FLARM positions are meaningless without a fix.
+			return nil
+		}
+		if upd, ok := traffic.ParsePFLAA(line, lat, lon, alt); ok {
+			r.applyTraffic(time.Now().UTC(), "ogn-flarm", upd)
+		}
+		return nil
+	}
+}
+
+// OGNSnapshot reports the FLARM/OGN input health when enabled.
+func (r *liveRuntime) OGNSnapshot(nowUTC time.Time) (web.OGNSnapshot, bool) {
+	if r == nil || !r.cfg.OGN.Enable {
+		return web.OGNSnapshot{}, false
+	}
+	var out web.OGNSnapshot
+	if r.ognAPRS != nil {
+		st := r.ognAPRS.Snapshot(nowUTC)
+		out.APRS = &st
+	}
+	if r.ognFLARM != nil {
+		st := r.ognFLARM.Snapshot(nowUTC)
+		out.FLARM = &st
+	}
+	return out, true
+}
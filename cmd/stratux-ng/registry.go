@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"stratux-ng/internal/traffic"
+	"stratux-ng/internal/web"
+)
+
+// registryCheckInterval is how often the aircraft database file is checked
+// for a newer export.
+const registryCheckInterval = 30 * time.Second
+
+// registryWatcher keeps the traffic store's aircraft database in sync with
+// the configured file, rebuilding the index whenever the file changes.
+type registryWatcher struct {
+	store  *traffic.Store
+	cancel context.CancelFunc
+
+	mu      sync.Mutex
+	path    string
+	modTime time.Time
+	size    int64
+	snap    web.RegistrySnapshot
+}
+
+func newRegistryWatcher(store *traffic.Store, path string) *registryWatcher {
+	return &registryWatcher{store: store, path: path, snap: web.RegistrySnapshot{Path: path}}
+}
+
+// start checks the file now and then periodically until ctx ends or Close.
+func (w *registryWatcher) start(ctx context.Context) {
+	ctx, w.cancel = context.WithCancel(ctx)
+	go w.run(ctx)
+}
+
+func (w *registryWatcher) Close() {
+	if w != nil && w.cancel != nil {
+		w.cancel()
+	}
+}
+
+func (w *registryWatcher) run(ctx context.Context) {
+	t := time.NewTicker(registryCheckInterval)
+	defer t.Stop()
+	for {
+		w.check()
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+// setPath switches to another database file; an empty path drops the index.
+func (w *registryWatcher) setPath(path string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if path == w.path {
+		return
+	}
+	w.path, w.modTime, w.size = path, time.Time{}, 0
+	w.snap = web.RegistrySnapshot{Path: path}
+	w.store.SetRegistry(nil)
+}
+
+func (w *registryWatcher) check() {
+	w.mu.Lock()
+	path, modTime, size := w.path, w.modTime, w.size
+	w.mu.Unlock()
+	if path == "" {
+		return
+	}
+
+	fi, err := os.Stat(path)
+	if err != nil {
+		// Keep serving the last index if the file goes away mid-update.
+		w.setError(path, err)
+		return
+	}
+	if fi.ModTime().Equal(modTime) && fi.Size() == size {
+		return
+	}
+	db, err := traffic.LoadRegistry(path)
+	if err != nil {
+		log.Printf("aircraft registry load failed path=%s: %v", path, err)
+		w.setError(path, err)
+		return
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.path != path {
+		return
+	}
+	w.modTime, w.size = fi.ModTime(), fi.Size()
+	w.snap = web.RegistrySnapshot{Path: path, Entries: db.Len(), LoadedUTC: time.Now().UTC().Format(time.RFC3339)}
+	w.store.SetRegistry(db)
+	log.Printf("aircraft registry loaded path=%s entries=%d", path, db.Len())
+}
+
+func (w *registryWatcher) setError(path string, err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.path == path {
+		w.snap.LastError = err.Error()
+	}
+}
+
+func (w *registryWatcher) Snapshot() (web.RegistrySnapshot, bool) {
+	if w == nil {
+		return web.RegistrySnapshot{}, false
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.snap, w.path != ""
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"stratux-ng/internal/gdl90"
+	"stratux-ng/internal/traffic"
+)
+
+func TestRegistryWatcher_ReloadsChangedFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "aircraft-db.csv")
+	write := func(body string, mtime time.Time) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(body), 0o644); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+		if err := os.Chtimes(path, mtime, mtime); err != nil {
+			t.Fatalf("chtimes: %v", err)
+		}
+	}
+	store := traffic.NewStore(traffic.StoreConfig{TTL: time.Minute})
+	icao, _ := gdl90.ParseICAOHex("ABC123")
+	now := time.Now().UTC()
+	store.Apply(now, traffic.NewTrafficUpdateFromTraffic(gdl90.Traffic{ICAO: icao, LatDeg: 45, LonDeg: -122}))
+	tail := func() string { return store.SnapshotDetailed(now)[0].Traffic.Tail }
+
+	w := newRegistryWatcher(store, path)
+	w.check()
+	if snap, ok := w.Snapshot(); !ok || snap.LastError == "" || tail() != "" {
+		t.Fatalf("missing file: snap=%+v tail=%q", snap, tail())
+	}
+
+	write("icao24,registration\nABC123,N1AB\n", now.Add(-time.Hour))
+	w.check()
+	if snap, _ := w.Snapshot(); snap.Entries != 1 || snap.LastError != "" || tail() != "N1AB" {
+		t.Fatalf("first load: snap=%+v tail=%q", snap, tail())
+	}
+
+	// A newer export replaces the index without a restart.
+	write("icao24,registration\nABC123,N2CD\nDEF456,N3EF\n", now)
+	w.check()
+	if snap, _ := w.Snapshot(); snap.Entries != 2 || tail() != "N2CD" {
+		t.Fatalf("reload: snap=%+v tail=%q", snap, tail())
+	}
+
+	// A broken file keeps the last good index.
+	write("registration\nN9\n", now.Add(time.Minute))
+	w.check()
+	if snap, _ := w.Snapshot(); snap.LastError == "" || snap.Entries != 2 || tail() != "N2CD" {
+		t.Fatalf("bad file: snap=%+v tail=%q", snap, tail())
+	}
+
+	w.setPath("")
+	if _, ok := w.Snapshot(); ok || tail() != "" {
+		t.Fatalf("clearing path kept the index: tail=%q", tail())
+	}
+}
@@ -104,3 +104,23 @@ func TestTrafficReplay_Dump978Fixtures_EmitsGDL90Traffic(t *testing.T) {
 		t.Fatalf("expected at least 1 traffic (0x14) message, got %d", got)
 	}
 }
+
+func TestUAT978RawHandler_DownlinksOnlyWithoutJSON(t *testing.T) {
+	line := []byte("-08a1b2c340000151c71c105801940080b009d90cfc25040800900200001090000000;rs=2;ss=180;\n")
+
+	// With a JSON endpoint on the band, the raw downlink is left to it.
+	r := &liveRuntime{trafficStore: traffic.NewStore(traffic.StoreConfig{TTL: time.Minute})}
+	if err := r.uat978RawHandler("uat978", false)(line); err != nil {
+		t.Fatalf("handler: %v", err)
+	}
+	if got := r.trafficStore.Snapshot(time.Now().UTC()); len(got) != 0 {
+		t.Fatalf("targets=%d want 0", len(got))
+	}
+
+	if err := r.uat978RawHandler("uat978", true)(line); err != nil {
+		t.Fatalf("handler: %v", err)
+	}
+	if got := r.trafficStore.Snapshot(time.Now().UTC()); len(got) != 1 {
+		t.Fatalf("targets=%d want 1", len(got))
+	}
+}
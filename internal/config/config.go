@@ -25,8 +25,111 @@ type Config struct {
 	// External decoder inputs (planned): 1090 and 978.
 	//  - Both bands ingest newline-delimited JSON over TCP (dump1090-fa
 	//    --net-stratux-port, dump978-fa --json-port).
+	//  - 1090 can also ingest Beast binary (readsb/dump1090 port 30005),
+	//    SBS-1/BaseStation CSV (port 30003) or a polled aircraft.json file.
 	ADSB1090 DecoderBandConfig `yaml:"adsb1090"`
 	UAT978   DecoderBandConfig `yaml:"uat978"`
+
+	Traffic TrafficConfig `yaml:"traffic"`
+
+	// OGN ingests 868 MHz FLARM/OGN traffic (gliders, tow planes, many
+	// European GA aircraft).
+	OGN OGNConfig `yaml:"ogn"`
+
+	// TimeSync sets the system clock from GPS when no network time is
+	// available (Pis have no RTC).
+	TimeSync TimeSyncConfig `yaml:"timesync"`
+
+	// NTP serves GPS-derived time to EFBs on the AP network.
+	NTP NTPConfig `yaml:"ntp"`
+
+	// NMEAOut re-publishes raw GPS sentences for other software.
+	NMEAOut NMEAOutConfig `yaml:"nmea_out"`
+}
+
+// NMEAOutConfig configures the GPS NMEA passthrough server.
+type NMEAOutConfig struct {
+	Enable bool `yaml:"enable"`
+
+	// Listen is the TCP address clients connect to (default :10110).
+	Listen string `yaml:"listen"`
+
+	// UDPDest optionally also sends every sentence to host:port, e.g. the
+	// AP broadcast address 192.168.10.255:10110.
+	UDPDest string `yaml:"udp_dest"`
+
+	// RateHz limits each sentence type to this many fixes per second (1-5);
+	// 0 forwards at the receiver's rate.
+	RateHz int `yaml:"rate_hz"`
+
+	// Sentences restricts output to these types without talker ID (RMC,
+	// GGA, GSV, PUBX, ...); empty forwards everything.
+	Sentences []string `yaml:"sentences"`
+}
+
+// NTPConfig configures the SNTP server.
+type NTPConfig struct {
+	Enable bool `yaml:"enable"`
+
+	// Listen overrides the UDP bind address; the default is port 123 on
+	// wifi.ap_ip.
+	Listen string `yaml:"listen"`
+}
+
+// TimeSyncConfig configures setting the system clock from GPS time.
+type TimeSyncConfig struct {
+	Enable bool `yaml:"enable"`
+
+	// StepThreshold is the clock error at which the clock is stepped to GPS
+	// time. Serial GPS time is only good to a few hundred milliseconds.
+	StepThreshold time.Duration `yaml:"step_threshold"`
+
+	// PPSDevice is an optional kernel PPS device (e.g. /dev/pps0 from the
+	// pps-gpio overlay) used to keep the clock within a millisecond.
+	PPSDevice string `yaml:"pps_device"`
+}
+
+// OGNConfig configures FLARM/OGN traffic ingest. Set any combination of an
+// ogn-decode APRS endpoint and one FLARM NMEA source (TCP or serial).
+type OGNConfig struct {
+	Enable bool `yaml:"enable"`
+
+	// APRSAddr is the host:port of a local ogn-decode APRS output (usually
+	// 127.0.0.1:50001).
+	APRSAddr string `yaml:"aprs_addr"`
+
+	// FLARMAddr is a host:port emitting FLARM NMEA ($PFLAA), e.g. a FLARM
+	// unit behind a serial-to-TCP bridge.
+	FLARMAddr string `yaml:"flarm_addr"`
+
+	// FLARMDevice is a serial device carrying FLARM NMEA (e.g. /dev/ttyUSB1).
+	// FLARM data ports usually run at 19200 baud.
+	FLARMDevice string `yaml:"flarm_device"`
+	FLARMBaud   int    `yaml:"flarm_baud"`
+}
+
+// TrafficConfig tunes the merged traffic picture shared by both bands.
+type TrafficConfig struct {
+	// RegistryDB is an optional path to an offline aircraft database CSV (FAA
+	// MASTER.txt or an OpenSky dump, e.g. /data/stratux-ng/aircraft-db.csv)
+	// used to show registration, type and owner. The file is re-read when it
+	// changes, so a newer export can be dropped in without a restart.
+	RegistryDB string `yaml:"registry_db"`
+
+	Bearingless BearinglessConfig `yaml:"bearingless"`
+}
+
+// BearinglessConfig controls Mode C/S targets that report altitude but no
+// position. When enabled, those heard strongly enough are sent to EFBs as
+// bearingless targets at ownship position with an RSSI-based range estimate.
+type BearinglessConfig struct {
+	Enable bool `yaml:"enable"`
+	// RSSIAt1NmDbfs is the signal level of a typical transponder 1 nm away.
+	// Calibrate it against a known aircraft; antenna and gain change it a lot.
+	RSSIAt1NmDbfs float64 `yaml:"rssi_at_1nm_dbfs"`
+	// MaxRangeNm and MaxRelAltFeet limit which targets are reported.
+	MaxRangeNm    float64 `yaml:"max_range_nm"`
+	MaxRelAltFeet int     `yaml:"max_rel_alt_feet"`
 }
 
 // DecoderBandConfig describes one RF band ingest path (e.g. 1090 or 978).
@@ -38,6 +141,14 @@ type DecoderBandConfig struct {
 
 	Decoder DecoderConfig `yaml:"decoder"`
 	SDR     SDRSelector   `yaml:"sdr"`
+
+	// Inputs are additional ingest endpoints (e.g. a second receiver on another
+	// antenna, or a remote ground station) merged into the same traffic picture.
+	Inputs []DecoderInput `yaml:"inputs"`
+
+	// TowerDB is an optional path to a local FAA UAT ground-station list
+	// (CSV) used to name and annotate decoded towers. Only used for uat978.
+	TowerDB string `yaml:"tower_db"`
 }
 
 // DecoderConfig configures either:
@@ -48,9 +159,16 @@ type DecoderBandConfig struct {
 // - NDJSON-over-TCP via JSONListen/JSONAddr (e.g. dump978-fa --json-port,
 //   dump1090-fa --net-stratux-port)
 // - raw line-over-TCP via RawListen/RawAddr (e.g. dump978-fa --raw-port)
+// - Beast binary over TCP via BeastListen/BeastAddr (dump1090/readsb port
+//   30005; 1090 only)
+// - SBS-1/BaseStation CSV over TCP via SBSListen/SBSAddr (port 30003; 1090
+//   only)
+// - a readsb/dump1090 aircraft.json file polled via AircraftJSON (1090 only)
 
 // For NDJSON ingest, set exactly one of JSONListen or JSONAddr.
 // For raw ingest, set exactly one of RawListen or RawAddr.
+// For Beast ingest, set exactly one of BeastListen or BeastAddr.
+// For SBS ingest, set exactly one of SBSListen or SBSAddr.
 //
 // At least one ingest source must be configured when the band is enabled.
 type DecoderConfig struct {
@@ -61,9 +179,40 @@ type DecoderConfig struct {
 	JSONAddr   string `yaml:"json_addr"`
 
 	// RawListen/RawAddr configure a TCP endpoint that emits newline-delimited
-	// dump978-style raw messages (e.g. "+<hex>;rs=...;ss=...;").
+	// dump978-style raw messages (e.g. "+<hex>;rs=...;ss=...;"). Uplinks ("+")
+	// are relayed as weather; downlinks ("-") are decoded natively as traffic,
+	// so a raw-only 978 band needs no JSON endpoint.
 	RawListen string `yaml:"raw_listen"`
 	RawAddr   string `yaml:"raw_addr"`
+
+	// BeastListen/BeastAddr configure a TCP endpoint that emits Beast binary
+	// frames (dump1090/readsb --net-bo-port). Mode S messages are decoded
+	// natively, so any Beast-speaking decoder, local or remote, can feed 1090.
+	BeastListen string `yaml:"beast_listen"`
+	BeastAddr   string `yaml:"beast_addr"`
+
+	// SBSListen/SBSAddr configure a TCP endpoint that emits SBS-1/BaseStation
+	// CSV lines ("MSG,3,..."), as offered by most third-party feeders.
+	SBSListen string `yaml:"sbs_listen"`
+	SBSAddr   string `yaml:"sbs_addr"`
+
+	// AircraftJSON is the path of a readsb/dump1090 aircraft.json file (e.g.
+	// /run/readsb/aircraft.json). It is polled and successive snapshots are
+	// diffed into traffic updates.
+	AircraftJSON string `yaml:"aircraft_json"`
+}
+
+// DecoderInput is one extra ingest endpoint for a band. Exactly one endpoint
+// field must be set; band restrictions match DecoderConfig.
+type DecoderInput struct {
+	// Name identifies the input in status and per-target input lists.
+	Name string `yaml:"name"`
+
+	JSONAddr     string `yaml:"json_addr"`
+	RawAddr      string `yaml:"raw_addr"`
+	BeastAddr    string `yaml:"beast_addr"`
+	SBSAddr      string `yaml:"sbs_addr"`
+	AircraftJSON string `yaml:"aircraft_json"`
 }
 
 // SDRSelector describes how to select an SDR device.
@@ -93,6 +242,10 @@ type GPSConfig struct {
 	// Supported values:
 	// - "nmea": read NMEA sentences directly from a serial device
 	// - "gpsd": connect to gpsd and consume JSON reports
+	// - "udp", "tcp": receive NMEA sentences from the network, e.g. from a
+	//   tablet app such as GPS2IP
+	// - "static": a fixed position from Static, for ground stations
+	//   without a receiver (not allowed in Inputs)
 	//
 	// When empty, defaults to "nmea".
 	Source string `yaml:"source"`
@@ -102,14 +255,80 @@ type GPSConfig struct {
 	GPSDAddr string `yaml:"gpsd_addr"`
 
 	// Device is the serial device path (e.g. /dev/ttyACM0 or /dev/ttyUSB0).
-	// When empty, Stratux-NG will attempt to auto-detect a likely device.
+	// When empty, Stratux-NG watches for a likely device to appear.
 	Device string `yaml:"device"`
 
-	// Baud is the serial baud rate. Most USB u-blox receivers default to 9600.
+	// Baud is the serial baud rate tried first; other common rates are
+	// probed when it yields no valid data. Most USB u-blox receivers default
+	// to 9600.
 	Baud int `yaml:"baud"`
 
-	// HorizontalAccuracyM is used to derive NACp similarly to upstream Stratux.
+	// Listen is the local address for Source "udp" or "tcp" (default
+	// :10110, the NMEA-over-IP port).
+	Listen string `yaml:"listen"`
+
+	// HorizontalAccuracyM is used to derive NACp similarly to upstream Stratux
+	// when the receiver doesn't report its own accuracy (UBX NAV-PVT, gpsd).
 	HorizontalAccuracyM float64 `yaml:"horizontal_accuracy_m"`
+
+	// UBlox configures u-blox receivers (GPYes, VK-162) on the "nmea" source.
+	UBlox UBloxConfig `yaml:"ublox"`
+
+	// Inputs is an optional ordered list of GPS receivers, most preferred
+	// first. When set, it replaces the single source above and the service
+	// fails over between inputs based on fix quality, age and accuracy.
+	Inputs []GPSInputConfig `yaml:"inputs"`
+
+	// Integrity watches the fix for signs of jamming or spoofing.
+	Integrity GPSIntegrityConfig `yaml:"integrity"`
+
+	// Static is the position reported by the "static" source.
+	Static GPSStaticConfig `yaml:"static"`
+}
+
+// GPSStaticConfig is the surveyed position of a ground station. The
+// "static" source reports it as a stationary, on-ground fix flagged
+// "static" in status.
+type GPSStaticConfig struct {
+	LatDeg        float64 `yaml:"lat_deg"`
+	LonDeg        float64 `yaml:"lon_deg"`
+	ElevationFeet int     `yaml:"elevation_feet"`
+}
+
+// GPSIntegrityConfig controls the GPS integrity monitor. While it flags the
+// fix, ownship NACp and NIC are reported as unknown.
+type GPSIntegrityConfig struct {
+	Enable bool `yaml:"enable"`
+	// BaroToleranceFeet is how far the GPS-minus-pressure altitude offset
+	// may move before it counts as disagreement (default 500). A negative
+	// value turns the baro check off. Only used when AHRS provides pressure
+	// altitude.
+	BaroToleranceFeet float64 `yaml:"baro_tolerance_feet"`
+}
+
+// GPSInputConfig is one receiver in the GPS failover chain; fields have the
+// same meaning as in GPSConfig.
+type GPSInputConfig struct {
+	Source   string `yaml:"source"`
+	GPSDAddr string `yaml:"gpsd_addr"`
+	Device   string `yaml:"device"`
+	Baud     int    `yaml:"baud"`
+	Listen   string `yaml:"listen"`
+}
+
+// UBloxConfig controls startup configuration of u-blox receivers. UBX
+// messages are parsed whenever the receiver sends them; Configure makes the
+// receiver send them.
+type UBloxConfig struct {
+	// Configure sends UBX configuration at startup: navigation rate, port
+	// baud, GPS+GLONASS+Galileo+SBAS, the airborne dynamic model and UBX
+	// NAV-PVT/SAT/DOP/STATUS output. It is saved to battery-backed RAM.
+	Configure bool `yaml:"configure"`
+	// RateHz is the navigation rate: 1, 2, 4, 5 or 10 (default 5).
+	RateHz int `yaml:"rate_hz"`
+	// Baud is the port rate switched to after configuration (default
+	// 115200). Irrelevant for USB-connected receivers.
+	Baud int `yaml:"baud"`
 }
 
 type FanConfig struct {
@@ -311,6 +530,11 @@ func DefaultAndValidate(cfg *Config) error {
 		addr := strings.TrimSpace(b.Decoder.JSONAddr)
 		rawListen := strings.TrimSpace(b.Decoder.RawListen)
 		rawAddr := strings.TrimSpace(b.Decoder.RawAddr)
+		beastListen := strings.TrimSpace(b.Decoder.BeastListen)
+		beastAddr := strings.TrimSpace(b.Decoder.BeastAddr)
+		sbsListen := strings.TrimSpace(b.Decoder.SBSListen)
+		sbsAddr := strings.TrimSpace(b.Decoder.SBSAddr)
+		aircraftJSON := strings.TrimSpace(b.Decoder.AircraftJSON)
 
 		jsonSet := 0
 		if listen != "" {
@@ -326,8 +550,22 @@ func DefaultAndValidate(cfg *Config) error {
 		if rawAddr != "" {
 			rawSet++
 		}
-		if jsonSet == 0 && rawSet == 0 {
-			return fmt.Errorf("%s.decoder must set at least one ingest source (json_* or raw_*)", name)
+		beastSet := 0
+		if beastListen != "" {
+			beastSet++
+		}
+		if beastAddr != "" {
+			beastSet++
+		}
+		sbsSet := 0
+		if sbsListen != "" {
+			sbsSet++
+		}
+		if sbsAddr != "" {
+			sbsSet++
+		}
+		if jsonSet == 0 && rawSet == 0 && beastSet == 0 && sbsSet == 0 && aircraftJSON == "" && len(b.Inputs) == 0 {
+			return fmt.Errorf("%s.decoder must set at least one ingest source (json_*, raw_*, beast_*, sbs_*, aircraft_json or inputs)", name)
 		}
 		if jsonSet != 0 && jsonSet != 1 {
 			return fmt.Errorf("%s.decoder must set exactly one of json_listen or json_addr", name)
@@ -335,9 +573,27 @@ func DefaultAndValidate(cfg *Config) error {
 		if rawSet != 0 && rawSet != 1 {
 			return fmt.Errorf("%s.decoder must set exactly one of raw_listen or raw_addr", name)
 		}
+		if beastSet != 0 && beastSet != 1 {
+			return fmt.Errorf("%s.decoder must set exactly one of beast_listen or beast_addr", name)
+		}
+		if beastSet > 0 && name != "adsb1090" {
+			return fmt.Errorf("%s.decoder beast_* is only supported for adsb1090", name)
+		}
+		if sbsSet != 0 && sbsSet != 1 {
+			return fmt.Errorf("%s.decoder must set exactly one of sbs_listen or sbs_addr", name)
+		}
+		if sbsSet > 0 && name != "adsb1090" {
+			return fmt.Errorf("%s.decoder sbs_* is only supported for adsb1090", name)
+		}
+		if aircraftJSON != "" && name != "adsb1090" {
+			return fmt.Errorf("%s.decoder aircraft_json is only supported for adsb1090", name)
+		}
 		if rawSet > 0 && name != "uat978" {
 			return fmt.Errorf("%s.decoder raw_* is only supported for uat978", name)
 		}
+		if strings.TrimSpace(b.TowerDB) != "" && name != "uat978" {
+			return fmt.Errorf("%s.tower_db is only supported for uat978", name)
+		}
 		if listen != "" {
 			if _, err := net.ResolveTCPAddr("tcp", listen); err != nil {
 				return fmt.Errorf("%s.decoder.json_listen invalid: %w", name, err)
@@ -358,6 +614,67 @@ func DefaultAndValidate(cfg *Config) error {
 				return fmt.Errorf("%s.decoder.raw_addr invalid: %w", name, err)
 			}
 		}
+		if beastListen != "" {
+			if _, err := net.ResolveTCPAddr("tcp", beastListen); err != nil {
+				return fmt.Errorf("%s.decoder.beast_listen invalid: %w", name, err)
+			}
+		}
+		if beastAddr != "" {
+			if _, err := net.ResolveTCPAddr("tcp", beastAddr); err != nil {
+				return fmt.Errorf("%s.decoder.beast_addr invalid: %w", name, err)
+			}
+		}
+		if sbsListen != "" {
+			if _, err := net.ResolveTCPAddr("tcp", sbsListen); err != nil {
+				return fmt.Errorf("%s.decoder.sbs_listen invalid: %w", name, err)
+			}
+		}
+		if sbsAddr != "" {
+			if _, err := net.ResolveTCPAddr("tcp", sbsAddr); err != nil {
+				return fmt.Errorf("%s.decoder.sbs_addr invalid: %w", name, err)
+			}
+		}
+		names := map[string]bool{name: true}
+		for i := range b.Inputs {
+			in := &b.Inputs[i]
+			in.Name = strings.TrimSpace(in.Name)
+			in.JSONAddr = strings.TrimSpace(in.JSONAddr)
+			in.RawAddr = strings.TrimSpace(in.RawAddr)
+			in.BeastAddr = strings.TrimSpace(in.BeastAddr)
+			in.SBSAddr = strings.TrimSpace(in.SBSAddr)
+			in.AircraftJSON = strings.TrimSpace(in.AircraftJSON)
+			if in.Name == "" {
+				return fmt.Errorf("%s.inputs[%d].name must be non-empty", name, i)
+			}
+			if names[in.Name] {
+				return fmt.Errorf("%s.inputs[%d].name %q is not unique", name, i, in.Name)
+			}
+			names[in.Name] = true
+			set := 0
+			for _, v := range []string{in.JSONAddr, in.RawAddr, in.BeastAddr, in.SBSAddr, in.AircraftJSON} {
+				if v != "" {
+					set++
+				}
+			}
+			if set != 1 {
+				return fmt.Errorf("%s.inputs[%d] must set exactly one of json_addr, raw_addr, beast_addr, sbs_addr or aircraft_json", name, i)
+			}
+			if in.RawAddr != "" && name != "uat978" {
+				return fmt.Errorf("%s.inputs[%d].raw_addr is only supported for uat978", name, i)
+			}
+			if (in.BeastAddr != "" || in.SBSAddr != "" || in.AircraftJSON != "") && name != "adsb1090" {
+				return fmt.Errorf("%s.inputs[%d] beast_addr, sbs_addr and aircraft_json are only supported for adsb1090", name, i)
+			}
+			// Fixed field order keeps the reported error stable.
+			for _, f := range [][2]string{{"json_addr", in.JSONAddr}, {"raw_addr", in.RawAddr}, {"beast_addr", in.BeastAddr}, {"sbs_addr", in.SBSAddr}} {
+				if f[1] == "" {
+					continue
+				}
+				if _, err := net.ResolveTCPAddr("tcp", f[1]); err != nil {
+					return fmt.Errorf("%s.inputs[%d].%s invalid: %w", name, i, f[0], err)
+				}
+			}
+		}
 		// If we are supervising a decoder, a command is required.
 		if strings.TrimSpace(b.Decoder.Command) == "" {
 			// external decoder allowed
@@ -371,6 +688,41 @@ func DefaultAndValidate(cfg *Config) error {
 	if err := validateBand("uat978", &cfg.UAT978); err != nil {
 		return err
 	}
+	cfg.Traffic.RegistryDB = strings.TrimSpace(cfg.Traffic.RegistryDB)
+	if err := validateOGN(&cfg.OGN); err != nil {
+		return err
+	}
+	if err := validateTimeSync(&cfg.TimeSync, cfg.GPS.Enable); err != nil {
+		return err
+	}
+	cfg.NTP.Listen = strings.TrimSpace(cfg.NTP.Listen)
+	if cfg.NTP.Enable && !cfg.TimeSync.Enable {
+		return fmt.Errorf("ntp requires timesync.enable")
+	}
+	if cfg.NTP.Listen != "" {
+		if _, err := net.ResolveUDPAddr("udp", cfg.NTP.Listen); err != nil {
+			return fmt.Errorf("ntp.listen invalid: %w", err)
+		}
+	}
+	bl := &cfg.Traffic.Bearingless
+	if bl.RSSIAt1NmDbfs == 0 {
+		bl.RSSIAt1NmDbfs = -10
+	}
+	if bl.RSSIAt1NmDbfs > 0 {
+		return fmt.Errorf("traffic.bearingless.rssi_at_1nm_dbfs must be <= 0")
+	}
+	if bl.MaxRangeNm == 0 {
+		bl.MaxRangeNm = 5
+	}
+	if bl.MaxRangeNm < 0 {
+		return fmt.Errorf("traffic.bearingless.max_range_nm must be > 0")
+	}
+	if bl.MaxRelAltFeet == 0 {
+		bl.MaxRelAltFeet = 3000
+	}
+	if bl.MaxRelAltFeet < 0 {
+		return fmt.Errorf("traffic.bearingless.max_rel_alt_feet must be > 0")
+	}
 
 	if cfg.GDL90.Record.Enable {
 		if cfg.GDL90.Record.Path == "" {
@@ -399,8 +751,21 @@ func DefaultAndValidate(cfg *Config) error {
 		cfg.GPS.Source = "nmea"
 	}
 	cfg.GPS.Source = strings.ToLower(strings.TrimSpace(cfg.GPS.Source))
-	if cfg.GPS.Source != "nmea" && cfg.GPS.Source != "gpsd" {
-		return fmt.Errorf("gps.source must be one of: nmea, gpsd")
+	switch cfg.GPS.Source {
+	case "nmea", "gpsd":
+	case "udp", "tcp":
+		if strings.TrimSpace(cfg.GPS.Listen) == "" {
+			cfg.GPS.Listen = ":10110"
+		}
+		if _, _, err := net.SplitHostPort(strings.TrimSpace(cfg.GPS.Listen)); err != nil {
+			return fmt.Errorf("gps.listen must be host:port")
+		}
+	case "static":
+		if err := validateGPSStatic(cfg.GPS.Static); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("gps.source must be one of: nmea, gpsd, udp, tcp, static")
 	}
 	if cfg.GPS.Source == "gpsd" {
 		if strings.TrimSpace(cfg.GPS.GPSDAddr) == "" {
@@ -422,6 +787,65 @@ func DefaultAndValidate(cfg *Config) error {
 	if cfg.GPS.HorizontalAccuracyM < 0 {
 		return fmt.Errorf("gps.horizontal_accuracy_m must be >= 0")
 	}
+	if cfg.GPS.UBlox.RateHz == 0 {
+		cfg.GPS.UBlox.RateHz = 5
+	}
+	switch cfg.GPS.UBlox.RateHz {
+	case 1, 2, 4, 5, 10:
+	default:
+		return fmt.Errorf("gps.ublox.rate_hz must be one of: 1, 2, 4, 5, 10")
+	}
+	if cfg.GPS.UBlox.Baud == 0 {
+		cfg.GPS.UBlox.Baud = 115200
+	}
+	switch cfg.GPS.UBlox.Baud {
+	case 9600, 19200, 38400, 57600, 115200:
+	default:
+		return fmt.Errorf("gps.ublox.baud must be one of: 9600, 19200, 38400, 57600, 115200")
+	}
+	if cfg.GPS.Integrity.BaroToleranceFeet == 0 {
+		cfg.GPS.Integrity.BaroToleranceFeet = 500
+	}
+	if cfg.GPS.Integrity.BaroToleranceFeet < 0 {
+		// Off; normalized so the monitor sees one value for it.
+		cfg.GPS.Integrity.BaroToleranceFeet = -1
+	}
+	for i := range cfg.GPS.Inputs {
+		in := &cfg.GPS.Inputs[i]
+		in.Source = strings.ToLower(strings.TrimSpace(in.Source))
+		switch in.Source {
+		case "nmea":
+			if in.Baud == 0 {
+				in.Baud = 9600
+			}
+			if in.Baud < 0 {
+				return fmt.Errorf("gps.inputs[%d].baud must be > 0", i)
+			}
+		case "gpsd":
+			if strings.TrimSpace(in.GPSDAddr) == "" {
+				in.GPSDAddr = "127.0.0.1:2947"
+			}
+			if _, _, err := net.SplitHostPort(strings.TrimSpace(in.GPSDAddr)); err != nil {
+				return fmt.Errorf("gps.inputs[%d].gpsd_addr must be host:port", i)
+			}
+		case "udp", "tcp":
+			if strings.TrimSpace(in.Listen) == "" {
+				in.Listen = ":10110"
+			}
+			if _, _, err := net.SplitHostPort(strings.TrimSpace(in.Listen)); err != nil {
+				return fmt.Errorf("gps.inputs[%d].listen must be host:port", i)
+			}
+		case "static":
+			// A static position always looks healthy, so as a backup it would
+			// replace a failed receiver with the ground station's position.
+			return fmt.Errorf("gps.inputs[%d].source static is only allowed as gps.source", i)
+		default:
+			return fmt.Errorf("gps.inputs[%d].source must be one of: nmea, gpsd, udp, tcp", i)
+		}
+	}
+	if err := validateNMEAOut(&cfg.NMEAOut, &cfg.GPS); err != nil {
+		return err
+	}
 
 	if strings.TrimSpace(cfg.Ownship.ICAO) == "" {
 		cfg.Ownship.ICAO = "F00000"
@@ -592,3 +1016,114 @@ type WiFiConfig struct {
 	ClientSSID string `yaml:"client_ssid"`
 	ClientPass string `yaml:"client_pass"`
 }
+
+func validateOGN(o *OGNConfig) error {
+	o.APRSAddr = strings.TrimSpace(o.APRSAddr)
+	o.FLARMAddr = strings.TrimSpace(o.FLARMAddr)
+	o.FLARMDevice = strings.TrimSpace(o.FLARMDevice)
+	if o.FLARMBaud == 0 {
+		o.FLARMBaud = 19200
+	}
+	if !o.Enable {
+		return nil
+	}
+	if o.APRSAddr == "" && o.FLARMAddr == "" && o.FLARMDevice == "" {
+		return fmt.Errorf("ogn requires aprs_addr, flarm_addr or flarm_device when enabled")
+	}
+	if o.FLARMAddr != "" && o.FLARMDevice != "" {
+		return fmt.Errorf("ogn.flarm_addr and ogn.flarm_device are mutually exclusive")
+	}
+	for _, f := range [][2]string{{"aprs_addr", o.APRSAddr}, {"flarm_addr", o.FLARMAddr}} {
+		if f[1] == "" {
+			continue
+		}
+		if _, err := net.ResolveTCPAddr("tcp", f[1]); err != nil {
+			return fmt.Errorf("ogn.%s invalid: %w", f[0], err)
+		}
+	}
+	return nil
+}
+
+func validateTimeSync(t *TimeSyncConfig, gpsEnabled bool) error {
+	t.PPSDevice = strings.TrimSpace(t.PPSDevice)
+	if t.StepThreshold == 0 {
+		t.StepThreshold = time.Second
+	}
+	if t.StepThreshold < 100*time.Millisecond {
+		return fmt.Errorf("timesync.step_threshold must be >= 100ms")
+	}
+	if t.Enable && !gpsEnabled {
+		return fmt.Errorf("timesync requires gps.enable")
+	}
+	return nil
+}
+
+func validateNMEAOut(n *NMEAOutConfig, gps *GPSConfig) error {
+	n.Listen = strings.TrimSpace(n.Listen)
+	if n.Listen == "" {
+		n.Listen = ":10110"
+	}
+	if _, _, err := net.SplitHostPort(n.Listen); err != nil {
+		return fmt.Errorf("nmea_out.listen must be host:port")
+	}
+	n.UDPDest = strings.TrimSpace(n.UDPDest)
+	if n.UDPDest != "" {
+		if _, _, err := net.SplitHostPort(n.UDPDest); err != nil {
+			return fmt.Errorf("nmea_out.udp_dest must be host:port")
+		}
+	}
+	if n.RateHz < 0 || n.RateHz > 5 {
+		return fmt.Errorf("nmea_out.rate_hz must be between 0 and 5")
+	}
+	for i, t := range n.Sentences {
+		t = strings.ToUpper(strings.TrimSpace(t))
+		if t == "" {
+			return fmt.Errorf("nmea_out.sentences[%d] must not be empty", i)
+		}
+		n.Sentences[i] = t
+	}
+	if !n.Enable {
+		return nil
+	}
+	if !gps.Enable {
+		return fmt.Errorf("nmea_out requires gps.enable")
+	}
+	inputs := gps.Inputs
+	if len(inputs) == 0 {
+		inputs = []GPSInputConfig{{Source: gps.Source, Listen: gps.Listen}}
+	}
+	for _, in := range inputs {
+		if in.Source == "tcp" && listenAddrsOverlap(in.Listen, n.Listen) {
+			return fmt.Errorf("nmea_out.listen conflicts with the gps tcp input on %s", strings.TrimSpace(in.Listen))
+		}
+	}
+	return nil
+}
+
+// listenAddrsOverlap reports whether two TCP listen addresses would claim
+// the same port: same port, and the same host or either a wildcard.
+func listenAddrsOverlap(a, b string) bool {
+	hostA, portA, errA := net.SplitHostPort(strings.TrimSpace(a))
+	hostB, portB, errB := net.SplitHostPort(strings.TrimSpace(b))
+	if errA != nil || errB != nil || portA != portB {
+		return false
+	}
+	wildcard := func(h string) bool { return h == "" || h == "0.0.0.0" || h == "::" }
+	return hostA == hostB || wildcard(hostA) || wildcard(hostB)
+}
+
+func validateGPSStatic(st GPSStaticConfig) error {
+	if st.LatDeg == 0 && st.LonDeg == 0 {
+		return fmt.Errorf("gps.static.lat_deg and gps.static.lon_deg must be set for the static source")
+	}
+	if st.LatDeg < -90 || st.LatDeg > 90 {
+		return fmt.Errorf("gps.static.lat_deg must be between -90 and 90")
+	}
+	if st.LonDeg < -180 || st.LonDeg > 180 {
+		return fmt.Errorf("gps.static.lon_deg must be between -180 and 180")
+	}
+	if st.ElevationFeet < -1500 || st.ElevationFeet > 20000 {
+		return fmt.Errorf("gps.static.elevation_feet must be between -1500 and 20000")
+	}
+	return nil
+}
@@ -3,6 +3,8 @@ package config
 import (
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 	"testing"
 	"time"
 )
@@ -179,3 +181,289 @@ func TestLoad_AircraftProfileRequiresICAOAndCallsign(t *testing.T) {
 	_, err := Load(path)
 	requireErrEq(t, err, "aircraft.profiles[0].icao must be non-empty")
 }
+
+func TestLoad_TowerDBOnlyForUAT978(t *testing.T) {
+	path := writeTempConfig(t, "gdl90:\n  dest: '127.0.0.1:4000'\nadsb1090:\n  enable: true\n  tower_db: './towers.csv'\n  decoder:\n    json_addr: '127.0.0.1:30006'\n")
+	_, err := Load(path)
+	requireErrEq(t, err, "adsb1090.tower_db is only supported for uat978")
+}
+
+func TestLoad_BeastOnlyForADSB1090(t *testing.T) {
+	path := writeTempConfig(t, "gdl90:\n  dest: '127.0.0.1:4000'\nuat978:\n  enable: true\n  decoder:\n    beast_addr: '127.0.0.1:30005'\n")
+	_, err := Load(path)
+	requireErrEq(t, err, "uat978.decoder beast_* is only supported for adsb1090")
+}
+
+func TestLoad_BeastOnlyIngestAccepted(t *testing.T) {
+	path := writeTempConfig(t, "gdl90:\n  dest: '127.0.0.1:4000'\nadsb1090:\n  enable: true\n  decoder:\n    beast_addr: '127.0.0.1:30005'\n")
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.ADSB1090.Decoder.BeastAddr != "127.0.0.1:30005" {
+		t.Fatalf("beast_addr=%q", cfg.ADSB1090.Decoder.BeastAddr)
+	}
+}
+
+func TestLoad_SBSOnlyForADSB1090(t *testing.T) {
+	path := writeTempConfig(t, "gdl90:\n  dest: '127.0.0.1:4000'\nuat978:\n  enable: true\n  decoder:\n    sbs_addr: '127.0.0.1:30003'\n")
+	_, err := Load(path)
+	requireErrEq(t, err, "uat978.decoder sbs_* is only supported for adsb1090")
+}
+
+func TestLoad_AircraftJSONOnlyForADSB1090(t *testing.T) {
+	path := writeTempConfig(t, "gdl90:\n  dest: '127.0.0.1:4000'\nuat978:\n  enable: true\n  decoder:\n    aircraft_json: '/run/readsb/aircraft.json'\n")
+	_, err := Load(path)
+	requireErrEq(t, err, "uat978.decoder aircraft_json is only supported for adsb1090")
+}
+
+func TestLoad_AircraftJSONOnlyIngestAccepted(t *testing.T) {
+	path := writeTempConfig(t, "gdl90:\n  dest: '127.0.0.1:4000'\nadsb1090:\n  enable: true\n  decoder:\n    aircraft_json: '/run/readsb/aircraft.json'\n")
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.ADSB1090.Decoder.AircraftJSON != "/run/readsb/aircraft.json" {
+		t.Fatalf("aircraft_json=%q", cfg.ADSB1090.Decoder.AircraftJSON)
+	}
+}
+
+func TestLoad_DecoderInputs(t *testing.T) {
+	path := writeTempConfig(t, "gdl90:\n  dest: '127.0.0.1:4000'\nadsb1090:\n  enable: true\n  decoder:\n    json_addr: '127.0.0.1:30006'\n  inputs:\n    - name: ' top '\n      beast_addr: '192.168.10.2:30005'\n    - name: ground\n      sbs_addr: '10.0.0.5:30003'\n")
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if len(cfg.ADSB1090.Inputs) != 2 || cfg.ADSB1090.Inputs[0].Name != "top" || cfg.ADSB1090.Inputs[1].SBSAddr != "10.0.0.5:30003" {
+		t.Fatalf("inputs=%+v", cfg.ADSB1090.Inputs)
+	}
+}
+
+func TestLoad_DecoderInputsValidation(t *testing.T) {
+	cases := []struct {
+		yaml string
+		want string
+	}{
+		{
+			"adsb1090:\n  enable: true\n  inputs:\n    - name: a\n      json_addr: '127.0.0.1:1'\n      beast_addr: '127.0.0.1:2'\n",
+			"adsb1090.inputs[0] must set exactly one of json_addr, raw_addr, beast_addr, sbs_addr or aircraft_json",
+		},
+		{
+			"adsb1090:\n  enable: true\n  inputs:\n    - json_addr: '127.0.0.1:1'\n",
+			"adsb1090.inputs[0].name must be non-empty",
+		},
+		{
+			"adsb1090:\n  enable: true\n  decoder:\n    json_addr: '127.0.0.1:1'\n  inputs:\n    - name: adsb1090\n      json_addr: '127.0.0.1:2'\n",
+			`adsb1090.inputs[0].name "adsb1090" is not unique`,
+		},
+		{
+			"uat978:\n  enable: true\n  inputs:\n    - name: remote\n      beast_addr: '127.0.0.1:2'\n",
+			"uat978.inputs[0] beast_addr, sbs_addr and aircraft_json are only supported for adsb1090",
+		},
+		{
+			"adsb1090:\n  enable: true\n  inputs:\n    - name: remote\n      raw_addr: '127.0.0.1:2'\n",
+			"adsb1090.inputs[0].raw_addr is only supported for uat978",
+		},
+	}
+	for _, tc := range cases {
+		path := writeTempConfig(t, "gdl90:\n  dest: '127.0.0.1:4000'\n"+tc.yaml)
+		_, err := Load(path)
+		requireErrEq(t, err, tc.want)
+	}
+}
+
+func TestLoad_BearinglessDefaults(t *testing.T) {
+	path := writeTempConfig(t, "gdl90:\n  dest: '127.0.0.1:4000'\ntraffic:\n  bearingless:\n    enable: true\n")
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	bl := cfg.Traffic.Bearingless
+	if !bl.Enable || bl.RSSIAt1NmDbfs != -10 || bl.MaxRangeNm != 5 || bl.MaxRelAltFeet != 3000 {
+		t.Fatalf("unexpected bearingless defaults: %+v", bl)
+	}
+}
+
+func TestLoad_BearinglessPositiveRSSIRejected(t *testing.T) {
+	path := writeTempConfig(t, "gdl90:\n  dest: '127.0.0.1:4000'\ntraffic:\n  bearingless:\n    rssi_at_1nm_dbfs: 3\n")
+	_, err := Load(path)
+	requireErrEq(t, err, "traffic.bearingless.rssi_at_1nm_dbfs must be <= 0")
+}
+
+func TestLoad_OGNValidation(t *testing.T) {
+	path := writeTempConfig(t, "gdl90:\n  dest: '127.0.0.1:4000'\nogn:\n  enable: true\n")
+	_, err := Load(path)
+	requireErrEq(t, err, "ogn requires aprs_addr, flarm_addr or flarm_device when enabled")
+
+	path = writeTempConfig(t, "gdl90:\n  dest: '127.0.0.1:4000'\nogn:\n  enable: true\n  flarm_addr: '127.0.0.1:4353'\n  flarm_device: /dev/ttyUSB1\n")
+	_, err = Load(path)
+	requireErrEq(t, err, "ogn.flarm_addr and ogn.flarm_device are mutually exclusive")
+
+	path = writeTempConfig(t, "gdl90:\n  dest: '127.0.0.1:4000'\nogn:\n  enable: true\n  aprs_addr: '127.0.0.1:50001'\n  flarm_device: /dev/ttyUSB1\n")
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	if cfg.OGN.FLARMBaud != 19200 {
+		t.Fatalf("flarm_baud=%d want 19200", cfg.OGN.FLARMBaud)
+	}
+	// With both addresses bad, the first field is always the one reported.
+	path = writeTempConfig(t, "gdl90:\n  dest: '127.0.0.1:4000'\nogn:\n  enable: true\n  aprs_addr: nohost\n  flarm_addr: noport\n")
+	for i := 0; i < 10; i++ {
+		_, err = Load(path)
+		if err == nil || !strings.HasPrefix(err.Error(), "ogn.aprs_addr invalid:") {
+			t.Fatalf("err=%v", err)
+		}
+	}
+}
+
+func TestLoad_GPSUBloxDefaultsAndValidation(t *testing.T) {
+	path := writeTempConfig(t, "gdl90:\n  dest: '127.0.0.1:4000'\n")
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	if cfg.GPS.UBlox.RateHz != 5 || cfg.GPS.UBlox.Baud != 115200 {
+		t.Fatalf("ublox=%+v want rate_hz 5 baud 115200", cfg.GPS.UBlox)
+	}
+
+	path = writeTempConfig(t, "gdl90:\n  dest: '127.0.0.1:4000'\ngps:\n  ublox:\n    rate_hz: 3\n")
+	_, err = Load(path)
+	requireErrEq(t, err, "gps.ublox.rate_hz must be one of: 1, 2, 4, 5, 10")
+
+	path = writeTempConfig(t, "gdl90:\n  dest: '127.0.0.1:4000'\ngps:\n  ublox:\n    baud: 4800\n")
+	_, err = Load(path)
+	requireErrEq(t, err, "gps.ublox.baud must be one of: 9600, 19200, 38400, 57600, 115200")
+}
+
+func TestLoad_GPSInputsDefaultsAndValidation(t *testing.T) {
+	path := writeTempConfig(t, "gdl90:\n  dest: '127.0.0.1:4000'\ngps:\n  inputs:\n    - source: NMEA\n      device: /dev/ttyACM0\n    - source: gpsd\n")
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	want := []GPSInputConfig{
+		{Source: "nmea", Device: "/dev/ttyACM0", Baud: 9600},
+		{Source: "gpsd", GPSDAddr: "127.0.0.1:2947"},
+	}
+	if !slices.Equal(cfg.GPS.Inputs, want) {
+		t.Fatalf("inputs=%+v want %+v", cfg.GPS.Inputs, want)
+	}
+
+	path = writeTempConfig(t, "gdl90:\n  dest: '127.0.0.1:4000'\ngps:\n  inputs:\n    - source: nmea\n    - source: garmin\n")
+	_, err = Load(path)
+	requireErrEq(t, err, "gps.inputs[1].source must be one of: nmea, gpsd, udp, tcp")
+
+	path = writeTempConfig(t, "gdl90:\n  dest: '127.0.0.1:4000'\ngps:\n  inputs:\n    - source: gpsd\n      gpsd_addr: localhost\n")
+	_, err = Load(path)
+	requireErrEq(t, err, "gps.inputs[0].gpsd_addr must be host:port")
+}
+
+func TestLoad_GPSIntegrityDefaultsAndValidation(t *testing.T) {
+	path := writeTempConfig(t, "gdl90:\n  dest: '127.0.0.1:4000'\ngps:\n  enable: true\n  integrity:\n    enable: true\n")
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	if !cfg.GPS.Integrity.Enable || cfg.GPS.Integrity.BaroToleranceFeet != 500 {
+		t.Fatalf("integrity=%+v", cfg.GPS.Integrity)
+	}
+
+	path = writeTempConfig(t, "gdl90:\n  dest: '127.0.0.1:4000'\ngps:\n  integrity:\n    enable: true\n    baro_tolerance_feet: -50\n")
+	cfg, err = Load(path)
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	if cfg.GPS.Integrity.BaroToleranceFeet != -1 {
+		t.Fatalf("baro_tolerance_feet=%v want -1 (off)", cfg.GPS.Integrity.BaroToleranceFeet)
+	}
+}
+
+func TestLoad_GPSStaticValidation(t *testing.T) {
+	path := writeTempConfig(t, "gdl90:\n  dest: '127.0.0.1:4000'\ngps:\n  enable: true\n  source: static\n  static:\n    lat_deg: 45.5\n    lon_deg: -122.9\n    elevation_feet: 210\n")
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	if cfg.GPS.Source != "static" || cfg.GPS.Static != (GPSStaticConfig{LatDeg: 45.5, LonDeg: -122.9, ElevationFeet: 210}) {
+		t.Fatalf("gps=%+v", cfg.GPS)
+	}
+
+	path = writeTempConfig(t, "gdl90:\n  dest: '127.0.0.1:4000'\ngps:\n  enable: true\n  source: static\n")
+	_, err = Load(path)
+	requireErrEq(t, err, "gps.static.lat_deg and gps.static.lon_deg must be set for the static source")
+
+	path = writeTempConfig(t, "gdl90:\n  dest: '127.0.0.1:4000'\ngps:\n  enable: true\n  source: static\n  static:\n    lat_deg: 95\n    lon_deg: 10\n")
+	_, err = Load(path)
+	requireErrEq(t, err, "gps.static.lat_deg must be between -90 and 90")
+
+	path = writeTempConfig(t, "gdl90:\n  dest: '127.0.0.1:4000'\ngps:\n  inputs:\n    - source: nmea\n    - source: static\n  static:\n    lat_deg: 45.5\n    lon_deg: -122.9\n")
+	_, err = Load(path)
+	requireErrEq(t, err, "gps.inputs[1].source static is only allowed as gps.source")
+}
+
+func TestLoad_NMEAOutDefaultsAndValidation(t *testing.T) {
+	path := writeTempConfig(t, "gdl90:\n  dest: '127.0.0.1:4000'\ngps:\n  enable: true\nnmea_out:\n  enable: true\n  sentences: [rmc, ' gga']\n")
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	if cfg.NMEAOut.Listen != ":10110" || !slices.Equal(cfg.NMEAOut.Sentences, []string{"RMC", "GGA"}) {
+		t.Fatalf("nmea_out=%+v", cfg.NMEAOut)
+	}
+
+	path = writeTempConfig(t, "gdl90:\n  dest: '127.0.0.1:4000'\nnmea_out:\n  enable: true\n")
+	_, err = Load(path)
+	requireErrEq(t, err, "nmea_out requires gps.enable")
+
+	path = writeTempConfig(t, "gdl90:\n  dest: '127.0.0.1:4000'\nnmea_out:\n  rate_hz: 10\n")
+	_, err = Load(path)
+	requireErrEq(t, err, "nmea_out.rate_hz must be between 0 and 5")
+
+	path = writeTempConfig(t, "gdl90:\n  dest: '127.0.0.1:4000'\ngps:\n  enable: true\n  source: tcp\nnmea_out:\n  enable: true\n")
+	_, err = Load(path)
+	requireErrEq(t, err, "nmea_out.listen conflicts with the gps tcp input on :10110")
+
+	path = writeTempConfig(t, "gdl90:\n  dest: '127.0.0.1:4000'\ngps:\n  enable: true\n  source: tcp\nnmea_out:\n  enable: true\n  listen: 0.0.0.0:10110\n")
+	_, err = Load(path)
+	requireErrEq(t, err, "nmea_out.listen conflicts with the gps tcp input on :10110")
+
+	path = writeTempConfig(t, "gdl90:\n  dest: '127.0.0.1:4000'\ngps:\n  enable: true\n  source: tcp\n  listen: 192.168.10.1:10110\nnmea_out:\n  enable: true\n  listen: 127.0.0.1:10110\n")
+	if _, err := Load(path); err != nil {
+		t.Fatalf("distinct hosts rejected: %v", err)
+	}
+}
+
+func TestLoad_TimeSyncDefaultsAndValidation(t *testing.T) {
+	path := writeTempConfig(t, "gdl90:\n  dest: '127.0.0.1:4000'\ngps:\n  enable: true\ntimesync:\n  enable: true\n")
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	if cfg.TimeSync.StepThreshold != time.Second {
+		t.Fatalf("step_threshold=%v want 1s", cfg.TimeSync.StepThreshold)
+	}
+
+	path = writeTempConfig(t, "gdl90:\n  dest: '127.0.0.1:4000'\ntimesync:\n  enable: true\n")
+	_, err = Load(path)
+	requireErrEq(t, err, "timesync requires gps.enable")
+
+	path = writeTempConfig(t, "gdl90:\n  dest: '127.0.0.1:4000'\ntimesync:\n  step_threshold: 10ms\n")
+	_, err = Load(path)
+	requireErrEq(t, err, "timesync.step_threshold must be >= 100ms")
+}
+
+func TestLoad_NTPValidation(t *testing.T) {
+	path := writeTempConfig(t, "gdl90:\n  dest: '127.0.0.1:4000'\nntp:\n  enable: true\n")
+	_, err := Load(path)
+	requireErrEq(t, err, "ntp requires timesync.enable")
+
+	path = writeTempConfig(t, "gdl90:\n  dest: '127.0.0.1:4000'\ngps:\n  enable: true\ntimesync:\n  enable: true\nntp:\n  enable: true\n  listen: '192.168.10.1:123'\n")
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	if !cfg.NTP.Enable || cfg.NTP.Listen != "192.168.10.1:123" {
+		t.Fatalf("ntp=%+v", cfg.NTP)
+	}
+}
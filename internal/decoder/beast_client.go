@@ -0,0 +1,332 @@
+package decoder
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Beast frame types (dump1090/readsb --net-bo-port, usually 30005).
+const (
+	BeastModeAC     byte = '1'
+	BeastModeSShort byte = '2'
+	BeastModeSLong  byte = '3'
+	BeastStatus     byte = '4'
+
+	beastEscape = 0x1a
+)
+
+// BeastFrame is one decoded Beast message.
+type BeastFrame struct {
+	Type byte
+	// Timestamp is the 48-bit MLAT counter (12 MHz for most receivers).
+	Timestamp uint64
+	// Signal is the raw signal level byte (sqrt of power, 0-255).
+	Signal byte
+	Data   []byte
+}
+
+// RSSIDbfs converts the signal level byte to dBFS, as dump1090 reports it.
+func (f BeastFrame) RSSIDbfs() float64 {
+	s := math.Max(float64(f.Signal), 1) / 255.0
+	return 20 * math.Log10(s)
+}
+
+func beastMessageLen(typ byte) int {
+	switch typ {
+	case BeastModeAC:
+		return 2
+	case BeastModeSShort:
+		return 7
+	case BeastModeSLong, BeastStatus:
+		return 14
+	default:
+		return 0
+	}
+}
+
+// BeastReader splits a Beast byte stream into frames.
+type BeastReader struct {
+	r      *bufio.Reader
+	synced bool
+
+	// Dropped counts frames cut short by an unescaped 0x1a (a new frame
+	// starting mid-message, usually after lost bytes).
+	Dropped uint64
+}
+
+func NewBeastReader(r io.Reader) *BeastReader {
+	return &BeastReader{r: bufio.NewReader(r)}
+}
+
+// Next returns the next complete frame, skipping any bytes before a frame
+// start and undoing 0x1a escaping.
+func (br *BeastReader) Next() (BeastFrame, error) {
+	r := br.r
+	for {
+		if !br.synced {
+			b, err := r.ReadByte()
+			if err != nil {
+				return BeastFrame{}, err
+			}
+			if b != beastEscape {
+				continue
+			}
+		}
+		br.synced = false
+
+		typ, err := r.ReadByte()
+		if err != nil {
+			return BeastFrame{}, err
+		}
+		n := beastMessageLen(typ)
+		if n == 0 {
+			if typ == beastEscape {
+				// Could be the start of a frame after a stray escape.
+				br.synced = true
+			}
+			continue
+		}
+
+		buf := make([]byte, 7+n)
+		for i := 0; i < len(buf); i++ {
+			c, err := r.ReadByte()
+			if err != nil {
+				return BeastFrame{}, err
+			}
+			if c == beastEscape {
+				next, err := r.ReadByte()
+				if err != nil {
+					return BeastFrame{}, err
+				}
+				if next != beastEscape {
+					// Unescaped 0x1a: a new frame started mid-message.
+					_ = r.UnreadByte()
+					br.synced = true
+					buf = nil
+					break
+				}
+			}
+			buf[i] = c
+		}
+		if buf == nil {
+			br.Dropped++
+			continue
+		}
+
+		var ts uint64
+		for _, b := range buf[:6] {
+			ts = ts<<8 | uint64(b)
+		}
+		return BeastFrame{Type: typ, Timestamp: ts, Signal: buf[6], Data: buf[7:]}, nil
+	}
+}
+
+type BeastClientConfig struct {
+	Name string
+	Addr string
+
+	ReconnectDelay time.Duration
+
+	// DialTimeout is used for the initial TCP connect.
+	DialTimeout time.Duration
+}
+
+// BeastClient reads Beast binary frames from a TCP endpoint, reconnecting as
+// needed.
+type BeastClient struct {
+	cfg BeastClientConfig
+
+	started atomic.Bool
+	closed  atomic.Bool
+
+	mu       sync.RWMutex
+	state    string
+	lastErr  string
+	lastSeen time.Time
+	count    uint64
+	bad      uint64
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+type BeastSnapshot struct {
+	Name        string `json:"name"`
+	Addr        string `json:"addr"`
+	State       string `json:"state"`
+	LastError   string `json:"last_error,omitempty"`
+	LastSeenUTC string `json:"last_seen_utc,omitempty"`
+	Frames      uint64 `json:"frames"`
+	BadFrames   uint64 `json:"bad_frames,omitempty"`
+}
+
+func NewBeastClient(cfg BeastClientConfig) (*BeastClient, error) {
+	if cfg.Name == "" {
+		return nil, fmt.Errorf("beast client name is required")
+	}
+	if cfg.Addr == "" {
+		return nil, fmt.Errorf("beast client addr is required")
+	}
+	if cfg.ReconnectDelay <= 0 {
+		cfg.ReconnectDelay = 1 * time.Second
+	}
+	if cfg.DialTimeout <= 0 {
+		cfg.DialTimeout = 2 * time.Second
+	}
+
+	return &BeastClient{cfg: cfg, state: "stopped", done: make(chan struct{})}, nil
+}
+
+// Start connects to the configured TCP endpoint and reads Beast frames. For
+// each frame, onFrame is called; the frame's Data is not reused.
+//
+// onFrame should be fast; if it can block, it should offload work.
+func (c *BeastClient) Start(ctx context.Context, onFrame func(f BeastFrame) error) error {
+	if c == nil {
+		return fmt.Errorf("beast client is nil")
+	}
+	if c.closed.Load() {
+		return fmt.Errorf("beast client is closed")
+	}
+	if onFrame == nil {
+		return fmt.Errorf("beast onFrame is nil")
+	}
+	if c.started.Swap(true) {
+		return fmt.Errorf("beast client already started")
+	}
+
+	runCtx, cancel := context.WithCancel(ctx)
+	c.cancel = cancel
+	c.setState("connecting", "")
+
+	go func() {
+		defer close(c.done)
+		c.runLoop(runCtx, onFrame)
+	}()
+	return nil
+}
+
+func (c *BeastClient) Close() {
+	if c == nil {
+		return
+	}
+	if c.closed.Swap(true) {
+		return
+	}
+	if !c.started.Load() {
+		// Never started: there is no run loop to wait for.
+		return
+	}
+	if c.cancel != nil {
+		c.cancel()
+	}
+	<-c.done
+}
+
+func (c *BeastClient) Snapshot(nowUTC time.Time) BeastSnapshot {
+	if c == nil {
+		return BeastSnapshot{}
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	out := BeastSnapshot{
+		Name:      c.cfg.Name,
+		Addr:      c.cfg.Addr,
+		State:     c.state,
+		LastError: c.lastErr,
+		Frames:    c.count,
+		BadFrames: c.bad,
+	}
+	if !c.lastSeen.IsZero() {
+		out.LastSeenUTC = c.lastSeen.UTC().Format(time.RFC3339Nano)
+	}
+	return out
+}
+
+func (c *BeastClient) runLoop(ctx context.Context, onFrame func(f BeastFrame) error) {
+	dialer := &net.Dialer{Timeout: c.cfg.DialTimeout}
+
+	for {
+		select {
+		case <-ctx.Done():
+			c.setState("stopped", "")
+			return
+		default:
+		}
+
+		c.setState("connecting", "")
+		conn, err := dialer.DialContext(ctx, "tcp", c.cfg.Addr)
+		if err != nil {
+			c.setState("error", err.Error())
+			if !sleepCtx(ctx, c.cfg.ReconnectDelay) {
+				c.setState("stopped", "")
+				return
+			}
+			continue
+		}
+
+		c.setState("connected", "")
+		// Unblock the read when the context is cancelled.
+		stop := context.AfterFunc(ctx, func() { _ = conn.Close() })
+		reader := NewBeastReader(conn)
+		var dropped uint64
+
+		for {
+			f, err := reader.Next()
+			if reader.Dropped != dropped {
+				c.mu.Lock()
+				c.bad += reader.Dropped - dropped
+				c.mu.Unlock()
+				dropped = reader.Dropped
+			}
+			if err != nil {
+				_ = conn.Close()
+				switch {
+				case ctx.Err() != nil:
+				case errors.Is(err, net.ErrClosed), errors.Is(err, io.EOF):
+					c.setState("disconnected", "")
+				default:
+					c.setState("disconnected", err.Error())
+				}
+				break
+			}
+
+			if err := onFrame(f); err != nil {
+				c.setState("error", "handler: "+err.Error())
+				continue
+			}
+
+			now := time.Now().UTC()
+			c.mu.Lock()
+			c.lastSeen = now
+			c.count++
+			c.mu.Unlock()
+		}
+		stop()
+
+		if !sleepCtx(ctx, c.cfg.ReconnectDelay) {
+			c.setState("stopped", "")
+			return
+		}
+	}
+}
+
+func (c *BeastClient) setState(state string, lastErr string) {
+	c.mu.Lock()
+	c.state = state
+	if lastErr != "" {
+		c.lastErr = lastErr
+	} else if state == "connected" || state == "connecting" || state == "stopped" {
+		c.lastErr = ""
+	}
+	c.mu.Unlock()
+}
@@ -0,0 +1,53 @@
+package decoder
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+)
+
+func TestReadBeastFrame_UnescapesAndResyncs(t *testing.T) {
+	var in []byte
+	in = append(in, 0x00, 0x42) // junk before sync
+	// Short Mode S frame with an escaped 0x1a in the timestamp and payload.
+	in = append(in, 0x1a, '2', 0x00, 0x00, 0x1a, 0x1a, 0x00, 0x01, 0x02, 0x80)
+	in = append(in, 0x5d, 0x1a, 0x1a, 0x3c, 0x4d, 0x5e, 0x6f, 0x70)
+	// Truncated long frame, interrupted by the next frame start.
+	in = append(in, 0x1a, '3', 0x00, 0x01, 0x1a, '1', 0, 0, 0, 0, 0, 1, 0x10, 0x12, 0x34)
+
+	r := NewBeastReader(bytes.NewReader(in))
+	f, err := r.Next()
+	if err != nil {
+		t.Fatalf("Next: %v", err)
+	}
+	if f.Type != BeastModeSShort || f.Signal != 0x80 || f.Timestamp != 0x00001a000102 {
+		t.Fatalf("unexpected frame %+v", f)
+	}
+	if !bytes.Equal(f.Data, []byte{0x5d, 0x1a, 0x3c, 0x4d, 0x5e, 0x6f, 0x70}) {
+		t.Fatalf("unexpected data %x", f.Data)
+	}
+
+	f, err = r.Next()
+	if err != nil {
+		t.Fatalf("Next after resync: %v", err)
+	}
+	if r.Dropped != 1 {
+		t.Fatalf("dropped=%d want 1", r.Dropped)
+	}
+	if f.Type != BeastModeAC || !bytes.Equal(f.Data, []byte{0x12, 0x34}) {
+		t.Fatalf("unexpected Mode A/C frame %+v", f)
+	}
+	if _, err := r.Next(); !errors.Is(err, io.EOF) {
+		t.Fatalf("expected EOF, got %v", err)
+	}
+}
+
+func TestBeastFrame_RSSIDbfs(t *testing.T) {
+	if got := (BeastFrame{Signal: 255}).RSSIDbfs(); got != 0 {
+		t.Fatalf("full scale rssi=%f want 0", got)
+	}
+	if got := (BeastFrame{Signal: 0}).RSSIDbfs(); got > -48 || got < -49 {
+		t.Fatalf("floor rssi=%f", got)
+	}
+}
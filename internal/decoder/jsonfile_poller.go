@@ -101,6 +101,10 @@ func (p *JSONFilePoller) Close() {
 	if p.closed.Swap(true) {
 		return
 	}
+	if !p.started.Load() {
+		// Never started: there is no run loop to wait for.
+		return
+	}
 	if p.cancel != nil {
 		p.cancel()
 	}
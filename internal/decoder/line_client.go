@@ -6,7 +6,9 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"net"
+	"os"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -21,6 +23,10 @@ type LineClientConfig struct {
 
 	// DialTimeout is used for the initial TCP connect.
 	DialTimeout time.Duration
+
+	// Dial, when set, replaces the TCP connect (e.g. to read a serial
+	// device); Addr is then only used as a label.
+	Dial func(ctx context.Context) (io.ReadCloser, error)
 }
 
 type LineClient struct {
@@ -104,6 +110,10 @@ func (c *LineClient) Close() {
 	if c.closed.Swap(true) {
 		return
 	}
+	if !c.started.Load() {
+		// Never started: there is no run loop to wait for.
+		return
+	}
 	if c.cancel != nil {
 		c.cancel()
 	}
@@ -135,7 +145,18 @@ func (c *LineClient) Snapshot(nowUTC time.Time) LineSnapshot {
 }
 
 func (c *LineClient) runLoop(ctx context.Context, onLine func(line []byte) error) {
-	dialer := &net.Dialer{Timeout: c.cfg.DialTimeout}
+	dial := c.cfg.Dial
+	if dial == nil {
+		dialer := &net.Dialer{Timeout: c.cfg.DialTimeout}
+		dial = func(ctx context.Context) (io.ReadCloser, error) {
+			conn, err := dialer.DialContext(ctx, "tcp", c.cfg.Addr)
+			if err != nil {
+				return nil, err
+			}
+			_ = conn.SetReadDeadline(time.Time{})
+			return conn, nil
+		}
+	}
 
 	for {
 		select {
@@ -146,7 +167,7 @@ func (c *LineClient) runLoop(ctx context.Context, onLine func(line []byte) error
 		}
 
 		c.setState("connecting", "")
-		conn, err := dialer.DialContext(ctx, "tcp", c.cfg.Addr)
+		conn, err := dial(ctx)
 		if err != nil {
 			c.setState("error", err.Error())
 			if !sleepCtx(ctx, c.cfg.ReconnectDelay) {
@@ -157,7 +178,8 @@ func (c *LineClient) runLoop(ctx context.Context, onLine func(line []byte) error
 		}
 
 		c.setState("connected", "")
-		_ = conn.SetReadDeadline(time.Time{})
+		// Unblock a pending read when the client is closed.
+		stop := context.AfterFunc(ctx, func() { _ = conn.Close() })
 		reader := bufio.NewReader(conn)
 
 		for {
@@ -172,7 +194,9 @@ func (c *LineClient) runLoop(ctx context.Context, onLine func(line []byte) error
 			line, err := reader.ReadBytes('\n')
 			if err != nil {
 				_ = conn.Close()
-				if errors.Is(err, net.ErrClosed) {
+				// Closed by Close: a socket reports net.ErrClosed, a serial
+				// device os.ErrClosed.
+				if errors.Is(err, net.ErrClosed) || errors.Is(err, os.ErrClosed) {
 					c.setState("disconnected", "")
 				} else {
 					c.setState("disconnected", err.Error())
@@ -205,6 +229,7 @@ func (c *LineClient) runLoop(ctx context.Context, onLine func(line []byte) error
 			c.count++
 			c.mu.Unlock()
 		}
+		stop()
 
 		if !sleepCtx(ctx, c.cfg.ReconnectDelay) {
 			c.setState("stopped", "")
@@ -1,6 +1,9 @@
 package decoder
 
 import (
+	"context"
+	"io"
+	"strings"
 	"testing"
 	"time"
 )
@@ -22,3 +25,60 @@ func TestLineClient_setState_ClearsStaleErrorOnConnected(t *testing.T) {
 		t.Fatalf("last_error=%q want empty", snap.LastError)
 	}
 }
+
+func TestClients_CloseWithoutStart(t *testing.T) {
+	lc, _ := NewLineClient(LineClientConfig{Name: "t", Addr: "127.0.0.1:1"})
+	nc, _ := NewNDJSONClient(NDJSONClientConfig{Name: "t", Addr: "127.0.0.1:1"})
+	bc, _ := NewBeastClient(BeastClientConfig{Name: "t", Addr: "127.0.0.1:1"})
+	fp, _ := NewJSONFilePoller(JSONFilePollerConfig{Name: "t", Path: "/nonexistent"})
+	// A failed Start (nil handler) leaves nothing running either.
+	if err := lc.Start(context.Background(), nil); err == nil {
+		t.Fatalf("expected Start error")
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		lc.Close()
+		nc.Close()
+		bc.Close()
+		fp.Close()
+	}()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatalf("Close blocked on a client that never started")
+	}
+}
+
+func TestLineClient_CustomDial(t *testing.T) {
+	c, err := NewLineClient(LineClientConfig{
+		Name: "t",
+		Addr: "/dev/ttyFAKE@19200",
+		Dial: func(context.Context) (io.ReadCloser, error) {
+			return io.NopCloser(strings.NewReader("one\r\n\ntwo\n")), nil
+		},
+		ReconnectDelay: time.Hour,
+	})
+	if err != nil {
+		t.Fatalf("NewLineClient: %v", err)
+	}
+	lines := make(chan string, 4)
+	if err := c.Start(context.Background(), func(line []byte) error {
+		lines <- string(line)
+		return nil
+	}); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	defer c.Close()
+	for _, want := range []string{"one", "two"} {
+		select {
+		case got := <-lines:
+			if got != want {
+				t.Fatalf("line=%q want %q", got, want)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("timed out waiting for %q", want)
+		}
+	}
+}
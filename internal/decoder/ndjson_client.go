@@ -105,6 +105,10 @@ func (c *NDJSONClient) Close() {
 	if c.closed.Swap(true) {
 		return
 	}
+	if !c.started.Load() {
+		// Never started: there is no run loop to wait for.
+		return
+	}
 	if c.cancel != nil {
 		c.cancel()
 	}
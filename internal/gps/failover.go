@@ -0,0 +1,94 @@
+package gps
+
+import "time"
+
+const (
+	// inputStaleAfter is the fix age at which an input scores zero.
+	inputStaleAfter = 3 * time.Second
+
+	// healthyScore is the score an input needs to be kept or chosen.
+	healthyScore = 40
+
+	// failbackAfter is how long a preferred input must stay healthy before
+	// it takes over again, so a flapping receiver doesn't cause a switch
+	// on every fix.
+	failbackAfter = 10 * time.Second
+)
+
+// InputStatus is one failover chain entry as reported in Snapshot.
+type InputStatus struct {
+	Name      string `json:"name"`
+	Source    string `json:"source"`
+	Valid     bool   `json:"valid"`
+	Score     int    `json:"score"`
+	Active    bool   `json:"active"`
+	Sender    string `json:"sender,omitempty"`
+	LastError string `json:"last_error,omitempty"`
+}
+
+// inputScore rates an input 0..100 from its fix quality, accuracy and age.
+// Zero means unusable.
+func inputScore(snap Snapshot, fixAge time.Duration) int {
+	if !snap.Valid || fixAge < 0 || fixAge >= inputStaleAfter {
+		return 0
+	}
+
+	score := 30.0
+	if snap.FixMode != nil && *snap.FixMode >= 3 || snap.FixMode == nil && snap.AltFeet != nil {
+		score = 60
+	}
+	if q := snap.FixQuality; q != nil && (*q == 2 || *q == 4 || *q == 5) {
+		// DGPS/SBAS or RTK.
+		score += 10
+	}
+
+	switch {
+	case snap.HorizAccM != nil && *snap.HorizAccM > 0:
+		score += 30 * max(0, 1-*snap.HorizAccM/50)
+	case snap.HDOP != nil && *snap.HDOP > 0:
+		score += 30 * max(0, 1-(*snap.HDOP-1)/9)
+	default:
+		score += 15
+	}
+
+	score -= float64(fixAge / (100 * time.Millisecond))
+	return int(min(max(score, 0), 100))
+}
+
+// selectLocked returns the input to use given the current scores.
+//
+// The active input is kept while healthy. When it isn't, the best healthy
+// input takes over (the earlier one on a tie), or failing that the best one
+// with any fix if the active input has none. A more preferred input takes
+// back over once it has been healthy for failbackAfter.
+func (s *Service) selectLocked(now time.Time, scores []int) int {
+	for i, in := range s.inputs {
+		switch {
+		case scores[i] < healthyScore:
+			in.healthySince = time.Time{}
+		case in.healthySince.IsZero():
+			in.healthySince = now
+		}
+	}
+
+	cur := s.active
+	if scores[cur] < healthyScore {
+		best := 0
+		for i, sc := range scores {
+			if sc > scores[best] {
+				best = i
+			}
+		}
+		if scores[best] >= healthyScore || scores[cur] == 0 && scores[best] > 0 {
+			return best
+		}
+		return cur
+	}
+
+	for i := 0; i < cur; i++ {
+		if scores[i] >= healthyScore && now.Sub(s.inputs[i].healthySince) >= failbackAfter {
+			return i
+		}
+	}
+	return cur
+}
@@ -0,0 +1,94 @@
+package gps
+
+import (
+	"testing"
+	"time"
+)
+
+func fix3D(hAccM float64, lastFix string) Snapshot {
+	mode, alt := 3, 1200
+	return Snapshot{Valid: true, FixMode: &mode, AltFeet: &alt, HorizAccM: &hAccM, LastFixUTC: lastFix}
+}
+
+func TestInputScore(t *testing.T) {
+	if got := inputScore(Snapshot{}, 0); got != 0 {
+		t.Fatalf("invalid score=%d want 0", got)
+	}
+	if got := inputScore(fix3D(5, "a"), 0); got != 87 {
+		t.Fatalf("3D score=%d want 87", got)
+	}
+	if got := inputScore(fix3D(5, "a"), 500*time.Millisecond); got != 82 {
+		t.Fatalf("aged score=%d want 82", got)
+	}
+	if got := inputScore(fix3D(5, "a"), inputStaleAfter); got != 0 {
+		t.Fatalf("stale score=%d want 0", got)
+	}
+
+	// 2D with DGPS and only HDOP.
+	mode, q, hdop := 2, 2, 1.0
+	if got := inputScore(Snapshot{Valid: true, FixMode: &mode, FixQuality: &q, HDOP: &hdop}, 0); got != 70 {
+		t.Fatalf("2D score=%d want 70", got)
+	}
+}
+
+func TestServiceSnapshot_FailoverAndFailback(t *testing.T) {
+	s := New(Config{Enable: true, Inputs: []InputConfig{
+		{Source: "nmea", Device: "/dev/ttyACM0"},
+		{Source: "gpsd", GPSDAddr: "127.0.0.1:2947"},
+	}})
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	s.now = func() time.Time { return now }
+	primary, backup := s.inputs[0], s.inputs[1]
+
+	primary.store(fix3D(3, "t0"), now)
+	backup.store(fix3D(8, "t0"), now)
+	snap := s.Snapshot()
+	if snap.ActiveInput != "nmea:/dev/ttyACM0" || len(snap.Inputs) != 2 || !snap.Inputs[0].Active {
+		t.Fatalf("snapshot=%+v", snap)
+	}
+
+	// Primary stops updating: the backup takes over once it goes stale.
+	now = now.Add(2 * time.Second)
+	backup.store(fix3D(8, "t2"), now)
+	if snap := s.Snapshot(); snap.ActiveInput != "nmea:/dev/ttyACM0" {
+		t.Fatalf("switched early: %+v", snap.Inputs)
+	}
+	now = now.Add(2 * time.Second)
+	backup.store(fix3D(8, "t4"), now)
+	if snap := s.Snapshot(); snap.ActiveInput != "gpsd:127.0.0.1:2947" || !snap.Inputs[1].Active {
+		t.Fatalf("no failover: active=%s inputs=%+v", snap.ActiveInput, snap.Inputs)
+	}
+
+	// Primary recovers: it must stay healthy for failbackAfter first.
+	for i := 0; i <= int(failbackAfter/time.Second); i++ {
+		if snap := s.Snapshot(); i > 0 && snap.ActiveInput != "gpsd:127.0.0.1:2947" {
+			t.Fatalf("failed back after %ds", i-1)
+		}
+		now = now.Add(time.Second)
+		primary.store(fix3D(3, now.String()), now)
+		backup.store(fix3D(8, now.String()), now)
+	}
+	if snap := s.Snapshot(); snap.ActiveInput != "nmea:/dev/ttyACM0" {
+		t.Fatalf("no failback: active=%s", snap.ActiveInput)
+	}
+}
+
+func TestServiceSnapshot_SingleInputOmitsChain(t *testing.T) {
+	s := New(Config{Enable: true, Source: "gpsd", GPSDAddr: "127.0.0.1:2947"})
+	snap := s.Snapshot()
+	if !snap.Enabled || snap.Source != "gpsd" || snap.ActiveInput != "" || snap.Inputs != nil {
+		t.Fatalf("snapshot=%+v", snap)
+	}
+}
+
+func TestService_OnSentenceOnlyFromActiveInput(t *testing.T) {
+	var got []string
+	s := New(Config{Enable: true, Inputs: []InputConfig{{Source: "nmea"}, {Source: "udp"}}, OnSentence: func(line string) {
+		got = append(got, line)
+	}})
+	s.inputs[0].emit("$GPRMC,primary")
+	s.inputs[1].emit("$GPRMC,backup")
+	if len(got) != 1 || got[0] != "$GPRMC,primary" {
+		t.Fatalf("forwarded %q", got)
+	}
+}
@@ -100,6 +100,10 @@ type gpsdState struct {
 	vSpeedFPM int
 	vsOK      bool
 
+	// timeUTC is the TPV time of the last fix, received at timeAt.
+	timeUTC time.Time
+	timeAt  time.Time
+
 	lastFix time.Time
 	valid   bool
 
@@ -158,6 +162,10 @@ func (s *gpsdState) snapshot() Snapshot {
 	if !s.lastFix.IsZero() {
 		out.LastFixUTC = s.lastFix.UTC().Format(time.RFC3339Nano)
 	}
+	if !s.timeUTC.IsZero() {
+		out.TimeUTC = s.timeUTC.Format(time.RFC3339Nano)
+		out.TimeAt = s.timeAt
+	}
 	out.LatDeg = s.latDeg
 	out.LonDeg = s.lonDeg
 	out.LastError = s.lastErr
@@ -215,9 +223,11 @@ func (s *gpsdState) applyTPV(nowUTC time.Time, tpv gpsdTPV) bool {
 	}
 
 	fixTime := nowUTC
+	fixTimeOK := false
 	if strings.TrimSpace(tpv.Time) != "" {
 		if t, err := time.Parse(time.RFC3339Nano, tpv.Time); err == nil {
 			fixTime = t.UTC()
+			fixTimeOK = true
 		}
 	}
 
@@ -268,6 +278,9 @@ func (s *gpsdState) applyTPV(nowUTC time.Time, tpv gpsdTPV) bool {
 	if mode >= 2 && s.latOK && s.lonOK {
 		s.valid = true
 		s.lastFix = fixTime
+		if fixTimeOK {
+			s.timeUTC, s.timeAt = fixTime, nowUTC
+		}
 		updated = true
 	}
 
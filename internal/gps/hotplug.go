@@ -0,0 +1,373 @@
+package gps
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"stratux-ng/internal/serialport"
+)
+
+const (
+	// devicePollInterval paces the /dev scan while no receiver is attached.
+	devicePollInterval = time.Second
+
+	// probeTimeout is how long one device/baud pair gets to produce a
+	// checksum-valid sentence; receivers talk at least once a second.
+	probeTimeout = 2500 * time.Millisecond
+
+	// silenceTimeout drops a receiver that stopped talking (reset to
+	// another baud, wedged USB) so it is probed again.
+	silenceTimeout = 5 * time.Second
+
+	// ubxPollTimeout is how long an answering receiver gets to reply to a
+	// UBX poll before it is taken to be something other than a u-blox.
+	ubxPollTimeout = time.Second
+)
+
+// receiverBauds are the rates a receiver may be found at: common NMEA
+// defaults and whatever a u-blox may have been left at by a previous save or
+// another tool.
+var receiverBauds = []int{9600, 38400, 57600, 115200, 19200, 4800}
+
+// serialPort is an open receiver. *os.File from serialport.Open satisfies
+// it; tests substitute pseudo-terminals.
+type serialPort interface {
+	io.ReadWriteCloser
+	SetReadDeadline(t time.Time) error
+}
+
+func openSerial(device string, baud int) (serialPort, error) {
+	return serialport.Open(device, baud)
+}
+
+// listSerialDevices returns the USB serial devices a GPS may be on.
+func listSerialDevices() []string {
+	var out []string
+	for _, pattern := range []string{"/dev/ttyACM*", "/dev/ttyUSB*"} {
+		m, _ := filepath.Glob(pattern)
+		out = append(out, m...)
+	}
+	slices.Sort(out)
+	return out
+}
+
+// claimedDevices keeps two auto-detecting inputs off the same device.
+var claimedDevices sync.Map
+
+// receiverPort is a device that answered a probe, positioned after the
+// probed message.
+type receiverPort struct {
+	port   serialPort
+	r      *bufio.Reader
+	device string
+	baud   int
+	// ublox is set when the receiver replied to a UBX poll.
+	ublox bool
+}
+
+// skipped reports whether device is one of in.skip, which belong to other
+// parts of the system. Symlinks such as /dev/stratux-uatradio are resolved
+// on both sides.
+func (in *input) skipped(device string) bool {
+	dev := resolveDevice(device)
+	for _, s := range in.skip {
+		if resolveDevice(s) == dev {
+			return true
+		}
+	}
+	return false
+}
+
+func resolveDevice(path string) string {
+	if p, err := filepath.EvalSymlinks(path); err == nil {
+		return p
+	}
+	return path
+}
+
+// startNMEA attaches to a serial receiver in the background: it waits for a
+// device to appear, finds its baud rate, and starts over when the device is
+// unplugged or goes quiet.
+func (in *input) startNMEA(ctx context.Context, wg *sync.WaitGroup) error {
+	childCtx, cancel := context.WithCancel(ctx)
+	in.mu.Lock()
+	in.cancel = cancel
+	in.mu.Unlock()
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			rp, ok := in.attach(childCtx)
+			if !ok {
+				return
+			}
+			err := in.readNMEA(childCtx, rp)
+			_ = rp.port.Close()
+			device := rp.device
+			claimedDevices.Delete(device)
+			if childCtx.Err() != nil {
+				return
+			}
+			log.Printf("gps device %s lost: %v", device, err)
+			in.mu.Lock()
+			cur := in.snapshot()
+			cur.Valid = false
+			cur.LastError = fmt.Sprintf("gps device %s lost: %v", device, err)
+			in.last.Store(cur)
+			in.mu.Unlock()
+		}
+	}()
+	return nil
+}
+
+// attach polls for a device until one answers. Auto-detection leaves the
+// devices in in.skip alone.
+func (in *input) attach(ctx context.Context) (*receiverPort, bool) {
+	for {
+		var devices []string
+		for _, dev := range in.listDevices() {
+			if !in.skipped(dev) {
+				devices = append(devices, dev)
+			}
+		}
+		if dev := strings.TrimSpace(in.cfg.Device); dev != "" {
+			devices = []string{dev}
+		}
+		present := 0
+		for _, dev := range devices {
+			if ctx.Err() != nil {
+				return nil, false
+			}
+			if _, err := os.Stat(dev); err != nil {
+				continue
+			}
+			present++
+			if _, taken := claimedDevices.LoadOrStore(dev, in); taken {
+				continue
+			}
+			if rp, ok := in.probe(ctx, dev); ok {
+				return rp, true
+			}
+			claimedDevices.Delete(dev)
+		}
+		switch {
+		case present == 0 && strings.TrimSpace(in.cfg.Device) != "":
+			in.setError(fmt.Sprintf("gps device %s not present", devices[0]))
+		case present == 0:
+			in.setError("gps auto-detect: no /dev/ttyACM* or /dev/ttyUSB* found")
+		default:
+			in.setError(fmt.Sprintf("gps: no receiver answering on %s", strings.Join(devices, ", ")))
+		}
+		select {
+		case <-ctx.Done():
+			return nil, false
+		case <-time.After(devicePollInterval):
+		}
+	}
+}
+
+// probe tries the configured baud, then the others, until the device sends
+// a checksum-valid NMEA sentence or UBX frame. With ublox.configure, an
+// answering receiver is polled for UBX and, if it is a u-blox, moved to the
+// configured baud.
+func (in *input) probe(ctx context.Context, device string) (*receiverPort, bool) {
+	baud := in.cfg.Baud
+	if baud == 0 {
+		baud = 9600
+	}
+	var bauds []int
+	if in.ublox.Configure && in.ublox.Baud != 0 {
+		// Where an earlier attach left it.
+		bauds = append(bauds, in.ublox.Baud)
+	}
+	for _, b := range append([]int{baud}, receiverBauds...) {
+		if !slices.Contains(bauds, b) {
+			bauds = append(bauds, b)
+		}
+	}
+
+	for _, b := range bauds {
+		if ctx.Err() != nil {
+			return nil, false
+		}
+		rp, err := in.openReceiver(ctx, device, b)
+		if err != nil {
+			in.setError(fmt.Sprintf("gps open failed device=%s baud=%d: %v", device, b, err))
+			if errors.Is(err, os.ErrNotExist) || errors.Is(err, serialport.ErrBusy) {
+				return nil, false
+			}
+			continue
+		}
+		if rp == nil {
+			continue
+		}
+		if in.ublox.Configure {
+			rp.ublox = pollUBlox(rp.port, rp.r)
+		}
+		if rp.ublox && in.ublox.Baud != 0 && in.ublox.Baud != rp.baud {
+			return in.switchBaud(ctx, rp, in.ublox.Baud)
+		}
+		return rp, true
+	}
+	return nil, false
+}
+
+// openReceiver opens device at baud and waits for it to answer. It returns
+// nil without error when the device is silent or garbled at that rate.
+func (in *input) openReceiver(ctx context.Context, device string, baud int) (*receiverPort, error) {
+	port, err := in.openSerial(device, baud)
+	if err != nil {
+		return nil, err
+	}
+	stop := context.AfterFunc(ctx, func() { _ = port.Close() })
+	r := bufio.NewReaderSize(port, 4096)
+	ok := receiverAnswers(port, r)
+	stop()
+	if !ok {
+		_ = port.Close()
+		return nil, nil
+	}
+	return &receiverPort{port: port, r: r, device: device, baud: baud}, nil
+}
+
+// pollUBlox asks for the receiver version and reports whether a UBX frame
+// comes back. Any UBX frame counts: a receiver already sending NAV
+// messages is a u-blox too.
+func pollUBlox(port serialPort, r *bufio.Reader) bool {
+	if _, err := port.Write(ubxPacket(ubxClassMON, ubxMONVER, nil)); err != nil {
+		return false
+	}
+	if err := port.SetReadDeadline(time.Now().Add(ubxPollTimeout)); err != nil {
+		return false
+	}
+	for {
+		_, msg, err := readReceiverMessage(r)
+		if err != nil {
+			return false
+		}
+		if msg != nil {
+			return true
+		}
+	}
+}
+
+// switchBaud moves an attached u-blox to baud and reattaches at the new
+// rate. USB receivers ignore the serial rate and answer either way.
+func (in *input) switchBaud(ctx context.Context, rp *receiverPort, baud int) (*receiverPort, bool) {
+	for _, pkt := range ubxPortConfig(baud) {
+		if _, err := rp.port.Write(pkt); err != nil {
+			log.Printf("gps ublox port config write failed device=%s: %v", rp.device, err)
+			break
+		}
+	}
+	// Let the frames leave the UART before closing.
+	time.Sleep(100 * time.Millisecond)
+	_ = rp.port.Close()
+
+	next, err := in.openReceiver(ctx, rp.device, baud)
+	if err != nil || next == nil {
+		// Probed again on the next pass, at the new rate first.
+		in.setError(fmt.Sprintf("gps ublox not answering at baud=%d device=%s after port config", baud, rp.device))
+		return nil, false
+	}
+	next.ublox = true
+	return next, true
+}
+
+// receiverAnswers reads until a valid NMEA sentence or UBX frame arrives or
+// probeTimeout passes. Noise from a wrong baud rate fails the checksums.
+func receiverAnswers(port serialPort, r *bufio.Reader) bool {
+	if err := port.SetReadDeadline(time.Now().Add(probeTimeout)); err != nil {
+		return false
+	}
+	for {
+		line, msg, err := readReceiverMessage(r)
+		if err != nil {
+			return false
+		}
+		if msg != nil {
+			return true
+		}
+		if _, err := parseNMEASentence(strings.TrimSpace(line)); err == nil {
+			return true
+		}
+	}
+}
+
+// readNMEA feeds the attached receiver's output into a fresh state until the
+// device fails or goes quiet.
+func (in *input) readNMEA(ctx context.Context, rp *receiverPort) error {
+	port, r, device, baud := rp.port, rp.r, rp.device, rp.baud
+	stop := context.AfterFunc(ctx, func() { _ = port.Close() })
+	defer stop()
+
+	if rp.ublox {
+		for _, pkt := range ubxConfigPackets(in.ublox.RateHz) {
+			if _, err := port.Write(pkt); err != nil {
+				log.Printf("gps ublox config write failed device=%s: %v", device, err)
+				break
+			}
+		}
+	}
+
+	log.Printf("gps attached device=%s baud=%d ublox=%t ublox_configure=%t", device, baud, rp.ublox, in.ublox.Configure)
+
+	var st nmeaState
+	st.device = device
+	st.baud = baud
+	in.last.Store(st.snapshot())
+
+	for {
+		if err := port.SetReadDeadline(time.Now().Add(silenceTimeout)); err != nil {
+			return err
+		}
+		line, msg, err := readReceiverMessage(r)
+		if err != nil {
+			if errors.Is(err, os.ErrDeadlineExceeded) {
+				return fmt.Errorf("no data for %v", silenceTimeout)
+			}
+			return err
+		}
+
+		now := time.Now().UTC()
+		if msg != nil {
+			updated, uerr := st.applyUBX(now, msg)
+			if uerr != nil {
+				in.setError(uerr.Error())
+				continue
+			}
+			if updated {
+				in.store(st.snapshot(), now)
+			}
+			continue
+		}
+
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		sent, perr := parseNMEASentence(line)
+		if perr != nil {
+			// Avoid spamming on bad noise; just keep the last error.
+			in.setError(perr.Error())
+			continue
+		}
+		in.emit(line)
+
+		if updated := st.apply(now, sent); updated {
+			in.store(st.snapshot(), now)
+		}
+	}
+}
@@ -0,0 +1,313 @@
+//go:build linux
+
+package gps
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"golang.org/x/sys/unix"
+
+	"stratux-ng/internal/serialport"
+)
+
+// openPTY returns the master side of a new pseudo-terminal and the path of
+// its slave, which stands in for a USB receiver.
+func openPTY(t *testing.T) (*os.File, string) {
+	t.Helper()
+	fd, err := unix.Open("/dev/ptmx", unix.O_RDWR|unix.O_NOCTTY|unix.O_CLOEXEC, 0)
+	if err != nil {
+		t.Skipf("no pseudo-terminals: %v", err)
+	}
+	if err := unix.IoctlSetPointerInt(fd, unix.TIOCSPTLCK, 0); err != nil {
+		_ = unix.Close(fd)
+		t.Fatalf("unlockpt: %v", err)
+	}
+	n, err := unix.IoctlGetUint32(fd, unix.TIOCGPTN)
+	if err != nil {
+		_ = unix.Close(fd)
+		t.Fatalf("ptsname: %v", err)
+	}
+	return os.NewFile(uintptr(fd), "/dev/ptmx"), fmt.Sprintf("/dev/pts/%d", n)
+}
+
+// feedNMEA writes a fix to the master every 100ms until the returned stop
+// is called.
+func feedNMEA(master *os.File) (stop func()) {
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		burst := []byte(nmeaLine(testRMC) + "\r\n" + nmeaLine("GPGGA,123519,4807.038,N,01131.000,E,1,08,0.9,545.4,M,46.9,M,,") + "\r\n")
+		for {
+			if _, err := master.Write(burst); err != nil {
+				return
+			}
+			select {
+			case <-done:
+				return
+			case <-time.After(100 * time.Millisecond):
+			}
+		}
+	}()
+	return func() {
+		close(done)
+		wg.Wait()
+	}
+}
+
+// garbledPort mimics a receiver read at the wrong baud rate.
+type garbledPort struct {
+	*os.File
+}
+
+func (p garbledPort) Read(b []byte) (int, error) {
+	n, err := p.File.Read(b)
+	for i := range b[:n] {
+		b[i] ^= 0x5A
+	}
+	return n, err
+}
+
+func waitSnapshot(t *testing.T, in *input, what string, ok func(Snapshot) bool) Snapshot {
+	t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		snap := in.snapshot()
+		if ok(snap) {
+			return snap
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s: %+v", what, snap)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+func TestInput_NMEAProbesBaudAndReattaches(t *testing.T) {
+	var mu sync.Mutex
+	var devices []string
+	setDevices := func(d ...string) {
+		mu.Lock()
+		defer mu.Unlock()
+		devices = d
+	}
+
+	in := newInput(InputConfig{Source: "nmea"}, UBloxConfig{})
+	in.listDevices = func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return slices.Clone(devices)
+	}
+	in.openSerial = func(device string, baud int) (serialPort, error) {
+		f, err := serialport.Open(device, baud)
+		if err != nil || baud == 38400 {
+			return f, err
+		}
+		return garbledPort{f}, nil
+	}
+
+	var wg sync.WaitGroup
+	if err := in.start(context.Background(), &wg); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	defer func() {
+		in.close()
+		wg.Wait()
+	}()
+
+	waitSnapshot(t, in, "no device error", func(s Snapshot) bool { return s.LastError != "" && !s.Valid })
+
+	// Plug in a receiver talking at 38400: 9600 is tried first and fails
+	// the checksums.
+	m1, dev1 := openPTY(t)
+	stop1 := feedNMEA(m1)
+	setDevices(dev1)
+	snap := waitSnapshot(t, in, "first attach", func(s Snapshot) bool { return s.Valid })
+	if snap.Device != dev1 || snap.Baud != 38400 {
+		t.Fatalf("attached device=%s baud=%d want %s 38400", snap.Device, snap.Baud, dev1)
+	}
+
+	// Unplug.
+	stop1()
+	_ = m1.Close()
+	setDevices()
+	waitSnapshot(t, in, "loss", func(s Snapshot) bool { return !s.Valid })
+
+	// Replug, possibly under another name.
+	m2, dev2 := openPTY(t)
+	defer m2.Close()
+	stop2 := feedNMEA(m2)
+	defer stop2()
+	setDevices(dev2)
+	waitSnapshot(t, in, "reattach", func(s Snapshot) bool { return s.Valid && s.Device == dev2 })
+}
+
+func TestReceiverAnswers_RejectsNoise(t *testing.T) {
+	m, dev := openPTY(t)
+	defer m.Close()
+	f, err := serialport.Open(dev, 9600)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer f.Close()
+
+	if _, err := m.Write([]byte("$GPRMC,garbage*00\r\n\xb5\x62\x01\x07\x00\x00\x00\x00")); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	start := time.Now()
+	if receiverAnswers(f, bufio.NewReader(f)) {
+		t.Fatalf("noise accepted")
+	}
+	if time.Since(start) < probeTimeout {
+		t.Fatalf("probe returned early")
+	}
+}
+
+// fakeUBlox records what is written to a pseudo-terminal receiver. Its UART
+// runs at baud until a CFG-PRT moves it to the requested rate; reads at any
+// other rate are garbled. When ublox is set it answers UBX polls.
+type fakeUBlox struct {
+	master *os.File
+	ublox  bool
+
+	mu      sync.Mutex
+	baud    int
+	written []byte
+}
+
+func (f *fakeUBlox) run() {
+	buf := make([]byte, 512)
+	for {
+		n, err := f.master.Read(buf)
+		if err != nil {
+			return
+		}
+		f.mu.Lock()
+		f.written = append(f.written, buf[:n]...)
+		if f.ublox && bytes.Contains(buf[:n], ubxPacket(ubxClassMON, ubxMONVER, nil)) {
+			_, _ = f.master.Write(ubxPacket(ubxClassMON, ubxMONVER, make([]byte, 40)))
+		}
+		if i := bytes.Index(f.written, []byte{ubxSync1, ubxSync2, ubxClassCFG, ubxCFGPRT, 20, 0, 1}); i >= 0 && len(f.written) >= i+6+20 {
+			f.baud = int(binary.LittleEndian.Uint32(f.written[i+6+8:]))
+		}
+		f.mu.Unlock()
+	}
+}
+
+func (f *fakeUBlox) open(device string, baud int) (serialPort, error) {
+	p, err := serialport.Open(device, baud)
+	if err != nil {
+		return p, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if baud != f.baud {
+		return garbledPort{p}, nil
+	}
+	return p, nil
+}
+
+func (f *fakeUBlox) portConfigs() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return bytes.Count(f.written, []byte{ubxSync1, ubxSync2, ubxClassCFG, ubxCFGPRT})
+}
+
+func startFakeUBlox(t *testing.T, ublox bool) (*fakeUBlox, string) {
+	t.Helper()
+	m, dev := openPTY(t)
+	f := &fakeUBlox{master: m, ublox: ublox, baud: 9600}
+	stop := feedNMEA(m)
+	go f.run()
+	t.Cleanup(func() {
+		stop()
+		_ = m.Close()
+	})
+	return f, dev
+}
+
+func TestInput_NMEAConfiguresUBloxOnce(t *testing.T) {
+	f, dev := startFakeUBlox(t, true)
+	in := newInput(InputConfig{Source: "nmea"}, UBloxConfig{Configure: true, RateHz: 5, Baud: 115200})
+	in.listDevices = func() []string { return []string{dev} }
+	in.openSerial = f.open
+
+	var wg sync.WaitGroup
+	if err := in.start(context.Background(), &wg); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	defer func() {
+		in.close()
+		wg.Wait()
+	}()
+
+	snap := waitSnapshot(t, in, "attach at the new baud", func(s Snapshot) bool { return s.Valid })
+	if snap.Baud != 115200 {
+		t.Fatalf("attached baud=%d want 115200", snap.Baud)
+	}
+	// One CFG-PRT pair (UART1 and USB), not one per probed rate.
+	if n := f.portConfigs(); n != 2 {
+		t.Fatalf("CFG-PRT packets=%d want 2", n)
+	}
+}
+
+func TestInput_NMEALeavesOtherDevicesAlone(t *testing.T) {
+	// A FLARM: talks NMEA at 9600 but is no u-blox.
+	flarm, flarmDev := startFakeUBlox(t, false)
+
+	in := newInput(InputConfig{Source: "nmea"}, UBloxConfig{Configure: true, RateHz: 5})
+	in.listDevices = func() []string { return []string{flarmDev} }
+	in.openSerial = flarm.open
+
+	var wg sync.WaitGroup
+	if err := in.start(context.Background(), &wg); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	snap := waitSnapshot(t, in, "attach", func(s Snapshot) bool { return s.Valid })
+	in.close()
+	wg.Wait()
+	if snap.UBX {
+		t.Fatalf("non-u-blox reported as UBX")
+	}
+	flarm.mu.Lock()
+	cfg := bytes.Contains(flarm.written, []byte{ubxSync1, ubxSync2, ubxClassCFG})
+	flarm.mu.Unlock()
+	if cfg {
+		t.Fatalf("UBX configuration written to a device that is not a u-blox")
+	}
+
+	// Named as the FLARM device, it is not opened at all.
+	in = newInput(InputConfig{Source: "nmea"}, UBloxConfig{})
+	in.skip = []string{flarmDev}
+	in.listDevices = func() []string { return []string{flarmDev} }
+	opened := make(chan string, 1)
+	in.openSerial = func(device string, baud int) (serialPort, error) {
+		select {
+		case opened <- device:
+		default:
+		}
+		return serialport.Open(device, baud)
+	}
+	if err := in.start(context.Background(), &wg); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	waitSnapshot(t, in, "no receiver error", func(s Snapshot) bool { return s.LastError != "" })
+	time.Sleep(2 * devicePollInterval)
+	in.close()
+	wg.Wait()
+	select {
+	case dev := <-opened:
+		t.Fatalf("skipped device %s was opened", dev)
+	default:
+	}
+}
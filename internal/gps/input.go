@@ -0,0 +1,281 @@
+package gps
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// InputConfig is one GPS receiver in the failover chain.
+type InputConfig struct {
+	// Source selects how GPS is ingested: "nmea" (direct serial), "gpsd",
+	// "udp"/"tcp" (NMEA from the network, e.g. a tablet's GPS) or "static"
+	// (a fixed position, for ground stations). When empty, defaults to
+	// "nmea".
+	Source string
+
+	// GPSDAddr is host:port for gpsd when Source=="gpsd".
+	GPSDAddr string
+
+	// Device is the serial device path for Source=="nmea"; empty to
+	// auto-detect.
+	Device string
+	Baud   int
+
+	// Listen is the address network NMEA is received on for Source "udp"
+	// or "tcp" (default :10110).
+	Listen string
+}
+
+// input runs one receiver and keeps its latest snapshot.
+type input struct {
+	cfg   InputConfig
+	src   string
+	ublox UBloxConfig
+	// static is the position published by the "static" source.
+	static StaticPosition
+	// skip lists serial devices auto-detection must not open.
+	skip []string
+
+	// onSentence receives each checksum-valid NMEA sentence read (gpsd fixes
+	// are rendered as RMC/GGA); nil when passthrough is off.
+	onSentence func(line string)
+
+	// openSerial and listDevices reach the hardware; tests replace them.
+	openSerial  func(device string, baud int) (serialPort, error)
+	listDevices func() []string
+
+	cancel context.CancelFunc
+
+	last  atomic.Value // Snapshot
+	fixAt atomic.Value // time.Time: local time the fix last changed
+
+	mu     sync.Mutex
+	closer io.Closer
+
+	// healthySince is owned by the Service's selection.
+	healthySince time.Time
+}
+
+func newInput(cfg InputConfig, ublox UBloxConfig) *input {
+	src := strings.ToLower(strings.TrimSpace(cfg.Source))
+	if src == "" {
+		src = "nmea"
+	}
+	in := &input{cfg: cfg, src: src, ublox: ublox, openSerial: openSerial, listDevices: listSerialDevices}
+	in.last.Store(Snapshot{Enabled: true, Source: src, GPSDAddr: strings.TrimSpace(cfg.GPSDAddr), Device: cfg.Device, Baud: cfg.Baud})
+	return in
+}
+
+// name labels the input in status and logs, e.g. "nmea:/dev/ttyACM0".
+func (in *input) name() string {
+	switch in.src {
+	case "gpsd":
+		addr := strings.TrimSpace(in.cfg.GPSDAddr)
+		if addr == "" {
+			addr = gpsdDefaultAddr
+		}
+		return "gpsd:" + addr
+	case "udp", "tcp":
+		return in.src + ":" + in.listenAddr()
+	case "static":
+		return in.src
+	}
+	dev := strings.TrimSpace(in.cfg.Device)
+	if snap := in.snapshot(); snap.Device != "" {
+		dev = snap.Device
+	}
+	if dev == "" {
+		dev = "auto"
+	}
+	return in.src + ":" + dev
+}
+
+func (in *input) start(ctx context.Context, wg *sync.WaitGroup) error {
+	switch in.src {
+	case "gpsd":
+		return in.startGPSD(ctx, wg)
+	case "udp":
+		return in.startUDP(ctx, wg)
+	case "tcp":
+		return in.startTCP(ctx, wg)
+	case "static":
+		return in.startStatic(ctx, wg)
+	}
+	// Default: NMEA over serial.
+	return in.startNMEA(ctx, wg)
+}
+
+// store publishes a snapshot, noting when the fix itself changed so stale
+// receivers can be told apart from quiet ones.
+func (in *input) store(snap Snapshot, nowUTC time.Time) {
+	prev := in.snapshot()
+	if snap.Valid && snap.LastFixUTC != prev.LastFixUTC {
+		in.fixAt.Store(nowUTC)
+	}
+	in.last.Store(snap)
+}
+
+// fixAge is how long ago the fix last changed.
+func (in *input) fixAge(nowUTC time.Time) time.Duration {
+	t, ok := in.fixAt.Load().(time.Time)
+	if !ok {
+		return time.Duration(1<<63 - 1)
+	}
+	return nowUTC.Sub(t)
+}
+
+func (in *input) emit(line string) {
+	if in.onSentence != nil {
+		in.onSentence(line)
+	}
+}
+
+func (in *input) snapshot() Snapshot {
+	v := in.last.Load()
+	if v == nil {
+		return Snapshot{}
+	}
+	return v.(Snapshot)
+}
+
+func (in *input) setError(msg string) {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	cur := in.snapshot()
+	cur.LastError = msg
+	// Do not force Valid=false here; transient parse issues shouldn’t flip validity.
+	in.last.Store(cur)
+}
+
+func (in *input) close() {
+	in.mu.Lock()
+	cancel := in.cancel
+	closer := in.closer
+	in.cancel = nil
+	in.closer = nil
+	in.mu.Unlock()
+
+	if cancel != nil {
+		cancel()
+	}
+	if closer != nil {
+		_ = closer.Close()
+	}
+}
+
+func (in *input) startGPSD(ctx context.Context, wg *sync.WaitGroup) error {
+	addr := strings.TrimSpace(in.cfg.GPSDAddr)
+	if addr == "" {
+		addr = gpsdDefaultAddr
+	}
+
+	childCtx, cancel := context.WithCancel(ctx)
+	in.mu.Lock()
+	in.cancel = cancel
+	in.mu.Unlock()
+
+	// Publish initial snapshot.
+	in.last.Store(Snapshot{Enabled: true, Valid: false, Source: "gpsd", GPSDAddr: addr, Device: "gpsd"})
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		log.Printf("gps enabled source=gpsd addr=%s", addr)
+		st := newGPSDState(addr)
+		emittedFix := ""
+		backoff := 250 * time.Millisecond
+		maxBackoff := 10 * time.Second
+
+		for {
+			select {
+			case <-childCtx.Done():
+				return
+			default:
+			}
+
+			conn, err := dialGPSD(childCtx, addr)
+			if err != nil {
+				in.setError(fmt.Sprintf("gpsd dial failed addr=%s: %v", addr, err))
+				t := backoff
+				if t > maxBackoff {
+					t = maxBackoff
+				}
+				select {
+				case <-childCtx.Done():
+					return
+				case <-time.After(t):
+				}
+				if backoff < maxBackoff {
+					backoff *= 2
+				}
+				continue
+			}
+
+			// Reset backoff after a successful connection.
+			backoff = 250 * time.Millisecond
+
+			in.mu.Lock()
+			// Swap the closer so close() can interrupt an active connection.
+			in.closer = conn
+			in.mu.Unlock()
+
+			func() {
+				defer func() { _ = conn.Close() }()
+
+				// Start watching JSON reports.
+				if err := gpsdWatch(conn); err != nil {
+					in.setError(fmt.Sprintf("gpsd watch failed: %v", err))
+					return
+				}
+
+				scanner := bufio.NewScanner(conn)
+				scanner.Buffer(make([]byte, 0, 4096), 256*1024)
+				for {
+					select {
+					case <-childCtx.Done():
+						return
+					default:
+					}
+					if !scanner.Scan() {
+						err := scanner.Err()
+						if err == nil {
+							err = io.EOF
+						}
+						in.setError(fmt.Sprintf("gpsd read stopped: %v", err))
+						return
+					}
+					line := strings.TrimSpace(scanner.Text())
+					if line == "" {
+						continue
+					}
+					now := time.Now().UTC()
+					updated, perr := st.applyLine(now, line)
+					if perr != nil {
+						in.setError(perr.Error())
+						continue
+					}
+					if updated {
+						snap := st.snapshot()
+						in.store(snap, now)
+						if snap.LastFixUTC != emittedFix {
+							emittedFix = snap.LastFixUTC
+							for _, l := range snapshotNMEA(snap) {
+								in.emit(l)
+							}
+						}
+					}
+				}
+			}()
+			// Loop and reconnect.
+		}
+	}()
+	return nil
+}
@@ -0,0 +1,277 @@
+package gps
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Integrity check names reported in Integrity.Reasons.
+const (
+	integrityCN0      = "cn0_drop"
+	integrityPosition = "position"
+	integrityTime     = "time_jump"
+	integrityAltRate  = "alt_rate"
+	integrityBaro     = "baro"
+)
+
+const (
+	// integrityHold keeps the flag raised after the last failed check, so
+	// an intermittent jammer doesn't toggle the reported accuracy.
+	integrityHold = 30 * time.Second
+
+	// cn0DropDB is the per-satellite C/N0 loss counted as a drop, and
+	// cn0DropShare the share of tracked satellites that must drop together;
+	// jamming raises the noise floor for all of them at once.
+	cn0DropDB    = 8.0
+	cn0DropShare = 0.75
+	cn0MinSats   = 4
+	cn0Alpha     = 0.1
+
+	// positionSlackM absorbs receiver position noise; positionSlackShare
+	// speed changes and rounding between fixes.
+	positionSlackM     = 100.0
+	positionSlackShare = 0.5
+	// positionMaxGap skips the check across outages, when the distance
+	// flown isn't known from the reported speed.
+	positionMaxGap = 5 * time.Second
+
+	// timeJumpMax is the disagreement between receiver and local clock
+	// progress counted as a time jump.
+	timeJumpMax = 2 * time.Second
+
+	// altRateMaxFPM is well beyond any GA climb or descent; altSlackFeet
+	// absorbs vertical noise between closely spaced fixes.
+	altRateMaxFPM = 10000.0
+	altSlackFeet  = 200.0
+
+	// baroAlpha tracks the slowly changing GPS-minus-pressure altitude
+	// offset (weather, temperature).
+	baroAlpha = 0.01
+
+	metersPerNm = 1852.0
+)
+
+// Integrity is the GPS integrity monitor's view of the fix.
+type Integrity struct {
+	// Degraded is set while a check fails and for integrityHold after;
+	// NACp and NIC are reported as unknown meanwhile.
+	Degraded bool `json:"degraded"`
+	// Reasons lists the checks that failed within integrityHold.
+	Reasons []string `json:"reasons,omitempty"`
+
+	Events      int       `json:"events"`
+	LastEvent   string    `json:"last_event,omitempty"`
+	LastEventAt time.Time `json:"last_event_utc,omitempty"`
+}
+
+// IntegrityMonitor watches successive GPS fixes for signs of jamming or
+// spoofing: a C/N0 drop across all satellites, positions that don't match
+// the reported speed, time jumps, impossible altitude rates, and GPS
+// altitude drifting away from pressure altitude.
+type IntegrityMonitor struct {
+	// BaroToleranceFeet is the allowed change of the GPS-minus-pressure
+	// altitude offset; 0 or less disables the baro check.
+	BaroToleranceFeet float64
+
+	mu sync.Mutex
+
+	input     string
+	lastFix   string
+	prevAt    time.Time
+	prevLat   float64
+	prevLon   float64
+	prevGS    float64
+	prevGSOK  bool
+	prevAlt   float64
+	prevAltOK bool
+	prevTime  time.Time // receiver UTC
+	prevTAt   time.Time // local clock when prevTime arrived
+
+	cn0 map[satKey]float64
+
+	baroOffset   float64
+	baroOffsetOK bool
+
+	failedAt map[string]time.Time
+	out      Integrity
+}
+
+func NewIntegrityMonitor(baroToleranceFeet float64) *IntegrityMonitor {
+	return &IntegrityMonitor{
+		BaroToleranceFeet: baroToleranceFeet,
+		cn0:               map[satKey]float64{},
+		failedAt:          map[string]time.Time{},
+	}
+}
+
+// Check evaluates a snapshot taken at nowUTC. Checks run once per new fix;
+// baroFeet is the pressure altitude when baroOK.
+func (m *IntegrityMonitor) Check(nowUTC time.Time, snap Snapshot, baroFeet float64, baroOK bool) Integrity {
+	if m == nil {
+		return Integrity{}
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if snap.ActiveInput != m.input {
+		// A failover switches receivers; their fixes aren't comparable.
+		m.resetLocked()
+		m.input = snap.ActiveInput
+	}
+	if snap.Valid && snap.LastFixUTC != m.lastFix {
+		m.lastFix = snap.LastFixUTC
+		for _, f := range m.checkFix(nowUTC, snap, baroFeet, baroOK) {
+			m.fail(nowUTC, f[0], f[1])
+		}
+	}
+
+	var reasons []string
+	for name, at := range m.failedAt {
+		if nowUTC.Sub(at) < integrityHold {
+			reasons = append(reasons, name)
+		} else {
+			delete(m.failedAt, name)
+		}
+	}
+	slices.Sort(reasons)
+	if m.out.Degraded && len(reasons) == 0 {
+		log.Printf("gps integrity restored")
+	}
+	m.out.Degraded = len(reasons) > 0
+	m.out.Reasons = reasons
+	return m.out
+}
+
+func (m *IntegrityMonitor) resetLocked() {
+	m.prevAt, m.prevTime, m.prevTAt = time.Time{}, time.Time{}, time.Time{}
+	m.prevAltOK, m.prevGSOK = false, false
+	m.cn0 = map[satKey]float64{}
+	m.baroOffsetOK = false
+}
+
+func (m *IntegrityMonitor) fail(nowUTC time.Time, name, detail string) {
+	if _, active := m.failedAt[name]; !active {
+		m.out.Events++
+		m.out.LastEvent = name + ": " + detail
+		m.out.LastEventAt = nowUTC
+		log.Printf("gps integrity event %s: %s", name, detail)
+	}
+	m.failedAt[name] = nowUTC
+}
+
+// checkFix runs the checks against the previous fix and returns the failed
+// ones as {name, detail} pairs.
+func (m *IntegrityMonitor) checkFix(nowUTC time.Time, snap Snapshot, baroFeet float64, baroOK bool) [][2]string {
+	var failed [][2]string
+
+	if detail, ok := m.checkCN0(snap.SatelliteList); !ok {
+		failed = append(failed, [2]string{integrityCN0, detail})
+	}
+
+	gs, gsOK := 0.0, snap.GroundKt != nil
+	if gsOK {
+		gs = float64(*snap.GroundKt)
+	}
+	alt, altOK := 0.0, snap.AltFeet != nil
+	if altOK {
+		alt = float64(*snap.AltFeet)
+	}
+
+	// Fixes are spaced by when they arrived, not by when they're checked.
+	fixAt := nowUTC
+	if t, err := time.Parse(time.RFC3339Nano, snap.LastFixUTC); err == nil {
+		fixAt = t
+	}
+	if dt := fixAt.Sub(m.prevAt); !m.prevAt.IsZero() && dt > 0 && dt <= positionMaxGap {
+		// Without speed on both fixes (GGA-only NMEA, some gpsd setups) the
+		// distance flown isn't known.
+		if gsOK && m.prevGSOK {
+			gotM := distanceM(m.prevLat, m.prevLon, snap.LatDeg, snap.LonDeg)
+			wantM := (m.prevGS + gs) / 2 * metersPerNm * dt.Hours()
+			if math.Abs(gotM-wantM) > positionSlackM+positionSlackShare*wantM {
+				failed = append(failed, [2]string{integrityPosition, fmt.Sprintf("moved %.0f m in %v at %.0f kt", gotM, dt.Round(time.Millisecond), gs)})
+			}
+		}
+		if altOK && m.prevAltOK {
+			dAlt := math.Abs(alt - m.prevAlt)
+			if dAlt > altSlackFeet && dAlt/dt.Minutes() > altRateMaxFPM {
+				failed = append(failed, [2]string{integrityAltRate, fmt.Sprintf("%.0f ft in %v", dAlt, dt.Round(time.Millisecond))})
+			}
+		}
+	}
+	m.prevAt, m.prevLat, m.prevLon, m.prevGS, m.prevGSOK = fixAt, snap.LatDeg, snap.LonDeg, gs, gsOK
+	m.prevAlt, m.prevAltOK = alt, altOK
+
+	if t, err := time.Parse(time.RFC3339Nano, snap.TimeUTC); err == nil && !snap.TimeAt.IsZero() {
+		if !m.prevTime.IsZero() {
+			jump := t.Sub(m.prevTime) - snap.TimeAt.Sub(m.prevTAt)
+			if jump > timeJumpMax || jump < -timeJumpMax {
+				failed = append(failed, [2]string{integrityTime, fmt.Sprintf("receiver time moved %v against the local clock", jump.Round(time.Millisecond))})
+			}
+		}
+		m.prevTime, m.prevTAt = t, snap.TimeAt
+	}
+
+	if m.BaroToleranceFeet > 0 && altOK && baroOK {
+		offset := alt - baroFeet
+		switch {
+		case !m.baroOffsetOK:
+			m.baroOffset, m.baroOffsetOK = offset, true
+		case math.Abs(offset-m.baroOffset) > m.BaroToleranceFeet:
+			failed = append(failed, [2]string{integrityBaro, fmt.Sprintf("GPS altitude %.0f ft vs pressure altitude %.0f ft (usual offset %.0f ft)", alt, baroFeet, m.baroOffset)})
+		default:
+			m.baroOffset += baroAlpha * (offset - m.baroOffset)
+		}
+	}
+	return failed
+}
+
+// checkCN0 compares each tracked satellite's C/N0 with its running average.
+// Averages are only updated while no drop is seen, so a lasting jammer does
+// not become the new normal.
+func (m *IntegrityMonitor) checkCN0(sats []Satellite) (string, bool) {
+	tracked, dropped := 0, 0
+	var lossDB float64
+	for _, sv := range sats {
+		if sv.SNR == nil {
+			continue
+		}
+		avg, ok := m.cn0[satKey{sv.Constellation, sv.PRN}]
+		if !ok {
+			continue
+		}
+		tracked++
+		if d := avg - float64(*sv.SNR); d >= cn0DropDB {
+			dropped++
+			lossDB += d
+		}
+	}
+	if tracked >= cn0MinSats && float64(dropped) >= cn0DropShare*float64(tracked) {
+		return fmt.Sprintf("%d of %d satellites lost %.0f dB on average", dropped, tracked, lossDB/float64(dropped)), false
+	}
+	for _, sv := range sats {
+		if sv.SNR == nil {
+			continue
+		}
+		k := satKey{sv.Constellation, sv.PRN}
+		if avg, ok := m.cn0[k]; ok {
+			m.cn0[k] = avg + cn0Alpha*(float64(*sv.SNR)-avg)
+		} else {
+			m.cn0[k] = float64(*sv.SNR)
+		}
+	}
+	return "", true
+}
+
+func distanceM(lat1, lon1, lat2, lon2 float64) float64 {
+	const earthRadiusM = 6371008.8
+	rad := math.Pi / 180
+	dLat := (lat2 - lat1) * rad
+	dLon := (lon2 - lon1) * rad
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1*rad)*math.Cos(lat2*rad)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadiusM * math.Asin(math.Min(1, math.Sqrt(a)))
+}
@@ -0,0 +1,167 @@
+package gps
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+// integrityFeed produces 1 Hz fixes flying east at 120 kt.
+type integrityFeed struct {
+	now  time.Time
+	lon  float64
+	alt  int
+	gs   int
+	snr  int
+	sats int
+}
+
+func newIntegrityFeed() *integrityFeed {
+	return &integrityFeed{now: time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC), lon: -122.9, alt: 5000, gs: 120, snr: 42, sats: 8}
+}
+
+func (f *integrityFeed) next() Snapshot {
+	f.now = f.now.Add(time.Second)
+	// 120 kt along the 45th parallel.
+	f.lon += float64(f.gs) * metersPerNm / 3600 / distanceM(45, 0, 45, 1)
+	alt, gs, snr := f.alt, f.gs, f.snr
+	snap := Snapshot{
+		Valid:      true,
+		LatDeg:     45,
+		LonDeg:     f.lon,
+		AltFeet:    &alt,
+		GroundKt:   &gs,
+		TimeUTC:    f.now.Format(time.RFC3339Nano),
+		TimeAt:     f.now,
+		LastFixUTC: f.now.Format(time.RFC3339Nano),
+	}
+	for prn := 1; prn <= f.sats; prn++ {
+		snap.SatelliteList = append(snap.SatelliteList, Satellite{Constellation: ConstellationGPS, PRN: prn, SNR: &snr, Used: true})
+	}
+	return snap
+}
+
+func TestIntegrityMonitor_HealthyFlight(t *testing.T) {
+	m := NewIntegrityMonitor(500)
+	f := newIntegrityFeed()
+	for i := 0; i < 60; i++ {
+		f.alt += 10 // 600 fpm climb
+		if got := m.Check(f.now, f.next(), float64(f.alt)-150, true); got.Degraded || got.Events != 0 {
+			t.Fatalf("fix %d: integrity=%+v", i, got)
+		}
+	}
+}
+
+func TestIntegrityMonitor_Checks(t *testing.T) {
+	tests := []struct {
+		name   string
+		reason string
+		// mutate alters the feed or the next snapshot to inject the fault.
+		mutate func(f *integrityFeed, snap *Snapshot)
+	}{
+		{"cn0 drop", integrityCN0, func(f *integrityFeed, snap *Snapshot) {
+			f.snr = 30
+			*snap = f.next()
+		}},
+		{"position jump", integrityPosition, func(f *integrityFeed, snap *Snapshot) {
+			snap.LatDeg += 0.05
+		}},
+		{"time jump", integrityTime, func(f *integrityFeed, snap *Snapshot) {
+			snap.TimeUTC = f.now.Add(-time.Hour).Format(time.RFC3339Nano)
+		}},
+		{"altitude rate", integrityAltRate, func(f *integrityFeed, snap *Snapshot) {
+			alt := *snap.AltFeet + 2000
+			snap.AltFeet = &alt
+		}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			m := NewIntegrityMonitor(0)
+			f := newIntegrityFeed()
+			for i := 0; i < 10; i++ {
+				m.Check(f.now, f.next(), 0, false)
+			}
+			snap := f.next()
+			tc.mutate(f, &snap)
+			got := m.Check(f.now, snap, 0, false)
+			if !got.Degraded || !slices.Contains(got.Reasons, tc.reason) || got.Events == 0 {
+				t.Fatalf("integrity=%+v want reason %s", got, tc.reason)
+			}
+		})
+	}
+}
+
+func TestIntegrityMonitor_PositionNeedsGroundSpeed(t *testing.T) {
+	m := NewIntegrityMonitor(0)
+	f := newIntegrityFeed()
+	// GGA-only receiver: moving, but no ground speed reported.
+	for i := 0; i < 10; i++ {
+		snap := f.next()
+		snap.GroundKt = nil
+		if got := m.Check(f.now, snap, 0, false); got.Degraded {
+			t.Fatalf("fix %d: integrity=%+v", i, got)
+		}
+	}
+}
+
+func TestIntegrityMonitor_BaroDisagreementAndHold(t *testing.T) {
+	m := NewIntegrityMonitor(500)
+	f := newIntegrityFeed()
+	baro := float64(f.alt) - 150
+	for i := 0; i < 10; i++ {
+		m.Check(f.now, f.next(), baro, true)
+	}
+
+	// GPS altitude walks away from pressure altitude, as when spoofed.
+	var got Integrity
+	for i := 0; i < 10 && !got.Degraded; i++ {
+		f.alt += 100
+		got = m.Check(f.now, f.next(), baro, true)
+	}
+	if !got.Degraded || !slices.Equal(got.Reasons, []string{integrityBaro}) || got.Events != 1 {
+		t.Fatalf("integrity=%+v", got)
+	}
+
+	// Agreement returns, but the flag is held before clearing.
+	baro = float64(f.alt) - 150
+	got = m.Check(f.now, f.next(), baro, true)
+	if !got.Degraded {
+		t.Fatalf("flag dropped immediately: %+v", got)
+	}
+	for i := 0; i < int(integrityHold/time.Second); i++ {
+		got = m.Check(f.now, f.next(), baro, true)
+	}
+	if got.Degraded || got.Events != 1 || got.LastEvent == "" {
+		t.Fatalf("after hold: %+v", got)
+	}
+}
+
+func TestIntegrityMonitor_BaroCheckOff(t *testing.T) {
+	m := NewIntegrityMonitor(-1)
+	f := newIntegrityFeed()
+	for i := 0; i < 20; i++ {
+		f.alt += 100
+		if got := m.Check(f.now, f.next(), 4850, true); got.Degraded {
+			t.Fatalf("fix %d: integrity=%+v", i, got)
+		}
+	}
+}
+
+func TestIntegrityMonitor_FailoverResetsHistory(t *testing.T) {
+	m := NewIntegrityMonitor(0)
+	f := newIntegrityFeed()
+	for i := 0; i < 5; i++ {
+		snap := f.next()
+		snap.ActiveInput = "nmea:/dev/ttyACM0"
+		m.Check(f.now, snap, 0, false)
+	}
+	// The backup receiver's position differs slightly and its satellites
+	// are weaker; neither is an integrity event.
+	f.snr = 30
+	snap := f.next()
+	snap.ActiveInput = "gpsd:127.0.0.1:2947"
+	snap.LatDeg += 0.01
+	if got := m.Check(f.now, snap, 0, false); got.Degraded {
+		t.Fatalf("integrity=%+v", got)
+	}
+}
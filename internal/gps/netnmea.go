@@ -0,0 +1,216 @@
+package gps
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// netNMEADefaultListen is the NMEA 0183 over IP port (IEC 61162-450
+	// uses 10110), which apps such as GPS2IP can be pointed at.
+	netNMEADefaultListen = ":10110"
+
+	// netSenderTimeout is how long the current sender keeps the input to
+	// itself after its last sentence; a second device on the network is
+	// ignored rather than mixing two positions into one fix.
+	netSenderTimeout = 5 * time.Second
+)
+
+// netNMEAState decodes NMEA received over the network, locked to one sender
+// at a time.
+type netNMEAState struct {
+	mu     sync.Mutex
+	source string
+	listen string
+	sender string
+	lastAt time.Time
+	st     nmeaState
+
+	// emit receives the sender's validated sentences.
+	emit func(line string)
+}
+
+func newNetNMEAState(source, listen string, emit func(string)) *netNMEAState {
+	return &netNMEAState{source: source, listen: listen, emit: emit}
+}
+
+// applyLine feeds one sentence from sender. It returns the updated snapshot
+// when the fix changed.
+func (s *netNMEAState) applyLine(nowUTC time.Time, sender, line string) (Snapshot, bool, error) {
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return Snapshot{}, false, nil
+	}
+	sent, err := parseNMEASentence(line)
+	if err != nil {
+		return Snapshot{}, false, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if sender != s.sender {
+		if s.sender != "" && nowUTC.Sub(s.lastAt) < netSenderTimeout {
+			return Snapshot{}, false, nil
+		}
+		if s.sender != "" {
+			log.Printf("gps %s sender changed %s -> %s", s.source, s.sender, sender)
+		}
+		s.sender = sender
+		s.st = nmeaState{}
+	}
+	s.lastAt = nowUTC
+	if s.emit != nil {
+		s.emit(line)
+	}
+	if !s.st.apply(nowUTC, sent) {
+		return Snapshot{}, false, nil
+	}
+	return s.snapshotLocked(), true, nil
+}
+
+func (s *netNMEAState) snapshotLocked() Snapshot {
+	out := s.st.snapshot()
+	out.Source = s.source
+	out.Listen = s.listen
+	out.Sender = s.sender
+	return out
+}
+
+func (s *netNMEAState) snapshot() Snapshot {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.snapshotLocked()
+}
+
+func (in *input) listenAddr() string {
+	if addr := strings.TrimSpace(in.cfg.Listen); addr != "" {
+		return addr
+	}
+	return netNMEADefaultListen
+}
+
+func (in *input) startUDP(ctx context.Context, wg *sync.WaitGroup) error {
+	addr := in.listenAddr()
+	pc, err := net.ListenPacket("udp", addr)
+	if err != nil {
+		in.setError(fmt.Sprintf("gps udp listen failed addr=%s: %v", addr, err))
+		return err
+	}
+
+	childCtx, cancel := context.WithCancel(ctx)
+	in.mu.Lock()
+	in.closer = pc
+	in.cancel = cancel
+	in.mu.Unlock()
+
+	st := newNetNMEAState("udp", addr, in.emit)
+	in.last.Store(st.snapshot())
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		stop := context.AfterFunc(childCtx, func() { _ = pc.Close() })
+		defer stop()
+		defer func() { _ = pc.Close() }()
+
+		log.Printf("gps enabled source=udp listen=%s", addr)
+		buf := make([]byte, 4096)
+		for {
+			n, from, err := pc.ReadFrom(buf)
+			if err != nil {
+				if !errors.Is(err, net.ErrClosed) {
+					in.setError(fmt.Sprintf("gps udp read stopped: %v", err))
+				}
+				return
+			}
+			// One datagram may carry several sentences.
+			for _, line := range strings.Split(string(buf[:n]), "\n") {
+				in.applyNetLine(st, senderHost(from), line)
+			}
+		}
+	}()
+	return nil
+}
+
+func (in *input) startTCP(ctx context.Context, wg *sync.WaitGroup) error {
+	addr := in.listenAddr()
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		in.setError(fmt.Sprintf("gps tcp listen failed addr=%s: %v", addr, err))
+		return err
+	}
+
+	childCtx, cancel := context.WithCancel(ctx)
+	in.mu.Lock()
+	in.closer = ln
+	in.cancel = cancel
+	in.mu.Unlock()
+
+	st := newNetNMEAState("tcp", addr, in.emit)
+	in.last.Store(st.snapshot())
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		stop := context.AfterFunc(childCtx, func() { _ = ln.Close() })
+		defer stop()
+		defer func() { _ = ln.Close() }()
+
+		log.Printf("gps enabled source=tcp listen=%s", addr)
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				if !errors.Is(err, net.ErrClosed) {
+					in.setError(fmt.Sprintf("gps tcp accept stopped: %v", err))
+				}
+				return
+			}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				stop := context.AfterFunc(childCtx, func() { _ = conn.Close() })
+				defer stop()
+				defer func() { _ = conn.Close() }()
+
+				sender := senderHost(conn.RemoteAddr())
+				scanner := bufio.NewScanner(conn)
+				for scanner.Scan() {
+					in.applyNetLine(st, sender, scanner.Text())
+				}
+			}()
+		}
+	}()
+	return nil
+}
+
+func (in *input) applyNetLine(st *netNMEAState, sender, line string) {
+	now := time.Now().UTC()
+	snap, updated, err := st.applyLine(now, sender, line)
+	if err != nil {
+		in.setError(err.Error())
+		return
+	}
+	if updated {
+		in.store(snap, now)
+	}
+}
+
+// senderHost identifies a sender by address only; ports change between
+// connections and, for some apps, between datagrams.
+func senderHost(addr net.Addr) string {
+	if addr == nil {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(addr.String())
+	if err != nil {
+		return addr.String()
+	}
+	return host
+}
@@ -0,0 +1,82 @@
+package gps
+
+import (
+	"context"
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+const testRMC = "GPRMC,123519,A,4807.038,N,01131.000,E,022.4,084.4,230394,003.1,W"
+
+func TestNetNMEAState_LocksToSender(t *testing.T) {
+	st := newNetNMEAState("udp", ":10110", nil)
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+
+	snap, updated, err := st.applyLine(now, "192.168.10.21", nmeaLine(testRMC)+"\r")
+	if err != nil || !updated {
+		t.Fatalf("updated=%v err=%v", updated, err)
+	}
+	if !snap.Valid || snap.Source != "udp" || snap.Sender != "192.168.10.21" || snap.Listen != ":10110" {
+		t.Fatalf("snapshot=%+v", snap)
+	}
+
+	// A second device is ignored while the first keeps sending.
+	other := nmeaLine("GPRMC,123520,A,3400.000,N,11800.000,W,000.0,000.0,230394,,")
+	if _, updated, _ := st.applyLine(now.Add(time.Second), "192.168.10.22", other); updated {
+		t.Fatalf("second sender accepted")
+	}
+
+	// Once the first goes quiet, the second takes over with a fresh state.
+	snap, updated, _ = st.applyLine(now.Add(time.Second+netSenderTimeout), "192.168.10.22", other)
+	if !updated || snap.Sender != "192.168.10.22" || snap.LatDeg != 34 {
+		t.Fatalf("updated=%v snapshot=%+v", updated, snap)
+	}
+}
+
+func TestInput_UDPAndTCP(t *testing.T) {
+	for _, src := range []string{"udp", "tcp"} {
+		t.Run(src, func(t *testing.T) {
+			in := newInput(InputConfig{Source: src, Listen: "127.0.0.1:0"}, UBloxConfig{})
+			var wg sync.WaitGroup
+			if err := in.start(context.Background(), &wg); err != nil {
+				t.Fatalf("start: %v", err)
+			}
+			defer func() {
+				in.close()
+				wg.Wait()
+			}()
+
+			var addr net.Addr
+			in.mu.Lock()
+			switch l := in.closer.(type) {
+			case net.PacketConn:
+				addr = l.LocalAddr()
+			case net.Listener:
+				addr = l.Addr()
+			}
+			in.mu.Unlock()
+
+			conn, err := net.Dial(src, addr.String())
+			if err != nil {
+				t.Fatalf("dial: %v", err)
+			}
+			defer conn.Close()
+			if _, err := conn.Write([]byte(nmeaLine(testRMC) + "\r\n")); err != nil {
+				t.Fatalf("write: %v", err)
+			}
+
+			deadline := time.Now().Add(2 * time.Second)
+			for !in.snapshot().Valid {
+				if time.Now().After(deadline) {
+					t.Fatalf("no fix: %+v", in.snapshot())
+				}
+				time.Sleep(10 * time.Millisecond)
+			}
+			if snap := in.snapshot(); snap.Source != src || snap.Sender != "127.0.0.1" {
+				t.Fatalf("snapshot=%+v", snap)
+			}
+		})
+	}
+}
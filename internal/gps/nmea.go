@@ -11,6 +11,8 @@ import (
 
 type nmeaSentence struct {
 	Type string
+	// Talker is the talker ID (GP, GN, GL, ...) for standard sentences.
+	Talker string
 	// Fields is the comma-split NMEA payload (excluding $ and checksum).
 	Fields []string
 }
@@ -55,7 +57,11 @@ func parseNMEASentence(line string) (nmeaSentence, error) {
 	if len(t) > 3 {
 		t = t[len(t)-3:]
 	}
-	return nmeaSentence{Type: strings.ToUpper(t), Fields: parts}, nil
+	talker := ""
+	if len(typeField) == 5 && typeField[0] != 'P' {
+		talker = strings.ToUpper(typeField[:2])
+	}
+	return nmeaSentence{Type: strings.ToUpper(t), Talker: talker, Fields: parts}, nil
 }
 
 type nmeaState struct {
@@ -83,6 +89,37 @@ type nmeaState struct {
 	hdop         float64
 	hdopOK       bool
 
+	// Filled from GSA/GSV/GST and from UBX when the receiver speaks it.
+	fixMode    int
+	fixModeOK  bool
+	satsSeen   int
+	satsSeenOK bool
+	pdop       float64
+	pdopOK     bool
+	vdop       float64
+	vdopOK     bool
+	hAccM      float64
+	hAccOK     bool
+	vAccM      float64
+	vAccOK     bool
+	vSpeedFPM  int
+	vsOK       bool
+	geoidSepM  float64
+	geoidOK    bool
+
+	// Satellites in view (GSV) and used (GSA), see satellites.go.
+	sats satTracker
+
+	// timeUTC is the receiver's UTC time from ZDA, RMC or NAV-PVT, received
+	// at timeAt.
+	timeUTC time.Time
+	timeAt  time.Time
+
+	// ubxAt is the last UBX NAV message; pvtAt the last NAV-PVT fix, which
+	// takes precedence over RMC/GGA for a while.
+	ubxAt time.Time
+	pvtAt time.Time
+
 	lastFix time.Time
 	valid   bool
 
@@ -90,11 +127,29 @@ type nmeaState struct {
 }
 
 func (s *nmeaState) apply(nowUTC time.Time, sent nmeaSentence) bool {
+	if !s.pvtAt.IsZero() && nowUTC.Sub(s.pvtAt) < ubxPreferFor {
+		switch sent.Type {
+		case "RMC", "GGA", "GNS", "VTG", "GST":
+			return false
+		}
+	}
 	switch sent.Type {
 	case "RMC":
 		return s.applyRMC(nowUTC, sent.Fields)
 	case "GGA":
 		return s.applyGGA(nowUTC, sent.Fields)
+	case "GNS":
+		return s.applyGNS(nowUTC, sent.Fields)
+	case "VTG":
+		return s.applyVTG(sent.Fields)
+	case "GST":
+		return s.applyGST(sent.Fields)
+	case "ZDA":
+		return s.applyZDA(nowUTC, sent.Fields)
+	case "GSA":
+		return s.applyGSA(nowUTC, sent.Talker, sent.Fields)
+	case "GSV":
+		return s.applyGSV(nowUTC, sent.Talker, sent.Fields)
 	default:
 		return false
 	}
@@ -134,6 +189,52 @@ func (s *nmeaState) snapshot() Snapshot {
 		v := s.hdop
 		out.HDOP = &v
 	}
+	if s.fixModeOK {
+		v := s.fixMode
+		out.FixMode = &v
+	}
+	out.SatelliteList, out.Constellations = s.sats.snapshot()
+	if len(out.SatelliteList) > 0 {
+		seen := 0
+		for _, sv := range out.SatelliteList {
+			if sv.SNR != nil && *sv.SNR > 0 {
+				seen++
+			}
+		}
+		out.SatellitesSeen = &seen
+	} else if s.satsSeenOK {
+		v := s.satsSeen
+		out.SatellitesSeen = &v
+	}
+	if s.pdopOK {
+		v := s.pdop
+		out.PDOP = &v
+	}
+	if s.vdopOK {
+		v := s.vdop
+		out.VDOP = &v
+	}
+	if s.hAccOK {
+		v := s.hAccM
+		out.HorizAccM = &v
+	}
+	if s.vAccOK {
+		v := s.vAccM
+		out.VertAccM = &v
+	}
+	if s.vsOK {
+		v := s.vSpeedFPM
+		out.VertSpeedFPM = &v
+	}
+	if s.geoidOK {
+		v := s.geoidSepM
+		out.GeoidSepM = &v
+	}
+	if !s.timeUTC.IsZero() {
+		out.TimeUTC = s.timeUTC.Format(time.RFC3339Nano)
+		out.TimeAt = s.timeAt
+	}
+	out.UBX = !s.ubxAt.IsZero()
 	if !s.lastFix.IsZero() {
 		out.LastFixUTC = s.lastFix.UTC().Format(time.RFC3339Nano)
 	}
@@ -163,6 +264,9 @@ func (s *nmeaState) applyRMC(nowUTC time.Time, f []string) bool {
 		// Do not update validity on void fixes.
 		return false
 	}
+	if t, ok := parseNMEADateTime(f[9], f[1]); ok {
+		s.timeUTC, s.timeAt = t, nowUTC
+	}
 
 	lat, latOK := parseNMEALatLon(f[3], f[4])
 	lon, lonOK := parseNMEALatLon(f[5], f[6])
@@ -245,10 +349,15 @@ func (s *nmeaState) applyGGA(nowUTC time.Time, f []string) bool {
 
 	altM, ok := parseFloat(f[9])
 	if ok {
-		s.altFeet = int(math.Round(altM * 3.280839895013123))
+		s.altFeet = int(math.Round(altM * metersToFeet))
 		s.altOK = true
 		updated = true
 	}
+	if len(f) > 11 {
+		if sep, ok := parseFloat(f[11]); ok {
+			s.geoidSepM, s.geoidOK = sep, true
+		}
+	}
 
 	if s.latOK && s.lonOK {
 		s.lastFix = nowUTC
@@ -258,6 +367,194 @@ func (s *nmeaState) applyGGA(nowUTC time.Time, f []string) bool {
 	return false
 }
 
+// GNS: GNSS Fix Data, the multi-constellation GGA.
+// Fields:
+//
+//	0: talker+type
+//	1: time
+//	2: latitude
+//	3: N/S
+//	4: longitude
+//	5: E/W
+//	6: mode indicator, one char per constellation (N=no fix)
+//	7: number of satellites
+//	8: HDOP
+//	9: altitude (meters, MSL)
+//
+// 10: geoid separation (meters)
+func (s *nmeaState) applyGNS(nowUTC time.Time, f []string) bool {
+	if len(f) < 11 {
+		return false
+	}
+	mode := strings.ToUpper(strings.TrimSpace(f[6]))
+	// Report the best solution any constellation has; dead reckoning only
+	// counts when nothing better is available.
+	quality, estimated := 0, false
+	for _, c := range mode {
+		switch c {
+		case 'A', 'P':
+			quality = max(quality, 1)
+		case 'D', 'S':
+			quality = max(quality, 2)
+		case 'R':
+			quality = max(quality, 4)
+		case 'F':
+			quality = max(quality, 5)
+		case 'E':
+			estimated = true
+		}
+	}
+	if quality == 0 && estimated {
+		quality = 6
+	}
+	if quality == 0 {
+		return false
+	}
+	s.fixQuality, s.fixQualityOK = quality, true
+	if sats, err := strconv.Atoi(strings.TrimSpace(f[7])); err == nil {
+		s.satellites, s.satsOK = sats, true
+	}
+	if hdop, ok := parseFloat(f[8]); ok {
+		s.hdop, s.hdopOK = hdop, true
+	}
+	if altM, ok := parseFloat(f[9]); ok {
+		s.altFeet, s.altOK = int(math.Round(altM*metersToFeet)), true
+	}
+	if sep, ok := parseFloat(f[10]); ok {
+		s.geoidSepM, s.geoidOK = sep, true
+	}
+	lat, latOK := parseNMEALatLon(f[2], f[3])
+	lon, lonOK := parseNMEALatLon(f[4], f[5])
+	if !latOK || !lonOK {
+		return false
+	}
+	s.latDeg, s.latOK = lat, true
+	s.lonDeg, s.lonOK = lon, true
+	s.lastFix = nowUTC
+	s.valid = true
+	return true
+}
+
+// VTG: Course Over Ground and Ground Speed
+// Fields:
+//
+//	0: talker+type
+//	1: course (deg true)
+//	2: T
+//	3: course (deg magnetic)
+//	4: M
+//	5: speed (knots)
+//	6: N
+//	7: speed (km/h)
+//	8: K
+//	9: mode (A/D/E/M/S/N, NMEA 2.3+)
+func (s *nmeaState) applyVTG(f []string) bool {
+	if len(f) < 9 {
+		return false
+	}
+	if len(f) > 9 && strings.TrimSpace(f[9]) == "N" {
+		return false
+	}
+	updated := false
+	if trk, ok := parseFloat(f[1]); ok {
+		s.trackDeg, s.trkOK = math.Mod(trk+360.0, 360.0), true
+		updated = true
+	}
+	if gs, ok := parseFloat(f[5]); ok {
+		s.groundKt, s.gsOK = gs, true
+		updated = true
+	} else if kmh, ok := parseFloat(f[7]); ok {
+		s.groundKt, s.gsOK = kmh/1.852, true
+		updated = true
+	}
+	return updated
+}
+
+// GST: GNSS Pseudorange Error Statistics
+// Fields:
+//
+//	0: talker+type
+//	1: time
+//	2: RMS of pseudorange residuals
+//	3: error ellipse semi-major sigma (m)
+//	4: error ellipse semi-minor sigma (m)
+//	5: error ellipse orientation (deg)
+//	6: latitude sigma (m)
+//	7: longitude sigma (m)
+//	8: altitude sigma (m)
+func (s *nmeaState) applyGST(f []string) bool {
+	if len(f) < 9 {
+		return false
+	}
+	updated := false
+	latSD, latOK := parseFloat(f[6])
+	lonSD, lonOK := parseFloat(f[7])
+	if latOK && lonOK {
+		s.hAccM, s.hAccOK = math.Hypot(latSD, lonSD), true
+		updated = true
+	}
+	if altSD, ok := parseFloat(f[8]); ok {
+		s.vAccM, s.vAccOK = altSD, true
+		updated = true
+	}
+	return updated
+}
+
+// ZDA: Time and Date
+// Fields:
+//
+//	0: talker+type
+//	1: time (hhmmss.ss)
+//	2: day
+//	3: month
+//	4: year
+func (s *nmeaState) applyZDA(nowUTC time.Time, f []string) bool {
+	if len(f) < 5 {
+		return false
+	}
+	day, err1 := strconv.Atoi(strings.TrimSpace(f[2]))
+	month, err2 := strconv.Atoi(strings.TrimSpace(f[3]))
+	year, err3 := strconv.Atoi(strings.TrimSpace(f[4]))
+	if err1 != nil || err2 != nil || err3 != nil || year < 1980 || month < 1 || month > 12 || day < 1 || day > 31 {
+		return false
+	}
+	t, ok := parseNMEATime(time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC), f[1])
+	if !ok {
+		return false
+	}
+	s.timeUTC, s.timeAt = t, nowUTC
+	return true
+}
+
+// parseNMEADateTime combines an RMC date (ddmmyy) and time (hhmmss.sss).
+func parseNMEADateTime(date, tod string) (time.Time, bool) {
+	date = strings.TrimSpace(date)
+	if len(date) != 6 {
+		return time.Time{}, false
+	}
+	d, err := time.Parse("020106", date)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return parseNMEATime(d, tod)
+}
+
+// parseNMEATime adds an NMEA time of day (hhmmss[.sss]) to midnight of day.
+func parseNMEATime(day time.Time, tod string) (time.Time, bool) {
+	tod = strings.TrimSpace(tod)
+	if len(tod) < 6 {
+		return time.Time{}, false
+	}
+	hh, err1 := strconv.Atoi(tod[0:2])
+	mm, err2 := strconv.Atoi(tod[2:4])
+	sec, err3 := strconv.ParseFloat(tod[4:], 64)
+	if err1 != nil || err2 != nil || err3 != nil || hh > 23 || mm > 59 || sec < 0 || sec >= 61 {
+		return time.Time{}, false
+	}
+	d := time.Duration(hh)*time.Hour + time.Duration(mm)*time.Minute + time.Duration(math.Round(sec*1000))*time.Millisecond
+	return day.Add(d), true
+}
+
 func parseFloat(s string) (float64, bool) {
 	s = strings.TrimSpace(s)
 	if s == "" {
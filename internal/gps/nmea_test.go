@@ -107,3 +107,127 @@ func TestNMEAState_GGAParsesQualitySatsHDOP(t *testing.T) {
 		t.Fatalf("expected hdop 0.9, got %+v", snap.HDOP)
 	}
 }
+
+func applyNMEALines(t *testing.T, st *nmeaState, now time.Time, payloads ...string) {
+	t.Helper()
+	for _, p := range payloads {
+		sent, err := parseNMEASentence(nmeaLine(p))
+		if err != nil {
+			t.Fatalf("parse %q: %v", p, err)
+		}
+		st.apply(now, sent)
+	}
+}
+
+func TestNMEAState_GSVAndGSA(t *testing.T) {
+	var st nmeaState
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	applyNMEALines(t, &st, now,
+		"GPGSV,2,1,05,02,45,090,40,05,10,180,32,12,70,270,45,46,30,200,38",
+		"GPGSV,2,2,05,25,05,010,",
+		"GLGSV,1,1,02,65,20,045,35,66,60,300,41",
+		// u-blox NMEA 4.0 style: combined GSA without system ID.
+		"GNGSA,A,3,02,12,46,,,,,,,,,,1.60,0.90,1.30",
+		"GNGSA,A,3,66,,,,,,,,,,,,1.60,0.90,1.30",
+	)
+	snap := st.snapshot()
+	if len(snap.SatelliteList) != 7 {
+		t.Fatalf("satellites=%d want 7: %+v", len(snap.SatelliteList), snap.SatelliteList)
+	}
+	want := map[string]ConstellationCount{
+		ConstellationGPS:     {Name: ConstellationGPS, InView: 4, Used: 2},
+		ConstellationSBAS:    {Name: ConstellationSBAS, InView: 1, Used: 1},
+		ConstellationGLONASS: {Name: ConstellationGLONASS, InView: 2, Used: 1},
+	}
+	if len(snap.Constellations) != len(want) {
+		t.Fatalf("constellations=%+v", snap.Constellations)
+	}
+	for _, cc := range snap.Constellations {
+		if cc != want[cc.Name] {
+			t.Fatalf("constellation %s=%+v want %+v", cc.Name, cc, want[cc.Name])
+		}
+	}
+	// PRN 25 has no SNR: in view but not seen.
+	if snap.SatellitesSeen == nil || *snap.SatellitesSeen != 6 {
+		t.Fatalf("seen=%v", snap.SatellitesSeen)
+	}
+	if snap.FixMode == nil || *snap.FixMode != 3 {
+		t.Fatalf("fix mode=%v", snap.FixMode)
+	}
+	if snap.PDOP == nil || *snap.PDOP != 1.6 || snap.HDOP == nil || *snap.HDOP != 0.9 || snap.VDOP == nil || *snap.VDOP != 1.3 {
+		t.Fatalf("dops=%v/%v/%v", snap.PDOP, snap.HDOP, snap.VDOP)
+	}
+	first := snap.SatelliteList[0]
+	if first.Constellation != ConstellationGLONASS || first.PRN != 65 || first.Used || first.SNR == nil || *first.SNR != 35 {
+		t.Fatalf("first=%+v", first)
+	}
+
+	// An incomplete sequence does not replace the committed one, and stale
+	// data ages out.
+	applyNMEALines(t, &st, now.Add(time.Second), "GPGSV,2,2,05,25,05,010,")
+	if got := len(st.snapshot().SatelliteList); got != 7 {
+		t.Fatalf("satellites after partial=%d", got)
+	}
+	applyNMEALines(t, &st, now.Add(satStaleAfter+time.Second), "GLGSV,1,1,02,65,20,045,35,66,60,300,41")
+	if got := len(st.snapshot().SatelliteList); got != 2 {
+		t.Fatalf("satellites after stale=%d", got)
+	}
+}
+
+func TestNMEAState_GSVSignalIDAndSystemID(t *testing.T) {
+	var st nmeaState
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	applyNMEALines(t, &st, now,
+		// NMEA 4.10: one sequence per signal, the strongest SNR wins.
+		"GAGSV,1,1,01,11,40,100,30,1",
+		"GAGSV,1,1,01,11,40,100,42,7",
+		"GNGSA,A,3,11,,,,,,,,,,,,2.0,1.0,1.7,3",
+	)
+	snap := st.snapshot()
+	if len(snap.SatelliteList) != 1 {
+		t.Fatalf("satellites=%+v", snap.SatelliteList)
+	}
+	sv := snap.SatelliteList[0]
+	if sv.Constellation != ConstellationGalileo || !sv.Used || sv.SNR == nil || *sv.SNR != 42 {
+		t.Fatalf("sv=%+v", sv)
+	}
+}
+
+func TestNMEAState_GNSVTGGSTZDA(t *testing.T) {
+	var st nmeaState
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	applyNMEALines(t, &st, now,
+		"GNGNS,123519.00,4807.038,N,01131.000,E,ADN,14,0.8,545.4,46.9,,,V",
+		"GNVTG,084.4,T,081.2,M,022.4,N,041.5,K,D",
+		"GNGST,123519.00,1.2,3.0,2.0,45.0,2.4,1.8,4.1",
+		"GNZDA,123519.25,02,01,2026,00,00",
+	)
+	snap := st.snapshot()
+	if !snap.Valid || math.Abs(snap.LatDeg-48.1173) > 1e-3 || math.Abs(snap.LonDeg-11.5167) > 1e-3 {
+		t.Fatalf("position: %+v", snap)
+	}
+	if snap.FixQuality == nil || *snap.FixQuality != 2 {
+		t.Fatalf("fix quality=%v", snap.FixQuality)
+	}
+	if snap.AltFeet == nil || *snap.AltFeet != 1789 {
+		t.Fatalf("alt=%v", snap.AltFeet)
+	}
+	if snap.GeoidSepM == nil || *snap.GeoidSepM != 46.9 {
+		t.Fatalf("geoid=%v", snap.GeoidSepM)
+	}
+	if snap.GroundKt == nil || *snap.GroundKt != 22 || snap.TrackDeg == nil || math.Abs(*snap.TrackDeg-84.4) > 1e-9 {
+		t.Fatalf("gs=%v track=%v", snap.GroundKt, snap.TrackDeg)
+	}
+	if snap.HorizAccM == nil || math.Abs(*snap.HorizAccM-3.0) > 1e-9 || snap.VertAccM == nil || *snap.VertAccM != 4.1 {
+		t.Fatalf("acc=%v/%v", snap.HorizAccM, snap.VertAccM)
+	}
+	if snap.TimeUTC != "2026-01-02T12:35:19.25Z" {
+		t.Fatalf("time=%q", snap.TimeUTC)
+	}
+
+	// VTG with mode N (no fix) is ignored.
+	applyNMEALines(t, &st, now, "GNVTG,,T,,M,0.0,N,0.0,K,N")
+	if snap := st.snapshot(); *snap.GroundKt != 22 {
+		t.Fatalf("gs after void VTG=%v", *snap.GroundKt)
+	}
+}
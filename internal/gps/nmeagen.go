@@ -0,0 +1,77 @@
+package gps
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+)
+
+// nmeaChecksum frames a sentence body as "$body*CS".
+func nmeaChecksum(body string) string {
+	ck := byte(0)
+	for i := 0; i < len(body); i++ {
+		ck ^= body[i]
+	}
+	return fmt.Sprintf("$%s*%02X", body, ck)
+}
+
+// nmeaCoord formats a coordinate as (d)ddmm.mmmmm with its hemisphere.
+func nmeaCoord(deg float64, degWidth int, pos, neg string) (string, string) {
+	hemi := pos
+	if deg < 0 {
+		hemi = neg
+		deg = -deg
+	}
+	d := math.Floor(deg)
+	m := (deg - d) * 60
+	// Keep 59.999995 from rounding up to 60.00000.
+	if m >= 59.999995 {
+		d, m = d+1, 0
+	}
+	return fmt.Sprintf("%0*d%08.5f", degWidth, int(d), m), hemi
+}
+
+// snapshotNMEA renders a fix as RMC and GGA sentences, for consumers of the
+// passthrough output when the source speaks gpsd JSON rather than NMEA.
+func snapshotNMEA(snap Snapshot) []string {
+	if !snap.Valid {
+		return nil
+	}
+	t, err := time.Parse(time.RFC3339Nano, snap.LastFixUTC)
+	if err != nil {
+		return nil
+	}
+	t = t.UTC()
+	hms := t.Format("150405.00")
+	lat, ns := nmeaCoord(snap.LatDeg, 2, "N", "S")
+	lon, ew := nmeaCoord(snap.LonDeg, 3, "E", "W")
+
+	speed, track := "", ""
+	if snap.GroundKt != nil {
+		speed = strconv.Itoa(*snap.GroundKt)
+	}
+	if snap.TrackDeg != nil {
+		track = strconv.FormatFloat(*snap.TrackDeg, 'f', 1, 64)
+	}
+	rmc := nmeaChecksum(fmt.Sprintf("GPRMC,%s,A,%s,%s,%s,%s,%s,%s,%s,,,A", hms, lat, ns, lon, ew, speed, track, t.Format("020106")))
+
+	quality, sats, hdop, alt, geoid := 1, "", "", "", ""
+	if snap.FixQuality != nil && *snap.FixQuality > 0 {
+		quality = *snap.FixQuality
+	}
+	if snap.Satellites != nil {
+		sats = fmt.Sprintf("%02d", *snap.Satellites)
+	}
+	if snap.HDOP != nil {
+		hdop = strconv.FormatFloat(*snap.HDOP, 'f', 1, 64)
+	}
+	if snap.AltFeet != nil {
+		alt = strconv.FormatFloat(float64(*snap.AltFeet)*0.3048, 'f', 1, 64)
+	}
+	if snap.GeoidSepM != nil {
+		geoid = strconv.FormatFloat(*snap.GeoidSepM, 'f', 1, 64)
+	}
+	gga := nmeaChecksum(fmt.Sprintf("GPGGA,%s,%s,%s,%s,%s,%d,%s,%s,%s,M,%s,M,,", hms, lat, ns, lon, ew, quality, sats, hdop, alt, geoid))
+	return []string{rmc, gga}
+}
@@ -0,0 +1,39 @@
+package gps
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSnapshotNMEA_RoundTrips(t *testing.T) {
+	alt, gs, q, sats := 1790, 122, 1, 9
+	trk, hdop, geoid := 84.4, 0.9, 46.9
+	snap := Snapshot{
+		Valid: true, LatDeg: 48.1173, LonDeg: -11.516666667,
+		AltFeet: &alt, GroundKt: &gs, TrackDeg: &trk, FixQuality: &q, Satellites: &sats, HDOP: &hdop, GeoidSepM: &geoid,
+		LastFixUTC: "2026-10-18T12:35:19.5Z",
+	}
+	lines := snapshotNMEA(snap)
+	if len(lines) != 2 {
+		t.Fatalf("lines=%q", lines)
+	}
+	if want := "$GPRMC,123519.50,A,4807.03800,N,01131.00000,W,122,84.4,181026,,,A*6D"; lines[0] != want {
+		t.Fatalf("rmc=%q want %q", lines[0], want)
+	}
+
+	var st nmeaState
+	now := time.Date(2026, 10, 18, 12, 35, 20, 0, time.UTC)
+	applyNMEALines(t, &st, now, lines[0][1:len(lines[0])-3], lines[1][1:len(lines[1])-3])
+	got := st.snapshot()
+	if !got.Valid || *got.AltFeet != alt || *got.Satellites != sats || *got.GroundKt != gs {
+		t.Fatalf("parsed back %+v", got)
+	}
+	if d := got.LatDeg - snap.LatDeg; d > 1e-6 || d < -1e-6 {
+		t.Fatalf("lat=%v want %v", got.LatDeg, snap.LatDeg)
+	}
+
+	snap.Valid = false
+	if lines := snapshotNMEA(snap); lines != nil {
+		t.Fatalf("invalid fix rendered: %q", lines)
+	}
+}
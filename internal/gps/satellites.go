@@ -0,0 +1,326 @@
+package gps
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// satStaleAfter drops GSV/GSA data a receiver stopped repeating (e.g. a
+// constellation that lost all satellites stops sending its GSA).
+const satStaleAfter = 10 * time.Second
+
+// Constellation names used in Satellite and ConstellationCount.
+const (
+	ConstellationGPS     = "GPS"
+	ConstellationSBAS    = "SBAS"
+	ConstellationGLONASS = "GLONASS"
+	ConstellationGalileo = "Galileo"
+	ConstellationBeiDou  = "BeiDou"
+	ConstellationQZSS    = "QZSS"
+	ConstellationNavIC   = "NavIC"
+	ConstellationUnknown = "unknown"
+)
+
+// Satellite is one satellite in view as reported by GSV, with GSA's used flag.
+type Satellite struct {
+	Constellation string `json:"constellation"`
+	PRN           int    `json:"prn"`
+	ElevationDeg  *int   `json:"elevation_deg,omitempty"`
+	AzimuthDeg    *int   `json:"azimuth_deg,omitempty"`
+	// SNR is the carrier-to-noise ratio (dB-Hz); nil when not tracked.
+	SNR  *int `json:"snr_db,omitempty"`
+	Used bool `json:"used"`
+}
+
+// ConstellationCount summarizes one constellation.
+type ConstellationCount struct {
+	Name   string `json:"name"`
+	InView int    `json:"in_view"`
+	Used   int    `json:"used"`
+}
+
+type satKey struct {
+	constellation string
+	prn           int
+}
+
+// gsvCycle is one complete (or in-progress) GSV message sequence.
+type gsvCycle struct {
+	total int
+	next  int
+	sats  []Satellite
+	at    time.Time
+}
+
+type usedSet struct {
+	sats []satKey
+	at   time.Time
+}
+
+// satTracker assembles GSV sequences and GSA used lists. Receivers send one
+// GSV sequence per talker (and per signal on NMEA 4.10+) and one GSA per
+// constellation, so both are keyed by their source and replaced wholesale.
+type satTracker struct {
+	pending map[string]*gsvCycle
+	inView  map[string]*gsvCycle
+	used    map[string]usedSet
+}
+
+// talkerConstellation maps an NMEA talker ID to its constellation; "GN"
+// (combined) and unknown talkers return "".
+func talkerConstellation(talker string) string {
+	switch talker {
+	case "GP":
+		return ConstellationGPS
+	case "GL":
+		return ConstellationGLONASS
+	case "GA":
+		return ConstellationGalileo
+	case "GB", "BD":
+		return ConstellationBeiDou
+	case "GQ":
+		return ConstellationQZSS
+	case "GI":
+		return ConstellationNavIC
+	}
+	return ""
+}
+
+// systemIDTalker maps the NMEA 4.10 GSA/GSV system ID to a talker ID.
+func systemIDTalker(id string) string {
+	switch strings.TrimSpace(id) {
+	case "1":
+		return "GP"
+	case "2":
+		return "GL"
+	case "3":
+		return "GA"
+	case "4":
+		return "GB"
+	case "5":
+		return "GQ"
+	case "6":
+		return "GI"
+	}
+	return ""
+}
+
+// satConstellation resolves a PRN's constellation. GPS talkers also carry
+// SBAS PRNs; combined talkers are resolved by the u-blox extended PRN ranges.
+func satConstellation(talker string, prn int) string {
+	sbas := (prn >= 33 && prn <= 64) || (prn >= 120 && prn <= 158)
+	c := talkerConstellation(talker)
+	switch {
+	case c == ConstellationGPS && sbas:
+		return ConstellationSBAS
+	case c != "":
+		return c
+	case prn >= 1 && prn <= 32:
+		return ConstellationGPS
+	case sbas:
+		return ConstellationSBAS
+	case prn >= 65 && prn <= 96:
+		return ConstellationGLONASS
+	case prn >= 193 && prn <= 202:
+		return ConstellationQZSS
+	case prn >= 301 && prn <= 336:
+		return ConstellationGalileo
+	case prn >= 401 && prn <= 437:
+		return ConstellationBeiDou
+	}
+	return ConstellationUnknown
+}
+
+func atoiField(s string) (int, bool) {
+	v, err := strconv.Atoi(strings.TrimSpace(s))
+	return v, err == nil
+}
+
+// GSA: GNSS DOP and Active Satellites
+// Fields:
+//
+//	0: talker+type
+//	1: mode (M/A)
+//	2: fix mode (1=none, 2=2D, 3=3D)
+//	3-14: PRNs used
+//	15: PDOP
+//	16: HDOP
+//	17: VDOP
+//	18: system ID (NMEA 4.10)
+func (s *nmeaState) applyGSA(nowUTC time.Time, talker string, f []string) bool {
+	if len(f) < 18 {
+		return false
+	}
+	if len(f) > 18 {
+		if t := systemIDTalker(f[18]); t != "" {
+			talker = t
+		}
+	}
+	if mode, ok := atoiField(f[2]); ok && mode >= 1 && mode <= 3 {
+		s.fixMode, s.fixModeOK = mode, true
+	}
+	if v, ok := parseFloat(f[15]); ok {
+		s.pdop, s.pdopOK = v, true
+	}
+	if v, ok := parseFloat(f[16]); ok {
+		s.hdop, s.hdopOK = v, true
+	}
+	if v, ok := parseFloat(f[17]); ok {
+		s.vdop, s.vdopOK = v, true
+	}
+
+	set := usedSet{at: nowUTC}
+	for _, field := range f[3:15] {
+		if prn, ok := atoiField(field); ok && prn > 0 {
+			set.sats = append(set.sats, satKey{satConstellation(talker, prn), prn})
+		}
+	}
+	// A combined-talker GSA without a system ID covers one constellation
+	// per sentence; key it by what it contains so consecutive sentences in
+	// an epoch don't overwrite each other.
+	key := talker
+	if talkerConstellation(talker) == "" && len(set.sats) > 0 {
+		key = talker + "/" + set.sats[0].constellation
+	}
+	if s.sats.used == nil {
+		s.sats.used = make(map[string]usedSet)
+	}
+	s.sats.used[key] = set
+	s.sats.prune(nowUTC)
+	return true
+}
+
+// GSV: GNSS Satellites in View
+// Fields:
+//
+//	0: talker+type
+//	1: number of messages in this sequence
+//	2: message number
+//	3: satellites in view
+//	4-7, 8-11, ...: PRN, elevation, azimuth, SNR (up to four per message)
+//	last: signal ID (NMEA 4.10, when the count is odd)
+func (s *nmeaState) applyGSV(nowUTC time.Time, talker string, f []string) bool {
+	if len(f) < 4 {
+		return false
+	}
+	total, ok1 := atoiField(f[1])
+	num, ok2 := atoiField(f[2])
+	if !ok1 || !ok2 || total < 1 || num < 1 || num > total {
+		return false
+	}
+	groups := f[4:]
+	key := talker
+	if len(groups)%4 == 1 {
+		key = talker + "/" + strings.TrimSpace(groups[len(groups)-1])
+		groups = groups[:len(groups)-1]
+	}
+
+	t := &s.sats
+	if t.pending == nil {
+		t.pending = make(map[string]*gsvCycle)
+		t.inView = make(map[string]*gsvCycle)
+	}
+	cyc := t.pending[key]
+	if num == 1 {
+		cyc = &gsvCycle{total: total, next: 1}
+		t.pending[key] = cyc
+	}
+	if cyc == nil || cyc.total != total || cyc.next != num {
+		// Lost a message; wait for the next sequence.
+		delete(t.pending, key)
+		return false
+	}
+	for i := 0; i+4 <= len(groups); i += 4 {
+		prn, ok := atoiField(groups[i])
+		if !ok || prn <= 0 {
+			continue
+		}
+		sv := Satellite{Constellation: satConstellation(talker, prn), PRN: prn}
+		if v, ok := atoiField(groups[i+1]); ok {
+			sv.ElevationDeg = &v
+		}
+		if v, ok := atoiField(groups[i+2]); ok {
+			sv.AzimuthDeg = &v
+		}
+		if v, ok := atoiField(groups[i+3]); ok {
+			sv.SNR = &v
+		}
+		cyc.sats = append(cyc.sats, sv)
+	}
+	cyc.next++
+	if num < total {
+		return false
+	}
+	cyc.at = nowUTC
+	t.inView[key] = cyc
+	delete(t.pending, key)
+	t.prune(nowUTC)
+	return true
+}
+
+func (t *satTracker) prune(nowUTC time.Time) {
+	for k, c := range t.inView {
+		if nowUTC.Sub(c.at) > satStaleAfter {
+			delete(t.inView, k)
+		}
+	}
+	for k, u := range t.used {
+		if nowUTC.Sub(u.at) > satStaleAfter {
+			delete(t.used, k)
+		}
+	}
+}
+
+// snapshot merges the per-signal GSV sequences (keeping the strongest SNR)
+// and marks used satellites.
+func (t *satTracker) snapshot() ([]Satellite, []ConstellationCount) {
+	if len(t.inView) == 0 {
+		return nil, nil
+	}
+	used := make(map[satKey]bool)
+	for _, u := range t.used {
+		for _, k := range u.sats {
+			used[k] = true
+		}
+	}
+	merged := make(map[satKey]Satellite)
+	for _, c := range t.inView {
+		for _, sv := range c.sats {
+			k := satKey{sv.Constellation, sv.PRN}
+			prev, ok := merged[k]
+			if ok && (sv.SNR == nil || (prev.SNR != nil && *prev.SNR >= *sv.SNR)) {
+				continue
+			}
+			merged[k] = sv
+		}
+	}
+	list := make([]Satellite, 0, len(merged))
+	counts := make(map[string]*ConstellationCount)
+	for k, sv := range merged {
+		sv.Used = used[k]
+		list = append(list, sv)
+		cc := counts[sv.Constellation]
+		if cc == nil {
+			cc = &ConstellationCount{Name: sv.Constellation}
+			counts[sv.Constellation] = cc
+		}
+		cc.InView++
+		if sv.Used {
+			cc.Used++
+		}
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Constellation != list[j].Constellation {
+			return list[i].Constellation < list[j].Constellation
+		}
+		return list[i].PRN < list[j].PRN
+	})
+	out := make([]ConstellationCount, 0, len(counts))
+	for _, cc := range counts {
+		out = append(out, *cc)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return list, out
+}
@@ -1,15 +1,11 @@
 package gps
 
 import (
-	"bufio"
 	"context"
+	"errors"
 	"fmt"
-	"io"
 	"log"
-	"os"
-	"strings"
 	"sync"
-	"sync/atomic"
 	"time"
 )
 
@@ -37,8 +33,9 @@ import (
 type Config struct {
 	Enable bool
 
-	// Source selects how GPS is ingested: "nmea" (direct serial) or "gpsd".
-	// When empty, defaults to "nmea".
+	// Source selects how GPS is ingested: "nmea" (direct serial), "gpsd",
+	// "udp"/"tcp" (network NMEA) or "static". When empty, defaults to
+	// "nmea".
 	Source string
 
 	// GPSDAddr is host:port for gpsd when Source=="gpsd".
@@ -47,12 +44,37 @@ type Config struct {
 	// Device is the serial device path for Source=="nmea".
 	Device string
 	Baud   int
+
+	// Listen is the local address for Source "udp" or "tcp".
+	Listen string
+
+	// UBlox configures u-blox receivers on the serial source.
+	UBlox UBloxConfig
+
+	// Static is the position published by the "static" source.
+	Static StaticPosition
+
+	// SkipDevices are serial devices owned by other parts of the system
+	// (FLARM, UAT radio). Serial auto-detection never opens them.
+	SkipDevices []string
+
+	// Inputs is an ordered failover chain, most preferred first. When
+	// empty, the single input above is used.
+	Inputs []InputConfig
+
+	// OnSentence, when set, receives every checksum-valid NMEA sentence
+	// from the active input, without line terminator. gpsd fixes are
+	// rendered as RMC and GGA.
+	OnSentence func(line string)
 }
 
 type Snapshot struct {
 	Enabled  bool `json:"enabled"`
 	Valid    bool `json:"valid"`
 	FixStale bool `json:"fix_stale"`
+	// Static marks a synthetic fix from a configured position rather than
+	// a receiver.
+	Static bool `json:"static,omitempty"`
 
 	Source   string `json:"source,omitempty"`
 	GPSDAddr string `json:"gpsd_addr,omitempty"`
@@ -60,46 +82,97 @@ type Snapshot struct {
 	Device string `json:"device,omitempty"`
 	Baud   int    `json:"baud,omitempty"`
 
-	LatDeg       float64  `json:"lat_deg,omitempty"`
-	LonDeg       float64  `json:"lon_deg,omitempty"`
-	AltFeet      *int     `json:"alt_feet,omitempty"`
-	GroundKt     *int     `json:"ground_kt,omitempty"`
-	TrackDeg     *float64 `json:"track_deg,omitempty"`
-	FixQuality   *int     `json:"fix_quality,omitempty"`
-	FixMode      *int     `json:"fix_mode,omitempty"`
-	Satellites   *int     `json:"satellites,omitempty"`
-	HDOP         *float64 `json:"hdop,omitempty"`
-	HorizAccM    *float64 `json:"horiz_acc_m,omitempty"`
-	VertAccM     *float64 `json:"vert_acc_m,omitempty"`
-	VertSpeedFPM *int     `json:"vert_speed_fpm,omitempty"`
-	FixAgeSec    float64  `json:"fix_age_sec,omitempty"`
+	// Listen and Sender describe network NMEA sources: the local address
+	// and the host whose sentences are being used.
+	Listen string `json:"listen,omitempty"`
+	Sender string `json:"sender,omitempty"`
+
+	LatDeg     float64  `json:"lat_deg,omitempty"`
+	LonDeg     float64  `json:"lon_deg,omitempty"`
+	AltFeet    *int     `json:"alt_feet,omitempty"`
+	GroundKt   *int     `json:"ground_kt,omitempty"`
+	TrackDeg   *float64 `json:"track_deg,omitempty"`
+	FixQuality *int     `json:"fix_quality,omitempty"`
+	FixMode    *int     `json:"fix_mode,omitempty"`
+	Satellites *int     `json:"satellites,omitempty"`
+	HDOP       *float64 `json:"hdop,omitempty"`
+	PDOP       *float64 `json:"pdop,omitempty"`
+	VDOP       *float64 `json:"vdop,omitempty"`
+	// SatellitesSeen counts satellites with signal, used or not.
+	SatellitesSeen *int     `json:"satellites_seen,omitempty"`
+	HorizAccM      *float64 `json:"horiz_acc_m,omitempty"`
+	VertAccM       *float64 `json:"vert_acc_m,omitempty"`
+	VertSpeedFPM   *int     `json:"vert_speed_fpm,omitempty"`
+	FixAgeSec      float64  `json:"fix_age_sec,omitempty"`
+	// GeoidSepM is the geoid height above the WGS-84 ellipsoid (GGA/GNS).
+	GeoidSepM *float64 `json:"geoid_sep_m,omitempty"`
+
+	// SatelliteList and Constellations come from NMEA GSV/GSA.
+	SatelliteList  []Satellite          `json:"satellite_list,omitempty"`
+	Constellations []ConstellationCount `json:"constellations,omitempty"`
+
+	// TimeUTC is the receiver's UTC time (ZDA/RMC, gpsd TPV or UBX
+	// NAV-PVT), RFC3339. TimeAt is the local clock reading when it arrived,
+	// for time sync.
+	TimeUTC string    `json:"time_utc,omitempty"`
+	TimeAt  time.Time `json:"-"`
+
+	// UBX is set once u-blox binary NAV messages have been received.
+	UBX bool `json:"ubx,omitempty"`
+
+	// ActiveInput and Inputs describe the failover chain when more than
+	// one input is configured.
+	ActiveInput string        `json:"active_input,omitempty"`
+	Inputs      []InputStatus `json:"inputs,omitempty"`
+
+	// Integrity is set by the integrity monitor, when enabled.
+	Integrity *Integrity `json:"integrity,omitempty"`
 
 	LastFixUTC string `json:"last_fix_utc,omitempty"`
 	LastError  string `json:"last_error,omitempty"`
 }
 
 type Service struct {
-	cfg Config
-
-	cancel context.CancelFunc
+	cfg    Config
+	inputs []*input
 	wg     sync.WaitGroup
 
-	last atomic.Value // Snapshot
+	// now is the clock used for health scoring; tests override it.
+	now func() time.Time
 
-	mu     sync.Mutex
-	closer io.Closer
+	selMu  sync.Mutex
+	active int
 }
 
 func New(cfg Config) *Service {
-	s := &Service{cfg: cfg}
-	src := strings.ToLower(strings.TrimSpace(cfg.Source))
-	if src == "" {
-		src = "nmea"
+	s := &Service{cfg: cfg, now: func() time.Time { return time.Now().UTC() }}
+	ins := cfg.Inputs
+	if len(ins) == 0 {
+		ins = []InputConfig{{Source: cfg.Source, GPSDAddr: cfg.GPSDAddr, Device: cfg.Device, Baud: cfg.Baud, Listen: cfg.Listen}}
+	}
+	for _, ic := range ins {
+		in := newInput(ic, cfg.UBlox)
+		in.static = cfg.Static
+		in.skip = cfg.SkipDevices
+		if cfg.OnSentence != nil {
+			in.onSentence = func(line string) {
+				if s.isActive(in) {
+					cfg.OnSentence(line)
+				}
+			}
+		}
+		s.inputs = append(s.inputs, in)
 	}
-	s.last.Store(Snapshot{Enabled: cfg.Enable, Source: src, GPSDAddr: strings.TrimSpace(cfg.GPSDAddr), Device: cfg.Device, Baud: cfg.Baud})
 	return s
 }
 
+func (s *Service) isActive(in *input) bool {
+	s.selMu.Lock()
+	defer s.selMu.Unlock()
+	return s.inputs[s.active] == in
+}
+
+// Start brings up every input; it fails only when none could be started.
 func (s *Service) Start(ctx context.Context) error {
 	if s == nil {
 		return fmt.Errorf("gps service is nil")
@@ -111,206 +184,16 @@ func (s *Service) Start(ctx context.Context) error {
 		return fmt.Errorf("ctx is nil")
 	}
 
-	s.mu.Lock()
-	defer s.mu.Unlock()
-	if s.cancel != nil {
-		return nil
-	}
-
-	src := strings.ToLower(strings.TrimSpace(s.cfg.Source))
-	if src == "" {
-		src = "nmea"
-	}
-	if src == "gpsd" {
-		return s.startGPSDLocked(ctx)
-	}
-	// Default: NMEA over serial.
-	return s.startNMEALocked(ctx)
-}
-
-func (s *Service) startNMEALocked(ctx context.Context) error {
-
-	device := strings.TrimSpace(s.cfg.Device)
-	if device == "" {
-		device = autoDetectDevice()
-		if device == "" {
-			s.setErrorLocked("gps auto-detect failed: no /dev/ttyACM* or /dev/ttyUSB* found")
-			return fmt.Errorf("gps auto-detect failed")
+	var errs []error
+	for _, in := range s.inputs {
+		if err := in.start(ctx, &s.wg); err != nil {
+			log.Printf("gps input %s failed: %v", in.name(), err)
+			errs = append(errs, err)
 		}
 	}
-
-	baud := s.cfg.Baud
-	if baud == 0 {
-		baud = 9600
-	}
-
-	f, err := openSerial(device, baud)
-	if err != nil {
-		s.setErrorLocked(fmt.Sprintf("gps open failed device=%s baud=%d: %v", device, baud, err))
-		return err
+	if len(errs) == len(s.inputs) {
+		return errors.Join(errs...)
 	}
-	// Keep the file reference for Close().
-	s.closer = f
-
-	childCtx, cancel := context.WithCancel(ctx)
-	s.cancel = cancel
-
-	s.wg.Add(1)
-	go func() {
-		defer s.wg.Done()
-		defer func() {
-			_ = f.Close()
-		}()
-
-		log.Printf("gps enabled device=%s baud=%d", device, baud)
-
-		reader := bufio.NewScanner(f)
-		// NMEA sentences are typically < 82 chars, but allow some headroom.
-		reader.Buffer(make([]byte, 0, 256), 4096)
-
-		var st nmeaState
-		st.device = device
-		st.baud = baud
-
-		for {
-			select {
-			case <-childCtx.Done():
-				return
-			default:
-			}
-
-			if !reader.Scan() {
-				err := reader.Err()
-				if err == nil {
-					err = io.EOF
-				}
-				s.setError(fmt.Sprintf("gps read stopped: %v", err))
-				return
-			}
-
-			line := strings.TrimSpace(reader.Text())
-			if line == "" {
-				continue
-			}
-			// Some receivers may include non-NMEA chatter; filter quickly.
-			if !strings.HasPrefix(line, "$") {
-				continue
-			}
-
-			sent, perr := parseNMEASentence(line)
-			if perr != nil {
-				// Avoid spamming on bad noise; just keep the last error.
-				s.setError(perr.Error())
-				continue
-			}
-
-			if updated := st.apply(time.Now().UTC(), sent); updated {
-				s.last.Store(st.snapshot())
-			}
-		}
-	}()
-
-	// Publish initial snapshot.
-	s.last.Store(Snapshot{Enabled: true, Valid: false, Source: "nmea", Device: device, Baud: baud})
-	return nil
-}
-
-func (s *Service) startGPSDLocked(ctx context.Context) error {
-	addr := strings.TrimSpace(s.cfg.GPSDAddr)
-	if addr == "" {
-		addr = "127.0.0.1:2947"
-	}
-
-	childCtx, cancel := context.WithCancel(ctx)
-	s.cancel = cancel
-
-	s.wg.Add(1)
-	go func() {
-		defer s.wg.Done()
-
-		log.Printf("gps enabled source=gpsd addr=%s", addr)
-		st := newGPSDState(addr)
-		backoff := 250 * time.Millisecond
-		maxBackoff := 10 * time.Second
-
-		for {
-			select {
-			case <-childCtx.Done():
-				return
-			default:
-			}
-
-			conn, err := dialGPSD(childCtx, addr)
-			if err != nil {
-				s.setError(fmt.Sprintf("gpsd dial failed addr=%s: %v", addr, err))
-				t := backoff
-				if t > maxBackoff {
-					t = maxBackoff
-				}
-				select {
-				case <-childCtx.Done():
-					return
-				case <-time.After(t):
-				}
-				if backoff < maxBackoff {
-					backoff *= 2
-				}
-				continue
-			}
-
-			// Reset backoff after a successful connection.
-			backoff = 250 * time.Millisecond
-
-			s.mu.Lock()
-			// Swap the closer so Close() can interrupt an active connection.
-			s.closer = conn
-			s.mu.Unlock()
-
-			func() {
-				defer func() { _ = conn.Close() }()
-
-				// Start watching JSON reports.
-				if err := gpsdWatch(conn); err != nil {
-					s.setError(fmt.Sprintf("gpsd watch failed: %v", err))
-					return
-				}
-
-				scanner := bufio.NewScanner(conn)
-				scanner.Buffer(make([]byte, 0, 4096), 256*1024)
-				for {
-					select {
-					case <-childCtx.Done():
-						return
-					default:
-					}
-					if !scanner.Scan() {
-						err := scanner.Err()
-						if err == nil {
-							err = io.EOF
-						}
-						s.setError(fmt.Sprintf("gpsd read stopped: %v", err))
-						return
-					}
-					line := strings.TrimSpace(scanner.Text())
-					if line == "" {
-						continue
-					}
-					updated, perr := st.applyLine(time.Now().UTC(), line)
-					if perr != nil {
-						s.setError(perr.Error())
-						continue
-					}
-					if updated {
-						s.last.Store(st.snapshot())
-					}
-				}
-			}()
-			// Loop and reconnect.
-		}
-	}()
-
-	// Publish initial snapshot.
-	s.last.Store(Snapshot{Enabled: true, Valid: false, Source: "gpsd", GPSDAddr: addr, Device: "gpsd"})
 	return nil
 }
 
@@ -318,59 +201,48 @@ func (s *Service) Close() {
 	if s == nil {
 		return
 	}
-	s.mu.Lock()
-	cancel := s.cancel
-	closer := s.closer
-	s.cancel = nil
-	s.closer = nil
-	s.mu.Unlock()
-
-	if cancel != nil {
-		cancel()
-	}
-	if closer != nil {
-		_ = closer.Close()
+	for _, in := range s.inputs {
+		in.close()
 	}
 	s.wg.Wait()
 }
 
+// Snapshot returns the active input's data, re-evaluating the selection
+// first, with the status of every input attached when there are several.
 func (s *Service) Snapshot() Snapshot {
-	if s == nil {
-		return Snapshot{}
-	}
-	v := s.last.Load()
-	if v == nil {
+	if s == nil || len(s.inputs) == 0 {
 		return Snapshot{}
 	}
-	return v.(Snapshot)
-}
-
-func (s *Service) setError(msg string) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-	s.setErrorLocked(msg)
-}
-
-func (s *Service) setErrorLocked(msg string) {
-	cur := s.Snapshot()
-	cur.LastError = msg
-	// Do not force Valid=false here; transient parse issues shouldn’t flip validity.
-	s.last.Store(cur)
-}
-
-func autoDetectDevice() string {
-	// Keep it intentionally tiny and predictable.
-	candidates := []string{}
-	for i := 0; i < 10; i++ {
-		candidates = append(candidates, fmt.Sprintf("/dev/ttyACM%d", i))
-	}
-	for i := 0; i < 10; i++ {
-		candidates = append(candidates, fmt.Sprintf("/dev/ttyUSB%d", i))
-	}
-	for _, p := range candidates {
-		if _, err := os.Stat(p); err == nil {
-			return p
+	s.selMu.Lock()
+	defer s.selMu.Unlock()
+
+	now := s.now()
+	scores := make([]int, len(s.inputs))
+	for i, in := range s.inputs {
+		scores[i] = inputScore(in.snapshot(), in.fixAge(now))
+	}
+	if next := s.selectLocked(now, scores); next != s.active {
+		log.Printf("gps failover: %s (score %d) -> %s (score %d)",
+			s.inputs[s.active].name(), scores[s.active], s.inputs[next].name(), scores[next])
+		s.active = next
+	}
+
+	snap := s.inputs[s.active].snapshot()
+	snap.Enabled = s.cfg.Enable
+	if len(s.inputs) > 1 {
+		snap.ActiveInput = s.inputs[s.active].name()
+		for i, in := range s.inputs {
+			is := in.snapshot()
+			snap.Inputs = append(snap.Inputs, InputStatus{
+				Name:      in.name(),
+				Source:    in.src,
+				Valid:     is.Valid,
+				Score:     scores[i],
+				Active:    i == s.active,
+				Sender:    is.Sender,
+				LastError: is.LastError,
+			})
 		}
 	}
-	return ""
+	return snap
 }
@@ -0,0 +1,86 @@
+package gps
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// staticInterval is how often a static position is re-published; well
+// inside the 3 s after which ownship treats a fix as stale.
+const staticInterval = time.Second
+
+// StaticPosition is the surveyed position of a unit with no GPS receiver,
+// such as a ground station on a hangar roof.
+type StaticPosition struct {
+	LatDeg        float64
+	LonDeg        float64
+	ElevationFeet int
+}
+
+// staticSnapshot is a synthetic fix at pos: on the ground, stationary, and
+// flagged Static. Its time is the local clock, which is only as good as
+// whatever set it (NTP, RTC).
+func staticSnapshot(nowUTC time.Time, pos StaticPosition) Snapshot {
+	alt := pos.ElevationFeet
+	gs := 0
+	mode := 3
+	stamp := nowUTC.Format(time.RFC3339Nano)
+	return Snapshot{
+		Enabled:    true,
+		Valid:      true,
+		Static:     true,
+		Source:     "static",
+		LatDeg:     pos.LatDeg,
+		LonDeg:     pos.LonDeg,
+		AltFeet:    &alt,
+		GroundKt:   &gs,
+		FixMode:    &mode,
+		TimeUTC:    stamp,
+		TimeAt:     nowUTC,
+		LastFixUTC: stamp,
+	}
+}
+
+func (in *input) startStatic(ctx context.Context, wg *sync.WaitGroup) error {
+	pos := in.static
+	if pos.LatDeg < -90 || pos.LatDeg > 90 || pos.LonDeg < -180 || pos.LonDeg > 180 {
+		err := fmt.Errorf("gps static position out of range lat=%f lon=%f", pos.LatDeg, pos.LonDeg)
+		in.setError(err.Error())
+		return err
+	}
+
+	childCtx, cancel := context.WithCancel(ctx)
+	in.mu.Lock()
+	in.cancel = cancel
+	in.mu.Unlock()
+
+	publish := func() {
+		now := time.Now().UTC()
+		snap := staticSnapshot(now, pos)
+		in.store(snap, now)
+		for _, l := range snapshotNMEA(snap) {
+			in.emit(l)
+		}
+	}
+	publish()
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		log.Printf("gps enabled source=static lat=%.6f lon=%.6f elevation_ft=%d", pos.LatDeg, pos.LonDeg, pos.ElevationFeet)
+		t := time.NewTicker(staticInterval)
+		defer t.Stop()
+		for {
+			select {
+			case <-childCtx.Done():
+				return
+			case <-t.C:
+				publish()
+			}
+		}
+	}()
+	return nil
+}
@@ -0,0 +1,66 @@
+package gps
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestStaticSnapshot(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	snap := staticSnapshot(now, StaticPosition{LatDeg: 45.5, LonDeg: -122.9, ElevationFeet: 210})
+	if !snap.Valid || !snap.Static || snap.Source != "static" || snap.LatDeg != 45.5 || snap.LonDeg != -122.9 {
+		t.Fatalf("snapshot=%+v", snap)
+	}
+	if *snap.AltFeet != 210 || *snap.GroundKt != 0 || snap.TrackDeg != nil {
+		t.Fatalf("alt=%d gs=%d track=%v", *snap.AltFeet, *snap.GroundKt, snap.TrackDeg)
+	}
+	if snap.TimeUTC != "2026-10-18T12:00:00Z" || !snap.TimeAt.Equal(now) || snap.LastFixUTC != snap.TimeUTC {
+		t.Fatalf("time=%q at=%v fix=%q", snap.TimeUTC, snap.TimeAt, snap.LastFixUTC)
+	}
+}
+
+func TestInput_Static(t *testing.T) {
+	var mu sync.Mutex
+	var lines []string
+	in := newInput(InputConfig{Source: "static"}, UBloxConfig{})
+	in.static = StaticPosition{LatDeg: 45.5, LonDeg: -122.9, ElevationFeet: 210}
+	in.onSentence = func(line string) {
+		mu.Lock()
+		defer mu.Unlock()
+		lines = append(lines, line)
+	}
+	var wg sync.WaitGroup
+	if err := in.start(context.Background(), &wg); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	defer func() {
+		in.close()
+		wg.Wait()
+	}()
+
+	if snap := in.snapshot(); !snap.Valid || !snap.Static || in.name() != "static" {
+		t.Fatalf("name=%s snapshot=%+v", in.name(), snap)
+	}
+	if age := in.fixAge(time.Now().UTC()); age > time.Second {
+		t.Fatalf("fix age %v", age)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(lines) != 2 || !strings.HasPrefix(lines[0], "$GPRMC,") || !strings.HasPrefix(lines[1], "$GPGGA,") {
+		t.Fatalf("sentences=%q", lines)
+	}
+}
+
+func TestInput_StaticRejectsBadPosition(t *testing.T) {
+	in := newInput(InputConfig{Source: "static"}, UBloxConfig{})
+	in.static = StaticPosition{LatDeg: 91}
+	var wg sync.WaitGroup
+	if err := in.start(context.Background(), &wg); err == nil {
+		in.close()
+		t.Fatalf("expected error")
+	}
+	wg.Wait()
+}
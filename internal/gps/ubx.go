@@ -0,0 +1,354 @@
+package gps
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"time"
+)
+
+// UBX is u-blox's binary protocol. Frames are:
+//
+//	0xB5 0x62 class id len(LE u16) payload ck_a ck_b
+//
+// with an 8-bit Fletcher checksum over class..payload.
+const (
+	ubxSync1 = 0xB5
+	ubxSync2 = 0x62
+
+	ubxClassNAV = 0x01
+	ubxClassACK = 0x05
+	ubxClassCFG = 0x06
+	ubxClassMON = 0x0A
+
+	ubxNAVStatus = 0x03
+	ubxNAVDOP    = 0x04
+	ubxNAVPVT    = 0x07
+	ubxNAVSAT    = 0x35
+
+	ubxMONVER = 0x04
+
+	ubxACKNak = 0x00
+	ubxACKAck = 0x01
+
+	ubxCFGPRT  = 0x00
+	ubxCFGMSG  = 0x01
+	ubxCFGRATE = 0x08
+	ubxCFGCFG  = 0x09
+	ubxCFGSBAS = 0x16
+	ubxCFGNAV5 = 0x24
+	ubxCFGGNSS = 0x3E
+
+	// ubxMaxPayload bounds what we buffer; NAV-SAT with every
+	// constellation tracked stays well below this.
+	ubxMaxPayload = 2048
+
+	// ubxPreferFor is how long NAV-PVT takes precedence over NMEA RMC/GGA,
+	// which lack accuracy estimates.
+	ubxPreferFor = 2 * time.Second
+
+	metersToFeet = 3.280839895013123
+	mpsToKnots   = 1.9438444924406
+)
+
+// UBloxConfig mirrors the u-blox startup configuration options.
+type UBloxConfig struct {
+	// Configure sends the configuration below to a receiver that answers
+	// a UBX poll, and saves it to battery-backed RAM.
+	Configure bool
+	// RateHz is the navigation solution rate.
+	RateHz int
+	// Baud is the port rate switched to after configuration.
+	Baud int
+}
+
+type ubxMsg struct {
+	Class   byte
+	ID      byte
+	Payload []byte
+}
+
+func ubxChecksum(b []byte) (byte, byte) {
+	var a, c byte
+	for _, v := range b {
+		a += v
+		c += a
+	}
+	return a, c
+}
+
+// ubxPacket frames a UBX message.
+func ubxPacket(class, id byte, payload []byte) []byte {
+	out := make([]byte, 0, 8+len(payload))
+	out = append(out, ubxSync1, ubxSync2, class, id, byte(len(payload)), byte(len(payload)>>8))
+	out = append(out, payload...)
+	a, c := ubxChecksum(out[2:])
+	return append(out, a, c)
+}
+
+// readReceiverMessage reads the next NMEA sentence or UBX frame from a
+// receiver stream that may interleave both. Exactly one of line and msg is
+// set. Bytes that belong to neither (noise, a baud mismatch) are skipped, as
+// are frames with a bad checksum.
+func readReceiverMessage(r *bufio.Reader) (line string, msg *ubxMsg, err error) {
+	for {
+		b, err := r.ReadByte()
+		if err != nil {
+			return "", nil, err
+		}
+		switch b {
+		case '$':
+			rest, err := r.ReadSlice('\n')
+			if errors.Is(err, bufio.ErrBufferFull) {
+				// Not NMEA; drop it.
+				continue
+			}
+			if err != nil {
+				return "", nil, err
+			}
+			return "$" + string(rest), nil, nil
+		case ubxSync1:
+			next, err := r.Peek(1)
+			if err != nil {
+				return "", nil, err
+			}
+			if next[0] != ubxSync2 {
+				continue
+			}
+			_, _ = r.ReadByte()
+			var hdr [4]byte
+			if _, err := io.ReadFull(r, hdr[:]); err != nil {
+				return "", nil, err
+			}
+			n := int(binary.LittleEndian.Uint16(hdr[2:]))
+			if n > ubxMaxPayload {
+				continue
+			}
+			body := make([]byte, n+2)
+			if _, err := io.ReadFull(r, body); err != nil {
+				return "", nil, err
+			}
+			a, c := ubxChecksum(append(hdr[:], body[:n]...))
+			if a != body[n] || c != body[n+1] {
+				continue
+			}
+			return "", &ubxMsg{Class: hdr[0], ID: hdr[1], Payload: body[:n]}, nil
+		}
+	}
+}
+
+// applyUBX folds a UBX message into the receiver state. NAV-PVT is the
+// primary solution; NAV-DOP, NAV-SAT and NAV-STATUS add detail.
+func (s *nmeaState) applyUBX(nowUTC time.Time, m *ubxMsg) (bool, error) {
+	p := m.Payload
+	switch {
+	case m.Class == ubxClassNAV && m.ID == ubxNAVPVT:
+		if len(p) < 92 {
+			return false, fmt.Errorf("ubx: short NAV-PVT (%d bytes)", len(p))
+		}
+		return s.applyNAVPVT(nowUTC, p), nil
+	case m.Class == ubxClassNAV && m.ID == ubxNAVDOP:
+		if len(p) < 18 {
+			return false, fmt.Errorf("ubx: short NAV-DOP (%d bytes)", len(p))
+		}
+		s.ubxAt = nowUTC
+		s.pdop, s.pdopOK = float64(binary.LittleEndian.Uint16(p[6:]))/100, true
+		s.vdop, s.vdopOK = float64(binary.LittleEndian.Uint16(p[10:]))/100, true
+		s.hdop, s.hdopOK = float64(binary.LittleEndian.Uint16(p[12:]))/100, true
+		return true, nil
+	case m.Class == ubxClassNAV && m.ID == ubxNAVStatus:
+		if len(p) < 16 {
+			return false, fmt.Errorf("ubx: short NAV-STATUS (%d bytes)", len(p))
+		}
+		s.ubxAt = nowUTC
+		s.setUBXFix(p[4], p[5]&0x01 != 0, p[5]&0x02 != 0)
+		return true, nil
+	case m.Class == ubxClassNAV && m.ID == ubxNAVSAT:
+		if len(p) < 8 || len(p) < 8+12*int(p[5]) {
+			return false, fmt.Errorf("ubx: short NAV-SAT (%d bytes)", len(p))
+		}
+		s.ubxAt = nowUTC
+		used, seen := 0, 0
+		for i := 0; i < int(p[5]); i++ {
+			sv := p[8+12*i:]
+			if sv[2] > 0 {
+				seen++
+			}
+			if binary.LittleEndian.Uint32(sv[8:])&0x08 != 0 {
+				used++
+			}
+		}
+		s.satellites, s.satsOK = used, true
+		s.satsSeen, s.satsSeenOK = seen, true
+		return true, nil
+	case m.Class == ubxClassACK && m.ID == ubxACKNak && len(p) >= 2:
+		return false, fmt.Errorf("ubx: receiver rejected %s", ubxMsgName(p[0], p[1]))
+	}
+	return false, nil
+}
+
+func (s *nmeaState) applyNAVPVT(nowUTC time.Time, p []byte) bool {
+	s.ubxAt = nowUTC
+	fixType := p[20]
+	flags := p[21]
+	s.setUBXFix(fixType, flags&0x01 != 0, flags&0x02 != 0)
+	s.satellites, s.satsOK = int(p[23]), true
+	s.pdop, s.pdopOK = float64(binary.LittleEndian.Uint16(p[76:]))/100, true
+	if flags&0x01 == 0 || fixType < 2 || fixType > 4 {
+		// No usable fix; leave the last position and validity alone like
+		// the NMEA path does for void RMC.
+		return true
+	}
+	s.pvtAt = nowUTC
+	if p[11]&0x07 == 0x07 {
+		// validDate, validTime and fullyResolved.
+		s.timeUTC = time.Date(int(binary.LittleEndian.Uint16(p[4:])), time.Month(p[6]), int(p[7]),
+			int(p[8]), int(p[9]), int(p[10]), int(int32(binary.LittleEndian.Uint32(p[16:]))), time.UTC)
+		s.timeAt = nowUTC
+	}
+
+	s.lonDeg, s.lonOK = float64(int32(binary.LittleEndian.Uint32(p[24:])))*1e-7, true
+	s.latDeg, s.latOK = float64(int32(binary.LittleEndian.Uint32(p[28:])))*1e-7, true
+	hMSL := float64(int32(binary.LittleEndian.Uint32(p[36:]))) / 1000
+	if fixType != 2 {
+		s.altFeet, s.altOK = int(math.Round(hMSL*metersToFeet)), true
+	}
+	s.hAccM, s.hAccOK = float64(binary.LittleEndian.Uint32(p[40:]))/1000, true
+	s.vAccM, s.vAccOK = float64(binary.LittleEndian.Uint32(p[44:]))/1000, true
+	velD := float64(int32(binary.LittleEndian.Uint32(p[56:]))) / 1000
+	s.vSpeedFPM, s.vsOK = int(math.Round(-velD*metersToFeet*60)), true
+	gSpeed := float64(int32(binary.LittleEndian.Uint32(p[60:]))) / 1000
+	s.groundKt, s.gsOK = gSpeed*mpsToKnots, true
+	s.trackDeg, s.trkOK = math.Mod(float64(int32(binary.LittleEndian.Uint32(p[64:])))*1e-5+360, 360), true
+
+	s.lastFix = nowUTC
+	s.valid = true
+	return true
+}
+
+// setUBXFix maps the UBX fix type onto the gpsd-style fix mode (1 none,
+// 2 2D, 3 3D) and GGA-style fix quality (1 GNSS, 2 differential/SBAS).
+func (s *nmeaState) setUBXFix(fixType byte, fixOK, diff bool) {
+	mode := 1
+	if fixOK {
+		switch fixType {
+		case 2:
+			mode = 2
+		case 3, 4:
+			mode = 3
+		}
+	}
+	s.fixMode, s.fixModeOK = mode, true
+	switch {
+	case mode == 1:
+		s.fixQuality = 0
+	case diff:
+		s.fixQuality = 2
+	default:
+		s.fixQuality = 1
+	}
+	s.fixQualityOK = true
+}
+
+func ubxMsgName(class, id byte) string {
+	if class == ubxClassCFG {
+		switch id {
+		case ubxCFGPRT:
+			return "CFG-PRT"
+		case ubxCFGMSG:
+			return "CFG-MSG"
+		case ubxCFGRATE:
+			return "CFG-RATE"
+		case ubxCFGCFG:
+			return "CFG-CFG"
+		case ubxCFGSBAS:
+			return "CFG-SBAS"
+		case ubxCFGNAV5:
+			return "CFG-NAV5"
+		case ubxCFGGNSS:
+			return "CFG-GNSS"
+		}
+	}
+	return fmt.Sprintf("0x%02X/0x%02X", class, id)
+}
+
+// ubxPortConfig switches UART1 to baud and enables UBX+NMEA in and out on
+// both UART1 and USB.
+func ubxPortConfig(baud int) [][]byte {
+	uart := make([]byte, 20)
+	uart[0] = 1                                           // UART1
+	binary.LittleEndian.PutUint32(uart[4:], 0x000008D0)   // 8N1
+	binary.LittleEndian.PutUint32(uart[8:], uint32(baud)) // baud
+	binary.LittleEndian.PutUint16(uart[12:], 0x0007)      // in: UBX+NMEA+RTCM
+	binary.LittleEndian.PutUint16(uart[14:], 0x0003)      // out: UBX+NMEA
+	usb := make([]byte, 20)
+	usb[0] = 3 // USB
+	binary.LittleEndian.PutUint16(usb[12:], 0x0007)
+	binary.LittleEndian.PutUint16(usb[14:], 0x0003)
+	return [][]byte{ubxPacket(ubxClassCFG, ubxCFGPRT, uart), ubxPacket(ubxClassCFG, ubxCFGPRT, usb)}
+}
+
+// ubxConfigPackets returns the navigation configuration sent once the port
+// runs at its final baud, ending with a save to battery-backed RAM.
+func ubxConfigPackets(rateHz int) [][]byte {
+	var out [][]byte
+
+	// Airborne <2g dynamic model.
+	nav5 := make([]byte, 36)
+	binary.LittleEndian.PutUint16(nav5[0:], 0x0001) // apply dynModel only
+	nav5[2] = 7
+	out = append(out, ubxPacket(ubxClassCFG, ubxCFGNAV5, nav5))
+
+	// GPS+QZSS, SBAS, Galileo and GLONASS on; BeiDou and IMES off.
+	type block struct {
+		id, res, max byte
+		on           bool
+	}
+	blocks := []block{
+		{0, 8, 16, true},  // GPS
+		{1, 1, 3, true},   // SBAS
+		{2, 4, 8, true},   // Galileo
+		{3, 0, 16, false}, // BeiDou
+		{4, 0, 8, false},  // IMES
+		{5, 0, 3, true},   // QZSS
+		{6, 8, 14, true},  // GLONASS
+	}
+	gnss := []byte{0, 0, 0xFF, byte(len(blocks))}
+	for _, b := range blocks {
+		flags := uint32(0x00010000) // L1 signal
+		if b.on {
+			flags |= 0x01
+		}
+		gnss = append(gnss, b.id, b.res, b.max, 0)
+		gnss = binary.LittleEndian.AppendUint32(gnss, flags)
+	}
+	out = append(out, ubxPacket(ubxClassCFG, ubxCFGGNSS, gnss))
+
+	// SBAS for ranging, corrections and integrity; auto PRN scan.
+	out = append(out, ubxPacket(ubxClassCFG, ubxCFGSBAS, []byte{0x01, 0x07, 3, 0, 0, 0, 0, 0}))
+
+	rate := make([]byte, 6)
+	binary.LittleEndian.PutUint16(rate[0:], uint16(1000/rateHz))
+	binary.LittleEndian.PutUint16(rate[2:], 1)
+	out = append(out, ubxPacket(ubxClassCFG, ubxCFGRATE, rate))
+
+	// UBX NAV output on this port: PVT and DOP every solution, status and
+	// satellites about once a second.
+	for _, m := range [][3]byte{
+		{ubxClassNAV, ubxNAVPVT, 1},
+		{ubxClassNAV, ubxNAVDOP, 1},
+		{ubxClassNAV, ubxNAVStatus, byte(rateHz)},
+		{ubxClassNAV, ubxNAVSAT, byte(rateHz)},
+	} {
+		out = append(out, ubxPacket(ubxClassCFG, ubxCFGMSG, m[:]))
+	}
+
+	// Save everything to battery-backed RAM.
+	save := make([]byte, 13)
+	binary.LittleEndian.PutUint32(save[4:], 0x00001F1F)
+	save[12] = 0x01
+	return append(out, ubxPacket(ubxClassCFG, ubxCFGCFG, save))
+}
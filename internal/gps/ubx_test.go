@@ -0,0 +1,175 @@
+package gps
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+func navPVTPayload(fixType, flags, numSV byte, latDeg, lonDeg, hMSLm, hAccM, velDmps, gSpeedMps, headDeg float64) []byte {
+	p := make([]byte, 92)
+	p[20] = fixType
+	p[21] = flags
+	p[23] = numSV
+	binary.LittleEndian.PutUint32(p[24:], uint32(int32(math.Round(lonDeg*1e7))))
+	binary.LittleEndian.PutUint32(p[28:], uint32(int32(math.Round(latDeg*1e7))))
+	binary.LittleEndian.PutUint32(p[36:], uint32(int32(math.Round(hMSLm*1000))))
+	binary.LittleEndian.PutUint32(p[40:], uint32(math.Round(hAccM*1000)))
+	binary.LittleEndian.PutUint32(p[44:], 4500)
+	binary.LittleEndian.PutUint32(p[56:], uint32(int32(math.Round(velDmps*1000))))
+	binary.LittleEndian.PutUint32(p[60:], uint32(int32(math.Round(gSpeedMps*1000))))
+	binary.LittleEndian.PutUint32(p[64:], uint32(int32(math.Round(headDeg*1e5))))
+	binary.LittleEndian.PutUint16(p[76:], 125)
+	return p
+}
+
+func TestReadReceiverMessage_MixedStream(t *testing.T) {
+	var buf bytes.Buffer
+	buf.WriteString("garbage\r\n")
+	buf.WriteString(nmeaLine("GPGGA,123519,4807.038,N,01131.000,E,1,08,0.9,545.4,M,46.9,M,,") + "\r\n")
+	buf.Write(ubxPacket(ubxClassNAV, ubxNAVDOP, make([]byte, 18)))
+	bad := ubxPacket(ubxClassNAV, ubxNAVPVT, make([]byte, 92))
+	bad[len(bad)-1] ^= 0xFF
+	buf.Write(bad)
+	buf.WriteString(nmeaLine("GPRMC,123519,A,4807.038,N,01131.000,E,022.4,084.4,230394,003.1,W") + "\r\n")
+
+	r := bufio.NewReader(&buf)
+	line, msg, err := readReceiverMessage(r)
+	if err != nil || msg != nil || !strings.HasPrefix(line, "$GPGGA,") {
+		t.Fatalf("first: line=%q msg=%v err=%v", line, msg, err)
+	}
+	line, msg, err = readReceiverMessage(r)
+	if err != nil || msg == nil || msg.Class != ubxClassNAV || msg.ID != ubxNAVDOP || len(msg.Payload) != 18 {
+		t.Fatalf("second: line=%q msg=%+v err=%v", line, msg, err)
+	}
+	// The corrupted NAV-PVT is skipped.
+	line, msg, err = readReceiverMessage(r)
+	if err != nil || msg != nil || !strings.HasPrefix(line, "$GPRMC,") {
+		t.Fatalf("third: line=%q msg=%v err=%v", line, msg, err)
+	}
+}
+
+func TestNMEAState_NAVPVT(t *testing.T) {
+	var st nmeaState
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	p := navPVTPayload(3, 0x03, 11, 47.6062, -122.3321, 100, 1.8, -2.54, 51.4444, 275.5)
+	updated, err := st.applyUBX(now, &ubxMsg{Class: ubxClassNAV, ID: ubxNAVPVT, Payload: p})
+	if err != nil || !updated {
+		t.Fatalf("applyUBX updated=%v err=%v", updated, err)
+	}
+	snap := st.snapshot()
+	if !snap.Valid || !snap.UBX {
+		t.Fatalf("expected valid UBX fix, got %+v", snap)
+	}
+	if math.Abs(snap.LatDeg-47.6062) > 1e-6 || math.Abs(snap.LonDeg+122.3321) > 1e-6 {
+		t.Fatalf("position=%v,%v", snap.LatDeg, snap.LonDeg)
+	}
+	if snap.AltFeet == nil || *snap.AltFeet != 328 {
+		t.Fatalf("alt=%v", snap.AltFeet)
+	}
+	if snap.HorizAccM == nil || math.Abs(*snap.HorizAccM-1.8) > 1e-9 {
+		t.Fatalf("hAcc=%v", snap.HorizAccM)
+	}
+	if snap.VertAccM == nil || math.Abs(*snap.VertAccM-4.5) > 1e-9 {
+		t.Fatalf("vAcc=%v", snap.VertAccM)
+	}
+	if snap.GroundKt == nil || *snap.GroundKt != 100 {
+		t.Fatalf("gs=%v", snap.GroundKt)
+	}
+	if snap.TrackDeg == nil || math.Abs(*snap.TrackDeg-275.5) > 1e-6 {
+		t.Fatalf("track=%v", snap.TrackDeg)
+	}
+	if snap.VertSpeedFPM == nil || *snap.VertSpeedFPM != 500 {
+		t.Fatalf("vs=%v", snap.VertSpeedFPM)
+	}
+	if snap.FixMode == nil || *snap.FixMode != 3 || snap.FixQuality == nil || *snap.FixQuality != 2 {
+		t.Fatalf("fix mode=%v quality=%v", snap.FixMode, snap.FixQuality)
+	}
+	if snap.Satellites == nil || *snap.Satellites != 11 {
+		t.Fatalf("sats=%v", snap.Satellites)
+	}
+	if snap.PDOP == nil || math.Abs(*snap.PDOP-1.25) > 1e-9 {
+		t.Fatalf("pdop=%v", snap.PDOP)
+	}
+}
+
+func TestNMEAState_NAVPVTOverridesRMC(t *testing.T) {
+	var st nmeaState
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	p := navPVTPayload(3, 0x01, 9, 47.5, -122.5, 100, 2, 0, 10, 90)
+	if _, err := st.applyUBX(now, &ubxMsg{Class: ubxClassNAV, ID: ubxNAVPVT, Payload: p}); err != nil {
+		t.Fatal(err)
+	}
+	sent, err := parseNMEASentence(nmeaLine("GPRMC,123519,A,4807.038,N,01131.000,E,022.4,084.4,230394,003.1,W"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	st.apply(now.Add(200*time.Millisecond), sent)
+	if snap := st.snapshot(); math.Abs(snap.LatDeg-47.5) > 1e-9 {
+		t.Fatalf("RMC overrode fresh NAV-PVT: lat=%v", snap.LatDeg)
+	}
+	// Once NAV-PVT goes stale, NMEA takes over again.
+	st.apply(now.Add(ubxPreferFor+time.Second), sent)
+	if snap := st.snapshot(); math.Abs(snap.LatDeg-48.1173) > 1e-3 {
+		t.Fatalf("expected RMC position after PVT went stale, lat=%v", snap.LatDeg)
+	}
+}
+
+func TestNMEAState_NAVSATAndNAK(t *testing.T) {
+	var st nmeaState
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	p := make([]byte, 8+12*3)
+	p[5] = 3
+	// SV 0: used; SV 1: tracked only; SV 2: no signal.
+	p[8+2] = 40
+	binary.LittleEndian.PutUint32(p[8+8:], 0x08)
+	p[20+2] = 30
+	if _, err := st.applyUBX(now, &ubxMsg{Class: ubxClassNAV, ID: ubxNAVSAT, Payload: p}); err != nil {
+		t.Fatal(err)
+	}
+	snap := st.snapshot()
+	if snap.Satellites == nil || *snap.Satellites != 1 || snap.SatellitesSeen == nil || *snap.SatellitesSeen != 2 {
+		t.Fatalf("sats=%v seen=%v", snap.Satellites, snap.SatellitesSeen)
+	}
+
+	_, err := st.applyUBX(now, &ubxMsg{Class: ubxClassACK, ID: ubxACKNak, Payload: []byte{ubxClassCFG, ubxCFGGNSS}})
+	if err == nil || err.Error() != "ubx: receiver rejected CFG-GNSS" {
+		t.Fatalf("err=%v", err)
+	}
+}
+
+func TestUBXConfigPackets(t *testing.T) {
+	pkts := append(ubxPortConfig(115200), ubxConfigPackets(10)...)
+	if len(pkts) != 2+9 {
+		t.Fatalf("packets=%d", len(pkts))
+	}
+	var all []byte
+	for _, p := range pkts {
+		all = append(all, p...)
+	}
+	r := bufio.NewReader(bytes.NewReader(all))
+	for i := range pkts {
+		_, msg, err := readReceiverMessage(r)
+		if err != nil || msg == nil || msg.Class != ubxClassCFG {
+			t.Fatalf("packet %d: msg=%+v err=%v", i, msg, err)
+		}
+		switch msg.ID {
+		case ubxCFGPRT:
+			if msg.Payload[0] == 1 && binary.LittleEndian.Uint32(msg.Payload[8:]) != 115200 {
+				t.Fatalf("uart baud=%d", binary.LittleEndian.Uint32(msg.Payload[8:]))
+			}
+		case ubxCFGRATE:
+			if got := binary.LittleEndian.Uint16(msg.Payload); got != 100 {
+				t.Fatalf("measRate=%d", got)
+			}
+		case ubxCFGNAV5:
+			if msg.Payload[2] != 7 {
+				t.Fatalf("dynModel=%d", msg.Payload[2])
+			}
+		}
+	}
+}
@@ -0,0 +1,4 @@
+// Package nmeaout re-publishes the GPS receiver's NMEA sentences to other
+// software (flight loggers, moving maps), which can no longer open the serial
+// port themselves.
+package nmeaout
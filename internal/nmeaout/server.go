@@ -0,0 +1,321 @@
+package nmeaout
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// clientQueue bounds the sentences buffered for a slow TCP client;
+	// beyond it sentences are dropped for that client.
+	clientQueue = 256
+
+	writeTimeout = 2 * time.Second
+
+	// epochWindow groups the sentences a receiver sends for one fix (all
+	// GSV parts, one GSA per constellation) so rate limiting passes or
+	// drops them together.
+	epochWindow = 100 * time.Millisecond
+)
+
+type Config struct {
+	Enable bool
+	// Listen is the TCP address clients connect to.
+	Listen string
+	// UDPDest optionally also sends every sentence to this address, which
+	// may be a broadcast address.
+	UDPDest string
+	// RateHz limits each sentence type to this many fixes per second; 0
+	// forwards everything.
+	RateHz int
+	// Sentences restricts output to these types ("RMC", "GGA", "PUBX");
+	// empty forwards all.
+	Sentences []string
+	// Now defaults to time.Now.
+	Now func() time.Time
+}
+
+type Snapshot struct {
+	Enabled   bool   `json:"enabled"`
+	Listen    string `json:"listen,omitempty"`
+	UDPDest   string `json:"udp_dest,omitempty"`
+	Clients   int    `json:"clients"`
+	Sentences uint64 `json:"sentences"`
+	Dropped   uint64 `json:"dropped"`
+	LastError string `json:"last_error,omitempty"`
+}
+
+type client struct {
+	conn net.Conn
+	out  chan []byte
+}
+
+// Server forwards sentences to TCP clients and an optional UDP destination.
+type Server struct {
+	cfg   Config
+	types map[string]bool
+
+	sentences atomic.Uint64
+	dropped   atomic.Uint64
+
+	mu      sync.Mutex
+	ln      net.Listener
+	udp     net.Conn
+	clients map[*client]struct{}
+	epochAt map[string]time.Time
+	dueAt   map[string]time.Time
+	lastErr string
+
+	wg       sync.WaitGroup
+	stopOnce sync.Once
+	stopCh   chan struct{}
+}
+
+func New(cfg Config) *Server {
+	if cfg.Now == nil {
+		cfg.Now = time.Now
+	}
+	s := &Server{
+		cfg:     cfg,
+		clients: map[*client]struct{}{},
+		epochAt: map[string]time.Time{},
+		dueAt:   map[string]time.Time{},
+		stopCh:  make(chan struct{}),
+	}
+	if len(cfg.Sentences) > 0 {
+		s.types = map[string]bool{}
+		for _, t := range cfg.Sentences {
+			s.types[strings.ToUpper(strings.TrimSpace(t))] = true
+		}
+	}
+	return s
+}
+
+func (s *Server) Start(ctx context.Context) error {
+	if s == nil {
+		return fmt.Errorf("nmeaout: server is nil")
+	}
+	if !s.cfg.Enable {
+		return nil
+	}
+	ln, err := net.Listen("tcp", s.cfg.Listen)
+	if err != nil {
+		err = fmt.Errorf("nmeaout: listen %s: %w", s.cfg.Listen, err)
+		s.setErr(err.Error())
+		return err
+	}
+	var udp net.Conn
+	if s.cfg.UDPDest != "" {
+		udp, err = net.Dial("udp", s.cfg.UDPDest)
+		if err != nil {
+			_ = ln.Close()
+			err = fmt.Errorf("nmeaout: udp %s: %w", s.cfg.UDPDest, err)
+			s.setErr(err.Error())
+			return err
+		}
+	}
+	s.mu.Lock()
+	s.ln = ln
+	s.udp = udp
+	s.mu.Unlock()
+
+	stop := context.AfterFunc(ctx, s.Close)
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		defer stop()
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				if !errors.Is(err, net.ErrClosed) {
+					s.setErr(fmt.Sprintf("nmeaout: accept: %v", err))
+				}
+				return
+			}
+			s.serve(conn)
+		}
+	}()
+	return nil
+}
+
+func (s *Server) serve(conn net.Conn) {
+	c := &client{conn: conn, out: make(chan []byte, clientQueue)}
+	s.mu.Lock()
+	select {
+	case <-s.stopCh:
+		s.mu.Unlock()
+		_ = conn.Close()
+		return
+	default:
+	}
+	s.clients[c] = struct{}{}
+	s.mu.Unlock()
+	log.Printf("nmeaout client connected %s", conn.RemoteAddr())
+
+	// Clients only listen; reading notices them hanging up between
+	// sentences.
+	gone := make(chan struct{})
+	s.wg.Add(2)
+	go func() {
+		defer s.wg.Done()
+		defer close(gone)
+		_, _ = io.Copy(io.Discard, conn)
+	}()
+	go func() {
+		defer s.wg.Done()
+		defer func() {
+			s.mu.Lock()
+			delete(s.clients, c)
+			s.mu.Unlock()
+			_ = conn.Close()
+			log.Printf("nmeaout client disconnected %s", conn.RemoteAddr())
+		}()
+		for {
+			select {
+			case <-s.stopCh:
+				return
+			case <-gone:
+				return
+			case b := <-c.out:
+				_ = conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+				if _, err := conn.Write(b); err != nil {
+					return
+				}
+			}
+		}
+	}()
+}
+
+// Publish forwards one checksum-valid sentence (without line terminator)
+// if it passes the type filter and rate limit.
+func (s *Server) Publish(line string) {
+	if s == nil || !s.cfg.Enable {
+		return
+	}
+	addr := sentenceAddr(line)
+	if addr == "" {
+		return
+	}
+	if s.types != nil && !s.types[sentenceType(addr)] {
+		return
+	}
+	b := []byte(line + "\r\n")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.cfg.RateHz > 0 {
+		now := s.cfg.Now()
+		if now.Sub(s.epochAt[addr]) >= epochWindow {
+			due := s.dueAt[addr]
+			if now.Before(due.Add(-epochWindow)) {
+				return
+			}
+			s.epochAt[addr] = now
+			// Schedule from the due time rather than the arrival, so jitter
+			// (a 1 Hz receiver 999 ms apart) doesn't skip epochs; restart
+			// the schedule after a gap.
+			period := time.Second / time.Duration(s.cfg.RateHz)
+			if now.Sub(due) >= period {
+				due = now
+			}
+			s.dueAt[addr] = due.Add(period)
+		}
+	}
+	s.sentences.Add(1)
+	for c := range s.clients {
+		select {
+		case c.out <- b:
+		default:
+			s.dropped.Add(1)
+		}
+	}
+	if s.udp != nil {
+		if _, err := s.udp.Write(b); err != nil {
+			s.lastErr = fmt.Sprintf("nmeaout: udp: %v", err)
+		}
+	}
+}
+
+// sentenceAddr returns the address field ("GPRMC", "PUBX"), or "" when the
+// line isn't a sentence.
+func sentenceAddr(line string) string {
+	if !strings.HasPrefix(line, "$") {
+		return ""
+	}
+	end := strings.IndexAny(line, ",*")
+	if end < 2 {
+		return ""
+	}
+	return strings.ToUpper(line[1:end])
+}
+
+// sentenceType strips the talker ID: GNRMC and GPRMC are both RMC.
+// Proprietary sentences keep their full address.
+func sentenceType(addr string) string {
+	if addr[0] == 'P' || len(addr) != 5 {
+		return addr
+	}
+	return addr[2:]
+}
+
+func (s *Server) setErr(msg string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lastErr = msg
+}
+
+// Addr returns the bound TCP address once started.
+func (s *Server) Addr() net.Addr {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ln == nil {
+		return nil
+	}
+	return s.ln.Addr()
+}
+
+func (s *Server) Close() {
+	if s == nil {
+		return
+	}
+	s.stopOnce.Do(func() {
+		s.mu.Lock()
+		close(s.stopCh)
+		if s.ln != nil {
+			_ = s.ln.Close()
+		}
+		if s.udp != nil {
+			_ = s.udp.Close()
+		}
+		for c := range s.clients {
+			_ = c.conn.Close()
+		}
+		s.mu.Unlock()
+	})
+	s.wg.Wait()
+}
+
+func (s *Server) Snapshot() Snapshot {
+	if s == nil {
+		return Snapshot{}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return Snapshot{
+		Enabled:   s.cfg.Enable,
+		Listen:    s.cfg.Listen,
+		UDPDest:   s.cfg.UDPDest,
+		Clients:   len(s.clients),
+		Sentences: s.sentences.Load(),
+		Dropped:   s.dropped.Load(),
+		LastError: s.lastErr,
+	}
+}
@@ -0,0 +1,114 @@
+package nmeaout
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"testing"
+	"time"
+)
+
+const (
+	rmc = "$GPRMC,123519,A,4807.038,N,01131.000,E,022.4,084.4,230394,003.1,W*6A"
+	gga = "$GNGGA,123519,4807.038,N,01131.000,E,1,08,0.9,545.4,M,46.9,M,,*59"
+	gsv = "$GPGSV,2,1,08,01,40,083,46,02,17,308,41,12,07,344,39,14,22,228,45*75"
+	pub = "$PUBX,00,123519.00,4807.03800,N,01131.00000,E*00"
+)
+
+func waitClients(t *testing.T, s *Server, n int) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for s.Snapshot().Clients != n {
+		if time.Now().After(deadline) {
+			t.Fatalf("clients=%d want %d", s.Snapshot().Clients, n)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestServer_TCPAndUDPWithFilter(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("ListenPacket: %v", err)
+	}
+	defer pc.Close()
+
+	s := New(Config{Enable: true, Listen: "127.0.0.1:0", UDPDest: pc.LocalAddr().String(), Sentences: []string{"rmc", "PUBX"}})
+	if err := s.Start(context.Background()); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	defer s.Close()
+
+	conn, err := net.Dial("tcp", s.Addr().String())
+	if err != nil {
+		t.Fatalf("Dial: %v", err)
+	}
+	defer conn.Close()
+	waitClients(t, s, 1)
+
+	for _, l := range []string{rmc, gga, gsv, pub, "garbage"} {
+		s.Publish(l)
+	}
+
+	_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	r := bufio.NewReader(conn)
+	for _, want := range []string{rmc, pub} {
+		got, err := r.ReadString('\n')
+		if err != nil || got != want+"\r\n" {
+			t.Fatalf("tcp got %q err=%v want %q", got, err, want)
+		}
+	}
+
+	buf := make([]byte, 512)
+	_ = pc.SetReadDeadline(time.Now().Add(2 * time.Second))
+	n, _, err := pc.ReadFrom(buf)
+	if err != nil || string(buf[:n]) != rmc+"\r\n" {
+		t.Fatalf("udp got %q err=%v", buf[:n], err)
+	}
+
+	if snap := s.Snapshot(); snap.Sentences != 2 || snap.Dropped != 0 {
+		t.Fatalf("snapshot=%+v", snap)
+	}
+
+	conn.Close()
+	waitClients(t, s, 0)
+}
+
+func TestServer_RateLimitKeepsEpochsTogether(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	s := New(Config{Enable: true, RateHz: 1, Now: func() time.Time { return now }})
+
+	// 5 Hz receiver: only the first epoch of each second passes, with all
+	// of its GSV parts.
+	for i := 0; i < 10; i++ {
+		s.Publish(rmc)
+		s.Publish(gsv)
+		now = now.Add(20 * time.Millisecond)
+		s.Publish(gsv)
+		now = now.Add(180 * time.Millisecond)
+	}
+	if got := s.Snapshot().Sentences; got != 6 {
+		t.Fatalf("sentences=%d want 6", got)
+	}
+
+	// 1 Hz receiver at the configured rate: arrival jitter around the
+	// second must not drop epochs.
+	s = New(Config{Enable: true, RateHz: 1, Now: func() time.Time { return now }})
+	for i, step := range []time.Duration{999, 1001, 998, 1000, 995, 1003} {
+		s.Publish(rmc)
+		if got := s.Snapshot().Sentences; got != uint64(i+1) {
+			t.Fatalf("epoch %d dropped: sentences=%d", i, got)
+		}
+		now = now.Add(step * time.Millisecond)
+	}
+
+	// 5 Hz receiver limited to 2 Hz averages 2 epochs per second.
+	s = New(Config{Enable: true, RateHz: 2, Now: func() time.Time { return now }})
+	for i := 0; i < 25; i++ {
+		s.Publish(rmc)
+		now = now.Add(200 * time.Millisecond)
+	}
+	if got := s.Snapshot().Sentences; got != 10 {
+		t.Fatalf("sentences=%d want 10", got)
+	}
+}
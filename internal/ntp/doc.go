@@ -0,0 +1,3 @@
+// Package ntp serves the (GPS-disciplined) system time to EFBs on the AP
+// network over SNTP, since they usually have no other time source in flight.
+package ntp
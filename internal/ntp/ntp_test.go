@@ -0,0 +1,123 @@
+package ntp
+
+import (
+	"context"
+	"encoding/binary"
+	"net"
+	"testing"
+	"time"
+)
+
+func clientRequest(version byte, xmit uint64) []byte {
+	req := make([]byte, packetLen)
+	req[0] = version<<3 | modeClient
+	req[2] = 6
+	binary.BigEndian.PutUint64(req[40:], xmit)
+	return req
+}
+
+func TestResponse_Unsynchronized(t *testing.T) {
+	recv := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	resp, ok := response(clientRequest(4, 0x1234), recv, Status{})
+	if !ok {
+		t.Fatalf("expected response")
+	}
+	if leap, vn, mode := resp[0]>>6, resp[0]>>3&7, resp[0]&7; leap != leapUnsync || vn != 4 || mode != modeServer {
+		t.Fatalf("li=%d vn=%d mode=%d", leap, vn, mode)
+	}
+	if resp[1] != stratumNone {
+		t.Fatalf("stratum=%d", resp[1])
+	}
+	if got := binary.BigEndian.Uint64(resp[24:]); got != 0x1234 {
+		t.Fatalf("origin=%x", got)
+	}
+}
+
+func TestResponse_Synced(t *testing.T) {
+	ref := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	recv := ref.Add(1000 * time.Second)
+	st := Status{Synced: true, RefID: "GPS", RefTime: ref, Error: 100 * time.Millisecond}
+	resp, ok := response(clientRequest(3, 0), recv, st)
+	if !ok {
+		t.Fatalf("expected response")
+	}
+	if resp[0]>>6 != leapNone || resp[0]>>3&7 != 3 || resp[1] != stratumGPS || int8(resp[3]) != precision || resp[2] != 6 {
+		t.Fatalf("header=% x", resp[:4])
+	}
+	if string(resp[12:16]) != "GPS\x00" {
+		t.Fatalf("refid=%q", resp[12:16])
+	}
+	// 100ms + 1000s * 15ppm = 115ms.
+	disp := float64(binary.BigEndian.Uint32(resp[8:])) / 65536
+	if disp < 0.1149 || disp > 0.1151 {
+		t.Fatalf("root dispersion=%v", disp)
+	}
+	if sec := binary.BigEndian.Uint32(resp[32:]); int64(sec) != recv.Unix()+ntpEpochOffset {
+		t.Fatalf("receive seconds=%d", sec)
+	}
+	if sec := binary.BigEndian.Uint32(resp[16:]); int64(sec) != ref.Unix()+ntpEpochOffset {
+		t.Fatalf("reference seconds=%d", sec)
+	}
+}
+
+func TestResponse_IgnoresNonClientPackets(t *testing.T) {
+	req := clientRequest(4, 0)
+	req[0] = 4<<3 | modeServer
+	if _, ok := response(req, time.Now(), Status{}); ok {
+		t.Fatalf("answered a server packet")
+	}
+	if _, ok := response(make([]byte, 12), time.Now(), Status{}); ok {
+		t.Fatalf("answered a short packet")
+	}
+}
+
+func TestServer_RoundTrip(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 500e6, time.UTC)
+	srv := New(Config{
+		Enable: true,
+		Listen: "127.0.0.1:0",
+		Status: func() Status { return Status{Synced: true, RefID: "PPS", RefTime: now} },
+		Now:    func() time.Time { return now },
+	})
+	if err := srv.Start(context.Background()); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	defer srv.Close()
+
+	var addr net.Addr
+	for deadline := time.Now().Add(2 * time.Second); addr == nil && time.Now().Before(deadline); {
+		if addr = srv.Addr(); addr == nil {
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+	if addr == nil {
+		t.Fatalf("server did not bind")
+	}
+	conn, err := net.Dial("udp", addr.String())
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(2 * time.Second))
+	if _, err := conn.Write(clientRequest(4, 42)); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	resp := make([]byte, 64)
+	n, err := conn.Read(resp)
+	if err != nil || n != packetLen {
+		t.Fatalf("read n=%d err=%v", n, err)
+	}
+	if resp[1] != stratumGPS || string(resp[12:15]) != "PPS" {
+		t.Fatalf("stratum=%d refid=%q", resp[1], resp[12:16])
+	}
+	xmit := binary.BigEndian.Uint64(resp[40:])
+	if sec := int64(xmit >> 32); sec != now.Unix()+ntpEpochOffset {
+		t.Fatalf("transmit seconds=%d", sec)
+	}
+	if frac := xmit & 0xFFFFFFFF; frac != 1<<31 {
+		t.Fatalf("transmit fraction=%x", frac)
+	}
+	if snap := srv.Snapshot(); !snap.Listening || !snap.Synced || snap.Requests != 1 {
+		t.Fatalf("snapshot=%+v", snap)
+	}
+}
@@ -0,0 +1,99 @@
+package ntp
+
+import (
+	"encoding/binary"
+	"time"
+)
+
+const (
+	packetLen = 48
+
+	// ntpEpochOffset is the number of seconds from 1900 (NTP era 0) to 1970.
+	ntpEpochOffset = 2208988800
+
+	modeClient = 3
+	modeServer = 4
+
+	leapNone    = 0
+	leapUnsync  = 3
+	stratumGPS  = 1
+	stratumNone = 16
+
+	// precision is log2 of the clock read resolution (about 1us).
+	precision = -20
+
+	// driftPPM is the assumed free-running clock frequency error used to
+	// grow the dispersion since the last sync (RFC 5905 PHI).
+	driftPPM = 15
+)
+
+// Status is the server's view of its own clock.
+type Status struct {
+	// Synced is set once the clock has been set from a reference.
+	Synced bool
+	// RefID names the reference ("GPS", "PPS"); at most four characters.
+	RefID string
+	// RefTime is when the clock was last set or checked against the
+	// reference.
+	RefTime time.Time
+	// Error is the clock error right after RefTime.
+	Error time.Duration
+}
+
+func putTimestamp(b []byte, t time.Time) {
+	if t.IsZero() {
+		binary.BigEndian.PutUint64(b, 0)
+		return
+	}
+	sec := uint64(t.Unix() + ntpEpochOffset)
+	frac := uint64(t.Nanosecond()) << 32 / uint64(time.Second)
+	binary.BigEndian.PutUint64(b, sec<<32|frac)
+}
+
+// shortFormat encodes a duration as NTP 16.16 seconds, saturating.
+func shortFormat(d time.Duration) uint32 {
+	if d < 0 {
+		d = 0
+	}
+	v := uint64(d) << 16 / uint64(time.Second)
+	if v > 0xFFFFFFFF {
+		return 0xFFFFFFFF
+	}
+	return uint32(v)
+}
+
+// response builds the reply to a client request received at recv; the
+// transmit timestamp is filled in by the caller right before sending. It
+// returns false for packets that are not client requests.
+func response(req []byte, recv time.Time, st Status) ([]byte, bool) {
+	if len(req) < packetLen || req[0]&0x07 != modeClient {
+		return nil, false
+	}
+	version := req[0] >> 3 & 0x07
+	if version < 1 || version > 4 {
+		return nil, false
+	}
+
+	resp := make([]byte, packetLen)
+	leap, stratum := byte(leapUnsync), byte(stratumNone)
+	if st.Synced {
+		leap, stratum = leapNone, stratumGPS
+	}
+	resp[0] = leap<<6 | version<<3 | modeServer
+	resp[1] = stratum
+	resp[2] = req[2] // poll
+	prec := int8(precision)
+	resp[3] = byte(prec)
+	// Root delay (4:8) is zero for a reference clock.
+	if st.Synced {
+		disp := st.Error + recv.Sub(st.RefTime)*driftPPM/1e6
+		binary.BigEndian.PutUint32(resp[8:], shortFormat(disp))
+		copy(resp[12:16], st.RefID)
+		putTimestamp(resp[16:], st.RefTime)
+	} else {
+		binary.BigEndian.PutUint32(resp[8:], shortFormat(16*time.Second))
+	}
+	copy(resp[24:32], req[40:48]) // origin = client transmit
+	putTimestamp(resp[32:], recv)
+	return resp, true
+}
@@ -0,0 +1,179 @@
+package ntp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// rebindDelay paces retries while the listen address is unavailable (the AP
+// interface comes up after boot).
+const rebindDelay = 5 * time.Second
+
+type Config struct {
+	Enable bool
+	// Listen is the UDP address to serve on, e.g. 192.168.10.1:123.
+	Listen string
+	// Status reports the state of the local clock.
+	Status func() Status
+	// Now defaults to time.Now.
+	Now func() time.Time
+}
+
+type Snapshot struct {
+	Enabled   bool   `json:"enabled"`
+	Listen    string `json:"listen,omitempty"`
+	Listening bool   `json:"listening"`
+	Synced    bool   `json:"synced"`
+	Requests  uint64 `json:"requests"`
+	LastError string `json:"last_error,omitempty"`
+}
+
+// Server is a minimal SNTP (RFC 4330) server.
+type Server struct {
+	cfg Config
+
+	requests atomic.Uint64
+
+	mu        sync.Mutex
+	conn      net.PacketConn
+	listening bool
+	lastErr   string
+
+	wg       sync.WaitGroup
+	stopOnce sync.Once
+	stopCh   chan struct{}
+}
+
+func New(cfg Config) *Server {
+	if cfg.Now == nil {
+		cfg.Now = time.Now
+	}
+	if cfg.Status == nil {
+		cfg.Status = func() Status { return Status{} }
+	}
+	return &Server{cfg: cfg, stopCh: make(chan struct{})}
+}
+
+func (s *Server) Start(ctx context.Context) error {
+	if s == nil {
+		return fmt.Errorf("ntp: server is nil")
+	}
+	if !s.cfg.Enable {
+		return nil
+	}
+	if _, err := net.ResolveUDPAddr("udp", s.cfg.Listen); err != nil {
+		return fmt.Errorf("ntp: listen %q: %w", s.cfg.Listen, err)
+	}
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		for {
+			conn, err := net.ListenPacket("udp", s.cfg.Listen)
+			if err == nil {
+				s.serve(ctx, conn)
+			} else {
+				s.setErr(fmt.Sprintf("ntp: listen %s: %v", s.cfg.Listen, err))
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-s.stopCh:
+				return
+			case <-time.After(rebindDelay):
+			}
+		}
+	}()
+	return nil
+}
+
+func (s *Server) serve(ctx context.Context, conn net.PacketConn) {
+	s.mu.Lock()
+	s.conn = conn
+	s.listening = true
+	s.lastErr = ""
+	s.mu.Unlock()
+	stop := context.AfterFunc(ctx, func() { _ = conn.Close() })
+	defer func() {
+		stop()
+		_ = conn.Close()
+		s.mu.Lock()
+		s.conn = nil
+		s.listening = false
+		s.mu.Unlock()
+	}()
+
+	buf := make([]byte, 512)
+	for {
+		n, addr, err := conn.ReadFrom(buf)
+		if err != nil {
+			if !errors.Is(err, net.ErrClosed) {
+				s.setErr(fmt.Sprintf("ntp: read: %v", err))
+			}
+			return
+		}
+		recv := s.cfg.Now()
+		resp, ok := response(buf[:n], recv, s.cfg.Status())
+		if !ok {
+			continue
+		}
+		putTimestamp(resp[40:], s.cfg.Now())
+		if _, err := conn.WriteTo(resp, addr); err != nil {
+			s.setErr(fmt.Sprintf("ntp: write: %v", err))
+			continue
+		}
+		s.requests.Add(1)
+	}
+}
+
+func (s *Server) setErr(msg string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lastErr = msg
+}
+
+// Addr returns the bound address while listening.
+func (s *Server) Addr() net.Addr {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conn == nil {
+		return nil
+	}
+	return s.conn.LocalAddr()
+}
+
+func (s *Server) Close() {
+	if s == nil {
+		return
+	}
+	s.stopOnce.Do(func() {
+		close(s.stopCh)
+		s.mu.Lock()
+		if s.conn != nil {
+			_ = s.conn.Close()
+		}
+		s.mu.Unlock()
+	})
+	s.wg.Wait()
+}
+
+func (s *Server) Snapshot() Snapshot {
+	if s == nil {
+		return Snapshot{}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return Snapshot{
+		Enabled:   s.cfg.Enable,
+		Listen:    s.cfg.Listen,
+		Listening: s.listening,
+		Synced:    s.cfg.Status().Synced,
+		Requests:  s.requests.Load(),
+		LastError: s.lastErr,
+	}
+}
@@ -0,0 +1,8 @@
+// Package serialport opens serial devices (GPS receivers, FLARM units) in raw
+// mode for line-oriented protocols.
+package serialport
+
+import "errors"
+
+// ErrBusy is returned by Open when another user holds the device.
+var ErrBusy = errors.New("serial device in use")
@@ -1,17 +1,28 @@
 //go:build linux
 
-package gps
+package serialport
 
 import (
+	"errors"
 	"fmt"
 	"os"
 
 	"golang.org/x/sys/unix"
 )
 
-func openSerial(path string, baud int) (*os.File, error) {
-	flag := unix.O_RDWR | unix.O_NOCTTY
+// Open opens a serial device in raw 8N1 mode at the given baud rate. The
+// descriptor is non-blocking so the file goes through the runtime poller:
+// read deadlines work and Close interrupts a blocked Read.
+//
+// The device is held exclusively until closed. A device already held, by
+// this process or another that locks it or sets TIOCEXCL, is refused with
+// ErrBusy before its line settings are touched.
+func Open(path string, baud int) (*os.File, error) {
+	flag := unix.O_RDWR | unix.O_NOCTTY | unix.O_NONBLOCK
 	fd, err := unix.Open(path, flag, 0)
+	if errors.Is(err, unix.EBUSY) {
+		return nil, fmt.Errorf("%s: %w", path, ErrBusy)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -24,6 +35,18 @@ func openSerial(path string, baud int) (*os.File, error) {
 		}
 	}()
 
+	// TIOCEXCL does not stop root, which Stratux usually runs as; the
+	// advisory lock covers that case between cooperating users.
+	if err := unix.Flock(fd, unix.LOCK_EX|unix.LOCK_NB); err != nil {
+		if errors.Is(err, unix.EWOULDBLOCK) {
+			return nil, fmt.Errorf("%s: %w", path, ErrBusy)
+		}
+		return nil, err
+	}
+	if err := unix.IoctlSetInt(fd, unix.TIOCEXCL, 0); err != nil {
+		return nil, err
+	}
+
 	t, err := unix.IoctlGetTermios(fd, unix.TCGETS)
 	if err != nil {
 		return nil, err
@@ -34,16 +57,17 @@ func openSerial(path string, baud int) (*os.File, error) {
 		return nil, err
 	}
 
-	// Raw-ish mode (minimal line processing) for NMEA.
+	// Raw-ish mode (minimal line processing) for NMEA and similar line protocols.
 	t.Iflag &^= unix.IGNBRK | unix.BRKINT | unix.PARMRK | unix.ISTRIP | unix.INLCR | unix.IGNCR | unix.ICRNL | unix.IXON
 	t.Oflag &^= unix.OPOST
 	t.Lflag &^= unix.ECHO | unix.ECHONL | unix.ICANON | unix.ISIG | unix.IEXTEN
 	t.Cflag &^= unix.CSIZE | unix.PARENB
 	t.Cflag |= unix.CS8
 
-	// 1 second read timeout, return as soon as at least 1 byte is available.
+	// Return as soon as at least 1 byte is available; waiting is left to
+	// the poller.
 	t.Cc[unix.VMIN] = 1
-	t.Cc[unix.VTIME] = 10
+	t.Cc[unix.VTIME] = 0
 
 	// Set baud.
 	t.Cflag &^= unix.CBAUD
@@ -0,0 +1,96 @@
+//go:build linux
+
+package serialport
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// TestOpen_CloseInterruptsSilentRead covers a FLARM or GPS device that is
+// attached but never sends: shutdown must not hang in Read.
+func TestOpen_CloseInterruptsSilentRead(t *testing.T) {
+	fd, err := unix.Open("/dev/ptmx", unix.O_RDWR|unix.O_NOCTTY|unix.O_CLOEXEC, 0)
+	if err != nil {
+		t.Skipf("no pseudo-terminals: %v", err)
+	}
+	master := os.NewFile(uintptr(fd), "/dev/ptmx")
+	defer master.Close()
+	if err := unix.IoctlSetPointerInt(fd, unix.TIOCSPTLCK, 0); err != nil {
+		t.Fatalf("unlockpt: %v", err)
+	}
+	n, err := unix.IoctlGetUint32(fd, unix.TIOCGPTN)
+	if err != nil {
+		t.Fatalf("ptsname: %v", err)
+	}
+
+	f, err := Open(fmt.Sprintf("/dev/pts/%d", n), 9600)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	readErr := make(chan error, 1)
+	go func() {
+		_, err := f.Read(make([]byte, 64))
+		readErr <- err
+	}()
+	time.Sleep(50 * time.Millisecond)
+	_ = f.Close()
+
+	select {
+	case err := <-readErr:
+		if !errors.Is(err, os.ErrClosed) {
+			t.Fatalf("read err=%v want os.ErrClosed", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("Close did not interrupt Read")
+	}
+}
+
+// TestOpen_RefusesHeldDevice covers a GPS probe reaching a port the FLARM
+// reader already has open.
+func TestOpen_RefusesHeldDevice(t *testing.T) {
+	fd, err := unix.Open("/dev/ptmx", unix.O_RDWR|unix.O_NOCTTY|unix.O_CLOEXEC, 0)
+	if err != nil {
+		t.Skipf("no pseudo-terminals: %v", err)
+	}
+	master := os.NewFile(uintptr(fd), "/dev/ptmx")
+	defer master.Close()
+	if err := unix.IoctlSetPointerInt(fd, unix.TIOCSPTLCK, 0); err != nil {
+		t.Fatalf("unlockpt: %v", err)
+	}
+	n, err := unix.IoctlGetUint32(fd, unix.TIOCGPTN)
+	if err != nil {
+		t.Fatalf("ptsname: %v", err)
+	}
+	path := fmt.Sprintf("/dev/pts/%d", n)
+
+	f, err := Open(path, 9600)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	if _, err := Open(path, 38400); !errors.Is(err, ErrBusy) {
+		t.Fatalf("second Open err=%v want ErrBusy", err)
+	}
+	t.Run("keeps line settings", func(t *testing.T) {
+		tio, err := unix.IoctlGetTermios(int(f.Fd()), unix.TCGETS)
+		if err != nil {
+			t.Fatalf("tcgets: %v", err)
+		}
+		if tio.Cflag&unix.CBAUD != unix.B9600 {
+			t.Fatalf("baud changed by refused Open: cflag=%#o", tio.Cflag)
+		}
+	})
+
+	// Released on close.
+	_ = f.Close()
+	g, err := Open(path, 38400)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	_ = g.Close()
+}
@@ -0,0 +1,13 @@
+//go:build !linux
+
+package serialport
+
+import (
+	"fmt"
+	"os"
+)
+
+// Open is only implemented on Linux.
+func Open(path string, baud int) (*os.File, error) {
+	return nil, fmt.Errorf("serial ports are not supported on this platform")
+}
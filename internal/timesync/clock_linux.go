@@ -0,0 +1,53 @@
+//go:build linux
+
+package timesync
+
+import (
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+type systemClock struct{}
+
+// SystemClock adjusts CLOCK_REALTIME via adjtimex(2); it needs CAP_SYS_TIME.
+func SystemClock() Clock { return systemClock{} }
+
+func (systemClock) Now() time.Time { return time.Now() }
+
+func (systemClock) Step(offset time.Duration) error {
+	// ADJ_SETOFFSET adds the offset atomically; the nanosecond part must be
+	// non-negative.
+	sec, nsec := int64(offset/time.Second), int64(offset%time.Second)
+	if nsec < 0 {
+		sec--
+		nsec += int64(time.Second)
+	}
+	tx := unix.Timex{Modes: unix.ADJ_SETOFFSET | unix.ADJ_NANO}
+	setInt(&tx.Time.Sec, sec)
+	setInt(&tx.Time.Usec, nsec)
+	_, err := unix.Adjtimex(&tx)
+	return err
+}
+
+func (systemClock) Slew(offset time.Duration) error {
+	tx := unix.Timex{Modes: unix.ADJ_OFFSET_SINGLESHOT}
+	setInt(&tx.Offset, offset.Microseconds())
+	_, err := unix.Adjtimex(&tx)
+	return err
+}
+
+func (systemClock) ExternallySynced() bool {
+	var tx unix.Timex
+	if _, err := unix.Adjtimex(&tx); err != nil {
+		return false
+	}
+	// NTP daemons clear STA_UNSYNC once they discipline the clock; steps
+	// and single-shot slews leave it set.
+	return tx.Status&unix.STA_UNSYNC == 0
+}
+
+// setInt assigns to Timex fields, which are 32-bit on 32-bit platforms.
+func setInt[T ~int32 | ~int64](dst *T, v int64) {
+	*dst = T(v)
+}
@@ -0,0 +1,25 @@
+//go:build !linux
+
+package timesync
+
+import (
+	"fmt"
+	"time"
+)
+
+type systemClock struct{}
+
+// SystemClock can only read the clock on this platform.
+func SystemClock() Clock { return systemClock{} }
+
+func (systemClock) Now() time.Time { return time.Now() }
+
+func (systemClock) Step(time.Duration) error {
+	return fmt.Errorf("setting the clock is not supported on this platform")
+}
+
+func (systemClock) Slew(time.Duration) error {
+	return fmt.Errorf("setting the clock is not supported on this platform")
+}
+
+func (systemClock) ExternallySynced() bool { return false }
@@ -0,0 +1,4 @@
+// Package timesync sets the system clock from GPS (and optionally PPS) time.
+// Raspberry Pis have no RTC and the cockpit has no network, so without it the
+// clock is wrong until something else fixes it.
+package timesync
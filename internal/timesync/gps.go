@@ -0,0 +1,33 @@
+package timesync
+
+import (
+	"time"
+
+	"stratux-ng/internal/gps"
+)
+
+type gpsSource struct {
+	snapshot func() (gps.Snapshot, bool)
+}
+
+// GPSSource uses the receiver time (RMC/ZDA, gpsd TPV or UBX NAV-PVT) while
+// the receiver has a valid fix. Serial latency makes it good to a few hundred
+// milliseconds.
+func GPSSource(snapshot func() (gps.Snapshot, bool)) Source {
+	return gpsSource{snapshot: snapshot}
+}
+
+func (gpsSource) Name() string { return "gps" }
+
+func (g gpsSource) Sample() (Sample, bool) {
+	snap, ok := g.snapshot()
+	// A static position's time is the local clock itself.
+	if !ok || !snap.Valid || snap.Static || snap.TimeUTC == "" || snap.TimeAt.IsZero() {
+		return Sample{}, false
+	}
+	ref, err := time.Parse(time.RFC3339Nano, snap.TimeUTC)
+	if err != nil {
+		return Sample{}, false
+	}
+	return Sample{Ref: ref, At: snap.TimeAt}, true
+}
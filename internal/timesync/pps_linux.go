@@ -0,0 +1,87 @@
+//go:build linux
+
+package timesync
+
+import (
+	"os"
+	"sync"
+	"time"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// ppsFetch is PPS_FETCH: _IOWR('p', 0xa4, struct pps_fdata *). The size is
+// that of the pointer, as declared in linux/pps.h.
+const ppsFetch = 3<<30 | uintptr(unsafe.Sizeof(uintptr(0)))<<16 | 'p'<<8 | 0xa4
+
+type ppsKTime struct {
+	Sec   int64
+	Nsec  int32
+	Flags uint32
+}
+
+// ppsFData mirrors struct pps_fdata.
+type ppsFData struct {
+	AssertSeq uint32
+	ClearSeq  uint32
+	Assert    ppsKTime
+	Clear     ppsKTime
+	Mode      int32
+	_         int32
+	Timeout   ppsKTime
+}
+
+type ppsSource struct {
+	device string
+	coarse Source
+
+	mu sync.Mutex
+	f  *os.File
+}
+
+// PPSSource uses a kernel PPS device (e.g. /dev/pps0 from pps-gpio). The
+// pulse marks the start of a second; coarse (GPS time) says which one, so it
+// must be right to within half a second.
+func PPSSource(device string, coarse Source) Source {
+	return &ppsSource{device: device, coarse: coarse}
+}
+
+func (p *ppsSource) Name() string { return "pps" }
+
+func (p *ppsSource) Sample() (Sample, bool) {
+	assert, ok := p.fetch()
+	if !ok {
+		return Sample{}, false
+	}
+	c, ok := p.coarse.Sample()
+	if !ok {
+		return Sample{}, false
+	}
+	est := assert.Add(c.Ref.Sub(c.At))
+	return Sample{Ref: est.Round(time.Second), At: assert, Precise: true}, true
+}
+
+// fetch returns the system time of the last pulse.
+func (p *ppsSource) fetch() (time.Time, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.f == nil {
+		f, err := os.Open(p.device)
+		if err != nil {
+			return time.Time{}, false
+		}
+		p.f = f
+	}
+	// A zero timeout returns the current data without waiting.
+	var d ppsFData
+	if _, _, errno := unix.Syscall(unix.SYS_IOCTL, p.f.Fd(), ppsFetch, uintptr(unsafe.Pointer(&d))); errno != 0 {
+		_ = p.f.Close()
+		p.f = nil
+		return time.Time{}, false
+	}
+	if d.AssertSeq == 0 {
+		return time.Time{}, false
+	}
+	return time.Unix(d.Assert.Sec, int64(d.Assert.Nsec)), true
+}
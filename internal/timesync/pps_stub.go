@@ -0,0 +1,14 @@
+//go:build !linux
+
+package timesync
+
+// PPSSource is only implemented on Linux; elsewhere it never has a sample.
+func PPSSource(device string, coarse Source) Source {
+	return ppsSource{}
+}
+
+type ppsSource struct{}
+
+func (ppsSource) Name() string { return "pps" }
+
+func (ppsSource) Sample() (Sample, bool) { return Sample{}, false }
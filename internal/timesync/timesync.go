@@ -0,0 +1,228 @@
+package timesync
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	defaultStepThreshold = time.Second
+	defaultInterval      = time.Second
+
+	// sampleMaxAge discards references that stopped updating (GPS lost,
+	// no PPS pulses) so the next source is used instead.
+	sampleMaxAge = 3 * time.Second
+
+	// slewMin is the offset below which a precise source is left alone.
+	slewMin = time.Millisecond
+	// slewMax bounds one slew; the kernel's single-shot adjustment is
+	// limited to about half a second.
+	slewMax = 500 * time.Millisecond
+)
+
+// minValidTime rejects references from receivers with a bad almanac or a
+// GPS week rollover bug, which report dates decades in the past.
+var minValidTime = time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// Sample is one reading from a reference: Ref was the true UTC time when the
+// system clock read At.
+type Sample struct {
+	Ref time.Time
+	At  time.Time
+	// Precise marks sub-millisecond references (PPS) that are worth
+	// slewing towards; others only correct large errors.
+	Precise bool
+}
+
+// Source is a time reference.
+type Source interface {
+	Name() string
+	// Sample returns the latest reading, or false when the source has none.
+	Sample() (Sample, bool)
+}
+
+// Clock is the system clock being disciplined.
+type Clock interface {
+	Now() time.Time
+	// Step moves the clock by offset at once.
+	Step(offset time.Duration) error
+	// Slew gradually moves the clock by offset.
+	Slew(offset time.Duration) error
+	// ExternallySynced reports that another time daemon (NTP with a
+	// network) is disciplining the clock.
+	ExternallySynced() bool
+}
+
+type Config struct {
+	Enable bool
+
+	// StepThreshold is the offset at which the clock is stepped.
+	StepThreshold time.Duration
+	// Interval is how often the reference is checked.
+	Interval time.Duration
+
+	// Sources are tried in order; the first with a fresh sample is used.
+	Sources []Source
+	// Clock defaults to the system clock.
+	Clock Clock
+}
+
+type Snapshot struct {
+	Enabled bool `json:"enabled"`
+	// Source is the reference last used ("pps", "gps").
+	Source string `json:"source,omitempty"`
+	// Synced reports that the clock was found within the step threshold
+	// of the reference, or stepped to it.
+	Synced bool `json:"synced"`
+	// ExternalSync reports that another time daemon owns the clock.
+	ExternalSync bool    `json:"external_sync,omitempty"`
+	OffsetMs     float64 `json:"offset_ms"`
+
+	// LastSyncAt is when the clock last agreed with (or was stepped to)
+	// the reference.
+	LastSyncAt time.Time `json:"last_sync_utc,omitempty"`
+
+	Steps       int       `json:"steps"`
+	LastStepMs  float64   `json:"last_step_ms,omitempty"`
+	LastStepAt  time.Time `json:"last_step_utc,omitempty"`
+	LastCheckAt time.Time `json:"last_check_utc,omitempty"`
+	LastError   string    `json:"last_error,omitempty"`
+}
+
+type Service struct {
+	cfg Config
+
+	mu      sync.RWMutex
+	snap    Snapshot
+	lastRef time.Time
+
+	wg       sync.WaitGroup
+	stopOnce sync.Once
+	stopCh   chan struct{}
+}
+
+func New(cfg Config) *Service {
+	if cfg.StepThreshold <= 0 {
+		cfg.StepThreshold = defaultStepThreshold
+	}
+	if cfg.Interval <= 0 {
+		cfg.Interval = defaultInterval
+	}
+	if cfg.Clock == nil {
+		cfg.Clock = SystemClock()
+	}
+	return &Service{cfg: cfg, stopCh: make(chan struct{})}
+}
+
+func (s *Service) Start(ctx context.Context) error {
+	if s == nil {
+		return fmt.Errorf("timesync: service is nil")
+	}
+	if !s.cfg.Enable {
+		return nil
+	}
+	if len(s.cfg.Sources) == 0 {
+		return fmt.Errorf("timesync: no time sources")
+	}
+	s.mu.Lock()
+	s.snap.Enabled = true
+	s.mu.Unlock()
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		t := time.NewTicker(s.cfg.Interval)
+		defer t.Stop()
+		for {
+			s.check()
+			select {
+			case <-ctx.Done():
+				return
+			case <-s.stopCh:
+				return
+			case <-t.C:
+			}
+		}
+	}()
+	return nil
+}
+
+func (s *Service) Close() {
+	if s == nil {
+		return
+	}
+	s.stopOnce.Do(func() {
+		close(s.stopCh)
+	})
+	s.wg.Wait()
+}
+
+func (s *Service) Snapshot() Snapshot {
+	if s == nil {
+		return Snapshot{}
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.snap
+}
+
+// check compares the clock with the first fresh reference and steps or slews
+// it as needed.
+func (s *Service) check() {
+	clock := s.cfg.Clock
+	now := clock.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.snap.LastCheckAt = now.UTC()
+	s.snap.ExternalSync = clock.ExternallySynced()
+	if s.snap.ExternalSync {
+		return
+	}
+
+	for _, src := range s.cfg.Sources {
+		smp, ok := src.Sample()
+		if !ok {
+			continue
+		}
+		if age := now.Sub(smp.At); age < 0 || age > sampleMaxAge {
+			continue
+		}
+		if smp.Ref.Before(minValidTime) {
+			s.snap.LastError = fmt.Sprintf("timesync: %s reference %s is implausible", src.Name(), smp.Ref.UTC().Format(time.RFC3339))
+			continue
+		}
+		if !smp.Ref.After(s.lastRef) {
+			// Already used; also keeps readings taken before a step from
+			// being applied again.
+			return
+		}
+		s.lastRef = smp.Ref
+
+		offset := smp.Ref.Sub(smp.At)
+		s.snap.Source = src.Name()
+		s.snap.OffsetMs = float64(offset) / float64(time.Millisecond)
+		s.snap.LastError = ""
+
+		switch {
+		case offset >= s.cfg.StepThreshold || offset <= -s.cfg.StepThreshold:
+			if err := clock.Step(offset); err != nil {
+				s.snap.Synced = false
+				s.snap.LastError = fmt.Sprintf("timesync: step %v: %v", offset, err)
+				return
+			}
+			s.snap.Steps++
+			s.snap.LastStepMs = s.snap.OffsetMs
+			s.snap.LastStepAt = clock.Now().UTC()
+		case smp.Precise && (offset > slewMin || offset < -slewMin):
+			if err := clock.Slew(max(min(offset, slewMax), -slewMax)); err != nil {
+				s.snap.LastError = fmt.Sprintf("timesync: slew %v: %v", offset, err)
+			}
+		}
+		s.snap.Synced = true
+		s.snap.LastSyncAt = clock.Now().UTC()
+		return
+	}
+}
@@ -0,0 +1,155 @@
+package timesync
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"stratux-ng/internal/gps"
+)
+
+type fakeClock struct {
+	now      time.Time
+	steps    []time.Duration
+	slews    []time.Duration
+	external bool
+	stepErr  error
+}
+
+func (c *fakeClock) Now() time.Time { return c.now }
+
+func (c *fakeClock) Step(offset time.Duration) error {
+	if c.stepErr != nil {
+		return c.stepErr
+	}
+	c.steps = append(c.steps, offset)
+	c.now = c.now.Add(offset)
+	return nil
+}
+
+func (c *fakeClock) Slew(offset time.Duration) error {
+	c.slews = append(c.slews, offset)
+	return nil
+}
+
+func (c *fakeClock) ExternallySynced() bool { return c.external }
+
+type fakeSource struct {
+	name string
+	smp  Sample
+	ok   bool
+}
+
+func (s *fakeSource) Name() string { return s.name }
+
+func (s *fakeSource) Sample() (Sample, bool) { return s.smp, s.ok }
+
+// The clock is years behind until synced, as on a Pi without RTC or network.
+var bootTime = time.Date(2022, 1, 1, 0, 0, 10, 0, time.UTC)
+var gpsTime = time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+
+func TestCheck_StepsOnceForLargeOffset(t *testing.T) {
+	clk := &fakeClock{now: bootTime}
+	src := &fakeSource{name: "gps", ok: true, smp: Sample{Ref: gpsTime, At: bootTime.Add(-200 * time.Millisecond)}}
+	s := New(Config{Enable: true, Clock: clk, Sources: []Source{src}})
+
+	s.check()
+	want := gpsTime.Sub(bootTime.Add(-200 * time.Millisecond))
+	if len(clk.steps) != 1 || clk.steps[0] != want {
+		t.Fatalf("steps=%v want [%v]", clk.steps, want)
+	}
+	snap := s.Snapshot()
+	if !snap.Synced || snap.Steps != 1 || snap.Source != "gps" || snap.LastStepAt.IsZero() {
+		t.Fatalf("snapshot=%+v", snap)
+	}
+
+	// The same (pre-step) reading must not be applied again.
+	s.check()
+	if len(clk.steps) != 1 {
+		t.Fatalf("stepped again: %v", clk.steps)
+	}
+
+	// A fresh reading agreeing with the stepped clock changes nothing.
+	src.smp = Sample{Ref: gpsTime.Add(time.Second), At: clk.now.Add(900 * time.Millisecond)}
+	clk.now = clk.now.Add(time.Second)
+	s.check()
+	if len(clk.steps) != 1 || len(clk.slews) != 0 {
+		t.Fatalf("steps=%v slews=%v", clk.steps, clk.slews)
+	}
+	if snap := s.Snapshot(); !snap.Synced || snap.OffsetMs != -100 {
+		t.Fatalf("snapshot=%+v", snap)
+	}
+}
+
+func TestCheck_PreciseSourceSlewsAndFallsBack(t *testing.T) {
+	now := gpsTime.Add(2 * time.Second)
+	clk := &fakeClock{now: now}
+	pps := &fakeSource{name: "pps", ok: true, smp: Sample{Ref: gpsTime.Add(time.Second), At: gpsTime.Add(time.Second - 300*time.Microsecond), Precise: true}}
+	coarse := &fakeSource{name: "gps", ok: true, smp: Sample{Ref: gpsTime.Add(time.Second), At: gpsTime.Add(time.Second + 150*time.Millisecond)}}
+	s := New(Config{Enable: true, Clock: clk, Sources: []Source{pps, coarse}})
+
+	s.check()
+	if len(clk.slews) != 0 || s.Snapshot().Source != "pps" {
+		// 300us is below slewMin.
+		t.Fatalf("slews=%v snapshot=%+v", clk.slews, s.Snapshot())
+	}
+
+	pps.smp = Sample{Ref: gpsTime.Add(2 * time.Second), At: gpsTime.Add(2*time.Second - 20*time.Millisecond), Precise: true}
+	s.check()
+	if len(clk.slews) != 1 || clk.slews[0] != 20*time.Millisecond {
+		t.Fatalf("slews=%v", clk.slews)
+	}
+
+	// No pulses for a while: GPS takes over, and its latency is not slewed.
+	clk.now = clk.now.Add(5 * time.Second)
+	coarse.smp = Sample{Ref: gpsTime.Add(6 * time.Second), At: clk.now.Add(-time.Second + 150*time.Millisecond)}
+	s.check()
+	if snap := s.Snapshot(); snap.Source != "gps" || len(clk.slews) != 1 || len(clk.steps) != 0 {
+		t.Fatalf("snapshot=%+v slews=%v steps=%v", snap, clk.slews, clk.steps)
+	}
+}
+
+func TestCheck_SkipsExternalSyncAndImplausibleTime(t *testing.T) {
+	clk := &fakeClock{now: bootTime, external: true}
+	src := &fakeSource{name: "gps", ok: true, smp: Sample{Ref: gpsTime, At: bootTime}}
+	s := New(Config{Enable: true, Clock: clk, Sources: []Source{src}})
+	s.check()
+	if len(clk.steps) != 0 || !s.Snapshot().ExternalSync {
+		t.Fatalf("steps=%v snapshot=%+v", clk.steps, s.Snapshot())
+	}
+
+	clk.external = false
+	src.smp = Sample{Ref: time.Date(2006, 3, 5, 0, 0, 0, 0, time.UTC), At: bootTime}
+	s.check()
+	if snap := s.Snapshot(); len(clk.steps) != 0 || snap.Synced || snap.LastError == "" {
+		t.Fatalf("steps=%v snapshot=%+v", clk.steps, snap)
+	}
+
+	clk.stepErr = errors.New("operation not permitted")
+	src.smp = Sample{Ref: gpsTime, At: bootTime}
+	s.check()
+	if snap := s.Snapshot(); snap.Synced || snap.LastError != "timesync: step 42035h59m50s: operation not permitted" {
+		t.Fatalf("snapshot=%+v", snap)
+	}
+}
+
+func TestGPSSource(t *testing.T) {
+	at := time.Date(2026, 10, 18, 12, 0, 0, 300e6, time.UTC)
+	snap := gps.Snapshot{Valid: true, TimeUTC: "2026-10-18T12:00:00Z", TimeAt: at}
+	src := GPSSource(func() (gps.Snapshot, bool) { return snap, true })
+	smp, ok := src.Sample()
+	if !ok || !smp.Ref.Equal(gpsTime) || !smp.At.Equal(at) || smp.Precise {
+		t.Fatalf("sample=%+v ok=%v", smp, ok)
+	}
+
+	snap.Static = true
+	if _, ok := src.Sample(); ok {
+		t.Fatalf("expected no sample from a static position")
+	}
+
+	snap.Static = false
+	snap.Valid = false
+	if _, ok := src.Sample(); ok {
+		t.Fatalf("expected no sample without a fix")
+	}
+}
@@ -0,0 +1,239 @@
+package traffic
+
+import (
+	"encoding/json"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"stratux-ng/internal/gdl90"
+)
+
+const (
+	// aircraftJSONMaxSeen drops targets the decoder hasn't heard recently; readsb
+	// keeps them in aircraft.json for several minutes.
+	aircraftJSONMaxSeen = 60 * time.Second
+	// aircraftJSONMaxSeenPos is the oldest position still forwarded as traffic.
+	aircraftJSONMaxSeenPos = 30 * time.Second
+)
+
+// aircraftJSONDoc is the readsb/dump1090-fa/skyaware978 aircraft.json shape.
+// Older dump1090-fa field names (altitude, speed, vert_rate) are accepted too.
+type aircraftJSONDoc struct {
+	Now      float64                `json:"now"`
+	Aircraft []aircraftJSONAircraft `json:"aircraft"`
+}
+
+type aircraftJSONAircraft struct {
+	Hex      string          `json:"hex"`
+	Type     string          `json:"type"`
+	Flight   string          `json:"flight"`
+	AltBaro  json.RawMessage `json:"alt_baro"`
+	Altitude json.RawMessage `json:"altitude"`
+	AltGeom  *float64        `json:"alt_geom"`
+	GS       *float64        `json:"gs"`
+	Speed    *float64        `json:"speed"`
+	Track    *float64        `json:"track"`
+	BaroRate *float64        `json:"baro_rate"`
+	GeomRate *float64        `json:"geom_rate"`
+	VertRate *float64        `json:"vert_rate"`
+	Squawk   string          `json:"squawk"`
+	Emerg    string          `json:"emergency"`
+	Category string          `json:"category"`
+	Lat      *float64        `json:"lat"`
+	Lon      *float64        `json:"lon"`
+	NIC      *int            `json:"nic"`
+	NACp     *int            `json:"nac_p"`
+	RSSI     *float64        `json:"rssi"`
+	Seen     *float64        `json:"seen"`
+	SeenPos  *float64        `json:"seen_pos"`
+}
+
+type aircraftJSONState struct {
+	msgAt float64
+	posAt float64
+}
+
+// AircraftJSONTracker turns successive aircraft.json snapshots into traffic
+// updates, emitting only targets heard since the previous snapshot.
+//
+// Not safe for concurrent use; a poller calls it from one goroutine.
+type AircraftJSONTracker struct {
+	source Source
+	seen   map[string]aircraftJSONState
+}
+
+func NewAircraftJSONTracker(source Source) *AircraftJSONTracker {
+	return &AircraftJSONTracker{source: source, seen: make(map[string]aircraftJSONState)}
+}
+
+// Updates parses one aircraft.json document and returns the changes since the
+// previous call.
+func (t *AircraftJSONTracker) Updates(raw json.RawMessage) ([]TrafficUpdate, error) {
+	var doc aircraftJSONDoc
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return nil, err
+	}
+	now := doc.Now
+	if now == 0 {
+		now = float64(time.Now().UnixNano()) / 1e9
+	}
+
+	present := make(map[string]bool, len(doc.Aircraft))
+	var out []TrafficUpdate
+	for _, ac := range doc.Aircraft {
+		hex := strings.ToLower(strings.TrimSpace(ac.Hex))
+		present[hex] = true
+		upd, ok := t.update(now, hex, ac)
+		if ok {
+			out = append(out, upd)
+		}
+	}
+	for hex := range t.seen {
+		if !present[hex] {
+			delete(t.seen, hex)
+		}
+	}
+	return out, nil
+}
+
+func (t *AircraftJSONTracker) update(now float64, hex string, ac aircraftJSONAircraft) (TrafficUpdate, bool) {
+	if ac.Seen == nil || *ac.Seen > aircraftJSONMaxSeen.Seconds() {
+		return TrafficUpdate{}, false
+	}
+	addr, err := strconv.ParseUint(strings.TrimPrefix(hex, "~"), 16, 32)
+	if err != nil {
+		return TrafficUpdate{}, false
+	}
+	norm, ok := normalizeICAO(uint32(addr))
+	if !ok {
+		return TrafficUpdate{}, false
+	}
+	icao, _ := icaoBytes(norm)
+
+	prev := t.seen[hex]
+	msgAt := now - *ac.Seen
+	posAt := prev.posAt
+	newPos := false
+	if ac.Lat != nil && ac.Lon != nil && ac.SeenPos != nil && *ac.SeenPos <= aircraftJSONMaxSeenPos.Seconds() {
+		if at := now - *ac.SeenPos; at > prev.posAt+1e-3 {
+			posAt, newPos = at, true
+		}
+	}
+	if msgAt <= prev.msgAt+1e-3 && !newPos {
+		return TrafficUpdate{}, false
+	}
+	t.seen[hex] = aircraftJSONState{msgAt: msgAt, posAt: posAt}
+
+	out := TrafficUpdate{
+		ICAO:     icao,
+		Meta:     MetadataUpdate{ICAO: icao},
+		Source:   t.source,
+		AddrType: addrTypeFromName(ac.Type),
+		Link:     linkFromName(ac.Type),
+	}
+	if ac.RSSI != nil {
+		out.RSSI, out.HasRSSI = *ac.RSSI, true
+	}
+	m := &out.Meta
+	if fl := strings.ToUpper(strings.TrimSpace(ac.Flight)); fl != "" {
+		m.Tail, m.HasTail = fl, true
+	}
+	if sq := strings.TrimSpace(ac.Squawk); sq != "" {
+		m.Squawk, m.HasSquawk = sq, true
+	}
+	if code, ok := emergencyFromName(ac.Emerg); ok {
+		m.Emergency, m.HasEmergency = code, true
+	}
+
+	alt := ac.AltBaro
+	if len(alt) == 0 {
+		alt = ac.Altitude
+	}
+	if len(alt) > 0 {
+		var s string
+		var v float64
+		switch {
+		case json.Unmarshal(alt, &s) == nil && s == "ground":
+			m.OnGround, m.HasOnGround = true, true
+		case json.Unmarshal(alt, &v) == nil:
+			m.AltFeet, m.HasAlt = int(math.Round(v)), true
+			m.OnGround, m.HasOnGround = false, true
+		}
+	}
+	if !m.HasAlt && ac.AltGeom != nil {
+		m.AltFeet, m.HasAlt = int(math.Round(*ac.AltGeom)), true
+	}
+	if gs := firstFloat(ac.GS, ac.Speed); gs != nil {
+		m.GroundKt, m.HasGround = clampNonNegative(int(math.Round(*gs))), true
+	}
+	if ac.Track != nil {
+		m.TrackDeg, m.HasTrack = *ac.Track, true
+	}
+	if vr := firstFloat(ac.BaroRate, ac.GeomRate, ac.VertRate); vr != nil {
+		m.VvelFpm, m.HasVvel = int(math.Round(*vr)), true
+	}
+
+	if newPos {
+		nic := byte(8)
+		if ac.NIC != nil && *ac.NIC > 0 {
+			nic = clampNibble(*ac.NIC)
+		}
+		nacp := byte(8)
+		if ac.NACp != nil && *ac.NACp > 0 {
+			nacp = clampNibble(*ac.NACp)
+		}
+		emitter := emitterFromCategory(ac.Category)
+		if emitter == 0 {
+			emitter = 0x01
+		}
+		tr := gdl90.Traffic{
+			AddrType:        addrTypeFromName(ac.Type),
+			ICAO:            icao,
+			LatDeg:          *ac.Lat,
+			LonDeg:          *ac.Lon,
+			AltFeet:         m.AltFeet,
+			NIC:             nic,
+			NACp:            nacp,
+			GroundKt:        m.GroundKt,
+			TrackDeg:        m.TrackDeg,
+			VvelFpm:         m.VvelFpm,
+			OnGround:        m.OnGround,
+			EmitterCategory: emitter,
+			Tail:            m.Tail,
+		}
+		out.Traffic = &tr
+	}
+
+	if out.Empty() {
+		return TrafficUpdate{}, false
+	}
+	return out, true
+}
+
+// emitterFromCategory maps an "A1".."D7" emitter category to GDL90.
+func emitterFromCategory(cat string) byte {
+	if len(cat) != 2 || cat[1] < '0' || cat[1] > '7' {
+		return 0
+	}
+	ca := int(cat[1] - '0')
+	switch cat[0] {
+	case 'A':
+		return emitterCategory(4, ca)
+	case 'B':
+		return emitterCategory(3, ca)
+	case 'C':
+		return emitterCategory(2, ca)
+	}
+	return 0
+}
+
+func firstFloat(vs ...*float64) *float64 {
+	for _, v := range vs {
+		if v != nil {
+			return v
+		}
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package traffic
+
+import (
+	"testing"
+)
+
+func TestAircraftJSONTracker_DiffsSnapshots(t *testing.T) {
+	tr := NewAircraftJSONTracker(Source1090)
+
+	doc1 := []byte(`{"now":1000.0,"aircraft":[
+		{"hex":"a1b2c3","type":"adsb_icao","flight":"N12345  ","alt_baro":5500,"gs":120.4,"track":270,"baro_rate":-640,
+		 "squawk":"1200","category":"A1","lat":45.1,"lon":-122.5,"nic":8,"nac_p":9,"seen_pos":0.5,"seen":0.1},
+		{"hex":"~0a0b0c","type":"tisb_trackfile","alt_baro":"ground","lat":45.2,"lon":-122.6,"seen_pos":1,"seen":1},
+		{"hex":"c0ffee","type":"mode_s","alt_baro":12000,"seen":2},
+		{"hex":"abcdef","type":"adsb_icao","alt_baro":3000,"lat":45,"lon":-122,"seen_pos":120,"seen":90}
+	]}`)
+	upds, err := tr.Updates(doc1)
+	if err != nil {
+		t.Fatalf("Updates: %v", err)
+	}
+	if len(upds) != 3 {
+		t.Fatalf("expected 3 updates (stale target dropped), got %d", len(upds))
+	}
+	a := upds[0]
+	if a.Traffic == nil || a.Traffic.NACp != 9 || a.Traffic.AltFeet != 5500 || a.Traffic.GroundKt != 120 || a.Meta.Tail != "N12345" {
+		t.Fatalf("unexpected adsb update %+v", a)
+	}
+	if a.Traffic.EmitterCategory != 1 || a.Traffic.AddrType != 0 || !a.Meta.HasSquawk {
+		t.Fatalf("unexpected adsb metadata %+v", *a.Traffic)
+	}
+	if b := upds[1]; b.Traffic == nil || b.Traffic.AddrType != 3 || !b.Traffic.OnGround {
+		t.Fatalf("unexpected tisb update %+v", b)
+	}
+	if c := upds[2]; c.Traffic != nil || c.Meta.AltFeet != 12000 {
+		t.Fatalf("expected metadata-only mode_s update, got %+v", c)
+	}
+
+	// Nothing new heard: no updates.
+	doc2 := []byte(`{"now":1001.0,"aircraft":[
+		{"hex":"a1b2c3","type":"adsb_icao","alt_baro":5500,"lat":45.1,"lon":-122.5,"seen_pos":1.5,"seen":1.1}
+	]}`)
+	if upds, _ := tr.Updates(doc2); len(upds) != 0 {
+		t.Fatalf("expected no updates, got %d", len(upds))
+	}
+
+	// New message but same position: metadata only.
+	doc3 := []byte(`{"now":1002.0,"aircraft":[
+		{"hex":"a1b2c3","type":"adsb_icao","alt_baro":5600,"lat":45.1,"lon":-122.5,"seen_pos":2.5,"seen":0.2}
+	]}`)
+	upds, _ = tr.Updates(doc3)
+	if len(upds) != 1 || upds[0].Traffic != nil || upds[0].Meta.AltFeet != 5600 {
+		t.Fatalf("expected metadata-only update, got %+v", upds)
+	}
+}
+
+func TestEmitterFromCategory(t *testing.T) {
+	for cat, want := range map[string]byte{"A3": 3, "B1": 9, "B6": 14, "C1": 17, "C4": 19, "": 0, "Z9": 0} {
+		if got := emitterFromCategory(cat); got != want {
+			t.Fatalf("%q: got %d want %d", cat, got, want)
+		}
+	}
+}
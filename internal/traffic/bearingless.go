@@ -0,0 +1,64 @@
+package traffic
+
+import (
+	"math"
+	"time"
+)
+
+// bearinglessMaxAge is how recent a reply must be for a bearingless target;
+// older replies say little about where the aircraft is now.
+const bearinglessMaxAge = 15 * time.Second
+
+// BearinglessConfig calibrates range estimates for transponder-only targets.
+type BearinglessConfig struct {
+	// RSSIAt1Nm is the signal level (dBFS) expected from a typical
+	// transponder 1 nm away; range then follows free-space loss.
+	RSSIAt1Nm float64
+	// MaxRangeNm and MaxRelAltFeet limit which targets are reported.
+	MaxRangeNm    float64
+	MaxRelAltFeet int
+}
+
+// EstimateRangeNm converts a signal level to a free-space range estimate:
+// every 6 dB below the 1 nm reference doubles the range.
+func EstimateRangeNm(rssiDbfs, rssiAt1Nm float64) float64 {
+	return math.Pow(10, (rssiAt1Nm-rssiDbfs)/20)
+}
+
+// MarkBearingless turns recent position-less targets with altitude and signal
+// level into bearingless targets, as upstream Stratux does: the target is
+// placed at ownship position (with NIC/NACp 0) so EFBs can show proximity
+// and relative altitude without a bearing. ownAltFeet should be pressure
+// altitude when available, since Mode C reports pressure altitude.
+func MarkBearingless(snaps []TargetSnapshot, cfg BearinglessConfig, nowUTC time.Time, ownLat, ownLon float64, ownAltFeet int) {
+	for i := range snaps {
+		s := &snaps[i]
+		if s.PositionValid || !s.HasRSSI || s.Traffic.AltFeet == 0 || s.Traffic.OnGround {
+			continue
+		}
+		if addrNamespace(s.Traffic.AddrType) != addrNamespace(0) || nowUTC.Sub(s.SeenAt) > bearinglessMaxAge {
+			continue
+		}
+		rng := EstimateRangeNm(s.RSSI, cfg.RSSIAt1Nm)
+		rel := s.Traffic.AltFeet - ownAltFeet
+		if rng > cfg.MaxRangeNm || absInt(rel) > cfg.MaxRelAltFeet {
+			continue
+		}
+		s.Bearingless = true
+		s.EstRangeNm = rng
+		s.RelAltFeet = rel
+		s.Traffic.LatDeg, s.Traffic.LonDeg = ownLat, ownLon
+		s.Traffic.NIC, s.Traffic.NACp = 0, 0
+		s.Traffic.GroundKt, s.Traffic.TrackDeg = 0, 0
+		if s.Traffic.EmitterCategory == 0 {
+			s.Traffic.EmitterCategory = 0x01
+		}
+	}
+}
+
+func absInt(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
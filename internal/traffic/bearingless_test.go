@@ -0,0 +1,63 @@
+package traffic
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"stratux-ng/internal/gdl90"
+)
+
+func TestEstimateRangeNm(t *testing.T) {
+	if got := EstimateRangeNm(-10, -10); math.Abs(got-1) > 1e-9 {
+		t.Fatalf("range at reference=%v want 1", got)
+	}
+	// 6 dB weaker is about twice as far.
+	if got := EstimateRangeNm(-16, -10); math.Abs(got-2) > 0.01 {
+		t.Fatalf("range 6 dB down=%v want ~2", got)
+	}
+}
+
+func TestMarkBearingless(t *testing.T) {
+	now := time.Unix(1_000_000, 0).UTC()
+	cfg := BearinglessConfig{RSSIAt1Nm: -10, MaxRangeNm: 5, MaxRelAltFeet: 3000}
+	modeC := func(alt int, rssi float64, age time.Duration) TargetSnapshot {
+		return TargetSnapshot{
+			Traffic: gdl90.Traffic{ICAO: [3]byte{0xAB, 0xC1, 0x23}, AltFeet: alt, NIC: 8, NACp: 8},
+			SeenAt:  now.Add(-age),
+			RSSI:    rssi,
+			HasRSSI: true,
+		}
+	}
+	positioned := modeC(5500, -10, 0)
+	positioned.PositionValid = true
+	positioned.Traffic.LatDeg, positioned.Traffic.LonDeg = 45.1, -122.1
+	noRSSI := modeC(5500, -10, 0)
+	noRSSI.HasRSSI = false
+	snaps := []TargetSnapshot{
+		modeC(5800, -16, time.Second), // ~2 nm, 300 ft above
+		modeC(5500, -30, 0),           // ~10 nm: too far
+		modeC(9500, -10, 0),           // 4000 ft above: outside band
+		modeC(5500, -10, time.Minute), // stale
+		modeC(0, -10, 0),              // no altitude
+		noRSSI,
+		positioned,
+	}
+	MarkBearingless(snaps, cfg, now, 45, -122, 5500)
+
+	got := snaps[0]
+	if !got.Bearingless || math.Abs(got.EstRangeNm-2) > 0.01 || got.RelAltFeet != 300 {
+		t.Fatalf("expected bearingless ~2 nm +300 ft, got %+v", got)
+	}
+	if got.Traffic.LatDeg != 45 || got.Traffic.LonDeg != -122 || got.Traffic.NIC != 0 || got.Traffic.NACp != 0 {
+		t.Fatalf("expected target at ownship with NIC/NACp 0, got %+v", got.Traffic)
+	}
+	for i, s := range snaps[1:] {
+		if s.Bearingless {
+			t.Fatalf("snaps[%d] unexpectedly bearingless: %+v", i+1, s)
+		}
+	}
+	if snaps[6].Traffic.LatDeg != 45.1 {
+		t.Fatalf("positioned target moved: %+v", snaps[6].Traffic)
+	}
+}
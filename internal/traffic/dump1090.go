@@ -27,11 +27,14 @@ type MetadataUpdate struct {
 	HasOnGround bool
 	Squawk      string
 	HasSquawk   bool
+	// Emergency is the reported ADS-B/UAT emergency status as a GDL90 code.
+	Emergency    byte
+	HasEmergency bool
 }
 
 // Empty reports whether the update contains any useful metadata.
 func (m MetadataUpdate) Empty() bool {
-	return !m.HasTail && !m.HasGround && !m.HasTrack && !m.HasVvel && !m.HasAlt && !m.HasOnGround && !m.HasSquawk
+	return !m.HasTail && !m.HasGround && !m.HasTrack && !m.HasVvel && !m.HasAlt && !m.HasOnGround && !m.HasSquawk && !m.HasEmergency
 }
 
 // ParseDump1090RawJSON parses a single line from dump1090's Stratux NDJSON
@@ -57,6 +60,12 @@ func ParseDump1090RawJSON(raw json.RawMessage) (TrafficUpdate, bool) {
 		Meta: MetadataUpdate{ICAO: icao},
 	}
 	out.Source = Source1090
+	out.AddrType = addrType(msg.DF, msg.CA)
+	out.Link = linkFromDF(msg.DF, msg.CA)
+	out.Kind = ModeSKind(msg.DF)
+	if msg.SignalLevel != nil && *msg.SignalLevel > 0 {
+		out.RSSI, out.HasRSSI = 10*math.Log10(*msg.SignalLevel), true
+	}
 
 	if msg.Tail != nil {
 		tail := strings.ToUpper(strings.TrimSpace(*msg.Tail))
@@ -153,7 +162,7 @@ func ParseDump1090RawJSON(raw json.RawMessage) (TrafficUpdate, bool) {
 		}
 
 		t := gdl90.Traffic{
-			AddrType:        addrType(msg.DF, msg.CA),
+			AddrType:        out.AddrType,
 			ICAO:            icao,
 			LatDeg:          *msg.Lat,
 			LonDeg:          *msg.Lng,
@@ -198,6 +207,8 @@ type dump1090RawMessage struct {
 	Tail            *string  `json:"Tail"`
 	Squawk          *string  `json:"Squawk"`
 	EmitterCategory *int     `json:"Emitter_category"`
+	// SignalLevel is the linear signal power (0..1) from the Stratux fork.
+	SignalLevel *float64 `json:"SignalLevel"`
 }
 
 func normalizeICAO(addr uint32) (uint32, bool) {
@@ -247,6 +258,9 @@ func addrType(df, ca int) byte {
 		return 0
 	}
 	switch ca {
+	case 1:
+		// Non-ICAO (anonymous) address.
+		return 1
 	case 6:
 		return 2
 	case 2:
@@ -25,7 +25,8 @@ func TestParseDump1090RawJSONPosition(t *testing.T) {
         "Vvel": 256,
         "OnGround": false,
         "Tail": "N12345",
-        "Emitter_category": 3
+        "Emitter_category": 3,
+        "SignalLevel": 0.01
     }`
 	msg, ok := ParseDump1090RawJSON(json.RawMessage(raw))
 	if !ok {
@@ -54,6 +55,9 @@ func TestParseDump1090RawJSONPosition(t *testing.T) {
 	if !msg.Meta.HasTail || msg.Meta.Tail != "N12345" {
 		t.Errorf("metadata tail mismatch: %+v", msg.Meta)
 	}
+	if msg.Kind != "DF17" || !msg.HasRSSI || msg.RSSI != -20 {
+		t.Errorf("kind/rssi mismatch: kind=%q rssi=%v/%v", msg.Kind, msg.RSSI, msg.HasRSSI)
+	}
 }
 
 func TestParseDump1090RawJSONMetadataOnly(t *testing.T) {
@@ -41,6 +41,12 @@ type dump978Message struct {
 	VerticalVelGeom   *int     `json:"vertical_velocity_geometric"`
 	AirGroundState    *string  `json:"airground_state"`
 	Callsign          *string  `json:"callsign"`
+	AddressQualifier  string   `json:"address_qualifier"`
+	FlightPlanID      *string  `json:"flightplan_id"`
+	Emergency         *string  `json:"emergency"`
+	Metadata          *struct {
+		RSSI *float64 `json:"rssi"`
+	} `json:"metadata"`
 }
 
 func (m dump978Message) toUpdate() (TrafficUpdate, bool) {
@@ -148,8 +154,23 @@ func (m dump978Message) toUpdate() (TrafficUpdate, bool) {
 		meta.HasTail = true
 	}
 
+	if m.FlightPlanID != nil {
+		if sq := strings.TrimSpace(*m.FlightPlanID); sq != "" {
+			meta.Squawk, meta.HasSquawk = sq, true
+		}
+	}
+	if m.Emergency != nil {
+		meta.Emergency, meta.HasEmergency = emergencyFromName(*m.Emergency)
+	}
+
+	var rssi float64
+	hasRSSI := false
+	if m.Metadata != nil && m.Metadata.RSSI != nil {
+		rssi, hasRSSI = *m.Metadata.RSSI, true
+	}
+
 	traffic := gdl90.Traffic{
-		AddrType:        0x00,
+		AddrType:        addrTypeFromName(m.AddressQualifier),
 		ICAO:            icao,
 		LatDeg:          lat,
 		LonDeg:          lon,
@@ -163,7 +184,7 @@ func (m dump978Message) toUpdate() (TrafficUpdate, bool) {
 		Extrapolated:    false,
 		EmitterCategory: 0x01,
 		Tail:            tail,
-		PriorityStatus:  0,
+		PriorityStatus:  meta.Emergency,
 	}
 
 	return TrafficUpdate{
@@ -171,5 +192,9 @@ func (m dump978Message) toUpdate() (TrafficUpdate, bool) {
 		Traffic: &traffic,
 		Meta:    meta,
 		Source:  Source978,
+		Link:    linkFromName(m.AddressQualifier),
+		Kind:    KindUAT,
+		RSSI:    rssi,
+		HasRSSI: hasRSSI,
 	}, true
 }
@@ -0,0 +1,201 @@
+package traffic
+
+import (
+	"encoding/hex"
+	"strconv"
+	"strings"
+
+	"stratux-ng/internal/gdl90"
+	"stratux-ng/internal/uat978"
+)
+
+// ParseDump978RawDownlinkLine parses a dump978/dump978-fa raw downlink line and
+// decodes the UAT ADS-B frame into a unified traffic update.
+//
+// Expected format (fields may vary):
+//
+//	-<hex>;rs=<n>;ss=<n>;
+//
+// This lets a single raw connection carry both uplinks (weather) and
+// downlinks (traffic) without depending on dump978's JSON port.
+func ParseDump978RawDownlinkLine(line []byte) (TrafficUpdate, bool) {
+	d, ok := ParseDump978RawDownlink(line)
+	if !ok {
+		return TrafficUpdate{}, false
+	}
+	upd, ok := NewTrafficUpdateFromUATDownlink(d)
+	if ok {
+		upd.RSSI, upd.HasRSSI = ParseDump978RawSignal(line)
+	}
+	return upd, ok
+}
+
+// ParseDump978RawSignal returns the ";ss=" signal strength of a raw line in
+// dB, using the same scale as uplinks.
+func ParseDump978RawSignal(line []byte) (float64, bool) {
+	_, rest, _ := strings.Cut(strings.TrimSpace(string(line)), ";")
+	for _, p := range strings.Split(rest, ";") {
+		v, ok := strings.CutPrefix(strings.TrimSpace(p), "ss=")
+		if !ok {
+			continue
+		}
+		ss, err := strconv.Atoi(v)
+		if err != nil || ss <= 0 {
+			return 0, false
+		}
+		return uat978.SignalStrengthDbFromAmplitude(ss), true
+	}
+	return 0, false
+}
+
+// ParseDump978RawDownlink parses a raw downlink line and returns the decoded
+// frame, for callers that need fields beyond the traffic update (e.g. the
+// TIS-B site ID).
+func ParseDump978RawDownlink(line []byte) (uat978.DecodedDownlink, bool) {
+	frame, ok := parseDump978RawDownlinkHex(line)
+	if !ok {
+		return uat978.DecodedDownlink{}, false
+	}
+	return uat978.DecodeDownlinkFrame(frame)
+}
+
+func parseDump978RawDownlinkHex(line []byte) ([]byte, bool) {
+	s := strings.TrimSpace(string(line))
+	if s == "" {
+		return nil, false
+	}
+	first, _, _ := strings.Cut(s, ";")
+	if first == "" || first[0] != '-' {
+		return nil, false
+	}
+	hexStr := first[1:]
+	n := len(hexStr) / 2
+	if len(hexStr)%2 != 0 || (n != uat978.DownlinkShortFrameBytes && n != uat978.DownlinkLongFrameBytes) {
+		return nil, false
+	}
+	out := make([]byte, n)
+	if _, err := hex.Decode(out, []byte(hexStr)); err != nil {
+		return nil, false
+	}
+	return out, true
+}
+
+// uatAddrType maps a DO-282 address qualifier to the GDL90 traffic address
+// type. ADS-R rebroadcasts carry an ICAO address, so they report as ADS-B.
+func uatAddrType(q uat978.AddressQualifier) byte {
+	switch q {
+	case uat978.AddrADSBICAO, uat978.AddrADSRICAO, uat978.AddrReserved:
+		return 0
+	default:
+		return byte(q)
+	}
+}
+
+func uatLink(q uat978.AddressQualifier) Link {
+	switch {
+	case q.IsTISB():
+		return LinkTISB
+	case q.IsADSR():
+		return LinkADSR
+	default:
+		return LinkADSB
+	}
+}
+
+// NewTrafficUpdateFromUATDownlink converts a decoded UAT downlink into a
+// traffic update.
+func NewTrafficUpdateFromUATDownlink(d uat978.DecodedDownlink) (TrafficUpdate, bool) {
+	icao, ok := icaoBytes(d.Address)
+	if !ok || d.Address == 0 {
+		return TrafficUpdate{}, false
+	}
+
+	out := TrafficUpdate{
+		ICAO:     icao,
+		Meta:     MetadataUpdate{ICAO: icao},
+		Source:   Source978,
+		AddrType: uatAddrType(d.AddrQualifier),
+		Link:     uatLink(d.AddrQualifier),
+		Kind:     KindUAT,
+	}
+
+	// Prefer geometric altitude to match the dump978 JSON path.
+	altFeet, hasAlt := d.GeometricAltitude()
+	if !hasAlt {
+		altFeet, hasAlt = d.PressureAltitude()
+	}
+	if hasAlt {
+		out.Meta.AltFeet = altFeet
+		out.Meta.HasAlt = true
+	}
+	if d.HasGroundSpeed {
+		out.Meta.GroundKt = clampNonNegative(d.GroundSpeedKt)
+		out.Meta.HasGround = true
+	}
+	if d.HasTrack {
+		out.Meta.TrackDeg = d.TrackDeg
+		out.Meta.HasTrack = true
+	}
+	if d.HasVerticalRate {
+		out.Meta.VvelFpm = d.VerticalRateFpm
+		out.Meta.HasVvel = true
+	}
+	// The air/ground state is always present in an SV.
+	if d.PayloadType <= 10 {
+		out.Meta.OnGround = d.OnGround()
+		out.Meta.HasOnGround = true
+	}
+
+	if d.HasModeStatus {
+		out.Meta.Emergency, out.Meta.HasEmergency = d.EmergencyStatus&0x07, true
+	}
+
+	tail := ""
+	if d.HasModeStatus && d.Callsign != "" {
+		if d.CallsignIsFlightID {
+			tail = d.Callsign
+			if len(tail) > 8 {
+				tail = tail[:8]
+			}
+			out.Meta.Tail = tail
+			out.Meta.HasTail = true
+		} else {
+			out.Meta.Squawk = d.Callsign
+			out.Meta.HasSquawk = true
+		}
+	}
+
+	if d.HasPosition {
+		nacp := byte(8)
+		if d.HasModeStatus {
+			nacp = d.NACp & 0x0f
+		}
+		emitter := byte(0x01)
+		if d.HasModeStatus && d.EmitterCategory != 0 {
+			emitter = d.EmitterCategory
+		}
+		t := gdl90.Traffic{
+			AddrType:        uatAddrType(d.AddrQualifier),
+			ICAO:            icao,
+			LatDeg:          d.LatDeg,
+			LonDeg:          d.LonDeg,
+			AltFeet:         altFeet,
+			NIC:             d.NIC & 0x0f,
+			NACp:            nacp,
+			GroundKt:        out.Meta.GroundKt,
+			TrackDeg:        out.Meta.TrackDeg,
+			VvelFpm:         out.Meta.VvelFpm,
+			OnGround:        out.Meta.OnGround,
+			Extrapolated:    false,
+			EmitterCategory: emitter,
+			Tail:            tail,
+			PriorityStatus:  out.Meta.Emergency,
+		}
+		out.Traffic = &t
+	}
+
+	if out.Traffic == nil && out.Meta.Empty() {
+		return TrafficUpdate{}, false
+	}
+	return out, true
+}
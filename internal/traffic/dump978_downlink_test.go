@@ -0,0 +1,58 @@
+package traffic
+
+import (
+	"testing"
+	"time"
+)
+
+// Long (payload type 1) frame for A1B2C3 / N12345 at 45N 122.5W, 5500 ft baro,
+// 5600 ft geo, 100 kt north, +640 fpm.
+const dump978LongDownlinkHex = "08a1b2c340000151c71c105801940080b009d90cfc25040800900200001090000000"
+
+func TestParseDump978RawDownlinkLine_LongFrame(t *testing.T) {
+	line := []byte("-" + dump978LongDownlinkHex + ";rs=2;ss=180;\n")
+	upd, ok := ParseDump978RawDownlinkLine(line)
+	if !ok {
+		t.Fatalf("expected ok")
+	}
+	if upd.Source != Source978 {
+		t.Fatalf("expected source 978, got %q", upd.Source)
+	}
+	if upd.Traffic == nil {
+		t.Fatalf("expected traffic")
+	}
+	if upd.ICAO != [3]byte{0xA1, 0xB2, 0xC3} {
+		t.Fatalf("unexpected icao %x", upd.ICAO)
+	}
+	if upd.Traffic.AltFeet != 5600 {
+		t.Fatalf("expected geometric alt 5600, got %d", upd.Traffic.AltFeet)
+	}
+	if upd.Traffic.Tail != "N12345" || !upd.Meta.HasTail {
+		t.Fatalf("expected tail N12345, got %q", upd.Traffic.Tail)
+	}
+	if upd.Traffic.GroundKt != 100 || upd.Traffic.VvelFpm != 640 {
+		t.Fatalf("unexpected kinematics: %+v", *upd.Traffic)
+	}
+	if upd.Traffic.NIC != 8 || upd.Traffic.NACp != 9 {
+		t.Fatalf("unexpected NIC/NACp: %d/%d", upd.Traffic.NIC, upd.Traffic.NACp)
+	}
+	if upd.Kind != KindUAT || !upd.HasRSSI {
+		t.Fatalf("expected UAT kind with signal, got kind=%q rssi=%v/%v", upd.Kind, upd.RSSI, upd.HasRSSI)
+	}
+
+	s := NewStore(StoreConfig{})
+	now := time.Unix(1_000_000, 0).UTC()
+	s.Apply(now, upd)
+	if got := s.Snapshot(now); len(got) != 1 {
+		t.Fatalf("expected 1 target, got %d", len(got))
+	}
+}
+
+func TestParseDump978RawDownlinkLine_RejectsUplinkAndBadLength(t *testing.T) {
+	if _, ok := ParseDump978RawDownlinkLine([]byte("+01020304;ss=1;")); ok {
+		t.Fatalf("expected uplink to be rejected")
+	}
+	if _, ok := ParseDump978RawDownlinkLine([]byte("-0102;")); ok {
+		t.Fatalf("expected short frame to be rejected")
+	}
+}
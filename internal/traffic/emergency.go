@@ -0,0 +1,152 @@
+package traffic
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// GDL90 emergency/priority codes (traffic report byte 27, upper nibble). The
+// ADS-B and UAT emergency status fields use the same values.
+const (
+	EmergencyNone     byte = 0
+	EmergencyGeneral  byte = 1
+	EmergencyMedical  byte = 2
+	EmergencyMinFuel  byte = 3
+	EmergencyNoComm   byte = 4
+	EmergencyUnlawful byte = 5
+	EmergencyDowned   byte = 6
+)
+
+// emergencyEventsMax bounds the emergency event history.
+const emergencyEventsMax = 100
+
+// EmergencyEvent records a target entering (or changing) an emergency state.
+type EmergencyEvent struct {
+	Seq           uint64    `json:"seq"`
+	Time          time.Time `json:"time"`
+	ICAO          string    `json:"icao"`
+	Tail          string    `json:"tail,omitempty"`
+	Squawk        string    `json:"squawk,omitempty"`
+	Code          byte      `json:"code"`
+	Status        string    `json:"status"`
+	PositionValid bool      `json:"position_valid"`
+	LatDeg        float64   `json:"lat_deg,omitempty"`
+	LonDeg        float64   `json:"lon_deg,omitempty"`
+	AltFeet       int       `json:"alt_feet,omitempty"`
+	Source        Source    `json:"source,omitempty"`
+}
+
+// EmergencyName returns a short label for a GDL90 emergency code.
+func EmergencyName(code byte) string {
+	switch code {
+	case EmergencyNone:
+		return "none"
+	case EmergencyGeneral:
+		return "general"
+	case EmergencyMedical:
+		return "medical"
+	case EmergencyMinFuel:
+		return "minfuel"
+	case EmergencyNoComm:
+		return "nordo"
+	case EmergencyUnlawful:
+		return "unlawful"
+	case EmergencyDowned:
+		return "downed"
+	}
+	return "reserved"
+}
+
+// emergencyFromName maps the "emergency" strings used by dump978-fa and
+// readsb/dump1090-fa aircraft.json to a GDL90 code.
+func emergencyFromName(s string) (byte, bool) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "none":
+		return EmergencyNone, true
+	case "general":
+		return EmergencyGeneral, true
+	case "lifeguard", "medical":
+		return EmergencyMedical, true
+	case "minfuel":
+		return EmergencyMinFuel, true
+	case "nordo":
+		return EmergencyNoComm, true
+	case "unlawful":
+		return EmergencyUnlawful, true
+	case "downed":
+		return EmergencyDowned, true
+	}
+	return 0, false
+}
+
+// emergencyFromSquawk maps the emergency transponder codes.
+func emergencyFromSquawk(squawk string) byte {
+	switch strings.TrimSpace(squawk) {
+	case "7500":
+		return EmergencyUnlawful
+	case "7600":
+		return EmergencyNoComm
+	case "7700":
+		return EmergencyGeneral
+	}
+	return EmergencyNone
+}
+
+// priorityStatus combines the reported emergency status with the squawk. A
+// specific reported status wins; a bare "general" (as SBS feeds report any
+// emergency squawk) is refined by the squawk.
+func priorityStatus(reported byte, squawk string) byte {
+	if reported > EmergencyGeneral && reported <= EmergencyDowned {
+		return reported
+	}
+	if sq := emergencyFromSquawk(squawk); sq != EmergencyNone {
+		return sq
+	}
+	if reported == EmergencyGeneral {
+		return reported
+	}
+	return EmergencyNone
+}
+
+// updateEmergencyLocked refreshes the target's GDL90 priority status and
+// records an event when it enters or changes emergency state.
+func (s *Store) updateEmergencyLocked(nowUTC time.Time, tgt *target) {
+	prev := tgt.emergencyCode
+	code := priorityStatus(tgt.emergency, tgt.squawk)
+	tgt.traffic.PriorityStatus = code
+	tgt.emergencyCode = code
+	if code == EmergencyNone || code == prev {
+		return
+	}
+	s.eventSeq++
+	ev := EmergencyEvent{
+		Seq:           s.eventSeq,
+		Time:          nowUTC,
+		ICAO:          fmt.Sprintf("%02X%02X%02X", tgt.traffic.ICAO[0], tgt.traffic.ICAO[1], tgt.traffic.ICAO[2]),
+		Tail:          strings.TrimSpace(tgt.traffic.Tail),
+		Squawk:        tgt.squawk,
+		Code:          code,
+		Status:        EmergencyName(code),
+		PositionValid: tgt.hasPosition,
+		AltFeet:       tgt.traffic.AltFeet,
+		Source:        tgt.source,
+	}
+	if tgt.hasPosition {
+		ev.LatDeg, ev.LonDeg = tgt.traffic.LatDeg, tgt.traffic.LonDeg
+	}
+	s.events = append(s.events, ev)
+	if len(s.events) > emergencyEventsMax {
+		s.events = s.events[len(s.events)-emergencyEventsMax:]
+	}
+}
+
+// EmergencyEvents returns recorded emergency events, oldest first.
+func (s *Store) EmergencyEvents() []EmergencyEvent {
+	if s == nil {
+		return nil
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]EmergencyEvent(nil), s.events...)
+}
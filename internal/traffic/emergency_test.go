@@ -0,0 +1,101 @@
+package traffic
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"stratux-ng/internal/gdl90"
+)
+
+func TestPriorityStatus(t *testing.T) {
+	cases := []struct {
+		reported byte
+		squawk   string
+		want     byte
+	}{
+		{EmergencyNone, "1200", EmergencyNone},
+		{EmergencyNone, "7500", EmergencyUnlawful},
+		{EmergencyNone, "7600", EmergencyNoComm},
+		{EmergencyNone, "7700", EmergencyGeneral},
+		// A bare "general" flag is refined by the squawk.
+		{EmergencyGeneral, "7600", EmergencyNoComm},
+		{EmergencyGeneral, "", EmergencyGeneral},
+		// A specific reported status wins.
+		{EmergencyMedical, "7700", EmergencyMedical},
+		{EmergencyMinFuel, "", EmergencyMinFuel},
+		// Reserved values are ignored.
+		{7, "", EmergencyNone},
+	}
+	for _, c := range cases {
+		if got := priorityStatus(c.reported, c.squawk); got != c.want {
+			t.Fatalf("priorityStatus(%d, %q)=%d want %d", c.reported, c.squawk, got, c.want)
+		}
+	}
+}
+
+func TestStoreEmergencySquawkSetsPriorityAndLogsEvent(t *testing.T) {
+	store := NewStore(StoreConfig{TTL: time.Minute})
+	icao, _ := gdl90.ParseICAOHex("ABC123")
+	now := time.Unix(1_000_000, 0).UTC()
+	pos := NewTrafficUpdateFromTraffic(gdl90.Traffic{ICAO: icao, LatDeg: 45, LonDeg: -122, AltFeet: 5000, Tail: "N1AB"})
+	pos.Source = Source1090
+	store.Apply(now, pos)
+	squawk := func(sq string) TrafficUpdate {
+		return TrafficUpdate{ICAO: icao, Meta: MetadataUpdate{ICAO: icao, Squawk: sq, HasSquawk: true}}
+	}
+
+	store.Apply(now.Add(time.Second), squawk("7700"))
+	// Repeats of the same state don't add events, and later position
+	// reports keep the priority status.
+	store.Apply(now.Add(2*time.Second), squawk("7700"))
+	pos.Traffic.LatDeg = 45.001
+	store.Apply(now.Add(3*time.Second), pos)
+
+	gdl := store.Snapshot(now.Add(3 * time.Second))
+	if len(gdl) != 1 || gdl[0].PriorityStatus != EmergencyGeneral {
+		t.Fatalf("priority status not set: %+v", gdl)
+	}
+	msg, crcOK, err := gdl90.Unframe(gdl90.TrafficReportFrame(gdl[0]))
+	if err != nil || !crcOK || msg[27]>>4 != EmergencyGeneral {
+		t.Fatalf("0x14 emergency nibble: err=%v crc=%v msg=%x", err, crcOK, msg)
+	}
+
+	events := store.EmergencyEvents()
+	if len(events) != 1 {
+		t.Fatalf("expected 1 event, got %+v", events)
+	}
+	ev := events[0]
+	if ev.ICAO != "ABC123" || ev.Tail != "N1AB" || ev.Squawk != "7700" || ev.Status != "general" || !ev.PositionValid || ev.LatDeg != 45 || !ev.Time.Equal(now.Add(time.Second)) {
+		t.Fatalf("unexpected event %+v", ev)
+	}
+
+	// A change of emergency is a new event; squawking normal clears it.
+	store.Apply(now.Add(4*time.Second), squawk("7600"))
+	store.Apply(now.Add(5*time.Second), squawk("1200"))
+	if gdl := store.Snapshot(now.Add(5 * time.Second)); gdl[0].PriorityStatus != EmergencyNone {
+		t.Fatalf("priority not cleared: %d", gdl[0].PriorityStatus)
+	}
+	if events := store.EmergencyEvents(); len(events) != 2 || events[1].Status != "nordo" || events[1].Seq != 2 {
+		t.Fatalf("unexpected events %+v", events)
+	}
+}
+
+func TestEmergencyStatusFromDecoders(t *testing.T) {
+	// DF17 TC28/1: emergency state 5 (unlawful interference), Mode A 7500.
+	msg := withAddressParity(mustHex(t, "8DABC123E1AAA200000000000000"), 0)
+	upd, ok := NewModeSDecoder().Decode(time.Unix(1_000_000, 0).UTC(), msg)
+	if !ok || !upd.Meta.HasEmergency || upd.Meta.Emergency != EmergencyUnlawful || upd.Meta.Squawk != "7500" {
+		t.Fatalf("TC28 decode ok=%v meta=%+v", ok, upd.Meta)
+	}
+
+	upd, ok = ParseDump978NDJSON(json.RawMessage(`{"address":"ABC123","position":{"lat":45,"lon":-122},"emergency":"lifeguard","flightplan_id":"7700"}`))
+	if !ok || upd.Meta.Emergency != EmergencyMedical || !upd.Meta.HasEmergency || upd.Meta.Squawk != "7700" {
+		t.Fatalf("dump978 emergency ok=%v meta=%+v", ok, upd.Meta)
+	}
+
+	upd, ok = ParseSBSLine([]byte("MSG,6,1,1,A1B2C3,1,2026/01/01,12:00:00.000,2026/01/01,12:00:00.000,,5500,,,,,,7600,0,-1,0,0"))
+	if !ok || upd.Meta.Emergency != EmergencyGeneral || !upd.Meta.HasEmergency {
+		t.Fatalf("SBS emergency ok=%v meta=%+v", ok, upd.Meta)
+	}
+}
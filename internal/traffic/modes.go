@@ -0,0 +1,735 @@
+package traffic
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"stratux-ng/internal/gdl90"
+)
+
+const (
+	// modesCPRPairWindow bounds how far apart an even/odd CPR pair may be for
+	// a global airborne decode.
+	modesCPRPairWindow = 10 * time.Second
+	// modesLocalRefMaxAge bounds how old a previous position may be to serve as
+	// the reference for local CPR decoding.
+	modesLocalRefMaxAge = 5 * time.Minute
+	// modesAddrTTL is how long an address seen in a parity-checked message
+	// (DF11/17/18) is trusted to validate address/parity overlay replies.
+	modesAddrTTL = 60 * time.Second
+	// modesStateTTL is how long per-aircraft decoder state is kept.
+	modesStateTTL = 5 * time.Minute
+)
+
+// modesCRCPoly is the Mode S CRC-24 generator polynomial.
+const modesCRCPoly = 0xFFF409
+
+var modesCRCTable = func() [256]uint32 {
+	var t [256]uint32
+	for i := range t {
+		c := uint32(i) << 16
+		for j := 0; j < 8; j++ {
+			if c&0x800000 != 0 {
+				c = c<<1 ^ modesCRCPoly
+			} else {
+				c <<= 1
+			}
+		}
+		t[i] = c & 0xFFFFFF
+	}
+	return t
+}()
+
+// modesSyndrome returns the CRC of the message body XORed with its trailing
+// parity field: zero for clean DF17/18, the address for AP replies.
+func modesSyndrome(msg []byte) uint32 {
+	n := len(msg) - 3
+	var crc uint32
+	for _, b := range msg[:n] {
+		crc = (crc<<8 ^ modesCRCTable[byte(crc>>16)^b]) & 0xFFFFFF
+	}
+	parity := uint32(msg[n])<<16 | uint32(msg[n+1])<<8 | uint32(msg[n+2])
+	return crc ^ parity
+}
+
+type modesCPR struct {
+	lat, lon uint32
+	at       time.Time
+}
+
+type modesAircraft struct {
+	lastParity time.Time
+	lastSeen   time.Time
+
+	even, odd modesCPR
+
+	hasPos bool
+	lat    float64
+	lon    float64
+	posAt  time.Time
+
+	tail     string
+	emitter  byte
+	nacp     byte
+	groundKt int
+	trackDeg float64
+	vvelFpm  int
+}
+
+// ModeSDecoder decodes raw Mode S / 1090ES messages (as delivered by Beast
+// binary or AVR feeds) into traffic updates. It keeps per-aircraft CPR and
+// identification state, so one decoder should be used per feed.
+type ModeSDecoder struct {
+	mu       sync.Mutex
+	aircraft map[uint32]*modesAircraft
+	refLat   float64
+	refLon   float64
+	hasRef   bool
+	calls    int
+}
+
+func NewModeSDecoder() *ModeSDecoder {
+	return &ModeSDecoder{aircraft: make(map[uint32]*modesAircraft)}
+}
+
+// SetReference sets the receiver position used to resolve surface positions
+// for aircraft without a prior airborne fix.
+func (d *ModeSDecoder) SetReference(latDeg, lonDeg float64) {
+	if d == nil {
+		return
+	}
+	d.mu.Lock()
+	d.refLat, d.refLon, d.hasRef = latDeg, lonDeg, true
+	d.mu.Unlock()
+}
+
+// Decode parses one 7- or 14-byte Mode S message. Extended squitters (DF17/18)
+// must pass CRC; surveillance replies (DF4/5/20/21) are accepted only when the
+// address recovered from parity belongs to a recently heard aircraft.
+func (d *ModeSDecoder) Decode(nowUTC time.Time, msg []byte) (TrafficUpdate, bool) {
+	if d == nil || len(msg) < 7 {
+		return TrafficUpdate{}, false
+	}
+	df := int(msg[0] >> 3)
+	if df > 24 {
+		df = 24
+	}
+	want := 7
+	if df >= 16 {
+		want = 14
+	}
+	if len(msg) != want {
+		return TrafficUpdate{}, false
+	}
+	if nowUTC.IsZero() {
+		nowUTC = time.Now().UTC()
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.calls++
+	if d.calls%1024 == 0 {
+		d.pruneLocked(nowUTC)
+	}
+
+	syndrome := modesSyndrome(msg)
+	switch df {
+	case 11:
+		// All-call reply: parity is overlaid with the interrogator ID only.
+		if syndrome&0xFFFF80 != 0 {
+			return TrafficUpdate{}, false
+		}
+		addr := uint32(msg[1])<<16 | uint32(msg[2])<<8 | uint32(msg[3])
+		ac := d.aircraftLocked(addr)
+		ac.lastParity = nowUTC
+		ac.lastSeen = nowUTC
+		return TrafficUpdate{}, false
+	case 17, 18:
+		if syndrome != 0 {
+			return TrafficUpdate{}, false
+		}
+		upd, ok := d.decodeExtendedSquitterLocked(nowUTC, df, msg)
+		if ok {
+			ca := int(msg[0] & 0x07)
+			upd.AddrType, upd.Link = addrType(df, ca), linkFromDF(df, ca)
+			upd.Kind = ModeSKind(df)
+		}
+		return upd, ok
+	case 4, 5, 20, 21:
+		ac, ok := d.aircraft[syndrome]
+		if !ok || nowUTC.Sub(ac.lastParity) > modesAddrTTL {
+			return TrafficUpdate{}, false
+		}
+		ac.lastSeen = nowUTC
+		upd, ok := surveillanceUpdate(syndrome, df, msg)
+		upd.Kind = ModeSKind(df)
+		return upd, ok
+	}
+	return TrafficUpdate{}, false
+}
+
+// ModeSKind labels a Mode S downlink format for receiver rate stats.
+func ModeSKind(df int) string {
+	return "DF" + strconv.Itoa(df)
+}
+
+func (d *ModeSDecoder) aircraftLocked(addr uint32) *modesAircraft {
+	ac := d.aircraft[addr]
+	if ac == nil {
+		ac = &modesAircraft{}
+		d.aircraft[addr] = ac
+	}
+	return ac
+}
+
+func (d *ModeSDecoder) pruneLocked(nowUTC time.Time) {
+	for addr, ac := range d.aircraft {
+		if nowUTC.Sub(ac.lastSeen) > modesStateTTL {
+			delete(d.aircraft, addr)
+		}
+	}
+}
+
+// surveillanceUpdate decodes altitude (DF4/20) or squawk (DF5/21) replies.
+func surveillanceUpdate(addr uint32, df int, msg []byte) (TrafficUpdate, bool) {
+	icao, _ := icaoBytes(addr)
+	out := TrafficUpdate{ICAO: icao, Meta: MetadataUpdate{ICAO: icao}, Source: Source1090}
+
+	// Flight status: 1 and 3 are "on ground".
+	switch msg[0] & 0x07 {
+	case 0, 2:
+		out.Meta.OnGround, out.Meta.HasOnGround = false, true
+	case 1, 3:
+		out.Meta.OnGround, out.Meta.HasOnGround = true, true
+	}
+
+	field := int(msg[2])<<8&0x1F00 | int(msg[3])
+	if df == 4 || df == 20 {
+		if alt, ok := decodeAC13(field); ok {
+			out.Meta.AltFeet, out.Meta.HasAlt = alt, true
+		}
+	} else {
+		out.Meta.Squawk, out.Meta.HasSquawk = squawkFromID13(field), true
+	}
+	if out.Meta.Empty() {
+		return TrafficUpdate{}, false
+	}
+	return out, true
+}
+
+func (d *ModeSDecoder) decodeExtendedSquitterLocked(nowUTC time.Time, df int, msg []byte) (TrafficUpdate, bool) {
+	ca := int(msg[0] & 0x07)
+	if df == 18 && (ca == 3 || ca == 4 || ca == 7) {
+		// Coarse TIS-B, management and reserved formats don't use the ES ME
+		// layout; fine TIS-B (CF 2, 5) and ADS-R (CF 6) do.
+		return TrafficUpdate{}, false
+	}
+	addr := uint32(msg[1])<<16 | uint32(msg[2])<<8 | uint32(msg[3])
+	icao, ok := icaoBytes(addr)
+	if !ok || addr == 0 {
+		return TrafficUpdate{}, false
+	}
+	ac := d.aircraftLocked(addr)
+	ac.lastSeen = nowUTC
+	if df == 17 {
+		ac.lastParity = nowUTC
+	}
+
+	me := msg[4:11]
+	tc := int(me[0] >> 3)
+	out := TrafficUpdate{ICAO: icao, Meta: MetadataUpdate{ICAO: icao}, Source: Source1090}
+
+	switch {
+	case tc >= 1 && tc <= 4:
+		ac.emitter = emitterCategory(tc, int(me[0]&0x07))
+		if cs := decodeCallsign(me[1:7]); cs != "" {
+			ac.tail = cs
+			out.Meta.Tail, out.Meta.HasTail = cs, true
+		}
+	case tc >= 5 && tc <= 8:
+		return d.surfacePositionLocked(nowUTC, df, ca, tc, icao, ac, me)
+	case (tc >= 9 && tc <= 18) || (tc >= 20 && tc <= 22):
+		return d.airbornePositionLocked(nowUTC, df, ca, tc, icao, ac, me)
+	case tc == 19:
+		if !decodeVelocity(me, ac) {
+			return TrafficUpdate{}, false
+		}
+		out.Meta.GroundKt, out.Meta.HasGround = ac.groundKt, true
+		out.Meta.TrackDeg, out.Meta.HasTrack = ac.trackDeg, true
+		out.Meta.VvelFpm, out.Meta.HasVvel = ac.vvelFpm, true
+	case tc == 28:
+		// Aircraft status, subtype 1: emergency/priority status and Mode A.
+		if me[0]&0x07 != 1 {
+			return TrafficUpdate{}, false
+		}
+		out.Meta.Emergency, out.Meta.HasEmergency = me[1]>>5, true
+		if id13 := int(me[1]&0x1F)<<8 | int(me[2]); id13 != 0 {
+			out.Meta.Squawk, out.Meta.HasSquawk = squawkFromID13(id13), true
+		}
+	case tc == 31:
+		// Aircraft operational status: NACp is in ME bits 45-48.
+		ac.nacp = me[5] & 0x0F
+	}
+	if out.Meta.Empty() {
+		return TrafficUpdate{}, false
+	}
+	return out, true
+}
+
+func (d *ModeSDecoder) airbornePositionLocked(nowUTC time.Time, df, ca, tc int, icao [3]byte, ac *modesAircraft, me []byte) (TrafficUpdate, bool) {
+	alt, hasAlt := 0, false
+	if ac12 := int(me[1])<<4 | int(me[2])>>4; ac12 != 0 {
+		alt, hasAlt = decodeAC12(ac12)
+	}
+
+	odd := me[2]&0x04 != 0
+	cpr := modesCPR{
+		lat: uint32(me[2]&0x03)<<15 | uint32(me[3])<<7 | uint32(me[4])>>1,
+		lon: uint32(me[4]&0x01)<<16 | uint32(me[5])<<8 | uint32(me[6]),
+		at:  nowUTC,
+	}
+	if odd {
+		ac.odd = cpr
+	} else {
+		ac.even = cpr
+	}
+
+	lat, lon, ok := 0.0, 0.0, false
+	if !ac.even.at.IsZero() && !ac.odd.at.IsZero() && absDuration(ac.even.at.Sub(ac.odd.at)) <= modesCPRPairWindow {
+		lat, lon, ok = cprGlobalAirborne(ac.even, ac.odd, odd)
+	}
+	if !ok && ac.hasPos && nowUTC.Sub(ac.posAt) <= modesLocalRefMaxAge {
+		lat, lon, ok = cprLocal(ac.lat, ac.lon, cpr, odd, 360)
+	}
+
+	out := TrafficUpdate{ICAO: icao, Meta: MetadataUpdate{ICAO: icao, OnGround: false, HasOnGround: true}, Source: Source1090}
+	if hasAlt {
+		out.Meta.AltFeet, out.Meta.HasAlt = alt, true
+	}
+	if !ok {
+		return out, true
+	}
+	ac.hasPos, ac.lat, ac.lon, ac.posAt = true, lat, lon, nowUTC
+
+	t := ac.traffic(df, ca, tc, icao, lat, lon)
+	t.AltFeet = alt
+	out.Traffic = &t
+	return out, true
+}
+
+func (d *ModeSDecoder) surfacePositionLocked(nowUTC time.Time, df, ca, tc int, icao [3]byte, ac *modesAircraft, me []byte) (TrafficUpdate, bool) {
+	out := TrafficUpdate{ICAO: icao, Meta: MetadataUpdate{ICAO: icao, OnGround: true, HasOnGround: true}, Source: Source1090}
+	if kt, ok := surfaceMovementKt(int(me[0]&0x07)<<4 | int(me[1])>>4); ok {
+		out.Meta.GroundKt, out.Meta.HasGround = kt, true
+		ac.groundKt = kt
+	}
+	if me[1]&0x08 != 0 {
+		trk := float64(int(me[1]&0x07)<<4|int(me[2])>>4) * 360.0 / 128.0
+		out.Meta.TrackDeg, out.Meta.HasTrack = trk, true
+		ac.trackDeg = trk
+	}
+	ac.vvelFpm = 0
+
+	odd := me[2]&0x04 != 0
+	cpr := modesCPR{
+		lat: uint32(me[2]&0x03)<<15 | uint32(me[3])<<7 | uint32(me[4])>>1,
+		lon: uint32(me[4]&0x01)<<16 | uint32(me[5])<<8 | uint32(me[6]),
+		at:  nowUTC,
+	}
+	refLat, refLon, haveRef := d.refLat, d.refLon, d.hasRef
+	if ac.hasPos && nowUTC.Sub(ac.posAt) <= modesLocalRefMaxAge {
+		refLat, refLon, haveRef = ac.lat, ac.lon, true
+	}
+	if !haveRef {
+		return out, true
+	}
+	lat, lon, ok := cprLocal(refLat, refLon, cpr, odd, 90)
+	if !ok {
+		return out, true
+	}
+	ac.hasPos, ac.lat, ac.lon, ac.posAt = true, lat, lon, nowUTC
+
+	t := ac.traffic(df, ca, tc, icao, lat, lon)
+	t.OnGround = true
+	out.Traffic = &t
+	return out, true
+}
+
+// traffic builds a GDL90 target from cached state plus a fresh position.
+func (ac *modesAircraft) traffic(df, ca, tc int, icao [3]byte, lat, lon float64) gdl90.Traffic {
+	nic := deriveNIC(df, tc, 0)
+	if nic == 0 {
+		nic = 8
+	}
+	nacp := ac.nacp
+	if nacp == 0 {
+		nacp = 8
+	}
+	if nacp < 7 && nacp < nic {
+		nacp = nic
+	}
+	emitter := ac.emitter
+	if emitter == 0 {
+		emitter = 0x01
+	}
+	return gdl90.Traffic{
+		AddrType:        addrType(df, ca),
+		ICAO:            icao,
+		LatDeg:          lat,
+		LonDeg:          lon,
+		NIC:             nic,
+		NACp:            nacp,
+		GroundKt:        ac.groundKt,
+		TrackDeg:        ac.trackDeg,
+		VvelFpm:         ac.vvelFpm,
+		EmitterCategory: emitter,
+		Tail:            ac.tail,
+	}
+}
+
+// decodeVelocity handles airborne velocity (TC19) subtypes 1-4. Airspeed and
+// heading (subtypes 3/4) stand in for ground speed and track, as Stratux does.
+func decodeVelocity(me []byte, ac *modesAircraft) bool {
+	st := int(me[0] & 0x07)
+	switch st {
+	case 1, 2:
+		ew := int(me[1]&0x03)<<8 | int(me[2])
+		ns := int(me[3]&0x7F)<<3 | int(me[4])>>5
+		if ew == 0 || ns == 0 {
+			return false
+		}
+		vx, vy := float64(ew-1), float64(ns-1)
+		if st == 2 {
+			vx, vy = vx*4, vy*4
+		}
+		if me[1]&0x04 != 0 {
+			vx = -vx
+		}
+		if me[3]&0x80 != 0 {
+			vy = -vy
+		}
+		ac.groundKt = int(math.Round(math.Hypot(vx, vy)))
+		trk := math.Atan2(vx, vy) * 180 / math.Pi
+		if trk < 0 {
+			trk += 360
+		}
+		ac.trackDeg = trk
+	case 3, 4:
+		if me[1]&0x04 == 0 {
+			return false
+		}
+		ac.trackDeg = float64(int(me[1]&0x03)<<8|int(me[2])) * 360.0 / 1024.0
+		as := int(me[3]&0x7F)<<3 | int(me[4])>>5
+		if as == 0 {
+			return false
+		}
+		as--
+		if st == 4 {
+			as *= 4
+		}
+		ac.groundKt = as
+	default:
+		return false
+	}
+	ac.vvelFpm = 0
+	if vr := int(me[4]&0x07)<<6 | int(me[5])>>2; vr != 0 {
+		ac.vvelFpm = (vr - 1) * 64
+		if me[4]&0x08 != 0 {
+			ac.vvelFpm = -ac.vvelFpm
+		}
+	}
+	return true
+}
+
+const modesCallsignChars = "#ABCDEFGHIJKLMNOPQRSTUVWXYZ##### ###############0123456789######"
+
+// decodeCallsign unpacks eight 6-bit characters.
+func decodeCallsign(b []byte) string {
+	var bits uint64
+	for _, c := range b[:6] {
+		bits = bits<<8 | uint64(c)
+	}
+	var sb strings.Builder
+	for i := 7; i >= 0; i-- {
+		c := modesCallsignChars[(bits>>(uint(i)*6))&0x3F]
+		if c == '#' {
+			return ""
+		}
+		sb.WriteByte(c)
+	}
+	return strings.TrimSpace(sb.String())
+}
+
+// emitterCategory maps the ES identification type/category to the GDL90
+// emitter category.
+func emitterCategory(tc, ca int) byte {
+	switch tc {
+	case 4:
+		if ca >= 1 && ca <= 7 {
+			return byte(ca)
+		}
+	case 3:
+		switch ca {
+		case 1:
+			return 9 // glider
+		case 2:
+			return 10 // lighter than air
+		case 3:
+			return 11 // parachutist
+		case 4:
+			return 12 // ultralight
+		case 6:
+			return 14 // UAV
+		case 7:
+			return 15 // space vehicle
+		}
+	case 2:
+		switch ca {
+		case 1:
+			return 17 // surface emergency vehicle
+		case 3:
+			return 18 // surface service vehicle
+		case 4, 5, 6, 7:
+			return 19 // point obstacle
+		}
+	}
+	return 0
+}
+
+// surfaceMovementKt decodes the 7-bit surface movement field (DO-260B
+// quantization, rounded to whole knots).
+func surfaceMovementKt(mov int) (int, bool) {
+	var kt float64
+	switch {
+	case mov == 0 || mov > 124:
+		return 0, false
+	case mov == 1:
+		kt = 0
+	case mov <= 8:
+		kt = 0.125 * float64(mov-1)
+	case mov <= 12:
+		kt = 1 + 0.25*float64(mov-9)
+	case mov <= 38:
+		kt = 2 + 0.5*float64(mov-13)
+	case mov <= 93:
+		kt = 15 + float64(mov-39)
+	case mov <= 108:
+		kt = 70 + 2*float64(mov-94)
+	case mov <= 123:
+		kt = 100 + 5*float64(mov-109)
+	default:
+		kt = 175
+	}
+	return int(math.Round(kt)), true
+}
+
+// decodeAC13 decodes the 13-bit altitude code of DF0/4/16/20 (feet only).
+func decodeAC13(field int) (int, bool) {
+	if field == 0 || field&0x0040 != 0 {
+		// Unavailable, or metric altitude.
+		return 0, false
+	}
+	if field&0x0010 != 0 {
+		n := (field&0x1F80)>>2 | (field&0x0020)>>1 | field&0x000F
+		return n*25 - 1000, true
+	}
+	return gillhamAltitude(field)
+}
+
+// decodeAC12 decodes the 12-bit ES altitude field.
+func decodeAC12(field int) (int, bool) {
+	if field&0x0010 != 0 {
+		n := (field&0x0FE0)>>1 | field&0x000F
+		return n*25 - 1000, true
+	}
+	return gillhamAltitude((field&0x0FC0)<<1 | field&0x003F)
+}
+
+// id13ToHex reorders the 13-bit identity field into 0xABCD digit nibbles.
+func id13ToHex(f int) int {
+	h := 0
+	bit := func(mask, out int) {
+		if f&mask != 0 {
+			h |= out
+		}
+	}
+	bit(0x1000, 0x0010) // C1
+	bit(0x0800, 0x1000) // A1
+	bit(0x0400, 0x0020) // C2
+	bit(0x0200, 0x2000) // A2
+	bit(0x0100, 0x0040) // C4
+	bit(0x0080, 0x4000) // A4
+	bit(0x0020, 0x0100) // B1
+	bit(0x0010, 0x0001) // D1
+	bit(0x0008, 0x0200) // B2
+	bit(0x0004, 0x0002) // D2
+	bit(0x0002, 0x0400) // B4
+	bit(0x0001, 0x0004) // D4
+	return h
+}
+
+func squawkFromID13(field int) string {
+	h := id13ToHex(field)
+	return string([]byte{
+		byte('0' + (h>>12)&7),
+		byte('0' + (h>>8)&7),
+		byte('0' + (h>>4)&7),
+		byte('0' + h&7),
+	})
+}
+
+// gillhamAltitude decodes a Gillham (Mode C) coded AC13 field to feet.
+func gillhamAltitude(field int) (int, bool) {
+	a := id13ToHex(field)
+	if a&0xFFFF8889 != 0 || a&0x00F0 == 0 {
+		return 0, false
+	}
+	hundreds := 0
+	if a&0x0010 != 0 {
+		hundreds ^= 0x007
+	}
+	if a&0x0020 != 0 {
+		hundreds ^= 0x003
+	}
+	if a&0x0040 != 0 {
+		hundreds ^= 0x001
+	}
+	if hundreds&5 == 5 {
+		hundreds ^= 2
+	}
+	if hundreds > 5 {
+		return 0, false
+	}
+	fiveHundreds := 0
+	for _, m := range []struct{ mask, x int }{
+		{0x0002, 0x0FF}, {0x0004, 0x07F},
+		{0x1000, 0x03F}, {0x2000, 0x01F}, {0x4000, 0x00F},
+		{0x0100, 0x007}, {0x0200, 0x003}, {0x0400, 0x001},
+	} {
+		if a&m.mask != 0 {
+			fiveHundreds ^= m.x
+		}
+	}
+	if fiveHundreds&1 != 0 {
+		hundreds = 6 - hundreds
+	}
+	n := fiveHundreds*5 + hundreds - 13
+	if n < -12 {
+		return 0, false
+	}
+	return n * 100, true
+}
+
+// cprNL is the number of longitude zones at the given latitude.
+func cprNL(lat float64) int {
+	lat = math.Abs(lat)
+	switch {
+	case lat == 0:
+		return 59
+	case lat == 87:
+		return 2
+	case lat > 87:
+		return 1
+	}
+	const nz = 15
+	a := 1 - math.Cos(math.Pi/(2*nz))
+	b := math.Cos(math.Pi/180*lat) * math.Cos(math.Pi/180*lat)
+	return int(math.Floor(2 * math.Pi / math.Acos(1-a/b)))
+}
+
+func cprMod(a, b int) int {
+	r := a % b
+	if r < 0 {
+		r += b
+	}
+	return r
+}
+
+func cprModF(a, b float64) float64 {
+	r := math.Mod(a, b)
+	if r < 0 {
+		r += b
+	}
+	return r
+}
+
+// cprGlobalAirborne decodes an even/odd airborne pair; the most recent
+// message's latitude zone is used.
+func cprGlobalAirborne(even, odd modesCPR, oddLatest bool) (float64, float64, bool) {
+	const scale = 131072.0
+	lat0, lat1 := float64(even.lat), float64(odd.lat)
+	lon0, lon1 := float64(even.lon), float64(odd.lon)
+
+	j := int(math.Floor((59*lat0-60*lat1)/scale + 0.5))
+	rlat0 := 360.0 / 60 * (float64(cprMod(j, 60)) + lat0/scale)
+	rlat1 := 360.0 / 59 * (float64(cprMod(j, 59)) + lat1/scale)
+	if rlat0 >= 270 {
+		rlat0 -= 360
+	}
+	if rlat1 >= 270 {
+		rlat1 -= 360
+	}
+	if rlat0 < -90 || rlat0 > 90 || rlat1 < -90 || rlat1 > 90 {
+		return 0, 0, false
+	}
+	if cprNL(rlat0) != cprNL(rlat1) {
+		return 0, 0, false
+	}
+
+	rlat, nl := rlat0, cprNL(rlat0)
+	ni, cprLon := nl, lon0
+	if oddLatest {
+		rlat = rlat1
+		ni, cprLon = max(nl-1, 1), lon1
+	}
+	m := int(math.Floor((lon0*float64(nl-1)-lon1*float64(nl))/scale + 0.5))
+	rlon := 360.0 / float64(ni) * (float64(cprMod(m, ni)) + cprLon/scale)
+	rlon -= math.Floor((rlon+180)/360) * 360
+	return rlat, rlon, true
+}
+
+// cprLocal decodes a single CPR message relative to a reference position.
+// span is 360 for airborne and 90 for surface encoding.
+func cprLocal(refLat, refLon float64, cpr modesCPR, odd bool, span float64) (float64, float64, bool) {
+	const scale = 131072.0
+	zones := 60.0
+	if odd {
+		zones = 59
+	}
+	dlat := span / zones
+	fLat := float64(cpr.lat) / scale
+	fLon := float64(cpr.lon) / scale
+
+	j := math.Floor(refLat/dlat) + math.Floor(0.5+cprModF(refLat, dlat)/dlat-fLat)
+	rlat := dlat * (j + fLat)
+	if rlat < -90 || rlat > 90 || math.Abs(rlat-refLat) > dlat/2 {
+		return 0, 0, false
+	}
+
+	ni := cprNL(rlat)
+	if odd {
+		ni--
+	}
+	if ni < 1 {
+		ni = 1
+	}
+	dlon := span / float64(ni)
+	m := math.Floor(refLon/dlon) + math.Floor(0.5+cprModF(refLon, dlon)/dlon-fLon)
+	rlon := dlon * (m + fLon)
+	if math.Abs(rlon-refLon) > dlon/2 {
+		return 0, 0, false
+	}
+	rlon -= math.Floor((rlon+180)/360) * 360
+	return rlat, rlon, true
+}
+
+func absDuration(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+	return d
+}
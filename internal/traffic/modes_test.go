@@ -0,0 +1,160 @@
+package traffic
+
+import (
+	"encoding/hex"
+	"math"
+	"testing"
+	"time"
+)
+
+func mustHex(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatalf("hex: %v", err)
+	}
+	return b
+}
+
+// withAddressParity fills in the trailing parity so the syndrome equals addr,
+// as in a real DF4/5/20/21 reply.
+func withAddressParity(msg []byte, addr uint32) []byte {
+	n := len(msg) - 3
+	msg[n], msg[n+1], msg[n+2] = 0, 0, 0
+	p := modesSyndrome(msg) ^ addr
+	msg[n], msg[n+1], msg[n+2] = byte(p>>16), byte(p>>8), byte(p)
+	return msg
+}
+
+func TestModeSDecoder_IdentificationAndAirbornePosition(t *testing.T) {
+	d := NewModeSDecoder()
+	now := time.Unix(1_000_000, 0).UTC()
+
+	upd, ok := d.Decode(now, mustHex(t, "8D4840D6202CC371C32CE0576098"))
+	if !ok || upd.Meta.Tail != "KLM1023" || upd.ICAO != [3]byte{0x48, 0x40, 0xD6} {
+		t.Fatalf("unexpected identification update %+v ok=%v", upd, ok)
+	}
+
+	// Odd then even: the pair resolves on the even (most recent) message.
+	upd, ok = d.Decode(now, mustHex(t, "8D40621D58C386435CC412692AD6"))
+	if !ok || upd.Traffic != nil || !upd.Meta.HasAlt || upd.Meta.AltFeet != 38000 {
+		t.Fatalf("expected altitude-only update, got %+v ok=%v", upd, ok)
+	}
+	upd, ok = d.Decode(now.Add(time.Second), mustHex(t, "8D40621D58C382D690C8AC2863A7"))
+	if !ok || upd.Traffic == nil {
+		t.Fatalf("expected position, got %+v ok=%v", upd, ok)
+	}
+	if math.Abs(upd.Traffic.LatDeg-52.2572) > 1e-3 || math.Abs(upd.Traffic.LonDeg-3.9194) > 1e-3 {
+		t.Fatalf("unexpected position %.5f,%.5f", upd.Traffic.LatDeg, upd.Traffic.LonDeg)
+	}
+	if upd.Traffic.AltFeet != 38000 || upd.Source != Source1090 {
+		t.Fatalf("unexpected traffic %+v", *upd.Traffic)
+	}
+
+	// A corrupted squitter fails CRC.
+	bad := mustHex(t, "8D40621D58C382D690C8AC2863A7")
+	bad[6] ^= 0x01
+	if _, ok := d.Decode(now, bad); ok {
+		t.Fatalf("expected CRC failure")
+	}
+}
+
+func TestModeSDecoder_FineTISBNonICAOPosition(t *testing.T) {
+	d := NewModeSDecoder()
+	now := time.Unix(1_000_000, 0).UTC()
+
+	// The airborne position pair above, re-sent as DF18 CF=5: fine TIS-B
+	// with a non-ICAO address.
+	tisb := func(s string) []byte {
+		msg := mustHex(t, s)
+		msg[0] = 18<<3 | 5
+		return withAddressParity(msg, 0)
+	}
+	if _, ok := d.Decode(now, tisb("8D40621D58C386435CC412692AD6")); !ok {
+		t.Fatalf("odd CF=5 squitter rejected")
+	}
+	upd, ok := d.Decode(now.Add(time.Second), tisb("8D40621D58C382D690C8AC2863A7"))
+	if !ok || upd.Traffic == nil {
+		t.Fatalf("expected position, got %+v ok=%v", upd, ok)
+	}
+	if upd.Link != LinkTISB || upd.AddrType != 3 || upd.Traffic.AddrType != 3 {
+		t.Fatalf("link=%v addrType=%d traffic addrType=%d", upd.Link, upd.AddrType, upd.Traffic.AddrType)
+	}
+	if math.Abs(upd.Traffic.LatDeg-52.2572) > 1e-3 || math.Abs(upd.Traffic.LonDeg-3.9194) > 1e-3 {
+		t.Fatalf("unexpected position %.5f,%.5f", upd.Traffic.LatDeg, upd.Traffic.LonDeg)
+	}
+
+	// Coarse TIS-B (CF=3) has its own layout and is still dropped.
+	coarse := mustHex(t, "8D40621D58C386435CC412692AD6")
+	coarse[0] = 18<<3 | 3
+	if _, ok := d.Decode(now, withAddressParity(coarse, 0)); ok {
+		t.Fatalf("CF=3 squitter accepted")
+	}
+}
+
+func TestModeSDecoder_Velocity(t *testing.T) {
+	d := NewModeSDecoder()
+	now := time.Unix(1_000_000, 0).UTC()
+
+	upd, ok := d.Decode(now, mustHex(t, "8D485020994409940838175B284F"))
+	if !ok || upd.Meta.GroundKt != 159 || upd.Meta.VvelFpm != -832 || math.Abs(upd.Meta.TrackDeg-182.88) > 0.01 {
+		t.Fatalf("unexpected ground velocity %+v ok=%v", upd.Meta, ok)
+	}
+	upd, ok = d.Decode(now, mustHex(t, "8DA05F219B06B6AF189400CBC33F"))
+	if !ok || upd.Meta.GroundKt != 375 || upd.Meta.VvelFpm != -2304 || math.Abs(upd.Meta.TrackDeg-243.98) > 0.01 {
+		t.Fatalf("unexpected airspeed velocity %+v ok=%v", upd.Meta, ok)
+	}
+}
+
+func TestModeSDecoder_SurfacePositionNeedsReference(t *testing.T) {
+	d := NewModeSDecoder()
+	now := time.Unix(1_000_000, 0).UTC()
+	msg := mustHex(t, "8C4841753AAB238733C8CD4020B1")
+
+	upd, ok := d.Decode(now, msg)
+	if !ok || upd.Traffic != nil || !upd.Meta.OnGround || upd.Meta.GroundKt != 18 {
+		t.Fatalf("expected metadata-only surface update, got %+v ok=%v", upd, ok)
+	}
+
+	d.SetReference(52.32, 4.73)
+	upd, ok = d.Decode(now, msg)
+	if !ok || upd.Traffic == nil || !upd.Traffic.OnGround {
+		t.Fatalf("expected surface position, got %+v ok=%v", upd, ok)
+	}
+	if math.Abs(upd.Traffic.LatDeg-52.32304) > 1e-4 || math.Abs(upd.Traffic.LonDeg-4.73047) > 1e-4 {
+		t.Fatalf("unexpected surface position %.5f,%.5f", upd.Traffic.LatDeg, upd.Traffic.LonDeg)
+	}
+}
+
+func TestModeSDecoder_SurveillanceRepliesRequireKnownAddress(t *testing.T) {
+	d := NewModeSDecoder()
+	now := time.Unix(1_000_000, 0).UTC()
+	addr := uint32(0x4840D6)
+
+	// DF5, squawk 7700 (A=7, B=7).
+	df5 := withAddressParity([]byte{5 << 3, 0x00, 0x0A, 0xAA, 0, 0, 0}, addr)
+	if _, ok := d.Decode(now, df5); ok {
+		t.Fatalf("expected unknown address to be rejected")
+	}
+
+	if _, ok := d.Decode(now, mustHex(t, "8D4840D6202CC371C32CE0576098")); !ok {
+		t.Fatalf("identification failed")
+	}
+	upd, ok := d.Decode(now, df5)
+	if !ok || upd.Meta.Squawk != "7700" || upd.ICAO != [3]byte{0x48, 0x40, 0xD6} {
+		t.Fatalf("unexpected squawk update %+v ok=%v", upd, ok)
+	}
+
+	// DF4, Q-bit altitude 38000 ft, airborne.
+	n := (38000 + 1000) / 25
+	ac13 := (n&0x7E0)<<2 | (n&0x10)<<1 | 0x10 | n&0x0F
+	df4 := withAddressParity([]byte{4 << 3, 0, byte(ac13 >> 8), byte(ac13), 0, 0, 0}, addr)
+	upd, ok = d.Decode(now, df4)
+	if !ok || upd.Meta.AltFeet != 38000 || !upd.Meta.HasOnGround || upd.Meta.OnGround {
+		t.Fatalf("unexpected altitude update %+v ok=%v", upd.Meta, ok)
+	}
+
+	if _, ok := d.Decode(now.Add(2*modesAddrTTL), df4); ok {
+		t.Fatalf("expected stale address to be rejected")
+	}
+}
@@ -0,0 +1,340 @@
+package traffic
+
+import (
+	"bytes"
+	"math"
+	"strconv"
+	"strings"
+
+	"stratux-ng/internal/gdl90"
+)
+
+// SourceOGN marks traffic heard on the 868 MHz FLARM/OGN band, either via a
+// local ogn-decode (APRS) or a FLARM unit (PFLAA).
+const SourceOGN Source = "ogn"
+
+// AddrTypeOGN is the address type for FLARM and OGN tracker IDs. They are
+// self-assigned and would collide with ICAO addresses and ADS-B anonymous
+// IDs, so they get their own namespace; GDL90 has no such type, so
+// OGNWireAddrType is sent instead.
+const AddrTypeOGN byte = 6
+
+// OGNWireAddrType is the GDL90 address type sent for AddrTypeOGN targets
+// (ADS-B with self-assigned address).
+const OGNWireAddrType byte = 1
+
+// Receiver rate stat labels for the two OGN/FLARM formats.
+const (
+	KindOGNAPRS = "OGN"
+	KindPFLAA   = "PFLAA"
+)
+
+// OGN/FLARM integrity: positions are plain GPS fixes without an integrity
+// figure, so report a modest NIC/NACp that loses to a fresh ADS-B report of
+// the same aircraft.
+const (
+	ognNIC  = 8
+	ognNACp = 8
+)
+
+const (
+	ognMetersToFeet = 3.28084
+	ognMpsToKt      = 1.943844
+	earthRadiusM    = 6371000.0
+)
+
+// ognAddrType maps the OGN/FLARM address type (0 random, 1 ICAO, 2 FLARM,
+// 3 OGN) to a GDL90 address type. ICAO addresses share the ADS-B namespace
+// so the same aircraft heard on both bands is shown once.
+func ognAddrType(t int) byte {
+	if t == 1 {
+		return 0
+	}
+	return AddrTypeOGN
+}
+
+// ognEmitterCategory maps the FLARM/OGN aircraft type to a GDL90 emitter
+// category.
+func ognEmitterCategory(t int) byte {
+	switch t {
+	case 1: // glider
+		return 9
+	case 2, 5, 8: // tow plane, drop plane, powered aircraft
+		return 1
+	case 3: // helicopter
+		return 7
+	case 4: // skydiver
+		return 11
+	case 6, 7: // hang glider, paraglider
+		return 12
+	case 9: // jet
+		return 3
+	case 11, 12: // balloon, airship
+		return 10
+	case 13: // UAV
+		return 14
+	case 15: // static obstacle
+		return 20
+	}
+	return 0
+}
+
+// ParseOGNAPRS parses one APRS position line as emitted by ogn-decode, e.g.
+//
+//	FLRDDA5BA>APRS,qAS,LFNX:/160829h4415.41N/00600.03E'342/049/A=005524 !W66! id0ADDA5BA -454fpm
+//
+// The altitude is GPS altitude (MSL). Receiver beacons, comments and targets
+// that request no tracking are ignored.
+func ParseOGNAPRS(line []byte) (TrafficUpdate, bool) {
+	line = bytes.TrimSpace(line)
+	if len(line) == 0 || line[0] == '#' {
+		return TrafficUpdate{}, false
+	}
+	s := string(line)
+	gt := strings.IndexByte(s, '>')
+	colon := strings.IndexByte(s, ':')
+	if gt <= 0 || colon < gt {
+		return TrafficUpdate{}, false
+	}
+	callsign, body := s[:gt], s[colon+1:]
+	if len(body) == 0 {
+		return TrafficUpdate{}, false
+	}
+	switch body[0] {
+	case '/', '@':
+		// Timestamped position: "/HHMMSSh".
+		if len(body) < 8 {
+			return TrafficUpdate{}, false
+		}
+		body = body[8:]
+	case '!', '=':
+		body = body[1:]
+	default:
+		return TrafficUpdate{}, false
+	}
+	// Fixed part: lat (8), symbol table (1), lon (9), symbol code (1).
+	if len(body) < 19 {
+		return TrafficUpdate{}, false
+	}
+	lat, ok := parseAPRSCoord(body[0:8], 2, 'N', 'S')
+	if !ok {
+		return TrafficUpdate{}, false
+	}
+	lon, ok := parseAPRSCoord(body[9:18], 3, 'E', 'W')
+	if !ok {
+		return TrafficUpdate{}, false
+	}
+	rest := body[19:]
+
+	tr := gdl90.Traffic{NIC: ognNIC, NACp: ognNACp}
+	// Course/speed extension "CCC/SSS".
+	if len(rest) >= 7 && rest[3] == '/' {
+		if crs, err := strconv.Atoi(rest[0:3]); err == nil {
+			tr.TrackDeg = float64(crs % 360)
+		}
+		if spd, err := strconv.Atoi(rest[4:7]); err == nil {
+			tr.GroundKt = spd
+		}
+		rest = rest[7:]
+	}
+	if !strings.HasPrefix(rest, "/A=") || len(rest) < 9 {
+		return TrafficUpdate{}, false
+	}
+	alt, err := strconv.Atoi(rest[3:9])
+	if err != nil {
+		return TrafficUpdate{}, false
+	}
+	tr.AltFeet = alt
+
+	var (
+		addr     uint64
+		addrType = -1
+		acftType int
+	)
+	for _, tok := range strings.Fields(rest[9:]) {
+		switch {
+		case len(tok) == 5 && strings.HasPrefix(tok, "!W") && tok[4] == '!':
+			// Precision enhancement: a third decimal of minutes.
+			if d := tok[2]; d >= '0' && d <= '9' {
+				lat += math.Copysign(float64(d-'0')/1000/60, lat)
+			}
+			if d := tok[3]; d >= '0' && d <= '9' {
+				lon += math.Copysign(float64(d-'0')/1000/60, lon)
+			}
+		case len(tok) == 10 && strings.HasPrefix(tok, "id"):
+			flags, err1 := strconv.ParseUint(tok[2:4], 16, 8)
+			a, err2 := strconv.ParseUint(tok[4:], 16, 32)
+			if err1 != nil || err2 != nil {
+				continue
+			}
+			if flags&0x40 != 0 {
+				// No-track: the pilot asked not to be tracked.
+				return TrafficUpdate{}, false
+			}
+			addr, addrType, acftType = a, int(flags&0x03), int(flags>>2)&0x0F
+		case strings.HasSuffix(tok, "fpm"):
+			if v, err := strconv.ParseFloat(strings.TrimSuffix(tok, "fpm"), 64); err == nil {
+				tr.VvelFpm = int(math.Round(v))
+			}
+		}
+	}
+	if addrType < 0 {
+		// No id field: fall back to the callsign prefix.
+		if len(callsign) != 9 {
+			return TrafficUpdate{}, false
+		}
+		switch callsign[:3] {
+		case "ICA":
+			addrType = 1
+		case "FLR":
+			addrType = 2
+		case "OGN":
+			addrType = 3
+		default:
+			return TrafficUpdate{}, false
+		}
+		if addr, err = strconv.ParseUint(callsign[3:], 16, 32); err != nil {
+			return TrafficUpdate{}, false
+		}
+	}
+	if addr == 0 || addr > 0xFFFFFF {
+		return TrafficUpdate{}, false
+	}
+	icao, _ := icaoBytes(uint32(addr))
+
+	tr.ICAO = icao
+	tr.LatDeg, tr.LonDeg = lat, lon
+	tr.AddrType = ognAddrType(addrType)
+	tr.EmitterCategory = ognEmitterCategory(acftType)
+	upd := NewTrafficUpdateFromTraffic(tr)
+	upd.Source = SourceOGN
+	upd.Kind = KindOGNAPRS
+	return upd, true
+}
+
+// parseAPRSCoord parses "DDMM.mmN" (degDigits 2) or "DDDMM.mmE" (3).
+func parseAPRSCoord(s string, degDigits int, pos, neg byte) (float64, bool) {
+	if len(s) != degDigits+6 {
+		return 0, false
+	}
+	deg, err := strconv.Atoi(s[:degDigits])
+	if err != nil {
+		return 0, false
+	}
+	mins, err := strconv.ParseFloat(s[degDigits:len(s)-1], 64)
+	if err != nil || mins >= 60 {
+		return 0, false
+	}
+	v := float64(deg) + mins/60
+	switch s[len(s)-1] {
+	case pos:
+	case neg:
+		v = -v
+	default:
+		return 0, false
+	}
+	limit := 90.0
+	if degDigits == 3 {
+		limit = 180
+	}
+	if v > limit || v < -limit {
+		return 0, false
+	}
+	return v, true
+}
+
+// PFLAA field indexes, zero-based (index 0 is the sentence name).
+const (
+	pflaaRelNorth = 2
+	pflaaRelEast  = 3
+	pflaaRelVert  = 4
+	pflaaIDType   = 5
+	pflaaID       = 6
+	pflaaTrack    = 7
+	pflaaSpeed    = 9
+	pflaaClimb    = 10
+	pflaaAcftType = 11
+	pflaaNoTrack  = 12
+)
+
+// ParsePFLAA parses a FLARM "$PFLAA" sentence. FLARM reports targets relative
+// to itself, so the ownship position and altitude are needed to place them;
+// ownAltFeet should be pressure altitude when available so the result is
+// comparable with transponder altitudes. Targets without a bearing and
+// no-track targets are ignored.
+func ParsePFLAA(line []byte, ownLat, ownLon float64, ownAltFeet int) (TrafficUpdate, bool) {
+	s := strings.TrimSpace(string(line))
+	if !strings.HasPrefix(s, "$PFLAA,") {
+		return TrafficUpdate{}, false
+	}
+	if star := strings.LastIndexByte(s, '*'); star >= 0 {
+		if !nmeaChecksumOK(s[1:star], s[star+1:]) {
+			return TrafficUpdate{}, false
+		}
+		s = s[:star]
+	}
+	f := strings.Split(s, ",")
+	if len(f) <= pflaaAcftType {
+		return TrafficUpdate{}, false
+	}
+	if len(f) > pflaaNoTrack && f[pflaaNoTrack] == "1" {
+		return TrafficUpdate{}, false
+	}
+	north, err1 := strconv.ParseFloat(f[pflaaRelNorth], 64)
+	east, err2 := strconv.ParseFloat(f[pflaaRelEast], 64)
+	vert, err3 := strconv.ParseFloat(f[pflaaRelVert], 64)
+	if err1 != nil || err2 != nil || err3 != nil {
+		return TrafficUpdate{}, false
+	}
+	idType, err := strconv.Atoi(f[pflaaIDType])
+	if err != nil {
+		return TrafficUpdate{}, false
+	}
+	addr, err := strconv.ParseUint(f[pflaaID], 16, 32)
+	if err != nil || addr == 0 || addr > 0xFFFFFF {
+		return TrafficUpdate{}, false
+	}
+	icao, _ := icaoBytes(uint32(addr))
+
+	lat := ownLat + north/earthRadiusM*180/math.Pi
+	lon := ownLon + east/(earthRadiusM*math.Cos(ownLat*math.Pi/180))*180/math.Pi
+	tr := gdl90.Traffic{
+		ICAO:     icao,
+		AddrType: ognAddrType(idType),
+		LatDeg:   lat,
+		LonDeg:   lon,
+		AltFeet:  ownAltFeet + int(math.Round(vert*ognMetersToFeet)),
+		NIC:      ognNIC,
+		NACp:     ognNACp,
+	}
+	if v, err := strconv.ParseFloat(f[pflaaTrack], 64); err == nil {
+		tr.TrackDeg = v
+	}
+	if v, err := strconv.ParseFloat(f[pflaaSpeed], 64); err == nil {
+		tr.GroundKt = clampNonNegative(int(math.Round(v * ognMpsToKt)))
+	}
+	if v, err := strconv.ParseFloat(f[pflaaClimb], 64); err == nil {
+		tr.VvelFpm = int(math.Round(v * ognMetersToFeet * 60))
+	}
+	if v, err := strconv.ParseUint(f[pflaaAcftType], 16, 8); err == nil {
+		tr.EmitterCategory = ognEmitterCategory(int(v))
+	}
+	upd := NewTrafficUpdateFromTraffic(tr)
+	upd.Source = SourceOGN
+	upd.Kind = KindPFLAA
+	return upd, true
+}
+
+// nmeaChecksumOK checks the XOR checksum of an NMEA sentence body (between
+// '$' and '*').
+func nmeaChecksumOK(body, sum string) bool {
+	want, err := strconv.ParseUint(strings.TrimSpace(sum), 16, 8)
+	if err != nil {
+		return false
+	}
+	var cs byte
+	for i := 0; i < len(body); i++ {
+		cs ^= body[i]
+	}
+	return cs == byte(want)
+}
@@ -0,0 +1,106 @@
+package traffic
+
+import (
+	"fmt"
+	"math"
+	"testing"
+)
+
+func TestParseOGNAPRS_FLARM(t *testing.T) {
+	line := "FLRDDA5BA>APRS,qAS,LFNX:/160829h4415.41N/00600.03E'342/049/A=005524 !W66! id0ADDA5BA -454fpm -1.1rot 8.8dB 0e +51.2kHz gps4x5"
+	upd, ok := ParseOGNAPRS([]byte(line))
+	if !ok || upd.Traffic == nil {
+		t.Fatalf("expected position update")
+	}
+	tr := upd.Traffic
+	if tr.ICAO != [3]byte{0xDD, 0xA5, 0xBA} || tr.AddrType != AddrTypeOGN || upd.Source != SourceOGN || upd.Kind != KindOGNAPRS {
+		t.Fatalf("unexpected identity: %+v src=%s kind=%s", tr, upd.Source, upd.Kind)
+	}
+	if math.Abs(tr.LatDeg-(44+15.416/60)) > 1e-6 || math.Abs(tr.LonDeg-(6+0.036/60)) > 1e-6 {
+		t.Fatalf("lat/lon=%v,%v", tr.LatDeg, tr.LonDeg)
+	}
+	if tr.AltFeet != 5524 || tr.TrackDeg != 342 || tr.GroundKt != 49 || tr.VvelFpm != -454 {
+		t.Fatalf("unexpected kinematics: %+v", tr)
+	}
+	// Aircraft type 2 (tow plane).
+	if tr.EmitterCategory != 1 {
+		t.Fatalf("emitter=%d want 1", tr.EmitterCategory)
+	}
+}
+
+func TestParseOGNAPRS_ICAOAddressSharesADSBNamespace(t *testing.T) {
+	line := "ICA3D1C35>OGFLR,qAS,Letzi:/093236h4727.56N/00810.24E'175/098/A=002680 !W37! id053D1C35 +000fpm +0.0rot"
+	upd, ok := ParseOGNAPRS([]byte(line))
+	if !ok {
+		t.Fatalf("expected position update")
+	}
+	if upd.Traffic.AddrType != 0 || upd.Traffic.ICAO != [3]byte{0x3D, 0x1C, 0x35} {
+		t.Fatalf("unexpected identity: %+v", upd.Traffic)
+	}
+	// Aircraft type 1 (glider).
+	if upd.Traffic.EmitterCategory != 9 {
+		t.Fatalf("emitter=%d want 9", upd.Traffic.EmitterCategory)
+	}
+}
+
+func TestParseOGNAPRS_Ignored(t *testing.T) {
+	for _, line := range []string{
+		"# aprsc 2.1.4",
+		// Receiver beacon.
+		"LFNX>APRS,TCPIP*,qAC,GLIDERN1:/160815h4415.00NI00600.00E&/A=001234 v0.2.8",
+		// No-track flag set.
+		"FLRDDA5BA>APRS,qAS,LFNX:/160829h4415.41N/00600.03E'342/049/A=005524 id4ADDA5BA",
+		// Status message.
+		"FLRDDA5BA>APRS,qAS,LFNX:>160829h hello",
+	} {
+		if _, ok := ParseOGNAPRS([]byte(line)); ok {
+			t.Fatalf("expected %q to be ignored", line)
+		}
+	}
+}
+
+func withNMEAChecksum(body string) string {
+	var cs byte
+	for i := 0; i < len(body); i++ {
+		cs ^= body[i]
+	}
+	return fmt.Sprintf("$%s*%02X", body, cs)
+}
+
+func TestParsePFLAA(t *testing.T) {
+	line := withNMEAChecksum("PFLAA,0,-1234,1234,220,2,DD8F12,180,,30,-1.4,1")
+	upd, ok := ParsePFLAA([]byte(line), 45, -122, 5000)
+	if !ok || upd.Traffic == nil {
+		t.Fatalf("expected position update")
+	}
+	tr := upd.Traffic
+	if tr.ICAO != [3]byte{0xDD, 0x8F, 0x12} || tr.AddrType != AddrTypeOGN || upd.Source != SourceOGN || upd.Kind != KindPFLAA {
+		t.Fatalf("unexpected identity: %+v", tr)
+	}
+	// 1234 m south and east of ownship, 220 m above.
+	if got := distanceNm(45, -122, tr.LatDeg, tr.LonDeg); math.Abs(got-1234*math.Sqrt2/1852) > 0.01 {
+		t.Fatalf("distance=%v nm", got)
+	}
+	if tr.LatDeg >= 45 || tr.LonDeg <= -122 {
+		t.Fatalf("expected target south-east of ownship: %v,%v", tr.LatDeg, tr.LonDeg)
+	}
+	if tr.AltFeet != 5722 || tr.GroundKt != 58 || tr.VvelFpm != -276 || tr.TrackDeg != 180 || tr.EmitterCategory != 9 {
+		t.Fatalf("unexpected kinematics: %+v", tr)
+	}
+}
+
+func TestParsePFLAA_Ignored(t *testing.T) {
+	for _, line := range []string{
+		// Bad checksum.
+		"$PFLAA,0,-1234,1234,220,2,DD8F12,180,,30,-1.4,1*00",
+		// No bearing (relative east missing).
+		withNMEAChecksum("PFLAA,0,1500,,220,2,DD8F12,,,,,1"),
+		// No-track.
+		withNMEAChecksum("PFLAA,0,-1234,1234,220,2,DD8F12,180,,30,-1.4,1,1,0,-70"),
+		withNMEAChecksum("PFLAU,2,1,2,1,0,,0,,"),
+	} {
+		if _, ok := ParsePFLAA([]byte(line), 45, -122, 5000); ok {
+			t.Fatalf("expected %q to be ignored", line)
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package traffic
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"stratux-ng/internal/gdl90"
+)
+
+// Rejection reasons reported in RejectStats.
+const (
+	rejectSpeed   = "speed"
+	rejectAltRate = "alt_rate"
+	rejectRange   = "range"
+)
+
+const (
+	// plausibilitySlackNm absorbs position noise and CPR rounding between
+	// closely spaced fixes.
+	plausibilitySlackNm = 2.0
+	// plausibilitySlackFeet absorbs altitude quantization (25/100 ft steps).
+	plausibilitySlackFeet = 300.0
+	// quarantineTTL is how long a suspicious fix waits for confirmation.
+	quarantineTTL = 30 * time.Second
+)
+
+// RejectStats counts positions held back by plausibility gating for one
+// source (the ingest input name, or the band when inputs aren't named).
+type RejectStats struct {
+	Source    string `json:"source"`
+	Speed     uint64 `json:"speed"`
+	AltRate   uint64 `json:"alt_rate"`
+	Range     uint64 `json:"range"`
+	Confirmed uint64 `json:"confirmed"`
+}
+
+// quarantine is a suspicious fix waiting for a consistent second one.
+type quarantine struct {
+	traffic gdl90.Traffic
+	at      time.Time
+}
+
+// SetReference sets the receiver position used for the range gate; ok=false
+// clears it (no GPS fix).
+func (s *Store) SetReference(latDeg, lonDeg float64, ok bool) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	s.refLat, s.refLon, s.hasRef = latDeg, lonDeg, ok
+	s.mu.Unlock()
+}
+
+// RejectStats returns plausibility counters per source, sorted by source.
+func (s *Store) RejectStats() []RejectStats {
+	if s == nil {
+		return nil
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make([]RejectStats, 0, len(s.rejects))
+	for _, v := range s.rejects {
+		out = append(out, *v)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Source < out[j].Source })
+	return out
+}
+
+// checkPositionLocked gates a new fix. It returns true when the fix may be
+// applied: it is consistent with the last accepted position, or it confirms
+// a quarantined fix. Otherwise the fix replaces the quarantine slot and the
+// rejection is counted.
+func (s *Store) checkPositionLocked(nowUTC time.Time, key targetKey, tgt target, upd TrafficUpdate) bool {
+	next := *upd.Traffic
+	if !hasValidPosition(next) {
+		return true
+	}
+
+	var reason string
+	if tgt.hasPosition {
+		reason = s.implausibleMove(tgt.traffic, tgt.posAt, next, nowUTC)
+	} else if s.hasRef && s.cfg.MaxRangeNm > 0 && distanceNm(s.refLat, s.refLon, next.LatDeg, next.LonDeg) > s.cfg.MaxRangeNm {
+		reason = rejectRange
+	}
+	if reason == "" {
+		delete(s.quarantine, key)
+		return true
+	}
+
+	stats := s.rejectStatsLocked(upd)
+	if p, ok := s.quarantine[key]; ok && nowUTC.Sub(p.at) < quarantineTTL && s.implausibleMove(p.traffic, p.at, next, nowUTC) == "" {
+		// Two consistent fixes: the earlier position was the bad one.
+		delete(s.quarantine, key)
+		stats.Confirmed++
+		return true
+	}
+
+	switch reason {
+	case rejectSpeed:
+		stats.Speed++
+	case rejectAltRate:
+		stats.AltRate++
+	case rejectRange:
+		stats.Range++
+	}
+	if s.quarantine == nil {
+		s.quarantine = make(map[targetKey]quarantine)
+	}
+	s.quarantine[key] = quarantine{traffic: next, at: nowUTC}
+	return false
+}
+
+// implausibleMove returns the rejection reason for moving from prev (at
+// prevAt) to next (at now), or "" when the move is plausible.
+func (s *Store) implausibleMove(prev gdl90.Traffic, prevAt time.Time, next gdl90.Traffic, now time.Time) string {
+	dt := now.Sub(prevAt).Hours()
+	if dt < 0 {
+		dt = 0
+	}
+	if s.cfg.MaxSpeedKt > 0 {
+		if d := distanceNm(prev.LatDeg, prev.LonDeg, next.LatDeg, next.LonDeg); d > s.cfg.MaxSpeedKt*dt+plausibilitySlackNm {
+			return rejectSpeed
+		}
+	}
+	if s.cfg.MaxVvelFpm > 0 && prev.AltFeet != 0 && next.AltFeet != 0 {
+		dAlt := math.Abs(float64(next.AltFeet - prev.AltFeet))
+		if dAlt > s.cfg.MaxVvelFpm*dt*60+plausibilitySlackFeet {
+			return rejectAltRate
+		}
+	}
+	return ""
+}
+
+func (s *Store) rejectStatsLocked(upd TrafficUpdate) *RejectStats {
+	key := upd.Input
+	if key == "" {
+		key = string(upd.Source)
+	}
+	if key == "" {
+		key = "unknown"
+	}
+	if s.rejects == nil {
+		s.rejects = make(map[string]*RejectStats)
+	}
+	st, ok := s.rejects[key]
+	if !ok {
+		st = &RejectStats{Source: key}
+		s.rejects[key] = st
+	}
+	return st
+}
+
+func distanceNm(lat1, lon1, lat2, lon2 float64) float64 {
+	const earthRadiusNm = 3440.065
+	rad := math.Pi / 180
+	dLat := (lat2 - lat1) * rad
+	dLon := (lon2 - lon1) * rad
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1*rad)*math.Cos(lat2*rad)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadiusNm * math.Asin(math.Min(1, math.Sqrt(a)))
+}
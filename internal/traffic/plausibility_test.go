@@ -0,0 +1,85 @@
+package traffic
+
+import (
+	"testing"
+	"time"
+
+	"stratux-ng/internal/gdl90"
+)
+
+func TestStoreQuarantinesPositionJump(t *testing.T) {
+	store := NewStore(StoreConfig{TTL: time.Minute})
+	icao, _ := gdl90.ParseICAOHex("ABC123")
+	now := time.Now().UTC()
+	fix := func(lat float64, alt int) TrafficUpdate {
+		u := NewTrafficUpdateFromTraffic(gdl90.Traffic{ICAO: icao, LatDeg: lat, LonDeg: -122.0, AltFeet: alt})
+		u.Source = Source1090
+		return u
+	}
+
+	store.Apply(now, fix(45.0, 5000))
+	// Bad global CPR: 300 nm away one second later.
+	store.Apply(now.Add(time.Second), fix(50.0, 5000))
+	snap := store.Snapshot(now.Add(time.Second))
+	if len(snap) != 1 || snap[0].LatDeg != 45.0 {
+		t.Fatalf("jump should be quarantined, got %+v", snap)
+	}
+	// Normal track continues.
+	store.Apply(now.Add(2*time.Second), fix(45.001, 5000))
+	if snap := store.Snapshot(now.Add(2 * time.Second)); snap[0].LatDeg != 45.001 {
+		t.Fatalf("consistent fix rejected: %+v", snap[0])
+	}
+	// Altitude jump of 10000 ft in a second.
+	store.Apply(now.Add(3*time.Second), fix(45.002, 15000))
+	if snap := store.Snapshot(now.Add(3 * time.Second)); snap[0].AltFeet != 5000 {
+		t.Fatalf("altitude jump accepted: %+v", snap[0])
+	}
+
+	stats := store.RejectStats()
+	if len(stats) != 1 || stats[0].Source != "1090" || stats[0].Speed != 1 || stats[0].AltRate != 1 {
+		t.Fatalf("stats=%+v", stats)
+	}
+}
+
+func TestStoreConfirmsQuarantinedFix(t *testing.T) {
+	store := NewStore(StoreConfig{TTL: time.Minute})
+	icao, _ := gdl90.ParseICAOHex("ABC123")
+	now := time.Now().UTC()
+	fix := func(lat float64) TrafficUpdate {
+		u := NewTrafficUpdateFromTraffic(gdl90.Traffic{ICAO: icao, LatDeg: lat, LonDeg: -122.0})
+		u.Input = "remote"
+		return u
+	}
+
+	// The first accepted fix was the bogus one; the real track confirms.
+	store.Apply(now, fix(50.0))
+	store.Apply(now.Add(time.Second), fix(45.0))
+	store.Apply(now.Add(2*time.Second), fix(45.001))
+	snap := store.Snapshot(now.Add(2 * time.Second))
+	if snap[0].LatDeg != 45.001 {
+		t.Fatalf("second consistent fix should be accepted, got %v", snap[0].LatDeg)
+	}
+	stats := store.RejectStats()
+	if len(stats) != 1 || stats[0].Source != "remote" || stats[0].Speed != 1 || stats[0].Confirmed != 1 {
+		t.Fatalf("stats=%+v", stats)
+	}
+}
+
+func TestStoreRangeGateForNewTargets(t *testing.T) {
+	store := NewStore(StoreConfig{TTL: time.Minute, MaxRangeNm: 250})
+	store.SetReference(45.0, -122.0, true)
+	icao, _ := gdl90.ParseICAOHex("ABC123")
+	now := time.Now().UTC()
+
+	store.Upsert(now, gdl90.Traffic{ICAO: icao, LatDeg: 10.0, LonDeg: 10.0})
+	if snap := store.SnapshotDetailed(now); len(snap) != 0 {
+		t.Fatalf("out-of-range first fix should be quarantined, got %+v", snap)
+	}
+	store.Upsert(now.Add(time.Second), gdl90.Traffic{ICAO: icao, LatDeg: 10.001, LonDeg: 10.0})
+	if snap := store.Snapshot(now.Add(time.Second)); len(snap) != 1 {
+		t.Fatalf("confirmed fix should be accepted, got %+v", snap)
+	}
+	if st := store.RejectStats(); len(st) != 1 || st[0].Range != 1 || st[0].Confirmed != 1 {
+		t.Fatalf("stats=%+v", st)
+	}
+}
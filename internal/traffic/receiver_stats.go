@@ -0,0 +1,213 @@
+package traffic
+
+import (
+	"math"
+	"time"
+)
+
+const (
+	// polarSectorDeg is the bearing width of one polar range sector.
+	polarSectorDeg = 10
+	polarSectors   = 360 / polarSectorDeg
+
+	// rateWindow is the window message rates are computed over.
+	rateWindow = 10 * time.Second
+
+	// rangeBucket/rangeBuckets keep per-minute maximum range for a day.
+	rangeBucket  = time.Minute
+	rangeBuckets = 24 * 60
+)
+
+// PolarAltBandsFeet are the lower edges of the altitude bands in the polar
+// range plot; the last band is open-ended.
+var PolarAltBandsFeet = []int{0, 10000, 20000, 30000}
+
+// rateCounter reports events per second over the last completed window (or
+// the current one until a window has completed).
+type rateCounter struct {
+	start    time.Time
+	count    uint64
+	lastRate float64
+	hasLast  bool
+}
+
+func (c *rateCounter) add(now time.Time) {
+	c.roll(now)
+	c.count++
+}
+
+func (c *rateCounter) roll(now time.Time) {
+	if c.start.IsZero() {
+		c.start = now
+		return
+	}
+	elapsed := now.Sub(c.start)
+	if elapsed < rateWindow {
+		return
+	}
+	if elapsed < 2*rateWindow {
+		c.lastRate = float64(c.count) / elapsed.Seconds()
+	} else {
+		// Nothing arrived for a whole window.
+		c.lastRate = 0
+	}
+	c.hasLast = true
+	c.start, c.count = now, 0
+}
+
+func (c *rateCounter) rate(now time.Time) float64 {
+	if c.start.IsZero() {
+		return 0
+	}
+	if now.Sub(c.start) >= 2*rateWindow {
+		return 0
+	}
+	if c.hasLast {
+		return c.lastRate
+	}
+	elapsed := now.Sub(c.start).Seconds()
+	if elapsed < 1 {
+		elapsed = 1
+	}
+	return float64(c.count) / elapsed
+}
+
+// receiverStats accumulates receiver-level performance figures. It is owned
+// by Store and guarded by its mutex.
+type receiverStats struct {
+	messages uint64
+	byKind   map[string]*rateCounter
+	total    rateCounter
+
+	// polar[band][sector] is the maximum range seen, in nm.
+	polar [][polarSectors]float64
+
+	// rangeMax is a ring of per-minute maximum ranges.
+	rangeMax   [rangeBuckets]float64
+	rangeEpoch [rangeBuckets]int64
+}
+
+// ReceiverStats is a snapshot of receiver performance.
+type ReceiverStats struct {
+	Messages       uint64             `json:"messages"`
+	MessageRate    float64            `json:"message_rate"`
+	RatesByKind    map[string]float64 `json:"rates_by_kind"`
+	MaxRangeHourNm float64            `json:"max_range_hour_nm"`
+	MaxRangeDayNm  float64            `json:"max_range_day_nm"`
+
+	// Polar range plot: PolarNm[band][sector] is the maximum range seen in
+	// altitude band AltBandsFeet[band] and bearing sector
+	// [sector*SectorDeg, (sector+1)*SectorDeg).
+	SectorDeg    int         `json:"sector_deg"`
+	AltBandsFeet []int       `json:"alt_bands_feet"`
+	PolarNm      [][]float64 `json:"polar_nm"`
+}
+
+func (rs *receiverStats) countMessage(now time.Time, kind string) {
+	rs.messages++
+	rs.total.add(now)
+	if kind == "" {
+		return
+	}
+	if rs.byKind == nil {
+		rs.byKind = make(map[string]*rateCounter)
+	}
+	c, ok := rs.byKind[kind]
+	if !ok {
+		c = &rateCounter{}
+		rs.byKind[kind] = c
+	}
+	c.add(now)
+}
+
+func (rs *receiverStats) observePosition(now time.Time, refLat, refLon, lat, lon float64, altFeet int) {
+	if rs.polar == nil {
+		rs.polar = make([][polarSectors]float64, len(PolarAltBandsFeet))
+	}
+	dist := distanceNm(refLat, refLon, lat, lon)
+	brg := bearingDeg(refLat, refLon, lat, lon)
+	sector := int(brg/polarSectorDeg) % polarSectors
+	band := 0
+	for i, lo := range PolarAltBandsFeet {
+		if altFeet >= lo {
+			band = i
+		}
+	}
+	if dist > rs.polar[band][sector] {
+		rs.polar[band][sector] = dist
+	}
+
+	epoch := now.Unix() / int64(rangeBucket/time.Second)
+	i := int(epoch % rangeBuckets)
+	if rs.rangeEpoch[i] != epoch {
+		rs.rangeEpoch[i], rs.rangeMax[i] = epoch, 0
+	}
+	if dist > rs.rangeMax[i] {
+		rs.rangeMax[i] = dist
+	}
+}
+
+func (rs *receiverStats) snapshot(now time.Time) ReceiverStats {
+	out := ReceiverStats{
+		Messages:     rs.messages,
+		MessageRate:  rs.total.rate(now),
+		RatesByKind:  make(map[string]float64, len(rs.byKind)),
+		SectorDeg:    polarSectorDeg,
+		AltBandsFeet: append([]int(nil), PolarAltBandsFeet...),
+		PolarNm:      make([][]float64, len(PolarAltBandsFeet)),
+	}
+	for kind, c := range rs.byKind {
+		out.RatesByKind[kind] = c.rate(now)
+	}
+	for band := range out.PolarNm {
+		row := make([]float64, polarSectors)
+		if rs.polar != nil {
+			copy(row, rs.polar[band][:])
+		}
+		out.PolarNm[band] = row
+	}
+	epoch := now.Unix() / int64(rangeBucket/time.Second)
+	for i := range rs.rangeEpoch {
+		age := epoch - rs.rangeEpoch[i]
+		if rs.rangeEpoch[i] == 0 || age < 0 || age >= rangeBuckets {
+			continue
+		}
+		out.MaxRangeDayNm = math.Max(out.MaxRangeDayNm, rs.rangeMax[i])
+		if age < 60 {
+			out.MaxRangeHourNm = math.Max(out.MaxRangeHourNm, rs.rangeMax[i])
+		}
+	}
+	return out
+}
+
+// CountMessage records a received message that produced no traffic update
+// (e.g. a DF11 all-call or a frame failing CRC) for receiver message rates.
+func (s *Store) CountMessage(nowUTC time.Time, kind string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	s.stats.countMessage(nowUTC.UTC(), kind)
+	s.mu.Unlock()
+}
+
+// ReceiverStats returns receiver-level message rates and range statistics.
+func (s *Store) ReceiverStats(nowUTC time.Time) ReceiverStats {
+	if s == nil {
+		return ReceiverStats{}
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.stats.snapshot(nowUTC.UTC())
+}
+
+func bearingDeg(lat1, lon1, lat2, lon2 float64) float64 {
+	rad := math.Pi / 180
+	y := math.Sin((lon2-lon1)*rad) * math.Cos(lat2*rad)
+	x := math.Cos(lat1*rad)*math.Sin(lat2*rad) - math.Sin(lat1*rad)*math.Cos(lat2*rad)*math.Cos((lon2-lon1)*rad)
+	brg := math.Atan2(y, x) / rad
+	if brg < 0 {
+		brg += 360
+	}
+	return brg
+}
@@ -0,0 +1,75 @@
+package traffic
+
+import (
+	"testing"
+	"time"
+
+	"stratux-ng/internal/gdl90"
+)
+
+func TestStoreTracksPerTargetSignalAndRate(t *testing.T) {
+	store := NewStore(StoreConfig{TTL: time.Minute})
+	icao, _ := gdl90.ParseICAOHex("ABC123")
+	now := time.Unix(1_000_000, 0).UTC()
+	for i, rssi := range []float64{-10, -20, -30} {
+		u := NewTrafficUpdateFromTraffic(gdl90.Traffic{ICAO: icao, LatDeg: 45 + float64(i)*0.001, LonDeg: -122, AltFeet: 5000})
+		u.Kind, u.RSSI, u.HasRSSI = "DF17", rssi, true
+		store.Apply(now.Add(time.Duration(i)*time.Second), u)
+	}
+	// Messages without a signal level don't disturb the RSSI figures.
+	store.Apply(now.Add(3*time.Second), TrafficUpdate{ICAO: icao, Kind: "DF4", Meta: MetadataUpdate{ICAO: icao, Squawk: "1200", HasSquawk: true}})
+
+	snaps := store.SnapshotDetailed(now.Add(4 * time.Second))
+	if len(snaps) != 1 {
+		t.Fatalf("expected 1 target, got %d", len(snaps))
+	}
+	got := snaps[0]
+	if got.Messages != 4 || !got.FirstSeen.Equal(now) {
+		t.Fatalf("messages=%d first_seen=%v", got.Messages, got.FirstSeen)
+	}
+	if got.MsgRate != 1 {
+		t.Fatalf("msg rate=%v, want 1", got.MsgRate)
+	}
+	if !got.HasRSSI || got.RSSI != -30 || got.RSSIAvg != -20 {
+		t.Fatalf("rssi=%v avg=%v has=%v", got.RSSI, got.RSSIAvg, got.HasRSSI)
+	}
+
+	stats := store.ReceiverStats(now.Add(4 * time.Second))
+	if stats.Messages != 4 || stats.MessageRate != 1 || stats.RatesByKind["DF17"] != 0.75 || stats.RatesByKind["DF4"] == 0 {
+		t.Fatalf("receiver stats=%+v", stats)
+	}
+}
+
+func TestStoreReceiverRangeStats(t *testing.T) {
+	store := NewStore(StoreConfig{TTL: time.Hour})
+	store.SetReference(45, -122, true)
+	now := time.Unix(1_000_000, 0).UTC()
+	fix := func(hex string, lat, lon float64, alt int) TrafficUpdate {
+		icao, _ := gdl90.ParseICAOHex(hex)
+		return NewTrafficUpdateFromTraffic(gdl90.Traffic{ICAO: icao, LatDeg: lat, LonDeg: lon, AltFeet: alt})
+	}
+
+	// 60 nm north at low altitude, ~26 nm south-east in the flight levels.
+	store.Apply(now, fix("A00001", 46, -122, 3000))
+	store.Apply(now, fix("A00002", 44.75, -121.5, 35000))
+	// Two hours later only a close target is heard.
+	later := now.Add(2 * time.Hour)
+	store.Apply(later, fix("A00003", 45.1, -122, 3000))
+
+	stats := store.ReceiverStats(later)
+	if stats.SectorDeg != 10 || len(stats.PolarNm) != len(PolarAltBandsFeet) || len(stats.PolarNm[0]) != 36 {
+		t.Fatalf("unexpected polar shape: sector=%d bands=%d", stats.SectorDeg, len(stats.PolarNm))
+	}
+	if north := stats.PolarNm[0][0]; north < 59.9 || north > 60.1 {
+		t.Fatalf("north low-band range=%v, want ~60", north)
+	}
+	if se, want := stats.PolarNm[3][12], distanceNm(45, -122, 44.75, -121.5); se != want {
+		t.Fatalf("south-east high-band range=%v, want %v", se, want)
+	}
+	if stats.PolarNm[0][12] != 0 || stats.PolarNm[3][0] != 0 {
+		t.Fatalf("ranges leaked across bands: %+v", stats.PolarNm)
+	}
+	if stats.MaxRangeDayNm < 59.9 || stats.MaxRangeHourNm > 6.1 || stats.MaxRangeHourNm < 5.9 {
+		t.Fatalf("max range hour=%v day=%v", stats.MaxRangeHourNm, stats.MaxRangeDayNm)
+	}
+}
@@ -0,0 +1,251 @@
+package traffic
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// RegistryEntry is what the offline aircraft database knows about one ICAO
+// address.
+type RegistryEntry struct {
+	Registration string `json:"registration,omitempty"`
+	// TypeCode is the ICAO type designator (e.g. "C172", "B738").
+	TypeCode string `json:"type_code,omitempty"`
+	// Wake is the ICAO wake turbulence category letter (L, M, H or J).
+	Wake  string `json:"wake,omitempty"`
+	Owner string `json:"owner,omitempty"`
+}
+
+// Registry is an immutable ICAO-address index built from an aircraft
+// database file. Entries are kept in a sorted array and repeated strings
+// (types, operators) are shared, so a full national registry stays small.
+type Registry struct {
+	keys    []uint32
+	entries []RegistryEntry
+}
+
+// LoadRegistry reads an aircraft database CSV from disk.
+func LoadRegistry(path string) (*Registry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ParseRegistry(f)
+}
+
+// ParseRegistry parses an aircraft database CSV.
+//
+// The first row must be a header. Columns are matched case-insensitively so
+// both the FAA releasable registry (MASTER.txt) and the OpenSky aircraft
+// database can be used unedited:
+//   - icao24/icao/hex/mode s code hex (required; 24-bit hex address)
+//   - registration/reg/n-number (FAA N-numbers get their "N" prefix back)
+//   - typecode/icao_type/type_code
+//   - wtc/wake/wake_category
+//   - owner/operator/name
+//
+// OpenSky's single-quoted dialect is accepted. Rows without a valid address
+// are skipped; a later row for the same address replaces an earlier one.
+func ParseRegistry(r io.Reader) (*Registry, error) {
+	br := bufio.NewReader(r)
+	first, err := br.Peek(1)
+	if err != nil && len(first) == 0 {
+		return nil, fmt.Errorf("registry: missing header")
+	}
+	next := csvRecords(br)
+	if first[0] == '\'' {
+		next = singleQuotedRecords(br)
+	}
+
+	header, err := next()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("registry: missing header")
+		}
+		return nil, fmt.Errorf("registry: %w", err)
+	}
+	col := map[string]int{}
+	setCol := func(key string, i int) {
+		if _, ok := col[key]; !ok {
+			col[key] = i
+		}
+	}
+	nNumber := false
+	for i, h := range header {
+		h = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(h, "\ufeff")))
+		switch h {
+		case "icao24", "icao", "hex", "mode s code hex", "mode_s_code_hex":
+			setCol("icao", i)
+		case "registration", "reg":
+			setCol("reg", i)
+		case "n-number", "n_number":
+			setCol("reg", i)
+			nNumber = true
+		case "typecode", "icao_type", "type_code", "icaotype":
+			setCol("type", i)
+		case "wtc", "wake", "wake_category":
+			setCol("wake", i)
+		case "owner":
+			col["owner"] = i
+		case "operator", "name":
+			setCol("owner", i)
+		}
+	}
+	if _, ok := col["icao"]; !ok {
+		return nil, fmt.Errorf("registry: header must include an icao24/hex column")
+	}
+
+	field := func(rec []string, key string) string {
+		i, ok := col[key]
+		if !ok || i >= len(rec) {
+			return ""
+		}
+		return strings.TrimSpace(rec[i])
+	}
+	strs := map[string]string{}
+	intern := func(s string) string {
+		if v, ok := strs[s]; ok {
+			return v
+		}
+		strs[s] = s
+		return s
+	}
+
+	type row struct {
+		key   uint32
+		entry RegistryEntry
+	}
+	var rows []row
+	for {
+		rec, err := next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("registry: %w", err)
+		}
+		hex := field(rec, "icao")
+		if len(hex) != 6 {
+			continue
+		}
+		key, err := strconv.ParseUint(hex, 16, 32)
+		if err != nil || key == 0 {
+			continue
+		}
+		reg := strings.ToUpper(field(rec, "reg"))
+		if nNumber && reg != "" && !strings.HasPrefix(reg, "N") {
+			reg = "N" + reg
+		}
+		e := RegistryEntry{
+			Registration: reg,
+			TypeCode:     intern(strings.ToUpper(field(rec, "type"))),
+			Wake:         intern(wakeCategory(field(rec, "wake"))),
+			Owner:        intern(field(rec, "owner")),
+		}
+		if e == (RegistryEntry{}) {
+			continue
+		}
+		rows = append(rows, row{key: uint32(key), entry: e})
+	}
+
+	sort.SliceStable(rows, func(i, j int) bool { return rows[i].key < rows[j].key })
+	db := &Registry{
+		keys:    make([]uint32, 0, len(rows)),
+		entries: make([]RegistryEntry, 0, len(rows)),
+	}
+	for _, r := range rows {
+		if n := len(db.keys); n > 0 && db.keys[n-1] == r.key {
+			db.entries[n-1] = r.entry
+			continue
+		}
+		db.keys = append(db.keys, r.key)
+		db.entries = append(db.entries, r.entry)
+	}
+	return db, nil
+}
+
+// Len returns the number of addresses in the registry.
+func (db *Registry) Len() int {
+	if db == nil {
+		return 0
+	}
+	return len(db.keys)
+}
+
+// Lookup returns the entry for a 24-bit ICAO address.
+func (db *Registry) Lookup(icao [3]byte) (RegistryEntry, bool) {
+	if db == nil {
+		return RegistryEntry{}, false
+	}
+	key := uint32(icao[0])<<16 | uint32(icao[1])<<8 | uint32(icao[2])
+	i := sort.Search(len(db.keys), func(i int) bool { return db.keys[i] >= key })
+	if i == len(db.keys) || db.keys[i] != key {
+		return RegistryEntry{}, false
+	}
+	return db.entries[i], true
+}
+
+// wakeCategory normalizes the ICAO wake turbulence category ("LIGHT",
+// "Medium", "L/M" ...) to a single letter.
+func wakeCategory(s string) string {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	switch {
+	case s == "":
+		return ""
+	case strings.HasPrefix(s, "L/M"):
+		return "M"
+	case strings.HasPrefix(s, "J"), strings.HasPrefix(s, "SUPER"):
+		return "J"
+	case s[0] == 'L' || s[0] == 'M' || s[0] == 'H':
+		return s[:1]
+	}
+	return ""
+}
+
+func csvRecords(r io.Reader) func() ([]string, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.LazyQuotes = true
+	cr.ReuseRecord = true
+	return cr.Read
+}
+
+// singleQuotedRecords splits the OpenSky dialect, where fields are quoted with
+// ' and embedded quotes are doubled.
+func singleQuotedRecords(r *bufio.Reader) func() ([]string, error) {
+	var rec []string
+	return func() ([]string, error) {
+		line, err := r.ReadString('\n')
+		if line == "" && err != nil {
+			return nil, err
+		}
+		line = strings.TrimRight(line, "\r\n")
+		rec = rec[:0]
+		var b strings.Builder
+		quoted := false
+		for i := 0; i < len(line); i++ {
+			c := line[i]
+			switch {
+			case c == '\'' && quoted && i+1 < len(line) && line[i+1] == '\'':
+				b.WriteByte('\'')
+				i++
+			case c == '\'':
+				quoted = !quoted
+			case c == ',' && !quoted:
+				rec = append(rec, b.String())
+				b.Reset()
+			default:
+				b.WriteByte(c)
+			}
+		}
+		return append(rec, b.String()), nil
+	}
+}
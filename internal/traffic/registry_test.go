@@ -0,0 +1,110 @@
+package traffic
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"stratux-ng/internal/gdl90"
+)
+
+func TestParseRegistry_FAAMaster(t *testing.T) {
+	// Trimmed MASTER.txt: space-padded fields and a trailing comma.
+	csv := "N-NUMBER,SERIAL NUMBER,MFR MDL CODE,TYPE REGISTRANT,NAME,MODE S CODE HEX,\n" +
+		"12345,17255123,2072738,1,SMITH JOHN                                        ,A061D9    ,\n" +
+		"1AB  ,1,1,1,BAD HEX,ZZZZZZ,\n"
+	db, err := ParseRegistry(strings.NewReader(csv))
+	if err != nil {
+		t.Fatalf("ParseRegistry: %v", err)
+	}
+	if db.Len() != 1 {
+		t.Fatalf("expected 1 entry, got %d", db.Len())
+	}
+	icao, _ := gdl90.ParseICAOHex("A061D9")
+	e, ok := db.Lookup(icao)
+	if !ok || e.Registration != "N12345" || e.Owner != "SMITH JOHN" {
+		t.Fatalf("unexpected entry ok=%v %+v", ok, e)
+	}
+}
+
+func TestParseRegistry_OpenSkyDialects(t *testing.T) {
+	quoted := "'icao24','registration','typecode','operator','owner'\n" +
+		"'4ca7b3','EI-DCL','B738','Ryanair','Ryanair, Ltd'\n" +
+		"'a1b2c3','N1PW','C172','',''\n" +
+		"'a1b2c3','N1PQ','C182','','O''Brien'\n"
+	db, err := ParseRegistry(strings.NewReader(quoted))
+	if err != nil {
+		t.Fatalf("ParseRegistry: %v", err)
+	}
+	if db.Len() != 2 {
+		t.Fatalf("expected 2 entries, got %d", db.Len())
+	}
+	e, ok := db.Lookup([3]byte{0x4c, 0xa7, 0xb3})
+	if !ok || e.Registration != "EI-DCL" || e.TypeCode != "B738" || e.Owner != "Ryanair, Ltd" {
+		t.Fatalf("unexpected entry ok=%v %+v", ok, e)
+	}
+	// Later rows win.
+	if e, _ := db.Lookup([3]byte{0xa1, 0xb2, 0xc3}); e.Registration != "N1PQ" || e.Owner != "O'Brien" {
+		t.Fatalf("duplicate not replaced: %+v", e)
+	}
+
+	plain := "icao24,registration,icao_type,wtc\n" +
+		"\"4ca7b3\",\"EI-DCL\",\"b738\",\"Medium\"\n"
+	db, err = ParseRegistry(strings.NewReader(plain))
+	if err != nil {
+		t.Fatalf("ParseRegistry: %v", err)
+	}
+	if e, ok := db.Lookup([3]byte{0x4c, 0xa7, 0xb3}); !ok || e.TypeCode != "B738" || e.Wake != "M" {
+		t.Fatalf("unexpected entry ok=%v %+v", ok, e)
+	}
+}
+
+func TestParseRegistry_RequiresAddressColumn(t *testing.T) {
+	if _, err := ParseRegistry(strings.NewReader("registration,typecode\nN1,C172\n")); err == nil {
+		t.Fatalf("expected error for missing icao column")
+	}
+	if _, err := ParseRegistry(strings.NewReader("")); err == nil {
+		t.Fatalf("expected error for empty file")
+	}
+}
+
+func TestStoreEnrichesFromRegistry(t *testing.T) {
+	db, err := ParseRegistry(strings.NewReader("icao24,registration,typecode,wtc,owner\nABC123,N123AB,C172,L,Flying Club\n"))
+	if err != nil {
+		t.Fatalf("ParseRegistry: %v", err)
+	}
+	store := NewStore(StoreConfig{TTL: time.Minute})
+	store.SetRegistry(db)
+	icao, _ := gdl90.ParseICAOHex("ABC123")
+	now := time.Now().UTC()
+	store.Apply(now, NewTrafficUpdateFromTraffic(gdl90.Traffic{ICAO: icao, LatDeg: 45, LonDeg: -122, AltFeet: 3000}))
+	// A self-assigned address that happens to collide is not looked up.
+	store.Apply(now, NewTrafficUpdateFromTraffic(gdl90.Traffic{ICAO: icao, AddrType: 1, LatDeg: 46, LonDeg: -122, AltFeet: 3000}))
+
+	snaps := store.SnapshotDetailed(now)
+	if len(snaps) != 2 {
+		t.Fatalf("expected 2 targets, got %d", len(snaps))
+	}
+	got := snaps[0]
+	if !got.HasRegistry || got.Registry.TypeCode != "C172" || got.Registry.Wake != "L" || got.Registry.Owner != "Flying Club" {
+		t.Fatalf("registry not attached: %+v", got)
+	}
+	if got.Traffic.Tail != "N123AB" {
+		t.Fatalf("tail not filled from registration: %q", got.Traffic.Tail)
+	}
+	if snaps[1].HasRegistry || snaps[1].Traffic.Tail != "" {
+		t.Fatalf("anonymous address enriched: %+v", snaps[1])
+	}
+
+	// A received callsign is never overwritten.
+	upd := TrafficUpdate{ICAO: icao, Meta: MetadataUpdate{ICAO: icao, Tail: "CLUB12", HasTail: true}}
+	store.Apply(now, upd)
+	if gdl := store.Snapshot(now); gdl[0].Tail != "CLUB12" {
+		t.Fatalf("callsign replaced: %q", gdl[0].Tail)
+	}
+
+	store.SetRegistry(nil)
+	if snaps := store.SnapshotDetailed(now); snaps[0].HasRegistry {
+		t.Fatalf("registry still applied after clearing")
+	}
+}
@@ -0,0 +1,134 @@
+package traffic
+
+import (
+	"bytes"
+	"math"
+	"strconv"
+	"strings"
+
+	"stratux-ng/internal/gdl90"
+)
+
+// SBS-1 (BaseStation, port 30003) field indexes, zero-based.
+const (
+	sbsMsgType   = 1
+	sbsHexIdent  = 4
+	sbsCallsign  = 10
+	sbsAltitude  = 11
+	sbsGround    = 12
+	sbsTrack     = 13
+	sbsLat       = 14
+	sbsLon       = 15
+	sbsVRate     = 16
+	sbsSquawk    = 17
+	sbsEmergency = 19
+	sbsOnGround  = 21
+)
+
+// ParseSBSLine parses one SBS-1 "MSG,<1-8>,..." line. Each message type carries
+// a different subset of fields; whatever is present is reported through Meta
+// so the store merges it into the existing target. Position messages (MSG,2
+// and MSG,3) also populate Traffic.
+func ParseSBSLine(line []byte) (TrafficUpdate, bool) {
+	line = bytes.TrimSpace(line)
+	if !bytes.HasPrefix(line, []byte("MSG,")) {
+		return TrafficUpdate{}, false
+	}
+	f := strings.Split(string(line), ",")
+	if len(f) < 11 {
+		return TrafficUpdate{}, false
+	}
+	field := func(i int) string {
+		if i >= len(f) {
+			return ""
+		}
+		return strings.TrimSpace(f[i])
+	}
+
+	msgType, err := strconv.Atoi(field(sbsMsgType))
+	if err != nil || msgType < 1 || msgType > 8 {
+		return TrafficUpdate{}, false
+	}
+
+	hex := field(sbsHexIdent)
+	// readsb/dump1090 prefix non-ICAO (TIS-B/ADS-R anonymous) addresses with "~".
+	nonICAO := strings.HasPrefix(hex, "~")
+	hex = strings.TrimPrefix(hex, "~")
+	addr, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return TrafficUpdate{}, false
+	}
+	norm, ok := normalizeICAO(uint32(addr))
+	if !ok {
+		return TrafficUpdate{}, false
+	}
+	icao, _ := icaoBytes(norm)
+
+	out := TrafficUpdate{ICAO: icao, Meta: MetadataUpdate{ICAO: icao}, Source: Source1090, Kind: "SBS"}
+	if nonICAO {
+		out.AddrType = 1
+	}
+	m := &out.Meta
+
+	if cs := strings.ToUpper(field(sbsCallsign)); cs != "" {
+		m.Tail, m.HasTail = cs, true
+	}
+	if v, err := strconv.ParseFloat(field(sbsAltitude), 64); err == nil {
+		m.AltFeet, m.HasAlt = int(math.Round(v)), true
+	}
+	if v, err := strconv.ParseFloat(field(sbsGround), 64); err == nil {
+		m.GroundKt, m.HasGround = clampNonNegative(int(math.Round(v))), true
+	}
+	if v, err := strconv.ParseFloat(field(sbsTrack), 64); err == nil {
+		m.TrackDeg, m.HasTrack = v, true
+	}
+	if v, err := strconv.ParseFloat(field(sbsVRate), 64); err == nil {
+		m.VvelFpm, m.HasVvel = int(math.Round(v)), true
+	}
+	if sq := field(sbsSquawk); sq != "" {
+		m.Squawk, m.HasSquawk = sq, true
+	}
+	// The emergency flag doesn't say which emergency; the squawk refines it.
+	switch field(sbsEmergency) {
+	case "-1", "1":
+		m.Emergency, m.HasEmergency = EmergencyGeneral, true
+	case "0":
+		m.Emergency, m.HasEmergency = EmergencyNone, true
+	}
+	switch field(sbsOnGround) {
+	case "-1", "1":
+		m.OnGround, m.HasOnGround = true, true
+	case "0":
+		m.OnGround, m.HasOnGround = false, true
+	}
+	if msgType == 2 {
+		// Surface position.
+		m.OnGround, m.HasOnGround = true, true
+	}
+
+	lat, errLat := strconv.ParseFloat(field(sbsLat), 64)
+	lon, errLon := strconv.ParseFloat(field(sbsLon), 64)
+	if (msgType == 2 || msgType == 3) && errLat == nil && errLon == nil && !(lat == 0 && lon == 0) {
+		t := gdl90.Traffic{
+			AddrType:        out.AddrType,
+			ICAO:            icao,
+			LatDeg:          lat,
+			LonDeg:          lon,
+			AltFeet:         m.AltFeet,
+			NIC:             8,
+			NACp:            8,
+			GroundKt:        m.GroundKt,
+			TrackDeg:        m.TrackDeg,
+			VvelFpm:         m.VvelFpm,
+			OnGround:        m.OnGround,
+			EmitterCategory: 0x01,
+			Tail:            m.Tail,
+		}
+		out.Traffic = &t
+	}
+
+	if out.Empty() {
+		return TrafficUpdate{}, false
+	}
+	return out, true
+}
@@ -0,0 +1,64 @@
+package traffic
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseSBSLine_MergesMessageTypes(t *testing.T) {
+	s := NewStore(StoreConfig{})
+	now := time.Unix(1_000_000, 0).UTC()
+
+	lines := []string{
+		"MSG,1,1,1,A1B2C3,1,2026/01/01,12:00:00.000,2026/01/01,12:00:00.000,N12345 ,,,,,,,,,,,0",
+		"MSG,4,1,1,A1B2C3,1,2026/01/01,12:00:00.000,2026/01/01,12:00:00.000,,,120,270.5,,,-640,,,,,0",
+		"MSG,6,1,1,A1B2C3,1,2026/01/01,12:00:00.000,2026/01/01,12:00:00.000,,5500,,,,,,1200,0,0,0,0",
+		"MSG,3,1,1,A1B2C3,1,2026/01/01,12:00:00.000,2026/01/01,12:00:00.000,,5525,,,45.12345,-122.54321,,,0,0,0,0",
+	}
+	for _, l := range lines {
+		upd, ok := ParseSBSLine([]byte(l))
+		if !ok {
+			t.Fatalf("expected ok for %q", l)
+		}
+		s.Apply(now, upd)
+	}
+
+	got := s.SnapshotDetailed(now)
+	if len(got) != 1 {
+		t.Fatalf("expected 1 target, got %d", len(got))
+	}
+	tr := got[0].Traffic
+	if !got[0].PositionValid || tr.LatDeg != 45.12345 || tr.LonDeg != -122.54321 {
+		t.Fatalf("unexpected position %+v", tr)
+	}
+	if tr.AltFeet != 5525 || tr.GroundKt != 120 || tr.TrackDeg != 270.5 || tr.VvelFpm != -640 {
+		t.Fatalf("unexpected kinematics %+v", tr)
+	}
+	if tr.Tail != "N12345" || got[0].Squawk != "1200" {
+		t.Fatalf("unexpected tail/squawk %q/%q", tr.Tail, got[0].Squawk)
+	}
+}
+
+func TestParseSBSLine_RejectsMalformed(t *testing.T) {
+	for _, l := range []string{
+		"",
+		"SEL,,496,2286,4CA4E5,27215,2010/02/19,18:06:07.710,2010/02/19,18:06:07.710,RYR1427",
+		"MSG,9,1,1,A1B2C3,1,,,,,,,,,,,,,,,,",
+		"MSG,3,1,1,ZZZZZZ,1,,,,,,5500,,,45,-122,,,,,,",
+		"MSG,8,1,1,A1B2C3,1,,,,,,,,,,,,,,,,",
+	} {
+		if _, ok := ParseSBSLine([]byte(l)); ok {
+			t.Fatalf("expected %q to be rejected", l)
+		}
+	}
+}
+
+func TestParseSBSLine_SurfaceAndNonICAO(t *testing.T) {
+	upd, ok := ParseSBSLine([]byte("MSG,2,1,1,~0A1B2C,1,,,,,,,12,90,37.5,-122.25,,,,,,-1"))
+	if !ok || upd.Traffic == nil {
+		t.Fatalf("expected surface position")
+	}
+	if !upd.Traffic.OnGround || upd.Traffic.AddrType != 1 || upd.Traffic.GroundKt != 12 {
+		t.Fatalf("unexpected surface traffic %+v", *upd.Traffic)
+	}
+}
@@ -1,5 +1,7 @@
 package traffic
 
+import "strings"
+
 // Source identifies which receiver provided a traffic update.
 type Source string
 
@@ -8,3 +10,111 @@ const (
 	Source1090    Source = "1090"
 	Source978     Source = "978"
 )
+
+// KindUAT labels UAT downlinks in receiver rate stats.
+const KindUAT = "UAT"
+
+// Link identifies how a report reached us. The same aircraft can be heard
+// directly, as an ADS-R rebroadcast and as a TIS-B track at once.
+type Link byte
+
+const (
+	// LinkUnknown is used when the source doesn't say (SBS, Mode S
+	// surveillance replies); it ranks with direct ADS-B since those are local
+	// receptions.
+	LinkUnknown Link = iota
+	LinkADSB
+	LinkADSR
+	LinkTISB
+)
+
+func (l Link) String() string {
+	switch l {
+	case LinkADSB:
+		return "adsb"
+	case LinkADSR:
+		return "adsr"
+	case LinkTISB:
+		return "tisb"
+	default:
+		return ""
+	}
+}
+
+// rank orders links for duplicate resolution; lower is preferred.
+func (l Link) rank() int {
+	switch l {
+	case LinkADSR:
+		return 1
+	case LinkTISB:
+		return 2
+	default:
+		return 0
+	}
+}
+
+// linkFromDF maps a 1090 downlink format and CA/CF field to a Link.
+func linkFromDF(df, ca int) Link {
+	switch {
+	case df == 17:
+		return LinkADSB
+	case df != 18:
+		return LinkUnknown
+	}
+	switch ca {
+	case 0, 1:
+		return LinkADSB
+	case 2, 3, 5:
+		return LinkTISB
+	case 6:
+		return LinkADSR
+	default:
+		return LinkUnknown
+	}
+}
+
+// linkFromName maps a readsb/dump978 address type name ("adsb_icao",
+// "adsr_other", "tisb_trackfile", ...) to a Link.
+func linkFromName(name string) Link {
+	switch {
+	case strings.HasPrefix(name, "adsb_"):
+		return LinkADSB
+	case strings.HasPrefix(name, "adsr_"):
+		return LinkADSR
+	case strings.HasPrefix(name, "tisb_"):
+		return LinkTISB
+	default:
+		return LinkUnknown
+	}
+}
+
+// addrTypeFromName maps a readsb/dump978 address type name to the GDL90
+// address type, matching addrType for the 1090 NDJSON path.
+func addrTypeFromName(name string) byte {
+	switch name {
+	case "adsb_other", "adsr_other":
+		return 1
+	case "tisb_icao", "adsr_icao":
+		return 2
+	case "tisb_trackfile", "tisb_other":
+		return 3
+	case "vehicle":
+		return 4
+	case "fixed_beacon":
+		return 5
+	default:
+		// adsb_icao, adsb_icao_nt, mlat, mode_s, adsc, unknown.
+		return 0
+	}
+}
+
+// addrNamespace groups GDL90 address types whose 24-bit addresses share one
+// space. ICAO-addressed reports (ADS-B and TIS-B with an ICAO address) fuse;
+// self-assigned, track-file, vehicle and beacon IDs each get their own space
+// so they never collide with a real ICAO address.
+func addrNamespace(addrType byte) byte {
+	if addrType == 2 {
+		return 0
+	}
+	return addrType
+}
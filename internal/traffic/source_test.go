@@ -0,0 +1,34 @@
+package traffic
+
+import "testing"
+
+func TestLinkAndAddrTypeMapping(t *testing.T) {
+	for _, tc := range []struct {
+		df, ca int
+		link   Link
+		at     byte
+	}{
+		{17, 5, LinkADSB, 0},
+		{18, 0, LinkADSB, 0},
+		{18, 1, LinkADSB, 1},
+		{18, 2, LinkTISB, 2},
+		{18, 5, LinkTISB, 3},
+		{18, 6, LinkADSR, 2},
+		{11, 5, LinkUnknown, 0},
+	} {
+		if got := linkFromDF(tc.df, tc.ca); got != tc.link {
+			t.Fatalf("linkFromDF(%d,%d)=%v want %v", tc.df, tc.ca, got, tc.link)
+		}
+		if got := addrType(tc.df, tc.ca); got != tc.at {
+			t.Fatalf("addrType(%d,%d)=%d want %d", tc.df, tc.ca, got, tc.at)
+		}
+	}
+	for name, want := range map[string]Link{"adsb_icao": LinkADSB, "adsr_icao": LinkADSR, "tisb_trackfile": LinkTISB, "mlat": LinkUnknown} {
+		if got := linkFromName(name); got != want {
+			t.Fatalf("linkFromName(%q)=%v want %v", name, got, want)
+		}
+	}
+	if addrNamespace(2) != addrNamespace(0) || addrNamespace(3) == addrNamespace(0) || addrNamespace(1) == addrNamespace(0) {
+		t.Fatalf("unexpected address namespaces")
+	}
+}
@@ -1,7 +1,9 @@
 package traffic
 
 import (
+	"maps"
 	"sort"
+	"strings"
 	"sync"
 	"time"
 
@@ -13,6 +15,16 @@ type StoreConfig struct {
 	MaxTargets int
 	// TTL controls how long a target is kept without updates.
 	TTL time.Duration
+	// MaxSpeedKt, MaxVvelFpm and MaxRangeNm gate new positions: a fix implying
+	// a faster move or climb than these from the last accepted fix, or a first
+	// fix farther than MaxRangeNm from the receiver (see SetReference), is
+	// quarantined until a second consistent fix confirms it.
+	MaxSpeedKt float64
+	MaxVvelFpm float64
+	MaxRangeNm float64
+	// PositionHold is how long a position from one input is preferred over a
+	// lower-quality (NACp, then NIC) position from a different input.
+	PositionHold time.Duration
 }
 
 type Store struct {
@@ -20,7 +32,26 @@ type Store struct {
 
 	cfg StoreConfig
 
-	targets map[[3]byte]target
+	targets map[targetKey]target
+
+	// Plausibility gating state.
+	refLat, refLon float64
+	hasRef         bool
+	quarantine     map[targetKey]quarantine
+	rejects        map[string]*RejectStats
+
+	stats    receiverStats
+	registry *Registry
+
+	events   []EmergencyEvent
+	eventSeq uint64
+}
+
+// targetKey keys targets by address namespace as well as address, so an
+// anonymous or TIS-B track-file ID never merges with a real ICAO address.
+type targetKey struct {
+	namespace byte
+	icao      [3]byte
 }
 
 type target struct {
@@ -29,10 +60,32 @@ type target struct {
 	hasPosition bool
 	squawk      string
 	source      Source
+
+	// inputs records when each ingest input last reported this target.
+	inputs   map[string]time.Time
+	posInput string
+	posAt    time.Time
+	posLink  Link
+
+	firstSeen time.Time
+	messages  uint64
+	rate      rateCounter
+	rssi      float64
+	rssiSum   float64
+	rssiCount uint64
+
+	// emergency is the last reported emergency/priority status;
+	// emergencyCode is the effective GDL90 code including the squawk.
+	emergency     byte
+	emergencyCode byte
+
+	// registry is filled in on snapshot from the aircraft database.
+	registry    RegistryEntry
+	hasRegistry bool
 }
 
 type evictionCandidate struct {
-	ico  [3]byte
+	key  targetKey
 	seen time.Time
 }
 
@@ -44,6 +97,34 @@ type TargetSnapshot struct {
 	SeenAt        time.Time
 	Squawk        string
 	Source        Source
+	// Inputs lists the ingest inputs that reported this target within the
+	// store TTL, sorted by name.
+	Inputs []string
+	// Link is how the current position was received.
+	Link Link
+
+	FirstSeen time.Time
+	// Messages counts updates received for this target; MsgRate is per
+	// second over the last few seconds.
+	Messages uint64
+	MsgRate  float64
+	// RSSI and RSSIAvg are the latest and mean signal level in dBFS, valid
+	// when HasRSSI is set.
+	RSSI    float64
+	RSSIAvg float64
+	HasRSSI bool
+
+	// Registry is the aircraft database entry for the address, valid when
+	// HasRegistry is set.
+	Registry    RegistryEntry
+	HasRegistry bool
+
+	// Bearingless marks a position-less target placed at ownship by
+	// MarkBearingless, with its RSSI range estimate and altitude relative to
+	// ownship.
+	Bearingless bool
+	EstRangeNm  float64
+	RelAltFeet  int
 }
 
 func hasValidPosition(t gdl90.Traffic) bool {
@@ -60,6 +141,14 @@ func icaoLess(a, b [3]byte) bool {
 	return a[2] < b[2]
 }
 
+// trafficLess orders by address, then address type for IDs that share bits.
+func trafficLess(a, b gdl90.Traffic) bool {
+	if a.ICAO != b.ICAO {
+		return icaoLess(a.ICAO, b.ICAO)
+	}
+	return a.AddrType < b.AddrType
+}
+
 func (s *Store) evictIfNeededLocked() {
 	if s == nil {
 		return
@@ -75,7 +164,7 @@ func (s *Store) evictIfNeededLocked() {
 	// Collect and evict oldest in one pass.
 	cands := make([]evictionCandidate, 0, len(s.targets))
 	for k, v := range s.targets {
-		cands = append(cands, evictionCandidate{ico: k, seen: v.seenAt})
+		cands = append(cands, evictionCandidate{key: k, seen: v.seenAt})
 	}
 	sort.Slice(cands, func(i, j int) bool {
 		return cands[i].seen.Before(cands[j].seen)
@@ -84,7 +173,7 @@ func (s *Store) evictIfNeededLocked() {
 		over = len(cands)
 	}
 	for i := 0; i < over; i++ {
-		delete(s.targets, cands[i].ico)
+		delete(s.targets, cands[i].key)
 	}
 }
 
@@ -95,9 +184,21 @@ func NewStore(cfg StoreConfig) *Store {
 	if cfg.TTL <= 0 {
 		cfg.TTL = 30 * time.Second
 	}
+	if cfg.PositionHold <= 0 {
+		cfg.PositionHold = 3 * time.Second
+	}
+	if cfg.MaxSpeedKt <= 0 {
+		cfg.MaxSpeedKt = 1200
+	}
+	if cfg.MaxVvelFpm <= 0 {
+		cfg.MaxVvelFpm = 20000
+	}
+	if cfg.MaxRangeNm <= 0 {
+		cfg.MaxRangeNm = 400
+	}
 	return &Store{
 		cfg:     cfg,
-		targets: make(map[[3]byte]target),
+		targets: make(map[targetKey]target),
 	}
 }
 
@@ -141,18 +242,57 @@ func (s *Store) Apply(nowUTC time.Time, update TrafficUpdate) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	tgt, exists := s.targets[upd.ICAO]
+	key := targetKey{namespace: addrNamespace(upd.AddrType), icao: upd.ICAO}
+	tgt, exists := s.targets[key]
 	if !exists && upd.Traffic == nil && upd.Meta.Empty() {
 		return
 	}
 	hadPrevious := exists
 	if !exists {
-		tgt = target{traffic: gdl90.Traffic{ICAO: upd.ICAO}}
+		tgt = target{traffic: gdl90.Traffic{ICAO: upd.ICAO}, firstSeen: nowUTC}
 		exists = true
 	}
+	s.stats.countMessage(nowUTC, upd.Kind)
+	tgt.messages++
+	tgt.rate.add(nowUTC)
+	if upd.HasRSSI {
+		tgt.rssi = upd.RSSI
+		tgt.rssiSum += upd.RSSI
+		tgt.rssiCount++
+	}
 	prevTraffic := tgt.traffic
 	updated := false
 
+	held := hadPrevious && s.holdPositionLocked(nowUTC, tgt, upd)
+	if !held && upd.Traffic != nil && !s.checkPositionLocked(nowUTC, key, tgt, upd) {
+		// Implausible fix: quarantined, so keep only identity fields.
+		held = true
+	}
+	if held {
+		// A better report of this target is still fresh, or this one failed
+		// plausibility; keep the current position and kinematics, taking only
+		// identity fields from this update.
+		upd.Traffic = nil
+		upd.Meta = MetadataUpdate{
+			ICAO:      upd.ICAO,
+			Tail:      upd.Meta.Tail,
+			HasTail:   upd.Meta.HasTail,
+			Squawk:    upd.Meta.Squawk,
+			HasSquawk: upd.Meta.HasSquawk,
+
+			Emergency:    upd.Meta.Emergency,
+			HasEmergency: upd.Meta.HasEmergency,
+		}
+		if upd.Meta.Empty() {
+			if hadPrevious {
+				tgt.inputs = withInput(tgt.inputs, upd.Input, nowUTC)
+				s.targets[key] = tgt
+			}
+			return
+		}
+		upd.Source = SourceUnknown
+	}
+
 	if upd.Traffic != nil {
 		traffic := *upd.Traffic
 		if hadPrevious {
@@ -161,6 +301,12 @@ func (s *Store) Apply(nowUTC time.Time, update TrafficUpdate) {
 		tgt.traffic = traffic
 		tgt.seenAt = nowUTC
 		tgt.hasPosition = hasValidPosition(traffic)
+		tgt.posInput = upd.Input
+		tgt.posAt = nowUTC
+		tgt.posLink = upd.Link
+		if s.hasRef && tgt.hasPosition {
+			s.stats.observePosition(nowUTC, s.refLat, s.refLon, traffic.LatDeg, traffic.LonDeg, traffic.AltFeet)
+		}
 		updated = true
 	}
 
@@ -173,12 +319,18 @@ func (s *Store) Apply(nowUTC time.Time, update TrafficUpdate) {
 	if upd.Meta.HasSquawk {
 		tgt.squawk = upd.Meta.Squawk
 	}
-	if upd.Source != SourceUnknown {
+	if upd.Meta.HasEmergency {
+		tgt.emergency = upd.Meta.Emergency
+	}
+	// Source follows the report that owns the position.
+	if upd.Source != SourceUnknown && (upd.Traffic != nil || tgt.source == SourceUnknown) {
 		tgt.source = upd.Source
 	}
+	tgt.inputs = withInput(tgt.inputs, upd.Input, nowUTC)
+	s.updateEmergencyLocked(nowUTC, &tgt)
 
 	if updated {
-		s.targets[upd.ICAO] = tgt
+		s.targets[key] = tgt
 	}
 
 	if upd.Traffic != nil {
@@ -202,7 +354,7 @@ func (s *Store) Snapshot(nowUTC time.Time) []gdl90.Traffic {
 		return nil
 	}
 	sort.Slice(out, func(i, j int) bool {
-		return icaoLess(out[i].ICAO, out[j].ICAO)
+		return trafficLess(out[i], out[j])
 	})
 	return out
 }
@@ -216,20 +368,75 @@ func (s *Store) SnapshotDetailed(nowUTC time.Time) []TargetSnapshot {
 	}
 	out := make([]TargetSnapshot, 0, len(cloned))
 	for _, v := range cloned {
-		out = append(out, TargetSnapshot{
+		ts := TargetSnapshot{
 			Traffic:       v.traffic,
 			PositionValid: v.hasPosition,
 			SeenAt:        v.seenAt,
 			Squawk:        v.squawk,
 			Source:        v.source,
-		})
+			Inputs:        inputsSince(v.inputs, nowUTC.Add(-s.cfg.TTL)),
+			Link:          v.posLink,
+			FirstSeen:     v.firstSeen,
+			Messages:      v.messages,
+			MsgRate:       v.rate.rate(nowUTC),
+			Registry:      v.registry,
+			HasRegistry:   v.hasRegistry,
+		}
+		if v.rssiCount > 0 {
+			ts.RSSI, ts.RSSIAvg, ts.HasRSSI = v.rssi, v.rssiSum/float64(v.rssiCount), true
+		}
+		out = append(out, ts)
 	}
 	sort.Slice(out, func(i, j int) bool {
-		return icaoLess(out[i].Traffic.ICAO, out[j].Traffic.ICAO)
+		return trafficLess(out[i].Traffic, out[j].Traffic)
 	})
 	return out
 }
 
+// holdPositionLocked reports whether the target's current position should be
+// kept over the update while it is younger than PositionHold: direct ADS-B
+// beats ADS-R beats TIS-B, and between different inputs on the same link the
+// better NACp/NIC wins. Metadata-only updates are held by a worse link too, so
+// TIS-B altitude doesn't overwrite direct ADS-B.
+func (s *Store) holdPositionLocked(nowUTC time.Time, tgt target, upd TrafficUpdate) bool {
+	if !tgt.hasPosition || nowUTC.Sub(tgt.posAt) >= s.cfg.PositionHold {
+		return false
+	}
+	if cur, next := tgt.posLink.rank(), upd.Link.rank(); cur != next {
+		return next > cur
+	}
+	if upd.Traffic == nil || upd.Input == "" || tgt.posInput == "" || tgt.posInput == upd.Input {
+		return false
+	}
+	return positionQuality(tgt.traffic) > positionQuality(*upd.Traffic)
+}
+
+func positionQuality(t gdl90.Traffic) int {
+	return int(t.NACp)<<4 | int(t.NIC)
+}
+
+func withInput(inputs map[string]time.Time, name string, at time.Time) map[string]time.Time {
+	if name == "" {
+		return inputs
+	}
+	if inputs == nil {
+		inputs = make(map[string]time.Time, 1)
+	}
+	inputs[name] = at
+	return inputs
+}
+
+func inputsSince(inputs map[string]time.Time, cutoff time.Time) []string {
+	var out []string
+	for name, at := range inputs {
+		if !at.Before(cutoff) {
+			out = append(out, name)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
 func (s *Store) carryForwardMetadata(dst *gdl90.Traffic, prev gdl90.Traffic, meta MetadataUpdate) {
 	if dst == nil {
 		return
@@ -294,11 +501,51 @@ func (s *Store) snapshotTargets(nowUTC time.Time) []target {
 				delete(s.targets, k)
 			}
 		}
+		for k, v := range s.quarantine {
+			if nowUTC.Sub(v.at) >= quarantineTTL {
+				delete(s.quarantine, k)
+			}
+		}
 	}
 	cloned := make([]target, 0, len(s.targets))
 	for _, v := range s.targets {
+		v.inputs = maps.Clone(v.inputs)
 		cloned = append(cloned, v)
 	}
+	registry := s.registry
 	s.mu.Unlock()
+
+	if registry != nil {
+		for i := range cloned {
+			enrichFromRegistry(registry, &cloned[i])
+		}
+	}
 	return cloned
 }
+
+// SetRegistry swaps the aircraft database used to enrich snapshots; nil
+// disables enrichment.
+func (s *Store) SetRegistry(db *Registry) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	s.registry = db
+	s.mu.Unlock()
+}
+
+// enrichFromRegistry attaches the database entry for real ICAO addresses and
+// uses the registration as the GDL90 tail when no callsign was received.
+func enrichFromRegistry(db *Registry, v *target) {
+	if addrNamespace(v.traffic.AddrType) != addrNamespace(0) {
+		return
+	}
+	e, ok := db.Lookup(v.traffic.ICAO)
+	if !ok {
+		return
+	}
+	v.registry, v.hasRegistry = e, true
+	if strings.TrimSpace(v.traffic.Tail) == "" && e.Registration != "" {
+		v.traffic.Tail = e.Registration
+	}
+}
@@ -118,3 +118,92 @@ func TestStoreSnapshotDetailedIncludesSquawkAndSource(t *testing.T) {
 		t.Fatalf("expected 1090 source, got %q", snap[0].Source)
 	}
 }
+
+func TestStoreApplyArbitratesPositionAcrossInputs(t *testing.T) {
+	store := NewStore(StoreConfig{TTL: time.Minute, PositionHold: 3 * time.Second})
+	icao, _ := gdl90.ParseICAOHex("ABC123")
+	now := time.Now().UTC()
+
+	pos := func(lat float64, nacp byte) *gdl90.Traffic {
+		return &gdl90.Traffic{ICAO: icao, LatDeg: lat, LonDeg: -122.0, NIC: 8, NACp: nacp}
+	}
+	store.Apply(now, TrafficUpdate{ICAO: icao, Traffic: pos(45.0, 10), Input: "top"})
+	// Worse input within the hold: position kept, input still recorded.
+	store.Apply(now.Add(time.Second), TrafficUpdate{ICAO: icao, Traffic: pos(45.001, 8), Input: "belly"})
+
+	snap := store.SnapshotDetailed(now.Add(time.Second))
+	if len(snap) != 1 || snap[0].Traffic.LatDeg != 45.0 {
+		t.Fatalf("expected top-antenna position to be held, got %+v", snap)
+	}
+	if len(snap[0].Inputs) != 2 || snap[0].Inputs[0] != "belly" || snap[0].Inputs[1] != "top" {
+		t.Fatalf("inputs=%v", snap[0].Inputs)
+	}
+
+	// Once the better input goes quiet, the other one takes over.
+	store.Apply(now.Add(4*time.Second), TrafficUpdate{ICAO: icao, Traffic: pos(45.002, 8), Input: "belly"})
+	snap = store.SnapshotDetailed(now.Add(4 * time.Second))
+	if snap[0].Traffic.LatDeg != 45.002 {
+		t.Fatalf("expected belly position after hold expired, got %v", snap[0].Traffic.LatDeg)
+	}
+}
+
+func TestStoreApplyPrefersDirectOverADSROverTISB(t *testing.T) {
+	store := NewStore(StoreConfig{TTL: time.Minute, PositionHold: 3 * time.Second})
+	icao, _ := gdl90.ParseICAOHex("ABC123")
+	now := time.Now().UTC()
+
+	report := func(lat float64, addrType byte, link Link, src Source, alt int) TrafficUpdate {
+		tr := gdl90.Traffic{ICAO: icao, AddrType: addrType, LatDeg: lat, LonDeg: -122.0, AltFeet: alt, NIC: 8, NACp: 9}
+		u := NewTrafficUpdateFromTraffic(tr)
+		u.Link, u.Source = link, src
+		return u
+	}
+
+	store.Apply(now, report(45.0, 2, LinkTISB, Source978, 3000))
+	store.Apply(now.Add(100*time.Millisecond), report(45.001, 0, LinkADSR, Source978, 3100))
+	store.Apply(now.Add(200*time.Millisecond), report(45.002, 0, LinkADSB, Source1090, 3200))
+	// Late TIS-B and ADS-R reports of the same aircraft are duplicates.
+	store.Apply(now.Add(300*time.Millisecond), report(45.003, 2, LinkTISB, Source978, 3300))
+	store.Apply(now.Add(400*time.Millisecond), report(45.004, 0, LinkADSR, Source978, 3400))
+
+	snap := store.SnapshotDetailed(now.Add(time.Second))
+	if len(snap) != 1 {
+		t.Fatalf("expected duplicates fused into 1 target, got %d", len(snap))
+	}
+	got := snap[0]
+	if got.Traffic.LatDeg != 45.002 || got.Traffic.AltFeet != 3200 || got.Link != LinkADSB || got.Source != Source1090 {
+		t.Fatalf("expected direct ADS-B to win, got %+v link=%v source=%v", got.Traffic, got.Link, got.Source)
+	}
+
+	// Once direct reception is stale, ADS-R takes over.
+	store.Apply(now.Add(4*time.Second), report(45.005, 0, LinkADSR, Source978, 3500))
+	snap = store.SnapshotDetailed(now.Add(4 * time.Second))
+	if snap[0].Traffic.LatDeg != 45.005 || snap[0].Link != LinkADSR {
+		t.Fatalf("expected ADS-R after direct went stale, got %+v", snap[0])
+	}
+}
+
+func TestStoreApplyKeepsAnonymousIDsApartFromICAO(t *testing.T) {
+	store := NewStore(StoreConfig{TTL: time.Minute})
+	icao, _ := gdl90.ParseICAOHex("ABC123")
+	now := time.Now().UTC()
+
+	store.Upsert(now, gdl90.Traffic{ICAO: icao, AddrType: 0, LatDeg: 45.0, LonDeg: -122.0})
+	store.Upsert(now, gdl90.Traffic{ICAO: icao, AddrType: 3, LatDeg: 46.0, LonDeg: -121.0})
+	store.Upsert(now, gdl90.Traffic{ICAO: icao, AddrType: 1, LatDeg: 47.0, LonDeg: -120.0})
+	// Metadata for the track file must land on the track file.
+	store.Apply(now, TrafficUpdate{ICAO: icao, AddrType: 3, Meta: MetadataUpdate{Tail: "TRK", HasTail: true}})
+
+	snap := store.Snapshot(now)
+	if len(snap) != 3 {
+		t.Fatalf("expected 3 distinct targets, got %d", len(snap))
+	}
+	for i, want := range []byte{0, 1, 3} {
+		if snap[i].AddrType != want {
+			t.Fatalf("snap[%d].AddrType=%d want %d", i, snap[i].AddrType, want)
+		}
+	}
+	if snap[0].Tail != "" || snap[2].Tail != "TRK" {
+		t.Fatalf("tail landed on wrong target: %q / %q", snap[0].Tail, snap[2].Tail)
+	}
+}
@@ -10,6 +10,21 @@ type TrafficUpdate struct {
 	Traffic *gdl90.Traffic
 	Meta    MetadataUpdate
 	Source  Source
+	// AddrType is the GDL90 address type; updates carrying Traffic use
+	// Traffic.AddrType. It keeps anonymous and TIS-B track-file IDs apart from
+	// real ICAO addresses with the same bits.
+	AddrType byte
+	// Link records how the report reached us (direct, ADS-R or TIS-B).
+	Link Link
+	// Kind labels the message for receiver rate stats ("DF17", "UAT", ...).
+	Kind string
+	// RSSI is the received signal level in dB (dBFS for 1090), when HasRSSI
+	// is set.
+	RSSI    float64
+	HasRSSI bool
+	// Input names the configured ingest input (e.g. "adsb1090" or a named
+	// entry under adsb1090.inputs) when several feed the same band.
+	Input string
 }
 
 // validICAO returns the update's ICAO or false if unavailable.
@@ -38,6 +53,7 @@ func (u TrafficUpdate) withDefaults() (TrafficUpdate, bool) {
 	}
 	if u.Traffic != nil {
 		u.Traffic.ICAO = icao
+		u.AddrType = u.Traffic.AddrType
 	}
 	if u.Meta.ICAO != icao {
 		u.Meta.ICAO = icao
@@ -68,5 +84,5 @@ func NewTrafficUpdateFromTraffic(t gdl90.Traffic) TrafficUpdate {
 	meta.OnGround = t.OnGround
 	meta.HasOnGround = true
 
-	return TrafficUpdate{ICAO: t.ICAO, Traffic: &t, Meta: meta, Source: SourceUnknown}
+	return TrafficUpdate{ICAO: t.ICAO, Traffic: &t, Meta: meta, Source: SourceUnknown, AddrType: t.AddrType}
 }
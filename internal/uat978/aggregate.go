@@ -2,6 +2,8 @@ package uat978
 
 import (
 	"fmt"
+	"log"
+	"math"
 	"sort"
 	"sync"
 	"time"
@@ -11,8 +13,19 @@ type AggregatorConfig struct {
 	MaxTowers int
 	MaxText   int
 	MaxRows   int
+
+	// TowerDB optionally names and annotates decoded towers.
+	TowerDB *TowerDB
 }
 
+const (
+	// trendMinutes is how many per-minute signal averages are kept per tower.
+	trendMinutes = 10
+	// towerActiveWindow is how recently a tower must have been heard to be
+	// considered for the service volume estimate.
+	towerActiveWindow = 5 * time.Minute
+)
+
 type Aggregator struct {
 	mu       sync.Mutex
 	towers   map[string]*towerStats
@@ -20,7 +33,12 @@ type Aggregator struct {
 	textRing []TextReport
 	textNext int
 	textSize int
-	cfg      AggregatorConfig
+	winds    map[string]WindsStation
+	// unlocated remembers stations already logged as missing from
+	// windsStationLocations.
+	unlocated map[string]bool
+	grids     map[uint32]map[gridKey]GridBlock
+	cfg       AggregatorConfig
 }
 
 type bucket struct {
@@ -30,16 +48,30 @@ type bucket struct {
 	ssN   uint32
 }
 
+type minuteBucket struct {
+	minute int64
+	sumDb  float64
+	n      uint32
+}
+
 type towerStats struct {
 	key string
 	lat float64
 	lon float64
 
+	station *GroundStation
+	siteID  byte
+
 	signalNowDb float64
 	signalMaxDb float64
+	firstSeen   time.Time
 	lastSeen    time.Time
 	total       uint64
+	fisbTotal   uint64
+	tisbTotal   uint64
+	lastTISB    time.Time
 	buckets     [60]bucket
+	minutes     [trendMinutes]minuteBucket
 }
 
 type productStats struct {
@@ -68,6 +100,40 @@ type TowerSnapshot struct {
 	MessagesTotal     uint64  `json:"messages_total"`
 	LastSeenUTC       string  `json:"last_seen_utc"`
 	HasSignalStrength bool    `json:"has_signal_strength"`
+
+	// Ground-station annotations (when a tower database is loaded).
+	ID              string  `json:"id,omitempty"`
+	Name            string  `json:"name,omitempty"`
+	State           string  `json:"state,omitempty"`
+	ServiceVolumeNm float64 `json:"service_volume_nm,omitempty"`
+
+	// Session history.
+	FirstSeenUTC        string   `json:"first_seen_utc,omitempty"`
+	TISBSiteID          byte     `json:"tisb_site_id,omitempty"`
+	FISBMessages        uint64   `json:"fisb_messages"`
+	TISBMessages        uint64   `json:"tisb_messages"`
+	LastTISBUTC         string   `json:"last_tisb_utc,omitempty"`
+	SignalTrend         string   `json:"signal_trend,omitempty"`
+	SignalTrendDbPerMin float64  `json:"signal_trend_db_per_min,omitempty"`
+	DistanceNm          *float64 `json:"distance_nm,omitempty"`
+	Active              bool     `json:"active"`
+}
+
+// ServiceVolumeEstimate identifies the tower whose service volume we are most
+// likely in. Method is "position" when derived from ownship position and
+// "signal" when falling back to the strongest tower heard.
+type ServiceVolumeEstimate struct {
+	Key        string   `json:"key"`
+	Name       string   `json:"name,omitempty"`
+	Method     string   `json:"method"`
+	DistanceNm *float64 `json:"distance_nm,omitempty"`
+}
+
+// TowersReport is the session-wide tower view served by /api/towers.
+type TowersReport struct {
+	Towers        []TowerSnapshot        `json:"towers"`
+	ServiceVolume *ServiceVolumeEstimate `json:"service_volume,omitempty"`
+	DatabaseSize  int                    `json:"database_size"`
 }
 
 type ProductSnapshot struct {
@@ -97,10 +163,13 @@ func NewAggregator(cfg AggregatorConfig) *Aggregator {
 		cfg.MaxRows = 50
 	}
 	return &Aggregator{
-		towers:   make(map[string]*towerStats),
-		products: make(map[uint32]*productStats),
-		textRing: make([]TextReport, cfg.MaxText),
-		cfg:      cfg,
+		towers:    make(map[string]*towerStats),
+		products:  make(map[uint32]*productStats),
+		textRing:  make([]TextReport, cfg.MaxText),
+		winds:     make(map[string]WindsStation),
+		unlocated: make(map[string]bool),
+		grids:     make(map[uint32]map[gridKey]GridBlock),
+		cfg:       cfg,
 	}
 }
 
@@ -122,17 +191,30 @@ func (a *Aggregator) Add(nowUTC time.Time, decoded DecodedUplink, signalDb float
 	tw := a.towers[key]
 	if tw == nil {
 		if len(a.towers) >= a.cfg.MaxTowers {
-			// Simple eviction: drop an arbitrary entry.
-			for k := range a.towers {
-				delete(a.towers, k)
-				break
+			// Keep session history for as many towers as possible: drop the
+			// one heard least recently.
+			var oldest *towerStats
+			for _, t := range a.towers {
+				if oldest == nil || t.lastSeen.Before(oldest.lastSeen) {
+					oldest = t
+				}
 			}
+			if oldest != nil {
+				delete(a.towers, oldest.key)
+			}
+		}
+		tw = &towerStats{key: key, lat: decoded.TowerLatDeg, lon: decoded.TowerLonDeg, signalMaxDb: -999, firstSeen: nowUTC}
+		if gs, ok := a.cfg.TowerDB.Lookup(decoded.TowerLatDeg, decoded.TowerLonDeg); ok {
+			tw.station = &gs
 		}
-		tw = &towerStats{key: key, lat: decoded.TowerLatDeg, lon: decoded.TowerLonDeg, signalMaxDb: -999}
 		a.towers[key] = tw
 	}
 	tw.lastSeen = nowUTC
 	tw.total++
+	tw.siteID = decoded.TISBSiteID
+	if len(decoded.ProductIDs) > 0 {
+		tw.fisbTotal++
+	}
 	if hasSignal {
 		tw.signalNowDb = signalDb
 		if signalDb > tw.signalMaxDb {
@@ -148,6 +230,13 @@ func (a *Aggregator) Add(nowUTC time.Time, decoded DecodedUplink, signalDb float
 	if hasSignal {
 		b.sumDb += signalDb
 		b.ssN++
+		minute := sec / 60
+		mb := &tw.minutes[int(minute%trendMinutes)]
+		if mb.minute != minute {
+			*mb = minuteBucket{minute: minute}
+		}
+		mb.sumDb += signalDb
+		mb.n++
 	}
 
 	// Product stats.
@@ -166,6 +255,26 @@ func (a *Aggregator) Add(nowUTC time.Time, decoded DecodedUplink, signalDb float
 		pb.count++
 	}
 
+	// Winds aloft: keep the latest table per station.
+	for _, msg := range decoded.WindsReports {
+		for _, st := range ParseWindsReport(msg, nowUTC) {
+			locateWindsStation(&st)
+			if st.LatDeg == nil && !a.unlocated[st.Station] {
+				a.unlocated[st.Station] = true
+				log.Printf("uat978 winds station %s has no known position; left out of wind estimates", st.Station)
+			}
+			a.winds[st.Station] = st
+		}
+	}
+
+	// Gridded products: latest bins per block; cleared blocks are dropped.
+	for _, b := range decoded.GridBlocks {
+		a.addGridBlockLocked(nowUTC, b)
+	}
+	for _, b := range decoded.EmptyGridBlocks {
+		a.clearGridBlockLocked(b)
+	}
+
 	// Text reports.
 	for _, line := range decoded.TextReports {
 		if line == "" {
@@ -184,57 +293,241 @@ func (a *Aggregator) Add(nowUTC time.Time, decoded DecodedUplink, signalDb float
 	}
 }
 
-func (a *Aggregator) Snapshot(nowUTC time.Time) (towers []TowerSnapshot, weather WeatherSnapshot) {
+// AddTISB attributes a TIS-B downlink report to the tower advertising the
+// given TIS-B site ID. When several towers share the ID (site IDs are only 4
+// bits), the most recently heard one wins.
+func (a *Aggregator) AddTISB(nowUTC time.Time, siteID byte) {
+	if a == nil || siteID == 0 {
+		return
+	}
+	if nowUTC.IsZero() {
+		nowUTC = time.Now().UTC()
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	var best *towerStats
+	for _, tw := range a.towers {
+		if tw.siteID != siteID {
+			continue
+		}
+		if best == nil || tw.lastSeen.After(best.lastSeen) {
+			best = tw
+		}
+	}
+	if best == nil {
+		return
+	}
+	best.tisbTotal++
+	best.lastTISB = nowUTC
+}
+
+// WindsSnapshot returns the latest winds aloft table per station, sorted by
+// station ID. Tables older than windsMaxAge are dropped.
+func (a *Aggregator) WindsSnapshot(nowUTC time.Time) []WindsStation {
 	if a == nil {
-		return nil, WeatherSnapshot{}
+		return nil
 	}
 	if nowUTC.IsZero() {
 		nowUTC = time.Now().UTC()
 	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	out := make([]WindsStation, 0, len(a.winds))
+	for k, st := range a.winds {
+		if nowUTC.Sub(st.received) > windsMaxAge {
+			delete(a.winds, k)
+			continue
+		}
+		out = append(out, st)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Station < out[j].Station })
+	return out
+}
+
+func (tw *towerStats) snapshot(nowUTC time.Time) TowerSnapshot {
 	sec := nowUTC.Unix()
 	minSec := sec - 59
+	var cnt uint64
+	var sumDb float64
+	var ssN uint64
+	for i := range tw.buckets {
+		b := tw.buckets[i]
+		if b.sec < minSec {
+			continue
+		}
+		cnt += uint64(b.count)
+		sumDb += b.sumDb
+		ssN += uint64(b.ssN)
+	}
+	avg := 0.0
+	hasSignal := ssN > 0
+	if hasSignal {
+		avg = sumDb / float64(ssN)
+	}
+	out := TowerSnapshot{
+		Key:               tw.key,
+		LatDeg:            tw.lat,
+		LonDeg:            tw.lon,
+		SignalNowDb:       tw.signalNowDb,
+		SignalAvg1MinDb:   avg,
+		SignalMaxDb:       tw.signalMaxDb,
+		MessagesLastMin:   cnt,
+		MessagesTotal:     tw.total,
+		HasSignalStrength: hasSignal,
+		TISBSiteID:        tw.siteID,
+		FISBMessages:      tw.fisbTotal,
+		TISBMessages:      tw.tisbTotal,
+		Active:            nowUTC.Sub(tw.lastSeen) <= towerActiveWindow,
+	}
+	if !tw.lastSeen.IsZero() {
+		out.LastSeenUTC = tw.lastSeen.UTC().Format(time.RFC3339Nano)
+	}
+	if !tw.firstSeen.IsZero() {
+		out.FirstSeenUTC = tw.firstSeen.UTC().Format(time.RFC3339Nano)
+	}
+	if !tw.lastTISB.IsZero() {
+		out.LastTISBUTC = tw.lastTISB.UTC().Format(time.RFC3339Nano)
+	}
+	if gs := tw.station; gs != nil {
+		out.ID = gs.ID
+		out.Name = gs.Name
+		out.State = gs.State
+		out.ServiceVolumeNm = gs.ServiceVolumeNm
+	}
+	out.SignalTrend, out.SignalTrendDbPerMin = tw.signalTrend(sec / 60)
+	return out
+}
+
+// signalTrend fits a line through the per-minute signal averages of the last
+// trendMinutes minutes. It needs at least three minutes of signal data.
+func (tw *towerStats) signalTrend(nowMinute int64) (string, float64) {
+	var n, sumX, sumY, sumXY, sumXX float64
+	for _, mb := range tw.minutes {
+		if mb.n == 0 || mb.minute > nowMinute || nowMinute-mb.minute >= trendMinutes {
+			continue
+		}
+		x := float64(mb.minute - nowMinute)
+		y := mb.sumDb / float64(mb.n)
+		n++
+		sumX += x
+		sumY += y
+		sumXY += x * y
+		sumXX += x * x
+	}
+	if n < 3 {
+		return "", 0
+	}
+	den := n*sumXX - sumX*sumX
+	if den == 0 {
+		return "", 0
+	}
+	slope := (n*sumXY - sumX*sumY) / den
+	slope = math.Round(slope*100) / 100
+	switch {
+	case slope > 0.5:
+		return "rising", slope
+	case slope < -0.5:
+		return "falling", slope
+	default:
+		return "steady", slope
+	}
+}
+
+// TowerReport returns every tower heard this session plus an estimate of
+// which tower's service volume we are in. When ownValid is false the estimate
+// falls back to the strongest recently heard tower.
+func (a *Aggregator) TowerReport(nowUTC time.Time, ownLat, ownLon float64, ownValid bool) TowersReport {
+	if a == nil {
+		return TowersReport{}
+	}
+	if nowUTC.IsZero() {
+		nowUTC = time.Now().UTC()
+	}
 
 	a.mu.Lock()
 	defer a.mu.Unlock()
 
-	towers = make([]TowerSnapshot, 0, len(a.towers))
+	rep := TowersReport{
+		Towers:       make([]TowerSnapshot, 0, len(a.towers)),
+		DatabaseSize: a.cfg.TowerDB.Len(),
+	}
 	for _, tw := range a.towers {
-		var cnt uint64
-		var sumDb float64
-		var ssN uint64
-		for i := range tw.buckets {
-			b := tw.buckets[i]
-			if b.sec < minSec {
+		ts := tw.snapshot(nowUTC)
+		if ownValid {
+			d := distanceNm(ownLat, ownLon, tw.lat, tw.lon)
+			ts.DistanceNm = &d
+		}
+		rep.Towers = append(rep.Towers, ts)
+	}
+	sort.Slice(rep.Towers, func(i, j int) bool {
+		if rep.Towers[i].LastSeenUTC == rep.Towers[j].LastSeenUTC {
+			return rep.Towers[i].Key < rep.Towers[j].Key
+		}
+		return rep.Towers[i].LastSeenUTC > rep.Towers[j].LastSeenUTC
+	})
+
+	var best *TowerSnapshot
+	for i := range rep.Towers {
+		ts := &rep.Towers[i]
+		if !ts.Active {
+			continue
+		}
+		if ownValid {
+			volume := ts.ServiceVolumeNm
+			if volume <= 0 {
+				volume = DefaultServiceVolumeNm
+			}
+			if *ts.DistanceNm > volume {
 				continue
 			}
-			cnt += uint64(b.count)
-			sumDb += b.sumDb
-			ssN += uint64(b.ssN)
+			if best == nil || *ts.DistanceNm < *best.DistanceNm {
+				best = ts
+			}
+			continue
 		}
-		if cnt == 0 {
+		if !ts.HasSignalStrength {
 			continue
 		}
-		avg := 0.0
-		hasSignal := ssN > 0
-		if hasSignal {
-			avg = sumDb / float64(ssN)
+		if best == nil || ts.SignalAvg1MinDb > best.SignalAvg1MinDb {
+			best = ts
 		}
-		lastSeen := ""
-		if !tw.lastSeen.IsZero() {
-			lastSeen = tw.lastSeen.UTC().Format(time.RFC3339Nano)
+	}
+	if best != nil {
+		est := &ServiceVolumeEstimate{Key: best.Key, Name: best.Name, Method: "signal"}
+		if ownValid {
+			est.Method = "position"
+			d := *best.DistanceNm
+			est.DistanceNm = &d
 		}
-		towers = append(towers, TowerSnapshot{
-			Key:               tw.key,
-			LatDeg:            tw.lat,
-			LonDeg:            tw.lon,
-			SignalNowDb:       tw.signalNowDb,
-			SignalAvg1MinDb:   avg,
-			SignalMaxDb:       tw.signalMaxDb,
-			MessagesLastMin:   cnt,
-			MessagesTotal:     tw.total,
-			LastSeenUTC:       lastSeen,
-			HasSignalStrength: hasSignal,
-		})
+		rep.ServiceVolume = est
+	}
+	return rep
+}
+
+func (a *Aggregator) Snapshot(nowUTC time.Time) (towers []TowerSnapshot, weather WeatherSnapshot) {
+	if a == nil {
+		return nil, WeatherSnapshot{}
+	}
+	if nowUTC.IsZero() {
+		nowUTC = time.Now().UTC()
+	}
+	sec := nowUTC.Unix()
+	minSec := sec - 59
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	towers = make([]TowerSnapshot, 0, len(a.towers))
+	for _, tw := range a.towers {
+		ts := tw.snapshot(nowUTC)
+		if ts.MessagesLastMin == 0 {
+			continue
+		}
+		towers = append(towers, ts)
 	}
 	if len(towers) > 0 {
 		sort.Slice(towers, func(i, j int) bool {
@@ -316,6 +609,18 @@ func productName(id uint32) string {
 		return "NEXRAD (Regional)"
 	case 64:
 		return "NEXRAD (National)"
+	case ProductTurbulenceLow:
+		return "Turbulence (Low)"
+	case ProductTurbulenceHigh:
+		return "Turbulence (High)"
+	case ProductCloudTops:
+		return "Cloud Tops"
+	case ProductIcingLow:
+		return "Icing (Low)"
+	case ProductIcingHigh:
+		return "Icing (High)"
+	case ProductLightning:
+		return "Lightning"
 	default:
 		return ""
 	}
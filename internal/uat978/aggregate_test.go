@@ -1,6 +1,7 @@
 package uat978
 
 import (
+	"strings"
 	"testing"
 	"time"
 )
@@ -67,3 +68,52 @@ func TestAggregator_WeatherProductsAndTextRing(t *testing.T) {
 		t.Fatalf("expected oldest kept text 'B', got %q", wx.Text[2].Text)
 	}
 }
+
+func TestAggregator_TowerReportHistoryAndServiceVolume(t *testing.T) {
+	db, err := ParseTowerDB(strings.NewReader("id,name,lat,lon,range_nm\nT1,Near,37.0,-122.0,50\nT2,Far,38.0,-121.0,50\n"))
+	if err != nil {
+		t.Fatalf("ParseTowerDB: %v", err)
+	}
+	agg := NewAggregator(AggregatorConfig{TowerDB: db})
+	base := time.Unix(1_000_020, 0).UTC()
+
+	near := DecodedUplink{TowerLatDeg: 37.0, TowerLonDeg: -122.0, TISBSiteID: 3, ProductIDs: []uint32{413}}
+	far := DecodedUplink{TowerLatDeg: 38.0, TowerLonDeg: -121.0, TISBSiteID: 4}
+	// Signal on the near tower rises ~2 dB/min over five minutes.
+	for m := 0; m < 5; m++ {
+		agg.Add(base.Add(time.Duration(m)*time.Minute), near, -30+float64(2*m), true)
+		agg.Add(base.Add(time.Duration(m)*time.Minute), far, -10, true)
+	}
+	now := base.Add(4 * time.Minute)
+	agg.AddTISB(now, 3)
+	agg.AddTISB(now, 9) // unknown site: ignored
+
+	rep := agg.TowerReport(now, 37.1, -122.0, true)
+	if len(rep.Towers) != 2 || rep.DatabaseSize != 2 {
+		t.Fatalf("unexpected report: %+v", rep)
+	}
+	var got TowerSnapshot
+	for _, tw := range rep.Towers {
+		if tw.Name == "Near" {
+			got = tw
+		}
+	}
+	if got.ID != "T1" || got.FirstSeenUTC != base.Format(time.RFC3339Nano) {
+		t.Fatalf("unexpected annotation/history: %+v", got)
+	}
+	if got.FISBMessages != 5 || got.TISBMessages != 1 || got.LastTISBUTC == "" {
+		t.Fatalf("unexpected service counts: fisb=%d tisb=%d", got.FISBMessages, got.TISBMessages)
+	}
+	if got.SignalTrend != "rising" {
+		t.Fatalf("expected rising trend, got %q (%.2f dB/min)", got.SignalTrend, got.SignalTrendDbPerMin)
+	}
+	if rep.ServiceVolume == nil || rep.ServiceVolume.Name != "Near" || rep.ServiceVolume.Method != "position" {
+		t.Fatalf("unexpected service volume: %+v", rep.ServiceVolume)
+	}
+
+	// Without ownship position, fall back to the strongest tower.
+	rep = agg.TowerReport(now, 0, 0, false)
+	if rep.ServiceVolume == nil || rep.ServiceVolume.Name != "Far" || rep.ServiceVolume.Method != "signal" {
+		t.Fatalf("unexpected signal-based service volume: %+v", rep.ServiceVolume)
+	}
+}
@@ -15,11 +15,27 @@ type DecodedUplink struct {
 	TowerLatDeg float64
 	TowerLonDeg float64
 
+	// TISBSiteID is the ground station's TIS-B site ID from the uplink header.
+	// Downlink TIS-B reports carry the same ID, which lets us attribute them
+	// to a tower.
+	TISBSiteID byte
+
 	// ProductIDs from FIS-B frames.
 	ProductIDs []uint32
 
 	// TextReports are DLAC-decoded strings (product 413) split into lines.
 	TextReports []string
+
+	// WindsReports are whole FD winds aloft bulletins (product 413 "WINDS"),
+	// kept unsplit so header and station rows can be parsed together.
+	WindsReports []string
+
+	// GridBlocks are decoded global-block bins for gridded products
+	// (lightning, cloud tops, icing, turbulence).
+	GridBlocks []GridBlock
+	// EmptyGridBlocks are blocks a gridded product reports as cleared; their
+	// Values are all zero.
+	EmptyGridBlocks []GridBlock
 }
 
 func DecodeUplinkFrame(frame []byte) (DecodedUplink, bool) {
@@ -40,7 +56,7 @@ func DecodeUplinkFrame(frame []byte) (DecodedUplink, bool) {
 	}
 
 	appDataValid := (uint32(frame[6]) & 0x20) != 0
-	out := DecodedUplink{TowerLatDeg: lat, TowerLonDeg: lon}
+	out := DecodedUplink{TowerLatDeg: lat, TowerLonDeg: lon, TISBSiteID: frame[7] >> 4}
 	if !appDataValid {
 		return out, true
 	}
@@ -70,6 +86,20 @@ func DecodeUplinkFrame(frame []byte) (DecodedUplink, bool) {
 		productID := ((uint32(payload[0]) & 0x1f) << 6) | (uint32(payload[1]) >> 2)
 		out.ProductIDs = append(out.ProductIDs, productID)
 
+		if IsGridProduct(productID) {
+			fisb, ok := fisbData(payload)
+			if !ok {
+				continue
+			}
+			if b, ok := decodeGridBlock(productID, fisb); ok {
+				b.APDUHour, b.APDUMinute, b.HasTime = fisbTime(payload)
+				out.GridBlocks = append(out.GridBlocks, b)
+			} else if empty, ok := decodeEmptyGridBlocks(productID, fisb); ok {
+				out.EmptyGridBlocks = append(out.EmptyGridBlocks, empty...)
+			}
+			continue
+		}
+
 		// For text products we DLAC-decode the FIS-B payload.
 		if productID == 413 {
 			fisb, ok := fisbData(payload)
@@ -77,6 +107,9 @@ func DecodeUplinkFrame(frame []byte) (DecodedUplink, bool) {
 				continue
 			}
 			msg := dlacDecode(fisb)
+			if IsWindsReport(msg) {
+				out.WindsReports = append(out.WindsReports, msg)
+			}
 			for _, line := range splitDLACLines(msg) {
 				line = strings.TrimSpace(line)
 				if line == "" {
@@ -0,0 +1,304 @@
+package uat978
+
+import (
+	"math"
+	"strings"
+)
+
+const (
+	// DownlinkShortFrameBytes is the length of a Basic UAT ADS-B message.
+	DownlinkShortFrameBytes = 18
+	// DownlinkLongFrameBytes is the length of a Long UAT ADS-B message.
+	DownlinkLongFrameBytes = 34
+)
+
+// AddressQualifier is the 3-bit HDR address qualifier from DO-282.
+type AddressQualifier byte
+
+const (
+	AddrADSBICAO       AddressQualifier = 0
+	AddrADSBSelfAssign AddressQualifier = 1
+	AddrTISBICAO       AddressQualifier = 2
+	AddrTISBTrackFile  AddressQualifier = 3
+	AddrSurfaceVehicle AddressQualifier = 4
+	AddrFixedBeacon    AddressQualifier = 5
+	AddrADSRICAO       AddressQualifier = 6
+	AddrReserved       AddressQualifier = 7
+)
+
+// IsTISB reports whether the qualifier identifies a TIS-B target.
+func (q AddressQualifier) IsTISB() bool {
+	return q == AddrTISBICAO || q == AddrTISBTrackFile
+}
+
+// IsADSR reports whether the qualifier identifies an ADS-R rebroadcast.
+func (q AddressQualifier) IsADSR() bool {
+	return q == AddrADSRICAO
+}
+
+// DecodedDownlink is a decode of a UAT ADS-B downlink (basic or long) frame.
+//
+// Field coverage follows dump978's uat_decode: HDR, State Vector (SV), Mode
+// Status (MS) and Auxiliary State Vector (AUXSV). Has* flags mirror the
+// "valid" bits of the reference decoder so callers can tell absent fields
+// apart from zero values.
+type DecodedDownlink struct {
+	// HDR.
+	PayloadType   byte
+	AddrQualifier AddressQualifier
+	Address       uint32
+
+	// SV.
+	HasPosition bool
+	LatDeg      float64
+	LonDeg      float64
+
+	HasAltitude        bool
+	AltitudeGeometric  bool
+	AltitudeFeet       int
+	NIC                byte
+	AirGroundState     byte
+	HasGroundSpeed     bool
+	GroundSpeedKt      int
+	HasTrack           bool
+	TrackDeg           float64
+	HasVerticalRate    bool
+	VerticalRateGeo    bool
+	VerticalRateFpm    int
+	UTCCoupled         bool
+	TISBSiteID         byte
+	HasDimensions      bool
+	LengthM            float64
+	WidthM             float64
+	PositionOffset     bool
+	HasSecondaryAlt    bool
+	SecondaryAltGeo    bool
+	SecondaryAltFeet   int
+	HasModeStatus      bool
+	EmitterCategory    byte
+	Callsign           string
+	CallsignIsFlightID bool
+	EmergencyStatus    byte
+	UATVersion         byte
+	SIL                byte
+	NACp               byte
+	NACv               byte
+	NICBaro            byte
+	HasCDTI            bool
+	HasACAS            bool
+	ACASRAActive       bool
+	IdentActive        bool
+	ATCServices        bool
+	HeadingMagnetic    bool
+}
+
+const (
+	AirGroundSubsonic   byte = 0
+	AirGroundSupersonic byte = 1
+	AirGroundOnGround   byte = 2
+)
+
+// OnGround reports whether the target declared itself on the surface.
+func (d DecodedDownlink) OnGround() bool {
+	return d.AirGroundState == AirGroundOnGround
+}
+
+// base40 alphabet used to pack MS callsigns and emitter category.
+const base40Alphabet = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZ  .."
+
+// DecodeDownlinkFrame decodes a basic (18-byte) or long (34-byte) UAT ADS-B
+// downlink frame as emitted (after FEC) by dump978's raw port.
+func DecodeDownlinkFrame(frame []byte) (DecodedDownlink, bool) {
+	if len(frame) != DownlinkShortFrameBytes && len(frame) != DownlinkLongFrameBytes {
+		return DecodedDownlink{}, false
+	}
+
+	var out DecodedDownlink
+	out.PayloadType = frame[0] >> 3
+	out.AddrQualifier = AddressQualifier(frame[0] & 0x07)
+	out.Address = uint32(frame[1])<<16 | uint32(frame[2])<<8 | uint32(frame[3])
+
+	// Basic frames always carry payload type 0; anything else must be long.
+	if len(frame) == DownlinkShortFrameBytes && out.PayloadType != 0 {
+		return DecodedDownlink{}, false
+	}
+	if len(frame) == DownlinkLongFrameBytes && out.PayloadType == 0 {
+		return DecodedDownlink{}, false
+	}
+
+	// Payload types 0-10 carry an SV; 11+ are reserved (HDR only).
+	if out.PayloadType <= 10 {
+		decodeSV(&out, frame)
+	}
+	switch out.PayloadType {
+	case 1, 3:
+		decodeMS(&out, frame)
+	}
+	switch out.PayloadType {
+	case 1, 2, 5, 6:
+		decodeAUXSV(&out, frame)
+	}
+	return out, true
+}
+
+func decodeSV(out *DecodedDownlink, frame []byte) {
+	rawLat := uint32(frame[4])<<15 | uint32(frame[5])<<7 | uint32(frame[6])>>1
+	rawLon := (uint32(frame[6])&0x01)<<23 | uint32(frame[7])<<15 | uint32(frame[8])<<7 | uint32(frame[9])>>1
+	out.NIC = frame[11] & 0x0f
+	if rawLat != 0 || rawLon != 0 || out.NIC != 0 {
+		lat := float64(rawLat) * 360.0 / 16777216.0
+		lon := float64(rawLon) * 360.0 / 16777216.0
+		if lat > 90 {
+			lat -= 180
+		}
+		if lon > 180 {
+			lon -= 360
+		}
+		out.HasPosition = true
+		out.LatDeg = lat
+		out.LonDeg = lon
+	}
+
+	out.AltitudeGeometric = frame[9]&0x01 != 0
+	rawAlt := int(frame[10])<<4 | int(frame[11]&0xf0)>>4
+	if rawAlt != 0 {
+		out.HasAltitude = true
+		out.AltitudeFeet = (rawAlt-1)*25 - 1000
+	}
+
+	out.AirGroundState = (frame[12] >> 6) & 0x03
+	switch out.AirGroundState {
+	case AirGroundSubsonic, AirGroundSupersonic:
+		rawNS := int(frame[12]&0x1f)<<6 | int(frame[13]&0xfc)>>2
+		rawEW := int(frame[13]&0x03)<<9 | int(frame[14])<<1 | int(frame[15]&0x80)>>7
+		nsOK := rawNS&0x3ff != 0
+		ewOK := rawEW&0x3ff != 0
+		if nsOK && ewOK {
+			ns := (rawNS & 0x3ff) - 1
+			if rawNS&0x400 != 0 {
+				ns = -ns
+			}
+			ew := (rawEW & 0x3ff) - 1
+			if rawEW&0x400 != 0 {
+				ew = -ew
+			}
+			if out.AirGroundState == AirGroundSupersonic {
+				ns *= 4
+				ew *= 4
+			}
+			out.HasGroundSpeed = true
+			out.GroundSpeedKt = int(math.Round(math.Hypot(float64(ns), float64(ew))))
+			if ns != 0 || ew != 0 {
+				track := math.Atan2(float64(ew), float64(ns)) * 180 / math.Pi
+				if track < 0 {
+					track += 360
+				}
+				out.HasTrack = true
+				out.TrackDeg = track
+			}
+		}
+
+		rawVV := int(frame[15]&0x7f)<<4 | int(frame[16]&0xf0)>>4
+		if rawVV&0x1ff != 0 {
+			out.HasVerticalRate = true
+			out.VerticalRateGeo = rawVV&0x400 == 0
+			vv := ((rawVV & 0x1ff) - 1) * 64
+			if rawVV&0x200 != 0 {
+				vv = -vv
+			}
+			out.VerticalRateFpm = vv
+		}
+
+	case AirGroundOnGround:
+		rawGS := int(frame[12]&0x1f)<<6 | int(frame[13]&0xfc)>>2
+		if rawGS&0x3ff != 0 {
+			out.HasGroundSpeed = true
+			out.GroundSpeedKt = (rawGS & 0x3ff) - 1
+		}
+		rawTrack := int(frame[13]&0x03)<<9 | int(frame[14])<<1 | int(frame[15]&0x80)>>7
+		if (rawTrack&0x600)>>9 != 0 {
+			out.HasTrack = true
+			out.TrackDeg = float64(rawTrack&0x1ff) * 360.0 / 512.0
+		}
+		out.HasDimensions = true
+		out.LengthM, out.WidthM = aircraftDimensions((frame[15] & 0x38) >> 3)
+		out.PositionOffset = frame[15]&0x04 != 0
+	}
+
+	out.UTCCoupled = frame[16]&0x08 != 0
+	if out.AddrQualifier.IsTISB() {
+		out.TISBSiteID = frame[16] & 0x0f
+	}
+}
+
+func aircraftDimensions(code byte) (length, width float64) {
+	lengths := [8]float64{15, 25, 35, 45, 55, 65, 75, 85}
+	widths := [8]float64{11.5, 28.5, 34, 38, 39.5, 45, 52, 72.5}
+	return lengths[code&0x07], widths[code&0x07]
+}
+
+func decodeMS(out *DecodedDownlink, frame []byte) {
+	raw1 := int(frame[17])<<8 | int(frame[18])
+	raw2 := int(frame[19])<<8 | int(frame[20])
+	raw3 := int(frame[21])<<8 | int(frame[22])
+
+	out.HasModeStatus = true
+	out.EmitterCategory = byte((raw1 / 1600) % 40)
+
+	var cs [8]byte
+	cs[0] = base40Alphabet[(raw1/40)%40]
+	cs[1] = base40Alphabet[raw1%40]
+	cs[2] = base40Alphabet[(raw2/1600)%40]
+	cs[3] = base40Alphabet[(raw2/40)%40]
+	cs[4] = base40Alphabet[raw2%40]
+	cs[5] = base40Alphabet[(raw3/1600)%40]
+	cs[6] = base40Alphabet[(raw3/40)%40]
+	cs[7] = base40Alphabet[raw3%40]
+	out.Callsign = strings.TrimSpace(string(cs[:]))
+
+	out.EmergencyStatus = (frame[23] >> 5) & 0x07
+	out.UATVersion = (frame[23] >> 2) & 0x07
+	out.SIL = frame[23] & 0x03
+	out.NACp = (frame[25] >> 4) & 0x0f
+	out.NACv = (frame[25] >> 1) & 0x07
+	out.NICBaro = frame[25] & 0x01
+	out.HasCDTI = frame[26]&0x80 != 0
+	out.HasACAS = frame[26]&0x40 != 0
+	out.ACASRAActive = frame[26]&0x20 != 0
+	out.IdentActive = frame[26]&0x10 != 0
+	out.ATCServices = frame[26]&0x08 != 0
+	out.HeadingMagnetic = frame[26]&0x04 != 0
+	out.CallsignIsFlightID = frame[26]&0x02 != 0
+}
+
+func decodeAUXSV(out *DecodedDownlink, frame []byte) {
+	rawAlt := int(frame[29])<<4 | int(frame[30]&0xf0)>>4
+	if rawAlt == 0 {
+		return
+	}
+	out.HasSecondaryAlt = true
+	out.SecondaryAltGeo = !out.AltitudeGeometric
+	out.SecondaryAltFeet = (rawAlt-1)*25 - 1000
+}
+
+// GeometricAltitude returns the geometric altitude from either the SV or AUXSV.
+func (d DecodedDownlink) GeometricAltitude() (int, bool) {
+	if d.HasAltitude && d.AltitudeGeometric {
+		return d.AltitudeFeet, true
+	}
+	if d.HasSecondaryAlt && d.SecondaryAltGeo {
+		return d.SecondaryAltFeet, true
+	}
+	return 0, false
+}
+
+// PressureAltitude returns the barometric altitude from either the SV or AUXSV.
+func (d DecodedDownlink) PressureAltitude() (int, bool) {
+	if d.HasAltitude && !d.AltitudeGeometric {
+		return d.AltitudeFeet, true
+	}
+	if d.HasSecondaryAlt && !d.SecondaryAltGeo {
+		return d.SecondaryAltFeet, true
+	}
+	return 0, false
+}
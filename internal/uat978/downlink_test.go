@@ -0,0 +1,152 @@
+package uat978
+
+import (
+	"math"
+	"testing"
+)
+
+// buildLongFrame packs a payload type 1 (SV + MS + AUXSV) frame.
+func buildLongFrame(t *testing.T) []byte {
+	t.Helper()
+	f := make([]byte, DownlinkLongFrameBytes)
+	f[0] = 1<<3 | byte(AddrADSBICAO)
+	f[1], f[2], f[3] = 0xA1, 0xB2, 0xC3
+
+	// 45.0N, 122.5W.
+	rawLat := uint32(math.Round(45.0 * 16777216.0 / 360.0))
+	rawLon := uint32(math.Round((360.0 - 122.5) * 16777216.0 / 360.0))
+	f[4] = byte(rawLat >> 15)
+	f[5] = byte(rawLat >> 7)
+	f[6] = byte(rawLat<<1) | byte(rawLon>>23)&0x01
+	f[7] = byte(rawLon >> 15)
+	f[8] = byte(rawLon >> 7)
+	f[9] = byte(rawLon << 1) // altitude type 0 = barometric
+
+	// 5500 ft baro, NIC 8.
+	rawAlt := (5500+1000)/25 + 1
+	f[10] = byte(rawAlt >> 4)
+	f[11] = byte(rawAlt<<4) | 8
+
+	// Airborne subsonic: 100 kt north, 0 kt east.
+	rawNS := 100 + 1
+	rawEW := 0 + 1
+	f[12] = byte(rawNS>>6) & 0x1f
+	f[13] = byte(rawNS<<2) | byte(rawEW>>9)&0x03
+	f[14] = byte(rawEW >> 1)
+	f[15] = byte(rawEW&0x01) << 7
+
+	// Climbing 640 fpm, geometric source.
+	rawVV := 640/64 + 1
+	f[15] |= byte(rawVV>>4) & 0x7f
+	f[16] = byte(rawVV << 4)
+
+	// MS: emitter category 1, callsign "N12345".
+	enc := func(s string, i int) int {
+		for j := 0; j < len(base40Alphabet); j++ {
+			if base40Alphabet[j] == s[i] {
+				return j
+			}
+		}
+		t.Fatalf("bad char %q", s[i])
+		return 0
+	}
+	cs := "N12345  "
+	raw1 := 1*1600 + enc(cs, 0)*40 + enc(cs, 1)
+	raw2 := enc(cs, 2)*1600 + enc(cs, 3)*40 + enc(cs, 4)
+	raw3 := enc(cs, 5)*1600 + enc(cs, 6)*40 + enc(cs, 7)
+	f[17], f[18] = byte(raw1>>8), byte(raw1)
+	f[19], f[20] = byte(raw2>>8), byte(raw2)
+	f[21], f[22] = byte(raw3>>8), byte(raw3)
+	f[23] = 2 << 2 // UAT version 2
+	f[25] = 9 << 4 // NACp 9
+	f[26] = 0x02   // callsign is a flight ID
+
+	// AUXSV: geometric 5600 ft.
+	rawAux := (5600+1000)/25 + 1
+	f[29] = byte(rawAux >> 4)
+	f[30] = byte(rawAux << 4)
+	return f
+}
+
+func TestDecodeDownlinkFrame_LongFrame(t *testing.T) {
+	d, ok := DecodeDownlinkFrame(buildLongFrame(t))
+	if !ok {
+		t.Fatalf("expected ok")
+	}
+	if d.PayloadType != 1 || d.AddrQualifier != AddrADSBICAO || d.Address != 0xA1B2C3 {
+		t.Fatalf("unexpected header: %+v", d)
+	}
+	if !d.HasPosition || math.Abs(d.LatDeg-45.0) > 1e-4 || math.Abs(d.LonDeg+122.5) > 1e-4 {
+		t.Fatalf("unexpected position: lat=%.6f lon=%.6f", d.LatDeg, d.LonDeg)
+	}
+	if alt, ok := d.PressureAltitude(); !ok || alt != 5500 {
+		t.Fatalf("expected baro 5500, got %d ok=%v", alt, ok)
+	}
+	if alt, ok := d.GeometricAltitude(); !ok || alt != 5600 {
+		t.Fatalf("expected geo 5600, got %d ok=%v", alt, ok)
+	}
+	if d.NIC != 8 || d.NACp != 9 {
+		t.Fatalf("expected NIC=8 NACp=9, got NIC=%d NACp=%d", d.NIC, d.NACp)
+	}
+	if !d.HasGroundSpeed || d.GroundSpeedKt != 100 {
+		t.Fatalf("expected 100 kt, got %d", d.GroundSpeedKt)
+	}
+	if !d.HasTrack || math.Abs(d.TrackDeg) > 0.01 {
+		t.Fatalf("expected track 0, got %.2f", d.TrackDeg)
+	}
+	if !d.HasVerticalRate || d.VerticalRateFpm != 640 || !d.VerticalRateGeo {
+		t.Fatalf("expected +640 fpm geo, got %d geo=%v", d.VerticalRateFpm, d.VerticalRateGeo)
+	}
+	if !d.HasModeStatus || d.Callsign != "N12345" || !d.CallsignIsFlightID {
+		t.Fatalf("unexpected callsign %q flightID=%v", d.Callsign, d.CallsignIsFlightID)
+	}
+	if d.EmitterCategory != 1 || d.UATVersion != 2 {
+		t.Fatalf("unexpected emitter=%d version=%d", d.EmitterCategory, d.UATVersion)
+	}
+	if d.OnGround() {
+		t.Fatalf("expected airborne")
+	}
+}
+
+func TestDecodeDownlinkFrame_BasicTISBOnGround(t *testing.T) {
+	f := make([]byte, DownlinkShortFrameBytes)
+	f[0] = byte(AddrTISBICAO)
+	f[1], f[2], f[3] = 0x00, 0x12, 0x34
+	f[4] = 0x20 // some latitude
+	f[11] = 7   // NIC 7
+	// Ground, 12 kt, true track 90 deg.
+	rawGS := 12 + 1
+	rawTrack := 1<<9 | 128
+	f[12] = 2<<6 | byte(rawGS>>6)&0x1f
+	f[13] = byte(rawGS<<2) | byte(rawTrack>>9)&0x03
+	f[14] = byte(rawTrack >> 1)
+	f[15] = byte(rawTrack&0x01) << 7
+	f[16] = 0x05 // TIS-B site ID
+
+	d, ok := DecodeDownlinkFrame(f)
+	if !ok {
+		t.Fatalf("expected ok")
+	}
+	if !d.AddrQualifier.IsTISB() || d.AddrQualifier.IsADSR() {
+		t.Fatalf("expected TIS-B qualifier, got %d", d.AddrQualifier)
+	}
+	if !d.OnGround() || d.GroundSpeedKt != 12 || math.Abs(d.TrackDeg-90) > 0.01 {
+		t.Fatalf("unexpected surface state: %+v", d)
+	}
+	if d.TISBSiteID != 5 {
+		t.Fatalf("expected site 5, got %d", d.TISBSiteID)
+	}
+	if d.HasModeStatus || d.HasSecondaryAlt {
+		t.Fatalf("basic frame should not carry MS/AUXSV")
+	}
+}
+
+func TestDecodeDownlinkFrame_RejectsBadLength(t *testing.T) {
+	if _, ok := DecodeDownlinkFrame(make([]byte, 20)); ok {
+		t.Fatalf("expected not ok")
+	}
+	// Long frame claiming payload type 0 is inconsistent.
+	if _, ok := DecodeDownlinkFrame(make([]byte, DownlinkLongFrameBytes)); ok {
+		t.Fatalf("expected not ok")
+	}
+}
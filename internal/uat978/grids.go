@@ -0,0 +1,363 @@
+package uat978
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// FIS-B gridded products decoded into per-level grids.
+const (
+	ProductTurbulenceLow  uint32 = 70
+	ProductTurbulenceHigh uint32 = 71
+	ProductCloudTops      uint32 = 84
+	ProductIcingLow       uint32 = 90
+	ProductIcingHigh      uint32 = 91
+	ProductLightning      uint32 = 103
+)
+
+// Global block representation geometry (DO-358): 450 blocks per 4' latitude
+// ring, each 48' wide (96' above 60 degrees), 32x4 bins per block.
+const (
+	gridBlockWidthDeg     = 48.0 / 60.0
+	gridWideBlockWidthDeg = 96.0 / 60.0
+	gridBlockHeightDeg    = 4.0 / 60.0
+	gridBlockThreshold    = 405000
+	gridBlocksPerRing     = 450
+	gridBinCols           = 32
+	gridBinRows           = 4
+	gridBins              = gridBinCols * gridBinRows
+)
+
+// Decoded blocks are dropped when not refreshed within a product's max age.
+// Lightning is rebroadcast every 5 minutes and goes stale quickly; icing,
+// turbulence and cloud tops every 15.
+const (
+	gridMaxAge          = 30 * time.Minute
+	lightningGridMaxAge = 7 * time.Minute
+)
+
+type gridProduct struct {
+	name  string
+	level string
+	// valueBits is the width of the bin value in each RLE byte; the remaining
+	// high bits carry run length - 1.
+	valueBits uint
+	maxAge    time.Duration
+}
+
+var gridProducts = map[uint32]gridProduct{
+	ProductTurbulenceLow:  {name: "Turbulence", level: "low", valueBits: 4, maxAge: gridMaxAge},
+	ProductTurbulenceHigh: {name: "Turbulence", level: "high", valueBits: 4, maxAge: gridMaxAge},
+	ProductCloudTops:      {name: "Cloud Tops", valueBits: 4, maxAge: gridMaxAge},
+	ProductIcingLow:       {name: "Icing", level: "low", valueBits: 4, maxAge: gridMaxAge},
+	ProductIcingHigh:      {name: "Icing", level: "high", valueBits: 4, maxAge: gridMaxAge},
+	ProductLightning:      {name: "Lightning", valueBits: 4, maxAge: lightningGridMaxAge},
+}
+
+// IsGridProduct reports whether productID is a decoded gridded product.
+func IsGridProduct(productID uint32) bool {
+	_, ok := gridProducts[productID]
+	return ok
+}
+
+// GridBlock is one decoded 32x4 bin block. Values are row-major starting at
+// the north-west corner; zero means "no data/none".
+type GridBlock struct {
+	ProductID   uint32
+	BlockNumber int
+	South       bool
+	ScaleFactor int
+	Values      [gridBins]byte
+	// APDUHour/APDUMinute are the product time from the APDU header.
+	APDUHour   int
+	APDUMinute int
+	HasTime    bool
+
+	received time.Time
+}
+
+// Bounds returns the block's north-west corner and size in degrees.
+func (b GridBlock) Bounds() (latN, lonW, latSize, lonSize float64) {
+	scale := 1.0
+	switch b.ScaleFactor {
+	case 1:
+		scale = 5
+	case 2:
+		scale = 9
+	}
+	bn := b.BlockNumber
+	if bn >= gridBlockThreshold {
+		bn &^= 1
+	}
+	rawLat := gridBlockHeightDeg * float64(bn/gridBlocksPerRing)
+	rawLon := float64(bn%gridBlocksPerRing) * gridBlockWidthDeg
+	lonSize = gridBlockWidthDeg * scale
+	if bn >= gridBlockThreshold {
+		lonSize = gridWideBlockWidthDeg * scale
+	}
+	latSize = gridBlockHeightDeg * scale
+	lonW = rawLon
+	if b.South {
+		latN = -rawLat
+	} else {
+		// The block number locates the south edge; scaled blocks extend
+		// further north.
+		latN = rawLat + latSize
+	}
+	if lonW > 180 {
+		lonW -= 360
+	}
+	return latN, lonW, latSize, lonSize
+}
+
+// GridCell is a single non-empty bin.
+type GridCell struct {
+	LatN    float64
+	LonW    float64
+	LatSize float64
+	LonSize float64
+	Value   byte
+}
+
+// Cells returns the non-empty bins of the block.
+func (b GridBlock) Cells() []GridCell {
+	latN, lonW, latSize, lonSize := b.Bounds()
+	binLat := latSize / gridBinRows
+	binLon := lonSize / gridBinCols
+	var out []GridCell
+	for i, v := range b.Values {
+		if v == 0 {
+			continue
+		}
+		row, col := i/gridBinCols, i%gridBinCols
+		out = append(out, GridCell{
+			LatN:    latN - float64(row)*binLat,
+			LonW:    lonW + float64(col)*binLon,
+			LatSize: binLat,
+			LonSize: binLon,
+			Value:   v,
+		})
+	}
+	return out
+}
+
+// decodeGridBlock decodes a run-length encoded global block from the FIS-B
+// APDU data (after the APDU time header). Empty-block lists are decoded by
+// decodeEmptyGridBlocks.
+func decodeGridBlock(productID uint32, data []byte) (GridBlock, bool) {
+	p, ok := gridProducts[productID]
+	if !ok || len(data) < 4 {
+		return GridBlock{}, false
+	}
+	if data[0]&0x80 == 0 {
+		return GridBlock{}, false
+	}
+	b := GridBlock{
+		ProductID:   productID,
+		South:       data[0]&0x40 != 0,
+		ScaleFactor: int(data[0]&0x30) >> 4,
+		BlockNumber: int(data[0]&0x0f)<<16 | int(data[1])<<8 | int(data[2]),
+	}
+	mask := byte(1)<<p.valueBits - 1
+	j := 0
+	for _, c := range data[3:] {
+		v := c & mask
+		run := int(c>>p.valueBits) + 1
+		for ; run > 0; run-- {
+			if j >= gridBins {
+				return GridBlock{}, false
+			}
+			b.Values[j] = v
+			j++
+		}
+	}
+	return b, true
+}
+
+// decodeEmptyGridBlocks decodes an empty-block list: blocks the ground
+// station has cleared. The header names the first empty block; the high
+// nibble of the next byte flags the four blocks after it, its low nibble
+// gives the count of further bitmap bytes, each flagging the next eight
+// blocks from the least significant bit up. The blocks are returned with
+// all bins zero.
+func decodeEmptyGridBlocks(productID uint32, data []byte) ([]GridBlock, bool) {
+	if _, ok := gridProducts[productID]; !ok || len(data) < 4 {
+		return nil, false
+	}
+	if data[0]&0x80 != 0 {
+		return nil, false
+	}
+	first := GridBlock{
+		ProductID:   productID,
+		South:       data[0]&0x40 != 0,
+		ScaleFactor: int(data[0]&0x30) >> 4,
+		BlockNumber: int(data[0]&0x0f)<<16 | int(data[1])<<8 | int(data[2]),
+	}
+	n := int(data[3] & 0x0f)
+	if len(data) < 4+n {
+		return nil, false
+	}
+	out := []GridBlock{first}
+	add := func(offset int) {
+		b := first
+		b.BlockNumber += offset
+		out = append(out, b)
+	}
+	for i := 0; i < 4; i++ {
+		if data[3]&(0x10<<i) != 0 {
+			add(1 + i)
+		}
+	}
+	for i, c := range data[4 : 4+n] {
+		for bit := 0; bit < 8; bit++ {
+			if c&(1<<bit) != 0 {
+				add(5 + 8*i + bit)
+			}
+		}
+	}
+	return out, true
+}
+
+// fisbTime returns the APDU hours/minutes for the given payload.
+func fisbTime(payload []byte) (hour, minute int, ok bool) {
+	if len(payload) < 5 {
+		return 0, 0, false
+	}
+	tOpt := (payload[1]&0x01)<<1 | payload[2]>>7
+	switch tOpt {
+	case 0, 1:
+		return int(payload[2]&0x7c) >> 2, int(payload[2]&0x03)<<4 | int(payload[3])>>4, true
+	case 2, 3:
+		return int(payload[3]&0x3e) >> 1, int(payload[3]&0x01)<<5 | int(payload[4])>>3, true
+	}
+	return 0, 0, false
+}
+
+// Grid is a snapshot of all fresh blocks of one gridded product.
+type Grid struct {
+	ProductID  uint32      `json:"product_id"`
+	Name       string      `json:"name"`
+	Level      string      `json:"level,omitempty"`
+	UpdatedUTC string      `json:"updated_utc"`
+	ValidTime  string      `json:"valid_time,omitempty"`
+	Blocks     []GridBlock `json:"-"`
+}
+
+// Hazard summarizes a gridded product relative to ownship.
+type Hazard struct {
+	ProductID uint32   `json:"product_id"`
+	Name      string   `json:"name"`
+	Level     string   `json:"level,omitempty"`
+	RadiusNm  float64  `json:"radius_nm"`
+	Within    bool     `json:"within"`
+	NearestNm *float64 `json:"nearest_nm,omitempty"`
+	MaxValue  byte     `json:"max_value,omitempty"`
+	Summary   string   `json:"summary"`
+}
+
+// HazardRadiusNm is the radius used for "hazard within N nm" reporting.
+const HazardRadiusNm = 20.0
+
+type gridKey struct {
+	south bool
+	block int
+	scale int
+}
+
+func (a *Aggregator) addGridBlockLocked(nowUTC time.Time, b GridBlock) {
+	b.received = nowUTC
+	m := a.grids[b.ProductID]
+	if m == nil {
+		m = make(map[gridKey]GridBlock)
+		a.grids[b.ProductID] = m
+	}
+	m[gridKey{south: b.South, block: b.BlockNumber, scale: b.ScaleFactor}] = b
+}
+
+// clearGridBlockLocked drops a block the ground station reported empty.
+func (a *Aggregator) clearGridBlockLocked(b GridBlock) {
+	delete(a.grids[b.ProductID], gridKey{south: b.South, block: b.BlockNumber, scale: b.ScaleFactor})
+}
+
+// Grids returns a snapshot of every gridded product with fresh blocks.
+func (a *Aggregator) Grids(nowUTC time.Time) []Grid {
+	if a == nil {
+		return nil
+	}
+	if nowUTC.IsZero() {
+		nowUTC = time.Now().UTC()
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	out := make([]Grid, 0, len(a.grids))
+	for pid, m := range a.grids {
+		p := gridProducts[pid]
+		g := Grid{ProductID: pid, Name: p.name, Level: p.level}
+		var newest GridBlock
+		for k, b := range m {
+			if nowUTC.Sub(b.received) > p.maxAge {
+				delete(m, k)
+				continue
+			}
+			g.Blocks = append(g.Blocks, b)
+			if b.received.After(newest.received) {
+				newest = b
+			}
+		}
+		if len(g.Blocks) == 0 {
+			continue
+		}
+		sort.Slice(g.Blocks, func(i, j int) bool { return g.Blocks[i].BlockNumber < g.Blocks[j].BlockNumber })
+		g.UpdatedUTC = newest.received.UTC().Format(time.RFC3339Nano)
+		if newest.HasTime {
+			g.ValidTime = fmt.Sprintf("%02d%02dZ", newest.APDUHour, newest.APDUMinute)
+		}
+		out = append(out, g)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ProductID < out[j].ProductID })
+	return out
+}
+
+// Hazards reports, for each gridded product, whether any non-empty bin lies
+// within HazardRadiusNm of the given position and how far the nearest is.
+func Hazards(grids []Grid, latDeg, lonDeg float64) []Hazard {
+	out := make([]Hazard, 0, len(grids))
+	for _, g := range grids {
+		h := Hazard{ProductID: g.ProductID, Name: g.Name, Level: g.Level, RadiusNm: HazardRadiusNm}
+		nearest := math.MaxFloat64
+		for _, b := range g.Blocks {
+			for _, c := range b.Cells() {
+				d := distanceNm(latDeg, lonDeg, c.LatN-c.LatSize/2, c.LonW+c.LonSize/2)
+				if d < nearest {
+					nearest = d
+				}
+				if d <= HazardRadiusNm && c.Value > h.MaxValue {
+					h.MaxValue = c.Value
+				}
+			}
+		}
+		if nearest != math.MaxFloat64 {
+			n := math.Round(nearest*10) / 10
+			h.NearestNm = &n
+			h.Within = nearest <= HazardRadiusNm
+		}
+		name := g.Name
+		if g.Level != "" {
+			name += " (" + g.Level + ")"
+		}
+		switch {
+		case h.Within:
+			h.Summary = fmt.Sprintf("%s within %.0f nm", name, HazardRadiusNm)
+		case h.NearestNm != nil:
+			h.Summary = fmt.Sprintf("%s %.0f nm", name, *h.NearestNm)
+		default:
+			h.Summary = "No " + name
+		}
+		out = append(out, h)
+	}
+	return out
+}
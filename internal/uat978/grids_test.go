@@ -0,0 +1,178 @@
+package uat978
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+// buildGridUplink wraps one global-block APDU in a minimal uplink frame.
+func buildGridUplink(productID uint32, hour, minute int, block []byte) []byte {
+	frame := make([]byte, UplinkFrameDataBytes)
+	frame[6] = 0x20 // application data valid
+	payload := []byte{
+		byte(productID >> 6 & 0x1f),
+		byte(productID&0x3f) << 2, // t_opt=0
+		byte(hour)<<2 | byte(minute>>4),
+		byte(minute&0x0f) << 4,
+	}
+	payload = append(payload, block...)
+	frame[8] = byte(len(payload) >> 1)
+	frame[9] = byte(len(payload)&1) << 7
+	copy(frame[10:], payload)
+	return frame
+}
+
+// Block 304046 spans 45.0000N..45.0667N, 123.2000W..122.4000W.
+var lightningBlock = []byte{
+	0x84, 0xa3, 0xae, // RLE, north, scale 0, block 304046
+	0xf3, 0xf3, // row 0: 32 bins of value 3
+	0xf0, 0xf0, 0xf0, 0xf0, 0xf0, 0xf0, // rows 1-3 empty
+}
+
+func TestDecodeUplinkFrame_GridBlock(t *testing.T) {
+	d, ok := DecodeUplinkFrame(buildGridUplink(ProductLightning, 14, 35, lightningBlock))
+	if !ok {
+		t.Fatalf("expected ok")
+	}
+	if len(d.ProductIDs) != 1 || d.ProductIDs[0] != ProductLightning {
+		t.Fatalf("unexpected products %v", d.ProductIDs)
+	}
+	if len(d.GridBlocks) != 1 {
+		t.Fatalf("expected 1 grid block, got %d", len(d.GridBlocks))
+	}
+	b := d.GridBlocks[0]
+	if b.BlockNumber != 304046 || b.South || b.ScaleFactor != 0 {
+		t.Fatalf("unexpected block header %+v", b)
+	}
+	if !b.HasTime || b.APDUHour != 14 || b.APDUMinute != 35 {
+		t.Fatalf("unexpected time %d:%d", b.APDUHour, b.APDUMinute)
+	}
+	latN, lonW, latSize, lonSize := b.Bounds()
+	if diff(latN, 45+1.0/15) > 1e-9 || diff(lonW, -123.2) > 1e-9 || diff(latSize, 1.0/15) > 1e-9 || diff(lonSize, 0.8) > 1e-9 {
+		t.Fatalf("unexpected bounds %f %f %f %f", latN, lonW, latSize, lonSize)
+	}
+	cells := b.Cells()
+	if len(cells) != 32 || cells[0].Value != 3 || diff(cells[1].LonW-cells[0].LonW, 0.025) > 1e-9 {
+		t.Fatalf("unexpected cells %+v", cells[:2])
+	}
+}
+
+func TestGridBlock_BoundsScaled(t *testing.T) {
+	// Block 304046 has its south edge at 45N.
+	for _, tc := range []struct {
+		scale                        int
+		latN, lonW, latSize, lonSize float64
+	}{
+		{0, 45 + 1.0/15, -123.2, 1.0 / 15, 0.8},
+		{1, 45 + 5.0/15, -123.2, 5.0 / 15, 4.0},
+		{2, 45 + 9.0/15, -123.2, 9.0 / 15, 7.2},
+	} {
+		b := GridBlock{BlockNumber: 304046, ScaleFactor: tc.scale}
+		latN, lonW, latSize, lonSize := b.Bounds()
+		if diff(latN, tc.latN) > 1e-9 || diff(lonW, tc.lonW) > 1e-9 || diff(latSize, tc.latSize) > 1e-9 || diff(lonSize, tc.lonSize) > 1e-9 {
+			t.Fatalf("scale %d: bounds %f %f %f %f", tc.scale, latN, lonW, latSize, lonSize)
+		}
+	}
+
+	// South of the equator the block number locates the north edge.
+	b := GridBlock{BlockNumber: 304046, South: true, ScaleFactor: 1}
+	if latN, _, _, _ := b.Bounds(); diff(latN, -45) > 1e-9 {
+		t.Fatalf("south latN=%f", latN)
+	}
+}
+
+func TestDecodeGridBlock_RejectsOverrun(t *testing.T) {
+	block := []byte{0x84, 0xa3, 0xae}
+	for i := 0; i < 9; i++ {
+		block = append(block, 0xf0)
+	}
+	if _, ok := decodeGridBlock(ProductLightning, block); ok {
+		t.Fatalf("expected 144 bins to be rejected")
+	}
+	if _, ok := decodeGridBlock(63, lightningBlock); ok {
+		t.Fatalf("expected non-grid product to be rejected")
+	}
+}
+
+func TestAggregator_GridsAndHazards(t *testing.T) {
+	agg := NewAggregator(AggregatorConfig{})
+	now := time.Unix(1_000_000, 0).UTC()
+	d, _ := DecodeUplinkFrame(buildGridUplink(ProductLightning, 14, 35, lightningBlock))
+	agg.Add(now, d, 0, false)
+
+	grids := agg.Grids(now)
+	if len(grids) != 1 || grids[0].Name != "Lightning" || grids[0].ValidTime != "1435Z" {
+		t.Fatalf("unexpected grids %+v", grids)
+	}
+
+	near := Hazards(grids, 45.0, -123.0)
+	if len(near) != 1 || !near[0].Within || near[0].MaxValue != 3 || near[0].NearestNm == nil {
+		t.Fatalf("unexpected hazard %+v", near)
+	}
+	if near[0].Summary != "Lightning within 20 nm" {
+		t.Fatalf("unexpected summary %q", near[0].Summary)
+	}
+	far := Hazards(grids, 44.0, -123.0)
+	if far[0].Within || far[0].NearestNm == nil || *far[0].NearestNm < 60 {
+		t.Fatalf("unexpected far hazard %+v", far[0])
+	}
+
+	if got := agg.Grids(now.Add(lightningGridMaxAge + time.Second)); len(got) != 0 {
+		t.Fatalf("expected stale lightning to expire, got %d", len(got))
+	}
+}
+
+func TestDecodeEmptyGridBlocks(t *testing.T) {
+	// First empty block 304046, bitmap flags +2 and, in the extra byte, +5
+	// and +12.
+	got, ok := decodeEmptyGridBlocks(ProductLightning, []byte{0x04, 0xa3, 0xae, 0x21, 0x81})
+	if !ok {
+		t.Fatalf("empty-block list not decoded")
+	}
+	var blocks []int
+	for _, b := range got {
+		if b.South || b.ScaleFactor != 0 || b.ProductID != ProductLightning {
+			t.Fatalf("unexpected block %+v", b)
+		}
+		blocks = append(blocks, b.BlockNumber)
+	}
+	if want := []int{304046, 304048, 304051, 304058}; !slices.Equal(blocks, want) {
+		t.Fatalf("blocks=%v want %v", blocks, want)
+	}
+	if _, ok := decodeEmptyGridBlocks(ProductLightning, []byte{0x04, 0xa3, 0xae, 0x02, 0x01}); ok {
+		t.Fatalf("expected short bitmap to be rejected")
+	}
+	if _, ok := decodeEmptyGridBlocks(ProductLightning, lightningBlock); ok {
+		t.Fatalf("expected RLE block to be rejected")
+	}
+}
+
+func TestAggregator_EmptyGridBlocksClear(t *testing.T) {
+	agg := NewAggregator(AggregatorConfig{})
+	now := time.Unix(1_000_000, 0).UTC()
+	for _, bn := range []byte{0xae, 0xaf, 0xb0} { // blocks 304046..304048
+		block := append([]byte{0x84, 0xa3, bn}, lightningBlock[3:]...)
+		d, _ := DecodeUplinkFrame(buildGridUplink(ProductLightning, 14, 35, block))
+		agg.Add(now, d, 0, false)
+	}
+
+	// The station clears 304046 and 304048; 304047 still has strikes.
+	d, _ := DecodeUplinkFrame(buildGridUplink(ProductLightning, 14, 40, []byte{0x04, 0xa3, 0xae, 0x20}))
+	if len(d.EmptyGridBlocks) != 2 {
+		t.Fatalf("empty blocks=%+v", d.EmptyGridBlocks)
+	}
+	agg.Add(now.Add(time.Minute), d, 0, false)
+
+	grids := agg.Grids(now.Add(time.Minute))
+	if len(grids) != 1 || len(grids[0].Blocks) != 1 || grids[0].Blocks[0].BlockNumber != 304047 {
+		t.Fatalf("unexpected grids %+v", grids)
+	}
+}
+
+func diff(a, b float64) float64 {
+	if a > b {
+		return a - b
+	}
+	return b - a
+}
@@ -0,0 +1,169 @@
+package uat978
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// DefaultServiceVolumeNm is used when a ground station entry does not specify
+// its own service volume radius.
+const DefaultServiceVolumeNm = 100.0
+
+// towerMatchNm is how close a decoded uplink position must be to a database
+// entry to be treated as the same ground station. Uplink positions are encoded
+// with ~2 m resolution, so this mostly absorbs survey differences in the CSV.
+const towerMatchNm = 1.0
+
+// GroundStation is a single entry of the local FAA ground-station list.
+type GroundStation struct {
+	ID              string  `json:"id,omitempty"`
+	Name            string  `json:"name,omitempty"`
+	State           string  `json:"state,omitempty"`
+	LatDeg          float64 `json:"lat_deg"`
+	LonDeg          float64 `json:"lon_deg"`
+	ServiceVolumeNm float64 `json:"service_volume_nm"`
+}
+
+// TowerDB is an immutable, in-memory ground-station list.
+type TowerDB struct {
+	stations []GroundStation
+}
+
+// LoadTowerDB reads a ground-station CSV from disk.
+func LoadTowerDB(path string) (*TowerDB, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ParseTowerDB(f)
+}
+
+// ParseTowerDB parses a ground-station CSV.
+//
+// The first row must be a header. Columns are matched case-insensitively:
+//   - lat/latitude and lon/lng/longitude (required)
+//   - id/site_id, name/site_name, state (optional)
+//   - service_volume_nm/range_nm (optional; defaults to DefaultServiceVolumeNm)
+//
+// Unknown columns are ignored so the FAA export can be used with minimal
+// editing. Rows with unparseable coordinates are skipped.
+func ParseTowerDB(r io.Reader) (*TowerDB, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+	cr.Comment = '#'
+
+	header, err := cr.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("tower db: missing header")
+		}
+		return nil, fmt.Errorf("tower db: %w", err)
+	}
+	col := map[string]int{}
+	for i, h := range header {
+		h = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(h, "\ufeff")))
+		switch h {
+		case "id", "site_id":
+			col["id"] = i
+		case "name", "site_name":
+			col["name"] = i
+		case "state":
+			col["state"] = i
+		case "lat", "latitude":
+			col["lat"] = i
+		case "lon", "lng", "longitude":
+			col["lon"] = i
+		case "service_volume_nm", "range_nm":
+			col["range"] = i
+		}
+	}
+	if _, ok := col["lat"]; !ok {
+		return nil, fmt.Errorf("tower db: header must include lat and lon columns")
+	}
+	if _, ok := col["lon"]; !ok {
+		return nil, fmt.Errorf("tower db: header must include lat and lon columns")
+	}
+
+	field := func(rec []string, key string) string {
+		i, ok := col[key]
+		if !ok || i >= len(rec) {
+			return ""
+		}
+		return strings.TrimSpace(rec[i])
+	}
+
+	db := &TowerDB{}
+	for {
+		rec, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("tower db: %w", err)
+		}
+		lat, err1 := strconv.ParseFloat(field(rec, "lat"), 64)
+		lon, err2 := strconv.ParseFloat(field(rec, "lon"), 64)
+		if err1 != nil || err2 != nil || lat < -90 || lat > 90 || lon < -180 || lon > 180 {
+			continue
+		}
+		gs := GroundStation{
+			ID:              field(rec, "id"),
+			Name:            field(rec, "name"),
+			State:           field(rec, "state"),
+			LatDeg:          lat,
+			LonDeg:          lon,
+			ServiceVolumeNm: DefaultServiceVolumeNm,
+		}
+		if v, err := strconv.ParseFloat(field(rec, "range"), 64); err == nil && v > 0 {
+			gs.ServiceVolumeNm = v
+		}
+		db.stations = append(db.stations, gs)
+	}
+	return db, nil
+}
+
+// Len returns the number of ground stations in the database.
+func (db *TowerDB) Len() int {
+	if db == nil {
+		return 0
+	}
+	return len(db.stations)
+}
+
+// Lookup returns the database entry closest to the given position, if any is
+// within matching distance.
+func (db *TowerDB) Lookup(latDeg, lonDeg float64) (GroundStation, bool) {
+	if db == nil {
+		return GroundStation{}, false
+	}
+	best := -1
+	bestNm := math.MaxFloat64
+	for i, gs := range db.stations {
+		d := distanceNm(latDeg, lonDeg, gs.LatDeg, gs.LonDeg)
+		if d < bestNm {
+			best, bestNm = i, d
+		}
+	}
+	if best < 0 || bestNm > towerMatchNm {
+		return GroundStation{}, false
+	}
+	return db.stations[best], true
+}
+
+// distanceNm returns the great-circle distance between two points.
+func distanceNm(lat1, lon1, lat2, lon2 float64) float64 {
+	const earthRadiusNm = 3440.065
+	toRad := func(d float64) float64 { return d * math.Pi / 180 }
+	dLat := toRad(lat2 - lat1)
+	dLon := toRad(lon2 - lon1)
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(toRad(lat1))*math.Cos(toRad(lat2))*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadiusNm * math.Asin(math.Min(1, math.Sqrt(a)))
+}
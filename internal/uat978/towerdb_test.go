@@ -0,0 +1,42 @@
+package uat978
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseTowerDB_HeaderColumnsAndLookup(t *testing.T) {
+	csv := "# FAA UAT ground stations\n" +
+		"Site_ID,Name,State,Latitude,Longitude,Range_NM,Extra\n" +
+		"KSEA1,Seattle,WA,47.4500,-122.3100,80,x\n" +
+		"KPDX1,Portland,OR,45.5900,-122.6000,,y\n" +
+		"BAD,Broken,ZZ,nope,-1,,\n"
+	db, err := ParseTowerDB(strings.NewReader(csv))
+	if err != nil {
+		t.Fatalf("ParseTowerDB: %v", err)
+	}
+	if db.Len() != 2 {
+		t.Fatalf("expected 2 stations, got %d", db.Len())
+	}
+
+	gs, ok := db.Lookup(47.4501, -122.3101)
+	if !ok {
+		t.Fatalf("expected match")
+	}
+	if gs.Name != "Seattle" || gs.ID != "KSEA1" || gs.ServiceVolumeNm != 80 {
+		t.Fatalf("unexpected station: %+v", gs)
+	}
+	gs, ok = db.Lookup(45.59, -122.60)
+	if !ok || gs.ServiceVolumeNm != DefaultServiceVolumeNm {
+		t.Fatalf("expected default service volume, got %+v ok=%v", gs, ok)
+	}
+	if _, ok := db.Lookup(40.0, -100.0); ok {
+		t.Fatalf("expected no match far away")
+	}
+}
+
+func TestParseTowerDB_RequiresLatLon(t *testing.T) {
+	if _, err := ParseTowerDB(strings.NewReader("id,name\nA,B\n")); err == nil {
+		t.Fatalf("expected error")
+	}
+}
@@ -0,0 +1,390 @@
+package uat978
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// WindsLevel is one altitude column of an FD winds/temperatures aloft report.
+type WindsLevel struct {
+	AltFeet       int      `json:"alt_feet"`
+	DirDeg        int      `json:"dir_deg"`
+	SpeedKt       int      `json:"speed_kt"`
+	LightVariable bool     `json:"light_variable,omitempty"`
+	TempC         *float64 `json:"temp_c,omitempty"`
+}
+
+// WindsStation is the decoded winds aloft table for a single FD station.
+type WindsStation struct {
+	Station     string       `json:"station"`
+	Issued      string       `json:"issued,omitempty"`
+	ReceivedUTC string       `json:"received_utc"`
+	LatDeg      *float64     `json:"lat_deg,omitempty"`
+	LonDeg      *float64     `json:"lon_deg,omitempty"`
+	Levels      []WindsLevel `json:"levels"`
+
+	received time.Time
+}
+
+// WindEstimate is a winds/temperature estimate interpolated to a position and
+// altitude from nearby FD stations.
+type WindEstimate struct {
+	AltFeet   int      `json:"alt_feet"`
+	DirDeg    float64  `json:"dir_deg"`
+	SpeedKt   float64  `json:"speed_kt"`
+	TempC     *float64 `json:"temp_c,omitempty"`
+	Stations  []string `json:"stations"`
+	NearestNm float64  `json:"nearest_nm"`
+}
+
+const (
+	// windsMaxAge bounds how long a received FD table is used.
+	windsMaxAge = 12 * time.Hour
+	// windsMaxRadiusNm bounds which stations contribute to an estimate.
+	windsMaxRadiusNm = 400.0
+	// windsMaxStations is how many nearby stations are blended.
+	windsMaxStations = 4
+)
+
+// IsWindsReport reports whether a DLAC text message is an FD winds bulletin.
+func IsWindsReport(text string) bool {
+	return strings.HasPrefix(strings.TrimSpace(text), "WINDS")
+}
+
+// ParseWindsReport parses an FD winds/temperatures aloft bulletin as carried
+// by FIS-B product 413:
+//
+//	WINDS SEA 171200Z  FT 3000 6000 9000 12000 18000 24000 30000 34000 39000
+//	SEA 2714 2721+02 2729-03 2738-08 2658-20 2670-32 750041 750449 750859
+//
+// The header may be followed by one or more station rows. Missing leading
+// columns (levels below station elevation) are right-aligned to the header.
+func ParseWindsReport(text string, receivedUTC time.Time) []WindsStation {
+	lines := strings.FieldsFunc(text, func(r rune) bool {
+		return r == '\n' || r == '\r' || r == 0x1E || r == 0x03
+	})
+	var alts []int
+	issued := ""
+	var out []WindsStation
+	for _, line := range lines {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if fields[0] == "WINDS" {
+			alts = nil
+			issued = ""
+			for i := 1; i < len(fields); i++ {
+				f := fields[i]
+				if len(f) == 7 && strings.HasSuffix(f, "Z") && isDigits(f[:6]) {
+					issued = f
+				}
+				if f == "FT" {
+					for _, a := range fields[i+1:] {
+						v, err := strconv.Atoi(a)
+						if err != nil {
+							break
+						}
+						alts = append(alts, v)
+					}
+					break
+				}
+			}
+			continue
+		}
+		if len(alts) == 0 || !isStationID(fields[0]) {
+			continue
+		}
+		groups := fields[1:]
+		if len(groups) > len(alts) {
+			groups = groups[len(groups)-len(alts):]
+		}
+		offset := len(alts) - len(groups)
+		st := WindsStation{
+			Station:     fields[0],
+			Issued:      issued,
+			ReceivedUTC: receivedUTC.UTC().Format(time.RFC3339Nano),
+			received:    receivedUTC.UTC(),
+		}
+		for i, g := range groups {
+			lvl, ok := parseWindsGroup(g, alts[offset+i])
+			if !ok {
+				continue
+			}
+			st.Levels = append(st.Levels, lvl)
+		}
+		if len(st.Levels) > 0 {
+			out = append(out, st)
+		}
+	}
+	return out
+}
+
+// parseWindsGroup decodes DDSS, DDSS±TT or DDSSTT (above 24000 ft the minus
+// sign is omitted). Directions of 51-86 encode speeds of 100-199 kt and
+// 9900 means light and variable.
+func parseWindsGroup(g string, altFeet int) (WindsLevel, bool) {
+	if len(g) < 4 || !isDigits(g[:4]) {
+		return WindsLevel{}, false
+	}
+	dd, _ := strconv.Atoi(g[:2])
+	ss, _ := strconv.Atoi(g[2:4])
+	lvl := WindsLevel{AltFeet: altFeet}
+	switch {
+	case dd == 99 && ss == 0:
+		lvl.LightVariable = true
+	case dd >= 51 && dd <= 86:
+		lvl.DirDeg = (dd - 50) * 10
+		lvl.SpeedKt = ss + 100
+	case dd <= 36:
+		lvl.DirDeg = dd * 10
+		lvl.SpeedKt = ss
+	default:
+		return WindsLevel{}, false
+	}
+	rest := g[4:]
+	switch {
+	case rest == "":
+	case len(rest) == 3 && (rest[0] == '+' || rest[0] == '-') && isDigits(rest[1:]):
+		t, _ := strconv.Atoi(rest[1:])
+		tc := float64(t)
+		if rest[0] == '-' {
+			tc = -tc
+		}
+		lvl.TempC = &tc
+	case len(rest) == 2 && isDigits(rest):
+		t, _ := strconv.Atoi(rest)
+		tc := -float64(t)
+		lvl.TempC = &tc
+	default:
+		return WindsLevel{}, false
+	}
+	return lvl, true
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func isStationID(s string) bool {
+	if len(s) < 3 || len(s) > 4 {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if (c < 'A' || c > 'Z') && (c < '0' || c > '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// windVector returns the (east, north) components of the air mass motion for
+// a wind blowing from dirDeg.
+func windVector(dirDeg, speedKt float64) (float64, float64) {
+	r := dirDeg * math.Pi / 180
+	return -speedKt * math.Sin(r), -speedKt * math.Cos(r)
+}
+
+// windFromVector is the inverse of windVector.
+func windFromVector(e, n float64) (dirDeg, speedKt float64) {
+	speedKt = math.Hypot(e, n)
+	if speedKt < 1e-9 {
+		return 0, 0
+	}
+	dirDeg = math.Atan2(-e, -n) * 180 / math.Pi
+	if dirDeg < 0 {
+		dirDeg += 360
+	}
+	return dirDeg, speedKt
+}
+
+// atAltitude interpolates a station table vertically. Altitudes outside the
+// table are clamped to the nearest level.
+func (st WindsStation) atAltitude(altFeet int) (e, n float64, tempC *float64, ok bool) {
+	if len(st.Levels) == 0 {
+		return 0, 0, nil, false
+	}
+	levels := append([]WindsLevel(nil), st.Levels...)
+	sort.Slice(levels, func(i, j int) bool { return levels[i].AltFeet < levels[j].AltFeet })
+
+	lo, hi := levels[0], levels[len(levels)-1]
+	for i := 0; i+1 < len(levels); i++ {
+		if altFeet >= levels[i].AltFeet && altFeet <= levels[i+1].AltFeet {
+			lo, hi = levels[i], levels[i+1]
+			break
+		}
+	}
+	if altFeet <= levels[0].AltFeet {
+		hi = lo
+	} else if altFeet >= levels[len(levels)-1].AltFeet {
+		lo = hi
+	}
+	f := 0.0
+	if hi.AltFeet != lo.AltFeet {
+		f = float64(altFeet-lo.AltFeet) / float64(hi.AltFeet-lo.AltFeet)
+	}
+	e1, n1 := windVector(float64(lo.DirDeg), float64(lo.SpeedKt))
+	e2, n2 := windVector(float64(hi.DirDeg), float64(hi.SpeedKt))
+	e = e1 + (e2-e1)*f
+	n = n1 + (n2-n1)*f
+
+	switch {
+	case lo.TempC != nil && hi.TempC != nil:
+		t := *lo.TempC + (*hi.TempC-*lo.TempC)*f
+		tempC = &t
+	case lo.TempC != nil:
+		t := *lo.TempC
+		tempC = &t
+	case hi.TempC != nil:
+		t := *hi.TempC
+		tempC = &t
+	}
+	return e, n, tempC, true
+}
+
+// InterpolateWinds estimates winds and temperature at a position and altitude
+// by blending the nearest located stations with inverse-distance weighting.
+func InterpolateWinds(stations []WindsStation, latDeg, lonDeg float64, altFeet int) (WindEstimate, bool) {
+	type cand struct {
+		st   WindsStation
+		dist float64
+	}
+	cands := make([]cand, 0, len(stations))
+	for _, st := range stations {
+		if st.LatDeg == nil || st.LonDeg == nil || len(st.Levels) == 0 {
+			continue
+		}
+		d := distanceNm(latDeg, lonDeg, *st.LatDeg, *st.LonDeg)
+		if d > windsMaxRadiusNm {
+			continue
+		}
+		cands = append(cands, cand{st: st, dist: d})
+	}
+	if len(cands) == 0 {
+		return WindEstimate{}, false
+	}
+	sort.Slice(cands, func(i, j int) bool { return cands[i].dist < cands[j].dist })
+	if len(cands) > windsMaxStations {
+		cands = cands[:windsMaxStations]
+	}
+
+	var sumW, sumE, sumN, sumT, sumTW float64
+	out := WindEstimate{AltFeet: altFeet, NearestNm: math.Round(cands[0].dist*10) / 10}
+	for _, c := range cands {
+		e, n, t, ok := c.st.atAltitude(altFeet)
+		if !ok {
+			continue
+		}
+		w := 1.0 / math.Max(c.dist*c.dist, 1)
+		sumW += w
+		sumE += e * w
+		sumN += n * w
+		if t != nil {
+			sumT += *t * w
+			sumTW += w
+		}
+		out.Stations = append(out.Stations, c.st.Station)
+	}
+	if sumW == 0 {
+		return WindEstimate{}, false
+	}
+	dir, spd := windFromVector(sumE/sumW, sumN/sumW)
+	out.DirDeg = math.Round(dir)
+	out.SpeedKt = math.Round(spd)
+	if out.DirDeg >= 360 {
+		out.DirDeg -= 360
+	}
+	if sumTW > 0 {
+		t := math.Round(sumT/sumTW*10) / 10
+		out.TempC = &t
+	}
+	return out, true
+}
+
+// WindTriangle solves for true airspeed, true heading and wind correction
+// angle given GPS ground speed/track and a wind estimate.
+func WindTriangle(groundKt, trackDeg float64, w WindEstimate) (tasKt, headingDeg, wcaDeg float64) {
+	tr := trackDeg * math.Pi / 180
+	ge, gn := groundKt*math.Sin(tr), groundKt*math.Cos(tr)
+	we, wn := windVector(w.DirDeg, w.SpeedKt)
+	ae, an := ge-we, gn-wn
+	tasKt = math.Hypot(ae, an)
+	headingDeg = math.Atan2(ae, an) * 180 / math.Pi
+	if headingDeg < 0 {
+		headingDeg += 360
+	}
+	wcaDeg = headingDeg - trackDeg
+	for wcaDeg > 180 {
+		wcaDeg -= 360
+	}
+	for wcaDeg < -180 {
+		wcaDeg += 360
+	}
+	return tasKt, headingDeg, wcaDeg
+}
+
+// windsStationLocations holds approximate positions of common FD stations.
+// Positions are only used to blend nearby tables, so airport-level accuracy
+// is plenty. Stations missing here are logged by the Aggregator and reported
+// as unlocated in the winds status.
+var windsStationLocations = map[string][2]float64{
+	"ABI": {32.48, -99.86},
+	"ABQ": {35.04, -106.61},
+	"ATL": {33.64, -84.43},
+	"BOS": {42.36, -71.01},
+	"BRO": {25.91, -97.42},
+	"BUF": {42.94, -78.73},
+	"CLE": {41.41, -81.85},
+	"DEN": {39.86, -104.67},
+	"DFW": {32.90, -97.04},
+	"DSM": {41.53, -93.66},
+	"DTW": {42.21, -83.35},
+	"ELP": {31.81, -106.38},
+	"GEG": {47.62, -117.53},
+	"HOU": {29.65, -95.28},
+	"JAX": {30.49, -81.69},
+	"JFK": {40.64, -73.78},
+	"LAS": {36.08, -115.15},
+	"LAX": {33.94, -118.41},
+	"MEM": {35.04, -89.98},
+	"MIA": {25.79, -80.29},
+	"MKC": {39.12, -94.59},
+	"MSP": {44.88, -93.22},
+	"MSY": {29.99, -90.26},
+	"OKC": {35.39, -97.60},
+	"OMA": {41.30, -95.89},
+	"PDX": {45.59, -122.60},
+	"PHX": {33.43, -112.01},
+	"PIT": {40.49, -80.23},
+	"SAN": {32.73, -117.19},
+	"SAT": {29.53, -98.47},
+	"SEA": {47.45, -122.31},
+	"SFO": {37.62, -122.38},
+	"SLC": {40.79, -111.98},
+	"STL": {38.75, -90.37},
+	"TPA": {27.98, -82.53},
+}
+
+// locateWindsStation fills in the station position when known.
+func locateWindsStation(st *WindsStation) {
+	if st == nil || st.LatDeg != nil {
+		return
+	}
+	if pos, ok := windsStationLocations[st.Station]; ok {
+		lat, lon := pos[0], pos[1]
+		st.LatDeg = &lat
+		st.LonDeg = &lon
+	}
+}
@@ -0,0 +1,86 @@
+package uat978
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+const testWindsBulletin = "WINDS SEA 171200Z  FT 3000 6000 9000 12000 18000 24000 30000 34000 39000\n" +
+	"SEA 2714 2721+02 2729-03 2738-08 2658-20 2670-32 750041 750449 750859\n" +
+	"PDX      9900+05 3615+01 0420-04 0525-16 0530-28 051043 052051 053057"
+
+func TestParseWindsReport_DecodesGroups(t *testing.T) {
+	now := time.Unix(1_000_000, 0).UTC()
+	got := ParseWindsReport(testWindsBulletin, now)
+	if len(got) != 2 {
+		t.Fatalf("expected 2 stations, got %d", len(got))
+	}
+	sea := got[0]
+	if sea.Station != "SEA" || sea.Issued != "171200Z" || len(sea.Levels) != 9 {
+		t.Fatalf("unexpected SEA table: %+v", sea)
+	}
+	l3000 := sea.Levels[0]
+	if l3000.AltFeet != 3000 || l3000.DirDeg != 270 || l3000.SpeedKt != 14 || l3000.TempC != nil {
+		t.Fatalf("unexpected 3000 ft level: %+v", l3000)
+	}
+	l6000 := sea.Levels[1]
+	if l6000.TempC == nil || *l6000.TempC != 2 {
+		t.Fatalf("expected +02C at 6000, got %+v", l6000)
+	}
+	// 750041 => 250 deg, 100 kt, -41C.
+	l30000 := sea.Levels[6]
+	if l30000.DirDeg != 250 || l30000.SpeedKt != 100 || l30000.TempC == nil || *l30000.TempC != -41 {
+		t.Fatalf("unexpected 30000 ft level: %+v", l30000)
+	}
+
+	// PDX omits 3000 ft; remaining groups are right-aligned.
+	pdx := got[1]
+	if len(pdx.Levels) != 8 || pdx.Levels[0].AltFeet != 6000 || !pdx.Levels[0].LightVariable {
+		t.Fatalf("unexpected PDX table: %+v", pdx.Levels)
+	}
+}
+
+func TestInterpolateWinds_VerticalAndHorizontal(t *testing.T) {
+	now := time.Unix(1_000_000, 0).UTC()
+	stations := ParseWindsReport(testWindsBulletin, now)
+	for i := range stations {
+		locateWindsStation(&stations[i])
+	}
+
+	// At the SEA station itself, halfway between 3000 and 6000 ft: wind from
+	// 270, speed between 14 and 21 kt.
+	est, ok := InterpolateWinds(stations[:1], 47.45, -122.31, 4500)
+	if !ok {
+		t.Fatalf("expected estimate")
+	}
+	if est.DirDeg != 270 || est.SpeedKt < 17 || est.SpeedKt > 18 {
+		t.Fatalf("unexpected estimate: %+v", est)
+	}
+	if est.TempC == nil || *est.TempC != 2 {
+		t.Fatalf("expected temp from the only level with temperature, got %+v", est.TempC)
+	}
+
+	// Between SEA and PDX both stations contribute.
+	est, ok = InterpolateWinds(stations, 46.5, -122.45, 9000)
+	if !ok || len(est.Stations) != 2 {
+		t.Fatalf("expected blended estimate, got %+v ok=%v", est, ok)
+	}
+
+	if _, ok := InterpolateWinds(stations, 25.0, -80.0, 9000); ok {
+		t.Fatalf("expected no estimate far from stations")
+	}
+}
+
+func TestWindTriangle_HeadwindAndCrosswind(t *testing.T) {
+	// Direct headwind: TAS = GS + wind, no correction.
+	tas, hdg, wca := WindTriangle(100, 360, WindEstimate{DirDeg: 0, SpeedKt: 20})
+	if math.Abs(tas-120) > 0.01 || math.Abs(wca) > 0.01 || math.Abs(hdg) > 0.01 && math.Abs(hdg-360) > 0.01 {
+		t.Fatalf("headwind: tas=%.2f hdg=%.2f wca=%.2f", tas, hdg, wca)
+	}
+	// Wind from the right (090) on a northbound track: crab right.
+	_, _, wca = WindTriangle(100, 0, WindEstimate{DirDeg: 90, SpeedKt: 20})
+	if wca <= 0 {
+		t.Fatalf("expected right correction, got %.2f", wca)
+	}
+}
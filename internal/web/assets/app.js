@@ -115,6 +115,10 @@
   const attHeading = document.getElementById('att-heading');
   const attPalt = document.getElementById('att-palt');
   const attGps = document.getElementById('att-gps');
+  const attWind = document.getElementById('att-wind');
+  const attTas = document.getElementById('att-tas');
+  const attWca = document.getElementById('att-wca');
+  const viewAttitude = document.getElementById('view-attitude');
   const attG = document.getElementById('att-g');
   const attGMin = document.getElementById('att-gmin');
   const attGMax = document.getElementById('att-gmax');
@@ -277,6 +281,7 @@
     const raw = String(value || '').trim().toLowerCase();
     if (raw === '1090') return '1090';
     if (raw === '978') return '978';
+    if (raw === 'ogn') return 'ogn';
     return 'unknown';
   }
 
@@ -284,6 +289,7 @@
     const key = trafficSourceKey(value);
     if (key === '1090') return '1090 MHz';
     if (key === '978') return '978 MHz';
+    if (key === 'ogn') return 'FLARM/OGN';
     return 'Unknown';
   }
 
@@ -623,6 +629,21 @@
     return trafficColumns.filter((col) => col.required || trafficColumnVisibility.get(col.key) !== false);
   }
 
+  const trafficEmergencyLabels = {
+    general: 'EMERGENCY',
+    medical: 'MEDICAL',
+    minfuel: 'MIN FUEL',
+    nordo: 'NORDO',
+    unlawful: 'HIJACK',
+    downed: 'DOWNED',
+  };
+
+  function trafficEmergencyLabel(target) {
+    const key = String(target?.emergency || '').trim();
+    if (!key || key === 'none') return '';
+    return trafficEmergencyLabels[key] || 'EMERGENCY';
+  }
+
   function formatTrafficCellValue(target, key) {
     const t = target || {};
     switch (key) {
@@ -634,6 +655,8 @@
       }
       case 'squawk': {
         const sq = String(t.squawk || '').trim();
+        const emerg = trafficEmergencyLabel(t);
+        if (emerg) return sq ? `${sq} ${emerg}` : emerg;
         return sq || '--';
       }
       case 'alt': {
@@ -667,6 +690,7 @@
       }
       case 'flags': {
         const flags = [];
+        if (trafficEmergencyLabel(t)) flags.push('EMRG');
         if (t.on_ground) flags.push('GND');
         if (t.extrapolated) flags.push('XTRP');
         return flags.join(' · ') || '--';
@@ -710,7 +734,8 @@
         .join('');
       const sourceKey = trafficSourceKey(target?.source);
       const rowTitle = trafficSourceLabel(target?.source);
-      rows.push(`<tr class="traffic-source-${sourceKey}" data-source="${sourceKey}" title="${escapeHtml(rowTitle)}">${cells}</tr>`);
+      const emergClass = trafficEmergencyLabel(target) ? ' traffic-emergency' : '';
+      rows.push(`<tr class="traffic-source-${sourceKey}${emergClass}" data-source="${sourceKey}" title="${escapeHtml(rowTitle)}">${cells}</tr>`);
     }
 
     if (!rows.length) {
@@ -720,6 +745,19 @@
     trGdl90TableBody.innerHTML = rows.join('');
   }
 
+  // Bearingless targets carry an RSSI range estimate and altitude relative to
+  // ownship instead of a position.
+  function formatTrafficProximity(target) {
+    if (!target?.bearingless) return '--';
+    const rng = Number(target?.est_range_nm);
+    const rel = Number(target?.rel_alt_feet);
+    let text = Number.isFinite(rng) ? `Nearby ~${fmtNum(rng, 1)} nm, unknown bearing` : 'Nearby, unknown bearing';
+    if (Number.isFinite(rel)) {
+      text += ` (${rel >= 0 ? '+' : '-'}${fmtInt(Math.abs(rel))} ft)`;
+    }
+    return text;
+  }
+
   function renderTrafficModeSTable(list) {
     if (!trModeSTableBody || !trModeSCount) return;
     const entries = Array.isArray(list) ? [...list] : [];
@@ -740,13 +778,16 @@
       const targetCell = escapeHtml(formatTrafficCellValue(target, 'target'));
       const squawkCell = escapeHtml(formatTrafficCellValue(target, 'squawk'));
       const altCell = escapeHtml(formatTrafficCellValue(target, 'alt'));
+      const proxCell = escapeHtml(formatTrafficProximity(target));
       const ageCell = escapeHtml(formatTrafficCellValue(target, 'age'));
       const sourceCell = escapeHtml(sourceTitle);
+      const emergClass = trafficEmergencyLabel(target) ? ' traffic-emergency' : '';
       rows.push(
-        `<tr class="traffic-source-${sourceKey}" data-source="${sourceKey}" title="${escapeHtml(sourceTitle)}">` +
+        `<tr class="traffic-source-${sourceKey}${emergClass}" data-source="${sourceKey}" title="${escapeHtml(sourceTitle)}">` +
           `<td>${targetCell}</td>` +
           `<td>${squawkCell}</td>` +
           `<td>${altCell}</td>` +
+          `<td>${proxCell}</td>` +
           `<td>${ageCell}</td>` +
           `<td>${sourceCell}</td>` +
         '</tr>',
@@ -754,7 +795,7 @@
     }
 
     if (!rows.length) {
-      rows.push('<tr class="traffic-table-empty-row"><td colspan="5">No Mode S / no-position traffic</td></tr>');
+      rows.push('<tr class="traffic-table-empty-row"><td colspan="6">No Mode S / no-position traffic</td></tr>');
       trModeSCount.textContent = 'No recent hits';
     } else {
       const label = entries.length === 1 ? '1 recent hit' : `${fmtInt(entries.length)} recent hits`;
@@ -801,7 +842,9 @@
 
     const rows = [];
     for (const t of list) {
-      const towerLabel = formatLatLon(t?.lat_deg, t?.lon_deg, 3);
+      const towerPos = formatLatLon(t?.lat_deg, t?.lon_deg, 3);
+      const towerName = String(t?.name || '').trim();
+      const towerLabel = towerName ? `${towerName} ${towerPos}` : towerPos;
       const msgMin = Number(t?.messages_last_min);
       const msgMinCell = Number.isFinite(msgMin) ? fmtInt(msgMin) : '--';
 
@@ -1621,7 +1664,8 @@
         if (Number.isFinite(brg)) brgStr = `${fmtNum(brg, 0)}°`;
       }
 
-      const labelShort = tail || icao;
+      const emerg = trafficEmergencyLabel(t);
+      const labelShort = emerg ? `⚠ ${tail || icao} ${emerg}` : (tail || icao);
       const labelLong = tail ? `${tail} (${icao})` : icao;
 
       // Always-visible label: show name + relative altitude.
@@ -1636,7 +1680,7 @@
       `.trim();
 
       const infoLines = [
-        labelLong,
+        emerg ? `${labelLong} · ${emerg}` : labelLong,
         relAltStr ? `Rel Alt: ${relAltStr}` : 'Rel Alt: --',
         (distStr || brgStr) ? `Pos: ${[distStr, brgStr].filter(Boolean).join(' ')}` : 'Pos: --',
         gsStr ? `GS: ${gsStr}` : 'GS: --',
@@ -1897,9 +1941,7 @@
 
     const gps = s?.gps || {};
 
-    function fmtFixMode(gpsSource, mode) {
-      const src = String(gpsSource || '').toLowerCase();
-      if (src !== 'gpsd') return '-';
+    function fmtFixMode(mode) {
       if (mode == null) return '-';
       switch (Number(mode)) {
         case 1:
@@ -1916,7 +1958,7 @@
     const gpsVsUi = (gps.vert_speed_fpm == null) ? null : applyOwnshipVsiUi(Number(gps.vert_speed_fpm));
     const gpsEnabledState = (gps.enabled === true) ? true : (gps.enabled === false ? false : null);
     setIndicator(stSummaryGpsEnabled, gpsEnabledState, 'GPS state');
-    const summaryFixMode = fmtFixMode(gps.source, gps.fix_mode);
+    const summaryFixMode = fmtFixMode(gps.fix_mode);
     setInput(stSummaryGpsFixMode, summaryFixMode || '--');
     setInput(stSummaryGpsHAcc, gps.horiz_acc_m == null ? '--' : fmtNum(gps.horiz_acc_m, 2));
     setInput(stSummaryGpsVAcc, gps.vert_acc_m == null ? '--' : fmtNum(gps.vert_acc_m, 2));
@@ -1931,13 +1973,13 @@
     setChecked(stGpsValid, !!gps.valid);
     setChecked(stGpsStale, !!gps.fix_stale);
     setInput(stGpsAge, gps.fix_age_sec == null ? '' : fmtNum(gps.fix_age_sec, 1));
-    setInput(stGpsSource, gps.source || '');
+    setInput(stGpsSource, gps.active_input || gps.source || '');
     setInput(stGpsDevice, gps.device || '');
     setInput(stGpsGPSDAddr, gps.gpsd_addr || '');
     setInput(stGpsBaud, gps.baud == null ? '' : String(gps.baud));
     setInput(stGpsLastFix, gps.last_fix_utc || '');
     setInput(stGpsFixQ, gps.fix_quality == null ? '' : String(gps.fix_quality));
-    setInput(stGpsFixMode, fmtFixMode(gps.source, gps.fix_mode));
+    setInput(stGpsFixMode, fmtFixMode(gps.fix_mode));
     setInput(stGpsSats, gps.satellites == null ? '' : String(gps.satellites));
     setInput(stGpsHdop, gps.hdop == null ? '' : fmtNum(gps.hdop, 1));
     setInput(stGpsHAcc, gps.horiz_acc_m == null ? '' : fmtNum(gps.horiz_acc_m, 1));
@@ -1948,7 +1990,9 @@
     setInput(stGpsGround, gps.ground_kt == null ? '' : String(gps.ground_kt));
     setInput(stGpsTrack, gps.track_deg == null ? '' : fmtNum(gps.track_deg, 1));
     setInput(stGpsVSpeed, gpsVsUi == null ? '' : String(gpsVsUi));
-    setInput(stGpsError, gps.last_error || '');
+    // A degraded integrity flag outranks receiver errors: the fix is suspect.
+    const integ = gps.integrity;
+    setInput(stGpsError, integ?.degraded ? `integrity degraded: ${(integ.reasons || []).join(', ')}` : (gps.last_error || ''));
 
     const fan = s?.fan || {};
     setInput(stFanBackend, fan.backend || '');
@@ -2881,6 +2925,35 @@
     setInput(attGps, gpsStatus);
   }
 
+  function setWindsText(w) {
+    const est = w?.estimate || null;
+    if (est && Number.isFinite(Number(est.dir_deg)) && Number.isFinite(Number(est.speed_kt))) {
+      const dir = String(Math.round(Number(est.dir_deg))).padStart(3, '0');
+      const temp = est.temp_c == null ? '' : ` ${fmtNum(est.temp_c, 0)}°C`;
+      setInput(attWind, `${dir}/${Math.round(Number(est.speed_kt))}${temp}`);
+    } else {
+      setInput(attWind, '--');
+    }
+    setInput(attTas, w?.tas_kt == null ? '--' : `${Math.round(Number(w.tas_kt))} kt`);
+    if (w?.wca_deg == null) {
+      setInput(attWca, '--');
+    } else {
+      const wca = Math.round(Number(w.wca_deg));
+      setInput(attWca, wca === 0 ? '0°' : `${wca > 0 ? 'R' : 'L'}${Math.abs(wca)}°`);
+    }
+  }
+
+  async function pollWinds() {
+    if (!viewAttitude?.classList.contains('active')) return;
+    try {
+      const resp = await fetch('/api/winds', { cache: 'no-store' });
+      if (!resp.ok) return;
+      setWindsText(await resp.json());
+    } catch {
+      // Winds are best-effort; keep the last values.
+    }
+  }
+
   async function loadSettings() {
     saveMsg.textContent = '';
     try {
@@ -3101,6 +3174,7 @@
       setStatusText(s);
       lastAttitude = s?.attitude || null;
       updateAttitudeTextFromDisplay();
+      pollWinds();
       lastGps = s?.gps || null;
       lastTraffic = s?.traffic || null;
       drawAttitude();
@@ -0,0 +1,235 @@
+package web
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"stratux-ng/internal/uat978"
+)
+
+// maxLayerPixels bounds the PNG layer size along either axis.
+const maxLayerPixels = 2048
+
+// SetWxGrids stores the latest decoded FIS-B gridded products and their
+// ownship-relative hazard summary.
+func (s *Status) SetWxGrids(_ time.Time, grids []uat978.Grid, hazards []uat978.Hazard) {
+	if s == nil {
+		return
+	}
+	s.wxGrids.Store(grids)
+	s.hazards.Store(hazards)
+}
+
+// WxGrids returns the latest decoded FIS-B gridded products.
+func (s *Status) WxGrids() []uat978.Grid {
+	if s == nil {
+		return nil
+	}
+	grids, _ := s.wxGrids.Load().([]uat978.Grid)
+	return grids
+}
+
+func (s *Status) wxGrid(productID uint32) (uat978.Grid, bool) {
+	for _, g := range s.WxGrids() {
+		if g.ProductID == productID {
+			return g, true
+		}
+	}
+	return uat978.Grid{}, false
+}
+
+type geoJSONFeature struct {
+	Type       string         `json:"type"`
+	Geometry   geoJSONPolygon `json:"geometry"`
+	Properties map[string]any `json:"properties"`
+}
+
+type geoJSONPolygon struct {
+	Type        string         `json:"type"`
+	Coordinates [][][2]float64 `json:"coordinates"`
+}
+
+type geoJSONLayer struct {
+	Type       string           `json:"type"`
+	ProductID  uint32           `json:"product_id"`
+	Name       string           `json:"name"`
+	Level      string           `json:"level,omitempty"`
+	UpdatedUTC string           `json:"updated_utc"`
+	ValidTime  string           `json:"valid_time,omitempty"`
+	Features   []geoJSONFeature `json:"features"`
+}
+
+// gridGeoJSON renders every non-empty bin as a rectangular polygon.
+func gridGeoJSON(g uat978.Grid) geoJSONLayer {
+	out := geoJSONLayer{
+		Type:       "FeatureCollection",
+		ProductID:  g.ProductID,
+		Name:       g.Name,
+		Level:      g.Level,
+		UpdatedUTC: g.UpdatedUTC,
+		ValidTime:  g.ValidTime,
+		Features:   []geoJSONFeature{},
+	}
+	for _, b := range g.Blocks {
+		for _, c := range b.Cells() {
+			n, w := c.LatN, c.LonW
+			s, e := c.LatN-c.LatSize, c.LonW+c.LonSize
+			out.Features = append(out.Features, geoJSONFeature{
+				Type: "Feature",
+				Geometry: geoJSONPolygon{
+					Type:        "Polygon",
+					Coordinates: [][][2]float64{{{w, n}, {e, n}, {e, s}, {w, s}, {w, n}}},
+				},
+				Properties: map[string]any{"value": c.Value},
+			})
+		}
+	}
+	return out
+}
+
+// layerColor maps a bin value to a translucent overlay color, from green
+// (lowest category) through yellow to red/magenta.
+func layerColor(v byte) color.NRGBA {
+	palette := [...]color.NRGBA{
+		{0x00, 0xc8, 0x00, 0xa0},
+		{0x9a, 0xd8, 0x00, 0xa8},
+		{0xff, 0xe0, 0x00, 0xb0},
+		{0xff, 0x9a, 0x00, 0xb8},
+		{0xff, 0x40, 0x00, 0xc0},
+		{0xe0, 0x00, 0x00, 0xc8},
+		{0xc0, 0x00, 0xc0, 0xd0},
+	}
+	i := int(v) - 1
+	if i >= len(palette) {
+		i = len(palette) - 1
+	}
+	return palette[i]
+}
+
+// gridPNG rasterizes the grid as an equirectangular image and returns it with
+// its bounds (south, west, north, east).
+func gridPNG(g uat978.Grid) ([]byte, [4]float64, bool) {
+	var cells []uat978.GridCell
+	south, west := math.Inf(1), math.Inf(1)
+	north, east := math.Inf(-1), math.Inf(-1)
+	minLat, minLon := math.Inf(1), math.Inf(1)
+	for _, b := range g.Blocks {
+		for _, c := range b.Cells() {
+			cells = append(cells, c)
+			south = math.Min(south, c.LatN-c.LatSize)
+			north = math.Max(north, c.LatN)
+			west = math.Min(west, c.LonW)
+			east = math.Max(east, c.LonW+c.LonSize)
+			minLat = math.Min(minLat, c.LatSize)
+			minLon = math.Min(minLon, c.LonSize)
+		}
+	}
+	if len(cells) == 0 {
+		return nil, [4]float64{}, false
+	}
+
+	w := int(math.Ceil((east - west) / minLon))
+	h := int(math.Ceil((north - south) / minLat))
+	if scale := math.Max(float64(w), float64(h)) / maxLayerPixels; scale > 1 {
+		w = int(math.Ceil(float64(w) / scale))
+		h = int(math.Ceil(float64(h) / scale))
+	}
+	pxLon := (east - west) / float64(w)
+	pxLat := (north - south) / float64(h)
+
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for _, c := range cells {
+		x0 := int(math.Floor((c.LonW - west) / pxLon))
+		x1 := int(math.Ceil((c.LonW + c.LonSize - west) / pxLon))
+		y0 := int(math.Floor((north - c.LatN) / pxLat))
+		y1 := int(math.Ceil((north - c.LatN + c.LatSize) / pxLat))
+		col := layerColor(c.Value)
+		for y := max(y0, 0); y < min(y1, h); y++ {
+			for x := max(x0, 0); x < min(x1, w); x++ {
+				img.SetNRGBA(x, y, col)
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, [4]float64{}, false
+	}
+	return buf.Bytes(), [4]float64{south, west, north, east}, true
+}
+
+func wxLayersHandler(status *Status) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.Header().Set("Allow", http.MethodGet)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		grids := status.WxGrids()
+		if grids == nil {
+			grids = []uat978.Grid{}
+		}
+		b, err := json.MarshalIndent(grids, "", "  ")
+		if err != nil {
+			http.Error(w, "marshal failed", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(b)
+		_, _ = w.Write([]byte("\n"))
+	}
+}
+
+// wxLayerHandler serves one gridded product as GeoJSON (default) or PNG:
+// /api/wx/layer?product=103&format=png. PNG bounds are returned in the
+// X-Layer-Bounds header as "south,west,north,east".
+func wxLayerHandler(status *Status) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.Header().Set("Allow", http.MethodGet)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		pid, err := strconv.ParseUint(r.URL.Query().Get("product"), 10, 32)
+		if err != nil || !uat978.IsGridProduct(uint32(pid)) {
+			http.Error(w, "invalid product", http.StatusBadRequest)
+			return
+		}
+		g, ok := status.wxGrid(uint32(pid))
+		if !ok {
+			http.Error(w, "no data", http.StatusNotFound)
+			return
+		}
+
+		switch r.URL.Query().Get("format") {
+		case "", "geojson":
+			b, err := json.MarshalIndent(gridGeoJSON(g), "", "  ")
+			if err != nil {
+				http.Error(w, "marshal failed", http.StatusInternalServerError)
+				return
+			}
+			w.Header().Set("Content-Type", "application/geo+json")
+			_, _ = w.Write(b)
+			_, _ = w.Write([]byte("\n"))
+		case "png":
+			b, bounds, ok := gridPNG(g)
+			if !ok {
+				http.Error(w, "no data", http.StatusNotFound)
+				return
+			}
+			w.Header().Set("Content-Type", "image/png")
+			w.Header().Set("X-Layer-Bounds", fmt.Sprintf("%.6f,%.6f,%.6f,%.6f", bounds[0], bounds[1], bounds[2], bounds[3]))
+			_, _ = w.Write(b)
+		default:
+			http.Error(w, "invalid format", http.StatusBadRequest)
+		}
+	}
+}
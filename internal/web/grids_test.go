@@ -0,0 +1,75 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"stratux-ng/internal/uat978"
+)
+
+func testLightningGrid() uat978.Grid {
+	b := uat978.GridBlock{ProductID: uat978.ProductLightning, BlockNumber: 304046}
+	b.Values[0] = 2
+	b.Values[33] = 5
+	return uat978.Grid{ProductID: uat978.ProductLightning, Name: "Lightning", UpdatedUTC: "2026-01-01T00:00:00Z", Blocks: []uat978.GridBlock{b}}
+}
+
+func TestAPI_WxLayer_GeoJSONAndPNG(t *testing.T) {
+	status := NewStatus()
+	n := 3.2
+	status.SetWxGrids(time.Now().UTC(), []uat978.Grid{testLightningGrid()}, []uat978.Hazard{{
+		ProductID: uat978.ProductLightning, Name: "Lightning", RadiusNm: 20, Within: true, NearestNm: &n, Summary: "Lightning within 20 nm",
+	}})
+	h := Handler(status, SettingsStore{}, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/wx/layer?product=103", nil)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status=%d body=%s", w.Code, w.Body.String())
+	}
+	var fc struct {
+		Type     string `json:"type"`
+		Features []struct {
+			Geometry struct {
+				Coordinates [][][2]float64 `json:"coordinates"`
+			} `json:"geometry"`
+			Properties map[string]any `json:"properties"`
+		} `json:"features"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &fc); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if fc.Type != "FeatureCollection" || len(fc.Features) != 2 {
+		t.Fatalf("unexpected geojson: %s", w.Body.String())
+	}
+	if ring := fc.Features[0].Geometry.Coordinates[0]; len(ring) != 5 || ring[0] != ring[4] {
+		t.Fatalf("expected closed ring, got %v", ring)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/wx/layer?product=103&format=png", nil)
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "image/png" {
+		t.Fatalf("status=%d ct=%q", w.Code, w.Header().Get("Content-Type"))
+	}
+	if !strings.HasPrefix(w.Body.String(), "\x89PNG") || w.Header().Get("X-Layer-Bounds") == "" {
+		t.Fatalf("expected png with bounds header")
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/wx/layer?product=84", nil)
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for missing product, got %d", w.Code)
+	}
+
+	snap := status.Snapshot(time.Now().UTC())
+	if len(snap.Hazards) != 1 || !snap.Hazards[0].Within {
+		t.Fatalf("expected hazards in status, got %+v", snap.Hazards)
+	}
+}
@@ -0,0 +1,51 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"stratux-ng/internal/traffic"
+)
+
+// SetReceiverStats stores the latest receiver message rate and range stats.
+func (s *Status) SetReceiverStats(_ time.Time, stats traffic.ReceiverStats) {
+	if s == nil {
+		return
+	}
+	s.receiver.Store(stats)
+}
+
+// ReceiverStats returns the latest receiver stats.
+func (s *Status) ReceiverStats() traffic.ReceiverStats {
+	if s == nil {
+		return traffic.ReceiverStats{}
+	}
+	stats, _ := s.receiver.Load().(traffic.ReceiverStats)
+	return stats
+}
+
+func receiverStatsHandler(status *Status) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.Header().Set("Allow", http.MethodGet)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		stats := status.ReceiverStats()
+		if stats.RatesByKind == nil {
+			stats.RatesByKind = map[string]float64{}
+		}
+		if stats.PolarNm == nil {
+			stats.PolarNm = [][]float64{}
+		}
+		b, err := json.MarshalIndent(stats, "", "  ")
+		if err != nil {
+			http.Error(w, "marshal failed", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(b)
+		_, _ = w.Write([]byte("\n"))
+	}
+}
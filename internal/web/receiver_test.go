@@ -0,0 +1,58 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"stratux-ng/internal/traffic"
+)
+
+func TestAPI_ReceiverStats(t *testing.T) {
+	status := NewStatus()
+	h := Handler(status, SettingsStore{}, nil)
+
+	// Before the first tick the shape is still complete.
+	req := httptest.NewRequest(http.MethodGet, "/api/receiver/stats", nil)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status=%d body=%s", w.Code, w.Body.String())
+	}
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(w.Body.Bytes(), &raw); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if string(raw["rates_by_kind"]) != "{}" || string(raw["polar_nm"]) != "[]" {
+		t.Fatalf("unexpected empty payload: %s", w.Body.String())
+	}
+
+	status.SetReceiverStats(time.Now().UTC(), traffic.ReceiverStats{
+		Messages:       120,
+		MessageRate:    12.5,
+		RatesByKind:    map[string]float64{"DF17": 10, "DF11": 2.5},
+		MaxRangeHourNm: 80,
+		MaxRangeDayNm:  150,
+		SectorDeg:      10,
+		AltBandsFeet:   []int{0, 10000},
+		PolarNm:        [][]float64{{80}, {150}},
+	})
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	var got traffic.ReceiverStats
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if got.Messages != 120 || got.RatesByKind["DF17"] != 10 || got.MaxRangeDayNm != 150 || len(got.PolarNm) != 2 {
+		t.Fatalf("unexpected stats: %+v", got)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/api/receiver/stats", nil)
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("POST status=%d", w.Code)
+	}
+}
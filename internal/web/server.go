@@ -53,6 +53,20 @@ func Handler(status *Status, settings SettingsStore, ahrsCtl AHRSController) htt
 		_, _ = w.Write([]byte("\n"))
 	})
 
+	// UAT ground-station coverage: session history plus a Stratux-compatible view.
+	mux.HandleFunc("/api/towers", towersHandler(status, false))
+	mux.HandleFunc("/getTowers", towersHandler(status, true))
+
+	// FIS-B winds aloft tables plus the ownship-relative estimate.
+	mux.HandleFunc("/api/winds", windsHandler(status))
+
+	// Receiver message rates and polar/max range coverage.
+	mux.HandleFunc("/api/receiver/stats", receiverStatsHandler(status))
+
+	// FIS-B gridded products (lightning, cloud tops, icing, turbulence) as map layers.
+	mux.HandleFunc("/api/wx/layers", wxLayersHandler(status))
+	mux.HandleFunc("/api/wx/layer", wxLayerHandler(status))
+
 	// AHRS actions (optional).
 	mux.HandleFunc("/api/ahrs/level", func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodPost {
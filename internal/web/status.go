@@ -8,6 +8,10 @@ import (
 	"stratux-ng/internal/decoder"
 	"stratux-ng/internal/fancontrol"
 	"stratux-ng/internal/gps"
+	"stratux-ng/internal/nmeaout"
+	"stratux-ng/internal/ntp"
+	"stratux-ng/internal/timesync"
+	"stratux-ng/internal/traffic"
 	"stratux-ng/internal/uat978"
 )
 
@@ -43,6 +47,18 @@ type Status struct {
 	traffic       atomic.Value // []TrafficSnapshot
 	adsb1090      atomic.Value // DecoderStatusSnapshot
 	uat978        atomic.Value // DecoderStatusSnapshot
+	towers        atomic.Value // uat978.TowersReport
+	winds         atomic.Value // WindsAloftSnapshot
+	wxGrids       atomic.Value // []uat978.Grid
+	hazards       atomic.Value // []uat978.Hazard
+	rejects       atomic.Value // []traffic.RejectStats
+	receiver      atomic.Value // traffic.ReceiverStats
+	registry      atomic.Value // RegistrySnapshot
+	emergencies   atomic.Value // []traffic.EmergencyEvent
+	ogn           atomic.Value // OGNSnapshot
+	timeSync      atomic.Value // timesync.Snapshot
+	ntp           atomic.Value // ntp.Snapshot
+	nmeaOut       atomic.Value // nmeaout.Snapshot
 }
 
 func NewStatus() *Status {
@@ -60,6 +76,8 @@ func NewStatus() *Status {
 	s.traffic.Store([]TrafficSnapshot{})
 	s.adsb1090.Store(DecoderStatusSnapshot{Enabled: false})
 	s.uat978.Store(DecoderStatusSnapshot{Enabled: false})
+	s.towers.Store(uat978.TowersReport{})
+	s.winds.Store(WindsAloftSnapshot{})
 	s.attSubs = make(map[int]chan AttitudeSnapshot)
 	return s
 }
@@ -68,20 +86,50 @@ func NewStatus() *Status {
 //
 // This is intended for bring-up and debugging.
 type DecoderStatusSnapshot struct {
-	Enabled      bool     `json:"enabled"`
-	SerialTag    string   `json:"serial_tag,omitempty"`
-	Command      string   `json:"command,omitempty"`
-	Args         []string `json:"args,omitempty"`
-	JSONEndpoint string   `json:"json_endpoint,omitempty"`
-	RawEndpoint  string   `json:"raw_endpoint,omitempty"`
-
-	Supervisor decoder.Snapshot        `json:"supervisor"`
-	Stream     *decoder.NDJSONSnapshot `json:"stream,omitempty"`
-	RawStream  *decoder.LineSnapshot   `json:"raw_stream,omitempty"`
+	Enabled       bool     `json:"enabled"`
+	SerialTag     string   `json:"serial_tag,omitempty"`
+	Command       string   `json:"command,omitempty"`
+	Args          []string `json:"args,omitempty"`
+	JSONEndpoint  string   `json:"json_endpoint,omitempty"`
+	RawEndpoint   string   `json:"raw_endpoint,omitempty"`
+	BeastEndpoint string   `json:"beast_endpoint,omitempty"`
+	SBSEndpoint   string   `json:"sbs_endpoint,omitempty"`
+	AircraftJSON  string   `json:"aircraft_json,omitempty"`
+
+	Supervisor  decoder.Snapshot        `json:"supervisor"`
+	Stream      *decoder.NDJSONSnapshot `json:"stream,omitempty"`
+	RawStream   *decoder.LineSnapshot   `json:"raw_stream,omitempty"`
+	BeastStream *decoder.BeastSnapshot  `json:"beast_stream,omitempty"`
+	SBSStream   *decoder.LineSnapshot   `json:"sbs_stream,omitempty"`
+
+	AircraftJSONPoll *decoder.JSONFileSnapshot `json:"aircraft_json_poll,omitempty"`
+
+	// Inputs reports the band's additional ingest inputs, if any.
+	Inputs []DecoderInputSnapshot `json:"inputs,omitempty"`
 
 	Decoded *UAT978DecodedSnapshot `json:"decoded,omitempty"`
 }
 
+// DecoderInputSnapshot is the health of one extra ingest input. Only the
+// stream field matching Kind is set.
+type DecoderInputSnapshot struct {
+	Name     string `json:"name"`
+	Kind     string `json:"kind"`
+	Endpoint string `json:"endpoint"`
+
+	Stream           *decoder.NDJSONSnapshot   `json:"stream,omitempty"`
+	RawStream        *decoder.LineSnapshot     `json:"raw_stream,omitempty"`
+	BeastStream      *decoder.BeastSnapshot    `json:"beast_stream,omitempty"`
+	SBSStream        *decoder.LineSnapshot     `json:"sbs_stream,omitempty"`
+	AircraftJSONPoll *decoder.JSONFileSnapshot `json:"aircraft_json_poll,omitempty"`
+}
+
+// OGNSnapshot is the health of the FLARM/OGN traffic inputs.
+type OGNSnapshot struct {
+	APRS  *decoder.LineSnapshot `json:"aprs,omitempty"`
+	FLARM *decoder.LineSnapshot `json:"flarm,omitempty"`
+}
+
 type UAT978DecodedSnapshot struct {
 	Towers  []uat978.TowerSnapshot `json:"towers,omitempty"`
 	Weather uat978.WeatherSnapshot `json:"weather,omitempty"`
@@ -115,22 +163,46 @@ func (s *Status) SetGPS(_ time.Time, snap gps.Snapshot) {
 // This is intended for visualizing traffic on the web map and is not a
 // certified traffic display.
 type TrafficSnapshot struct {
-	ICAO            string   `json:"icao"`
-	Tail            string   `json:"tail,omitempty"`
-	LatDeg          float64  `json:"lat_deg"`
-	LonDeg          float64  `json:"lon_deg"`
-	AltFeet         int      `json:"alt_feet"`
-	GroundKt        int      `json:"ground_kt"`
-	TrackDeg        float64  `json:"track_deg"`
-	VvelFpm         int      `json:"vvel_fpm"`
-	OnGround        bool     `json:"on_ground"`
-	Extrapolated    bool     `json:"extrapolated"`
-	PositionValid   bool     `json:"position_valid"`
-	Source          string   `json:"source,omitempty"`
-	Squawk          string   `json:"squawk,omitempty"`
+	ICAO          string   `json:"icao"`
+	Tail          string   `json:"tail,omitempty"`
+	LatDeg        float64  `json:"lat_deg"`
+	LonDeg        float64  `json:"lon_deg"`
+	AltFeet       int      `json:"alt_feet"`
+	GroundKt      int      `json:"ground_kt"`
+	TrackDeg      float64  `json:"track_deg"`
+	VvelFpm       int      `json:"vvel_fpm"`
+	OnGround      bool     `json:"on_ground"`
+	Extrapolated  bool     `json:"extrapolated"`
+	PositionValid bool     `json:"position_valid"`
+	Source        string   `json:"source,omitempty"`
+	Inputs        []string `json:"inputs,omitempty"`
+	Link          string   `json:"link,omitempty"`
+	AddrType      byte     `json:"addr_type,omitempty"`
+	Squawk        string   `json:"squawk,omitempty"`
+	// Emergency names the GDL90 emergency/priority status, when not none.
+	Emergency       string   `json:"emergency,omitempty"`
 	EmitterCategory byte     `json:"emitter_category,omitempty"`
 	DistanceNm      *float64 `json:"distance_nm,omitempty"`
 
+	// Bearingless targets have no position; EstRangeNm is estimated from
+	// signal strength and RelAltFeet is relative to ownship.
+	Bearingless bool     `json:"bearingless,omitempty"`
+	EstRangeNm  *float64 `json:"est_range_nm,omitempty"`
+	RelAltFeet  *int     `json:"rel_alt_feet,omitempty"`
+
+	// Reception stats for this target.
+	Messages     uint64   `json:"messages,omitempty"`
+	MsgRate      float64  `json:"msg_rate,omitempty"`
+	RSSIDbfs     *float64 `json:"rssi_dbfs,omitempty"`
+	RSSIAvgDbfs  *float64 `json:"rssi_avg_dbfs,omitempty"`
+	FirstSeenUTC string   `json:"first_seen_utc,omitempty"`
+
+	// From the offline aircraft database.
+	Registration string `json:"registration,omitempty"`
+	AircraftType string `json:"aircraft_type,omitempty"`
+	WakeCategory string `json:"wake_category,omitempty"`
+	Owner        string `json:"owner,omitempty"`
+
 	// Derived fields for UI.
 	LastSeenUTC string  `json:"last_seen_utc,omitempty"`
 	AgeSec      float64 `json:"age_sec,omitempty"`
@@ -160,6 +232,63 @@ func (s *Status) SetTraffic(nowUTC time.Time, traffic []TrafficSnapshot) {
 	s.traffic.Store(out)
 }
 
+func (s *Status) SetTrafficRejects(_ time.Time, stats []traffic.RejectStats) {
+	if s == nil {
+		return
+	}
+	s.rejects.Store(stats)
+}
+
+func (s *Status) SetEmergencyEvents(_ time.Time, events []traffic.EmergencyEvent) {
+	if s == nil {
+		return
+	}
+	s.emergencies.Store(events)
+}
+
+// RegistrySnapshot describes the loaded offline aircraft database.
+type RegistrySnapshot struct {
+	Path      string `json:"path"`
+	Entries   int    `json:"entries"`
+	LoadedUTC string `json:"loaded_utc,omitempty"`
+	LastError string `json:"last_error,omitempty"`
+}
+
+func (s *Status) SetRegistry(_ time.Time, snap RegistrySnapshot) {
+	if s == nil {
+		return
+	}
+	s.registry.Store(snap)
+}
+
+func (s *Status) SetOGN(_ time.Time, snap OGNSnapshot) {
+	if s == nil {
+		return
+	}
+	s.ogn.Store(snap)
+}
+
+func (s *Status) SetTimeSync(_ time.Time, snap timesync.Snapshot) {
+	if s == nil {
+		return
+	}
+	s.timeSync.Store(snap)
+}
+
+func (s *Status) SetNTP(_ time.Time, snap ntp.Snapshot) {
+	if s == nil {
+		return
+	}
+	s.ntp.Store(snap)
+}
+
+func (s *Status) SetNMEAOut(_ time.Time, snap nmeaout.Snapshot) {
+	if s == nil {
+		return
+	}
+	s.nmeaOut.Store(snap)
+}
+
 func (s *Status) SetFan(nowUTC time.Time, snap fancontrol.Snapshot) {
 	if nowUTC.IsZero() {
 		nowUTC = time.Now().UTC()
@@ -338,6 +467,23 @@ type StatusSnapshot struct {
 	UAT978          DecoderStatusSnapshot `json:"uat978"`
 	Disk            *DiskSnapshot         `json:"disk,omitempty"`
 	Network         *NetworkSnapshot      `json:"network,omitempty"`
+	Hazards         []uat978.Hazard       `json:"hazards,omitempty"`
+	// TrafficRejects counts traffic positions held back by plausibility
+	// gating, per ingest source.
+	TrafficRejects []traffic.RejectStats `json:"traffic_rejects,omitempty"`
+	// EmergencyEvents lists recent traffic emergencies (squawk 7500/7600/7700
+	// or reported emergency status), oldest first.
+	EmergencyEvents []traffic.EmergencyEvent `json:"emergency_events,omitempty"`
+	// Registry reports the offline aircraft database, when configured.
+	Registry *RegistrySnapshot `json:"registry,omitempty"`
+	// OGN reports the FLARM/OGN inputs, when enabled.
+	OGN *OGNSnapshot `json:"ogn,omitempty"`
+	// TimeSync reports setting the system clock from GPS, when enabled.
+	TimeSync *timesync.Snapshot `json:"timesync,omitempty"`
+	// NTP reports the SNTP server, when enabled.
+	NTP *ntp.Snapshot `json:"ntp,omitempty"`
+	// NMEAOut reports the GPS NMEA passthrough, when enabled.
+	NMEAOut *nmeaout.Snapshot `json:"nmea_out,omitempty"`
 }
 
 func (s *Status) Snapshot(nowUTC time.Time) StatusSnapshot {
@@ -350,7 +496,7 @@ func (s *Status) Snapshot(nowUTC time.Time) StatusSnapshot {
 
 	// Traffic: compute UI-friendly age/last-seen without mutating the stored slice.
 	trafficRaw := s.traffic.Load().([]TrafficSnapshot)
-	traffic := make([]TrafficSnapshot, 0, len(trafficRaw))
+	targets := make([]TrafficSnapshot, 0, len(trafficRaw))
 	for _, t := range trafficRaw {
 		if t.SeenUnixNano != 0 {
 			seenAt := time.Unix(0, t.SeenUnixNano).UTC()
@@ -361,7 +507,7 @@ func (s *Status) Snapshot(nowUTC time.Time) StatusSnapshot {
 			}
 			t.AgeSec = age
 		}
-		traffic = append(traffic, t)
+		targets = append(targets, t)
 	}
 
 	snap := StatusSnapshot{
@@ -376,12 +522,30 @@ func (s *Status) Snapshot(nowUTC time.Time) StatusSnapshot {
 		AHRSSensors:     s.ahrsSensors.Load().(AHRSSensorsSnapshot),
 		Fan:             s.fan.Load().(fancontrol.Snapshot),
 		GPS:             s.gps.Load().(gps.Snapshot),
-		Traffic:         traffic,
+		Traffic:         targets,
 		ADSB1090:        s.adsb1090.Load().(DecoderStatusSnapshot),
 		UAT978:          s.uat978.Load().(DecoderStatusSnapshot),
 		Disk:            snapshotDisk(nowUTC),
 		Network:         snapshotNetwork(nowUTC),
 	}
+	snap.Hazards, _ = s.hazards.Load().([]uat978.Hazard)
+	snap.TrafficRejects, _ = s.rejects.Load().([]traffic.RejectStats)
+	snap.EmergencyEvents, _ = s.emergencies.Load().([]traffic.EmergencyEvent)
+	if reg, ok := s.registry.Load().(RegistrySnapshot); ok && reg.Path != "" {
+		snap.Registry = &reg
+	}
+	if o, ok := s.ogn.Load().(OGNSnapshot); ok {
+		snap.OGN = &o
+	}
+	if ts, ok := s.timeSync.Load().(timesync.Snapshot); ok {
+		snap.TimeSync = &ts
+	}
+	if n, ok := s.ntp.Load().(ntp.Snapshot); ok {
+		snap.NTP = &n
+	}
+	if n, ok := s.nmeaOut.Load().(nmeaout.Snapshot); ok {
+		snap.NMEAOut = &n
+	}
 	if lastTick != 0 {
 		snap.LastTickUTC = time.Unix(0, lastTick).UTC().Format(time.RFC3339Nano)
 	}
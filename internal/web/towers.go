@@ -0,0 +1,84 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"stratux-ng/internal/uat978"
+)
+
+// SetTowers stores the latest session-wide UAT tower report.
+func (s *Status) SetTowers(_ time.Time, rep uat978.TowersReport) {
+	if s == nil {
+		return
+	}
+	s.towers.Store(rep)
+}
+
+// Towers returns the latest session-wide UAT tower report.
+func (s *Status) Towers() uat978.TowersReport {
+	if s == nil {
+		return uat978.TowersReport{}
+	}
+	rep, _ := s.towers.Load().(uat978.TowersReport)
+	return rep
+}
+
+// StratuxTower mirrors the per-tower object returned by upstream Stratux's
+// /getTowers endpoint, so existing tooling can consume Stratux-NG data.
+type StratuxTower struct {
+	Lat                      float64 `json:"Lat"`
+	Lng                      float64 `json:"Lng"`
+	SignalStrengthNow        float64 `json:"Signal_strength_now"`
+	SignalStrengthLastMinute float64 `json:"Signal_strength_last_minute"`
+	SignalStrengthMax        float64 `json:"Signal_strength_max"`
+	MessagesLastMinute       uint64  `json:"Messages_last_minute"`
+	MessagesTotal            uint64  `json:"Messages_total"`
+	Name                     string  `json:"Name,omitempty"`
+}
+
+// stratuxTowers converts a tower report into the upstream /getTowers shape
+// (a map keyed by "(lat,lon)").
+func stratuxTowers(rep uat978.TowersReport) map[string]StratuxTower {
+	out := make(map[string]StratuxTower, len(rep.Towers))
+	for _, t := range rep.Towers {
+		out[t.Key] = StratuxTower{
+			Lat:                      t.LatDeg,
+			Lng:                      t.LonDeg,
+			SignalStrengthNow:        t.SignalNowDb,
+			SignalStrengthLastMinute: t.SignalAvg1MinDb,
+			SignalStrengthMax:        t.SignalMaxDb,
+			MessagesLastMinute:       t.MessagesLastMin,
+			MessagesTotal:            t.MessagesTotal,
+			Name:                     t.Name,
+		}
+	}
+	return out
+}
+
+func towersHandler(status *Status, stratuxShape bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.Header().Set("Allow", http.MethodGet)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		rep := status.Towers()
+		if rep.Towers == nil {
+			rep.Towers = []uat978.TowerSnapshot{}
+		}
+		var v any = rep
+		if stratuxShape {
+			v = stratuxTowers(rep)
+		}
+		b, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			http.Error(w, "marshal failed", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(b)
+		_, _ = w.Write([]byte("\n"))
+	}
+}
@@ -0,0 +1,58 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"stratux-ng/internal/uat978"
+)
+
+func TestAPI_Towers_ReportAndStratuxShape(t *testing.T) {
+	status := NewStatus()
+	status.SetTowers(time.Now().UTC(), uat978.TowersReport{
+		Towers: []uat978.TowerSnapshot{{
+			Key:             "(37.000000,-122.000000)",
+			LatDeg:          37,
+			LonDeg:          -122,
+			Name:            "Near",
+			SignalAvg1MinDb: -12.5,
+			MessagesLastMin: 7,
+			MessagesTotal:   42,
+		}},
+		ServiceVolume: &uat978.ServiceVolumeEstimate{Key: "(37.000000,-122.000000)", Name: "Near", Method: "signal"},
+		DatabaseSize:  1,
+	})
+	h := Handler(status, SettingsStore{}, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/towers", nil)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status=%d body=%s", w.Code, w.Body.String())
+	}
+	var rep uat978.TowersReport
+	if err := json.Unmarshal(w.Body.Bytes(), &rep); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if len(rep.Towers) != 1 || rep.ServiceVolume == nil || rep.ServiceVolume.Name != "Near" {
+		t.Fatalf("unexpected report: %+v", rep)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/getTowers", nil)
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status=%d body=%s", w.Code, w.Body.String())
+	}
+	var legacy map[string]StratuxTower
+	if err := json.Unmarshal(w.Body.Bytes(), &legacy); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	tw, ok := legacy["(37.000000,-122.000000)"]
+	if !ok || tw.Lat != 37 || tw.MessagesLastMinute != 7 || tw.SignalStrengthLastMinute != -12.5 {
+		t.Fatalf("unexpected /getTowers payload: %s", w.Body.String())
+	}
+}
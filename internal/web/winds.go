@@ -0,0 +1,62 @@
+package web
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"stratux-ng/internal/uat978"
+)
+
+// WindsAloftSnapshot is the decoded FIS-B winds aloft view plus an estimate at
+// ownship position/altitude. TAS and wind correction are only present when
+// both AHRS and GPS are available.
+type WindsAloftSnapshot struct {
+	Stations   []uat978.WindsStation `json:"stations"`
+	Estimate   *uat978.WindEstimate  `json:"estimate,omitempty"`
+	TASKt      *float64              `json:"tas_kt,omitempty"`
+	HeadingDeg *float64              `json:"heading_deg,omitempty"`
+	WCADeg     *float64              `json:"wca_deg,omitempty"`
+	// Unlocated lists received stations without a known position, which
+	// the estimate can't use.
+	Unlocated []string `json:"unlocated,omitempty"`
+}
+
+// SetWinds stores the latest winds aloft snapshot.
+func (s *Status) SetWinds(_ time.Time, snap WindsAloftSnapshot) {
+	if s == nil {
+		return
+	}
+	s.winds.Store(snap)
+}
+
+// Winds returns the latest winds aloft snapshot.
+func (s *Status) Winds() WindsAloftSnapshot {
+	if s == nil {
+		return WindsAloftSnapshot{}
+	}
+	snap, _ := s.winds.Load().(WindsAloftSnapshot)
+	return snap
+}
+
+func windsHandler(status *Status) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.Header().Set("Allow", http.MethodGet)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		snap := status.Winds()
+		if snap.Stations == nil {
+			snap.Stations = []uat978.WindsStation{}
+		}
+		b, err := json.MarshalIndent(snap, "", "  ")
+		if err != nil {
+			http.Error(w, "marshal failed", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(b)
+		_, _ = w.Write([]byte("\n"))
+	}
+}
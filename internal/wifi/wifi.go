@@ -8,6 +8,22 @@ import (
 	"time"
 )
 
+// DefaultAPIP is the AP address when wifi.ap_ip is unset (as upstream Stratux).
+const DefaultAPIP = "192.168.10.1"
+
+// APHostIP returns the AP's own address from a configured ap_ip, which may
+// carry a prefix length.
+func APHostIP(ip string) string {
+	ip = strings.TrimSpace(ip)
+	if ip == "" {
+		return DefaultAPIP
+	}
+	if i := strings.IndexByte(ip, '/'); i >= 0 {
+		ip = ip[:i]
+	}
+	return ip
+}
+
 // EnsureAPInterface checks if the uap0 interface exists, and creates it if not.
 // This requires root privileges.
 func EnsureAPInterface() error {
@@ -50,7 +66,7 @@ func SetupAP(ssid, password, ip string) error {
 	}
 
 	if ip == "" {
-		ip = "192.168.10.1"
+		ip = DefaultAPIP
 	}
 	// Default to /24 if no mask provided
 	if !strings.Contains(ip, "/") {